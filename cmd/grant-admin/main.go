@@ -0,0 +1,51 @@
+// Command grant-admin makes an existing user an admin. Signups only make the
+// very first account of a new database the admin; run it once against a
+// database whose users predate roles, so that someone can grant the others:
+//
+//	go run ./cmd/grant-admin -email owner@example.com
+//
+// The user's sessions are ended and the role takes effect at their next
+// login.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/database"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the user to make an admin")
+	flag.Parse()
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+
+	if err := godotenv.Load(".env"); err != nil {
+		slog.Warn("No .env file loaded, using process environment")
+	}
+
+	client := database.ConnectDB()
+	defer database.DisconnectDB(client)
+	store := repository.NewMongoStore(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := store.Users.FindByEmail(ctx, *email)
+	if err != nil {
+		log.Fatalf("Error while fetching user %s: %v", *email, err)
+	}
+	if _, err := controllers.ChangeRole(ctx, store, user.UserId, constants.ROLE_ADMIN); err != nil {
+		log.Fatalf("Error while granting admin: %v", err)
+	}
+	slog.Info("Granted admin", slog.String("email", *email), slog.String("userId", user.UserId))
+}
@@ -0,0 +1,37 @@
+// Command migrate-money converts prices stored as floats by older versions
+// of the service into integer cents. Run it once against the database, with
+// the same CURRENCY and MONEY_ROUNDING as the service, before starting the
+// new version.
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/jrskg/go-restaurant/database"
+	"github.com/jrskg/go-restaurant/money"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func main() {
+	if err := godotenv.Load(".env"); err != nil {
+		slog.Warn("No .env file loaded, using process environment")
+	}
+	if err := money.Configure(os.Getenv("CURRENCY"), os.Getenv("MONEY_ROUNDING")); err != nil {
+		log.Fatal(err)
+	}
+
+	client := database.ConnectDB()
+	defer database.DisconnectDB(client)
+
+	migrated, err := repository.MigrateMoney(context.Background(), client)
+	for collection, count := range migrated {
+		slog.Info("Migrated prices", slog.String("collection", collection), slog.Int("documents", count))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,12 +1,212 @@
 package constants
 
 const (
-	DB_NAME               = "gorestaurant"
-	FOOD_COLLECTION       = "food"
-	MENU_COLLECTION       = "menu"
-	ORDER_COLLECTION      = "order"
-	TABLE_COLLECTION      = "table"
-	ORDER_ITEM_COLLECTION = "order_item"
-	USER_COLLECTION       = "user"
-	INVOICE_COLLECTION    = "invoice"
+	DB_NAME                  = "gorestaurant"
+	FOOD_COLLECTION          = "food"
+	MENU_COLLECTION          = "menu"
+	ORDER_COLLECTION         = "order"
+	TABLE_COLLECTION         = "table"
+	ORDER_ITEM_COLLECTION    = "order_item"
+	USER_COLLECTION          = "user"
+	INVOICE_COLLECTION       = "invoice"
+	REVOKED_TOKEN_COLLECTION = "revoked_token"
+	TAX_RATE_COLLECTION      = "tax_rate"
+	PAYMENT_COLLECTION       = "payment"
+	PROMOTION_COLLECTION     = "promotion"
+	SEQUENCE_COLLECTION      = "sequence"
+	DRAWER_COLLECTION        = "drawer"
+	BUSINESS_DAY_COLLECTION  = "business_day"
+	RESERVATION_COLLECTION   = "reservation"
+	FLOOR_PLAN_COLLECTION    = "floor_plan"
+	SECTION_COLLECTION       = "section"
+	SHIFT_COLLECTION         = "shift"
+	WAITLIST_COLLECTION      = "waitlist"
+)
+
+const (
+	MONGO_STORE  = "mongo"
+	MEMORY_STORE = "memory"
+)
+
+const (
+	ROLE_ADMIN   = "ADMIN"
+	ROLE_MANAGER = "MANAGER"
+	ROLE_WAITER  = "WAITER"
+	ROLE_KITCHEN = "KITCHEN"
+	ROLE_CASHIER = "CASHIER"
+)
+
+// ADMIN_BOOTSTRAP_SERIES is the sequence whose first number makes a signup
+// the first admin.
+const ADMIN_BOOTSTRAP_SERIES = "ADMIN_BOOTSTRAP"
+
+const (
+	ORDER_STATUS_OPEN            = "OPEN"
+	ORDER_STATUS_SENT_TO_KITCHEN = "SENT_TO_KITCHEN"
+	ORDER_STATUS_PREPARING       = "PREPARING"
+	ORDER_STATUS_READY           = "READY"
+	ORDER_STATUS_SERVED          = "SERVED"
+	ORDER_STATUS_PAID            = "PAID"
+	ORDER_STATUS_CANCELLED       = "CANCELLED"
+)
+
+const (
+	EVENT_ORDER_CREATED        = "order.created"
+	EVENT_ORDER_STATUS_CHANGED = "order.status_changed"
+	EVENT_ORDER_DELETED        = "order.deleted"
+	EVENT_ORDER_ITEM_CREATED   = "order_item.created"
+	EVENT_ORDER_ITEM_UPDATED   = "order_item.updated"
+	EVENT_ORDER_ITEM_VOIDED    = "order_item.voided"
+)
+
+const (
+	SIZE_SMALL  = "S"
+	SIZE_MEDIUM = "M"
+	SIZE_LARGE  = "L"
+)
+
+const (
+	INVOICE_STATUS_PENDING            = "PENDING"
+	INVOICE_STATUS_PARTIALLY_PAID     = "PARTIALLY_PAID"
+	INVOICE_STATUS_PAID               = "PAID"
+	INVOICE_STATUS_OVERPAID           = "OVERPAID"
+	INVOICE_STATUS_PARTIALLY_REFUNDED = "PARTIALLY_REFUNDED"
+	INVOICE_STATUS_REFUNDED           = "REFUNDED"
+	INVOICE_STATUS_VOIDED             = "VOIDED"
+)
+
+const (
+	PAYMENT_METHOD_CASH    = "CASH"
+	PAYMENT_METHOD_CARD    = "CARD"
+	PAYMENT_METHOD_VOUCHER = "VOUCHER"
+	PAYMENT_METHOD_WALLET  = "WALLET"
+)
+
+// Ledger entry types. Refunds and voids are recorded as negative amounts.
+const (
+	LEDGER_PAYMENT = "PAYMENT"
+	LEDGER_REFUND  = "REFUND"
+	LEDGER_VOID    = "VOID"
+)
+
+const (
+	DRAWER_STATUS_OPEN   = "OPEN"
+	DRAWER_STATUS_CLOSED = "CLOSED"
+	DRAWER_PAY_IN        = "PAY_IN"
+	DRAWER_PAY_OUT       = "PAY_OUT"
+)
+
+// A reservation is BOOKED until the party is seated, it is cancelled or
+// the party fails to turn up. Booked and seated reservations hold their
+// table.
+const (
+	RESERVATION_STATUS_BOOKED    = "BOOKED"
+	RESERVATION_STATUS_SEATED    = "SEATED"
+	RESERVATION_STATUS_CANCELLED = "CANCELLED"
+	RESERVATION_STATUS_NO_SHOW   = "NO_SHOW"
+)
+
+// A table is FREE until a party is seated, ORDERED once the party has
+// orders open, BILLED when every open order has its bill, and CLEANING once
+// all of them are paid, until it is cleared for the next party. A table
+// pushed together with another one for a large party is COMBINED until
+// they are split.
+const (
+	TABLE_STATUS_FREE     = "FREE"
+	TABLE_STATUS_SEATED   = "SEATED"
+	TABLE_STATUS_ORDERED  = "ORDERED"
+	TABLE_STATUS_BILLED   = "BILLED"
+	TABLE_STATUS_CLEANING = "CLEANING"
+	TABLE_STATUS_COMBINED = "COMBINED"
+)
+
+// A walk-in party is WAITING until a table is ready for it, NOTIFIED once
+// it has been told, and SEATED or CANCELLED when it leaves the list.
+const (
+	WAITLIST_STATUS_WAITING   = "WAITING"
+	WAITLIST_STATUS_NOTIFIED  = "NOTIFIED"
+	WAITLIST_STATUS_SEATED    = "SEATED"
+	WAITLIST_STATUS_CANCELLED = "CANCELLED"
+)
+
+// Wait quotes use the turn times of the last WAITLIST_HISTORY_DAYS days,
+// for table sizes with at least WAITLIST_MIN_TURN_SAMPLES settled orders.
+const (
+	WAITLIST_HISTORY_DAYS     = 28
+	WAITLIST_MIN_TURN_SAMPLES = 5
+)
+
+const (
+	TABLE_SHAPE_ROUND     = "ROUND"
+	TABLE_SHAPE_SQUARE    = "SQUARE"
+	TABLE_SHAPE_RECTANGLE = "RECTANGLE"
+	TABLE_SHAPE_BOOTH     = "BOOTH"
+)
+
+const (
+	TIP_RULE_HOURS            = "HOURS"
+	TIP_RULE_POINTS           = "POINTS"
+	TIP_RULE_HOURS_AND_POINTS = "HOURS_AND_POINTS"
+)
+
+const (
+	PROMOTION_PERCENT     = "PERCENT"
+	PROMOTION_FIXED       = "FIXED"
+	PROMOTION_BUY_X_GET_Y = "BUY_X_GET_Y"
+	PROMOTION_LEVEL_ITEM  = "ITEM"
+	PROMOTION_LEVEL_BILL  = "BILL"
+)
+
+// Invoice number series: one per fiscal year, one per branch, or one per
+// branch and fiscal year.
+const (
+	INVOICE_SERIES_YEAR        = "YEAR"
+	INVOICE_SERIES_BRANCH      = "BRANCH"
+	INVOICE_SERIES_BRANCH_YEAR = "BRANCH_YEAR"
+)
+
+const (
+	REPORT_PERIOD_DAY   = "DAY"
+	REPORT_PERIOD_WEEK  = "WEEK"
+	REPORT_PERIOD_MONTH = "MONTH"
+)
+
+const (
+	REPORT_RANK_TOP       = "TOP"
+	REPORT_RANK_BOTTOM    = "BOTTOM"
+	REPORT_BY_QUANTITY    = "QUANTITY"
+	REPORT_BY_REVENUE     = "REVENUE"
+	REPORT_DEFAULT_LIMIT  = 10
+	REPORT_MAX_RANGE_DAYS = 366
+)
+
+// Formats an invoice can be rendered in, chosen with the format query
+// parameter or the Accept header.
+const (
+	RECEIPT_FORMAT_JSON   = "json"
+	RECEIPT_FORMAT_PDF    = "pdf"
+	RECEIPT_FORMAT_TEXT   = "text"
+	RECEIPT_FORMAT_ESCPOS = "escpos"
+)
+
+const (
+	MIME_JSON   = "application/json"
+	MIME_PDF    = "application/pdf"
+	MIME_TEXT   = "text/plain"
+	MIME_ESCPOS = "application/vnd.escpos"
+)
+
+const (
+	REASON_CUSTOMER_COMPLAINT = "CUSTOMER_COMPLAINT"
+	REASON_WRONG_ITEM         = "WRONG_ITEM"
+	REASON_QUALITY            = "QUALITY"
+	REASON_DUPLICATE          = "DUPLICATE"
+	REASON_PRICING_ERROR      = "PRICING_ERROR"
+	REASON_OTHER              = "OTHER"
+)
+
+const (
+	SPLIT_BY_ITEM = "ITEM"
+	SPLIT_BY_SEAT = "SEAT"
+	SPLIT_EVENLY  = "EVEN"
 )
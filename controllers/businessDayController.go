@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+)
+
+var errBusinessDayClosed = errors.New("business day is already closed")
+
+// CloseBusinessDay takes the Z-report of a business day, today's unless
+// the body names another, and locks the day against further changes. The
+// cash drawers opened during the day have to be closed first.
+func CloseBusinessDay(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var closeDto models.CloseBusinessDayDto
+		if err := c.ShouldBindJSON(&closeDto); err != nil && !errors.Is(err, io.EOF) {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(closeDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		now := time.Now()
+		businessDay := closeDto.BusinessDay
+		if businessDay == "" {
+			businessDay = helpers.BusinessDay(now)
+		}
+		from, to, err := helpers.BusinessDayRange(businessDay)
+		if err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+		if from.After(now) {
+			utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("business day %s has not started yet", businessDay))
+			return
+		}
+
+		var report models.ZReport
+		code := http.StatusInternalServerError
+		err = store.Transaction(ctx, func(ctx context.Context) error {
+			closed, err := store.BusinessDays.IsClosed(ctx, businessDay)
+			if err != nil {
+				return err
+			}
+			if closed {
+				code = http.StatusConflict
+				return errBusinessDayClosed
+			}
+
+			drawer, err := store.Drawers.Current(ctx)
+			if err == nil && drawer.OpenedAt.Before(to) {
+				code = http.StatusConflict
+				return errors.New("close the cash drawer before closing the business day")
+			}
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+
+			report, err = zReport(ctx, store, businessDay, from, to)
+			if err != nil {
+				return err
+			}
+			closedAt := now.UTC()
+			report.Closed = true
+			report.ClosedBy = c.GetString("userId")
+			report.ClosedAt = &closedAt
+
+			stored, err := store.BusinessDays.Close(ctx, report)
+			if err != nil {
+				return err
+			}
+			if !stored {
+				code = http.StatusConflict
+				return errBusinessDayClosed
+			}
+			return nil
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while closing business day", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, report, "Business day closed successfully")
+	}
+}
+
+// GetZReport returns the Z-report a business day was closed with, or a
+// preview of it while the day is still open.
+func GetZReport(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		businessDay := c.Param("businessDay")
+		from, to, err := helpers.BusinessDayRange(businessDay)
+		if err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		report, err := store.BusinessDays.Get(ctx, businessDay)
+		if errors.Is(err, repository.ErrNotFound) {
+			report, err = zReport(ctx, store, businessDay, from, to)
+		}
+		if err != nil {
+			slog.Error("Error while fetching Z-report", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, report, "Z-report fetched successfully")
+	}
+}
+
+func zReport(ctx context.Context, store *repository.Store, businessDay string, from, to time.Time) (models.ZReport, error) {
+	invoices, err := store.Invoices.ListBetween(ctx, from, to)
+	if err != nil {
+		return models.ZReport{}, err
+	}
+	payments, err := store.Payments.ListBetween(ctx, from, to)
+	if err != nil {
+		return models.ZReport{}, err
+	}
+	drawers, err := store.Drawers.ListBetween(ctx, from, to)
+	if err != nil {
+		return models.ZReport{}, err
+	}
+	for i := range drawers {
+		if drawers[i], err = tallyOpenDrawer(ctx, store, drawers[i]); err != nil {
+			return models.ZReport{}, err
+		}
+	}
+	return helpers.BuildZReport(businessDay, from, to, invoices, payments, drawers), nil
+}
+
+// checkBusinessDayOpen refuses changes to what was recorded at during a
+// business day that has been closed.
+func checkBusinessDayOpen(ctx context.Context, store *repository.Store, at time.Time) (int, error) {
+	businessDay := helpers.BusinessDay(at)
+	closed, err := store.BusinessDays.IsClosed(ctx, businessDay)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if closed {
+		return http.StatusConflict, fmt.Errorf("business day %s is closed", businessDay)
+	}
+	return http.StatusOK, nil
+}
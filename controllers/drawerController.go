@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	errDrawerOpen   = errors.New("a cash drawer is already open")
+	errDrawerClosed = errors.New("cash drawer is closed")
+)
+
+// OpenDrawer starts a cash drawer session with the float counted into it.
+func OpenDrawer(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var openDto models.OpenDrawerDto
+		if err := c.BindJSON(&openDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(openDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		drawer := models.Drawer{
+			Status:       constants.DRAWER_STATUS_OPEN,
+			OpeningFloat: *openDto.OpeningFloat,
+			Movements:    make([]models.DrawerMovement, 0),
+			Currency:     money.Currency(),
+			OpenedBy:     c.GetString("userId"),
+			OpenedAt:     time.Now().UTC(),
+		}
+		drawer.ID = bson.NewObjectID()
+		drawer.DrawerId = drawer.ID.Hex()
+
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			_, err := store.Drawers.Current(ctx)
+			if err == nil {
+				return errDrawerOpen
+			}
+			if !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+			return store.Drawers.Create(ctx, drawer)
+		})
+		if errors.Is(err, errDrawerOpen) {
+			utils.ApiError(c, http.StatusConflict, err)
+			return
+		}
+		if err != nil {
+			slog.Error("Error while opening cash drawer", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, drawer, "Cash drawer opened successfully")
+	}
+}
+
+// GetCurrentDrawer returns the open drawer with the cash it should hold.
+func GetCurrentDrawer(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		drawer, err := store.Drawers.Current(ctx)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("no cash drawer is open"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching cash drawer", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		drawer, err = tallyOpenDrawer(ctx, store, drawer)
+		if err != nil {
+			slog.Error("Error while tallying cash drawer", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, drawer, "Cash drawer fetched successfully")
+	}
+}
+
+func GetDrawer(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		drawer, err := store.Drawers.Get(ctx, c.Param("drawerId"))
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("cash drawer not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching cash drawer", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		drawer, err = tallyOpenDrawer(ctx, store, drawer)
+		if err != nil {
+			slog.Error("Error while tallying cash drawer", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, drawer, "Cash drawer fetched successfully")
+	}
+}
+
+// RecordDrawerMovement records cash paid into or out of an open drawer.
+func RecordDrawerMovement(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var movementDto models.DrawerMovementDto
+		if err := c.BindJSON(&movementDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(movementDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		movement := models.DrawerMovement{
+			MovementId: bson.NewObjectID().Hex(),
+			Type:       movementDto.Type,
+			Amount:     *movementDto.Amount,
+			Reason:     movementDto.Reason,
+			RecordedBy: c.GetString("userId"),
+			CreatedAt:  time.Now().UTC(),
+		}
+
+		var drawer models.Drawer
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			drawer, code, err = openDrawer(ctx, store, c.Param("drawerId"))
+			if err != nil {
+				return err
+			}
+			if err := store.Drawers.AddMovement(ctx, drawer.DrawerId, movement); err != nil {
+				code = http.StatusInternalServerError
+				return err
+			}
+			drawer.Movements = append(drawer.Movements, movement)
+			return nil
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while recording cash drawer movement", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		drawer, err = tallyOpenDrawer(ctx, store, drawer)
+		if err != nil {
+			slog.Error("Error while tallying cash drawer", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, drawer, "Cash drawer movement recorded successfully")
+	}
+}
+
+// CloseDrawer closes a drawer with the cash counted in it, recording how
+// far over or short it is of what it should hold.
+func CloseDrawer(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var closeDto models.CloseDrawerDto
+		if err := c.BindJSON(&closeDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(closeDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		var drawer models.Drawer
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			drawer, code, err = openDrawer(ctx, store, c.Param("drawerId"))
+			if err != nil {
+				return err
+			}
+			code = http.StatusInternalServerError
+
+			closedAt := time.Now().UTC()
+			payments, err := store.Payments.ListBetween(ctx, drawer.OpenedAt, closedAt)
+			if err != nil {
+				return err
+			}
+			tally := helpers.TallyDrawer(drawer, payments)
+			closing := models.DrawerClosing{
+				Counted:   *closeDto.Counted,
+				OverShort: *closeDto.Counted - tally.Expected,
+				Note:      closeDto.Note,
+				ClosedBy:  c.GetString("userId"),
+				ClosedAt:  closedAt,
+			}
+			if err := store.Drawers.Close(ctx, drawer.DrawerId, tally, closing); err != nil {
+				return err
+			}
+			drawer.Status = constants.DRAWER_STATUS_CLOSED
+			drawer.Tally = &tally
+			drawer.Closing = &closing
+			return nil
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while closing cash drawer", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, drawer, "Cash drawer closed successfully")
+	}
+}
+
+// openDrawer fetches a drawer that has to be open.
+func openDrawer(ctx context.Context, store *repository.Store, drawerId string) (models.Drawer, int, error) {
+	drawer, err := store.Drawers.Get(ctx, drawerId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.Drawer{}, http.StatusNotFound, errors.New("cash drawer not found")
+	}
+	if err != nil {
+		return models.Drawer{}, http.StatusInternalServerError, err
+	}
+	if drawer.Status != constants.DRAWER_STATUS_OPEN {
+		return models.Drawer{}, http.StatusConflict, errDrawerClosed
+	}
+	return drawer, http.StatusOK, nil
+}
+
+// tallyOpenDrawer fills in the tally of a drawer that is still open from
+// the ledger so far. Closed drawers keep the tally they were closed with.
+func tallyOpenDrawer(ctx context.Context, store *repository.Store, drawer models.Drawer) (models.Drawer, error) {
+	if drawer.Status != constants.DRAWER_STATUS_OPEN {
+		return drawer, nil
+	}
+	payments, err := store.Payments.ListBetween(ctx, drawer.OpenedAt, time.Now().UTC())
+	if err != nil {
+		return models.Drawer{}, err
+	}
+	tally := helpers.TallyDrawer(drawer, payments)
+	drawer.Tally = &tally
+	return drawer, nil
+}
@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func CreateFloorPlan(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var floorPlan models.FloorPlan
+		if err := c.BindJSON(&floorPlan); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(floorPlan); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		floorPlan.CreatedAt = time.Now().UTC()
+		floorPlan.UpdatedAt = time.Now().UTC()
+		floorPlan.ID = bson.NewObjectID()
+		floorPlan.FloorPlanId = floorPlan.ID.Hex()
+
+		if err := store.FloorPlans.Create(ctx, floorPlan); err != nil {
+			slog.Error("Error while creating floor plan", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, floorPlan, "Floor plan created successfully")
+	}
+}
+
+// UpdateFloorPlan renames or resizes a floor plan. A plan cannot shrink
+// past the tables drawn on it.
+func UpdateFloorPlan(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		floorPlanId := c.Param("floorPlanId")
+		var updateDto models.UpdateFloorPlanDto
+		if err := c.BindJSON(&updateDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(updateDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		floorPlan, err := store.FloorPlans.Get(ctx, floorPlanId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("floor plan not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching floor plan", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if updateDto.Width != nil {
+			floorPlan.Width = *updateDto.Width
+		}
+		if updateDto.Height != nil {
+			floorPlan.Height = *updateDto.Height
+		}
+		tables, err := floorPlanTables(ctx, store, floorPlanId)
+		if err != nil {
+			slog.Error("Error while fetching tables", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		for _, table := range tables {
+			if table.Layout == nil {
+				continue
+			}
+			if err := helpers.CheckTableLayout(*table.Layout, floorPlan); err != nil {
+				utils.ApiError(c, http.StatusConflict, err)
+				return
+			}
+		}
+
+		err = store.FloorPlans.Update(ctx, floorPlanId, updateDto)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("floor plan not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while updating floor plan", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, updateDto, "Floor plan updated successfully")
+	}
+}
+
+// DeleteFloorPlan removes a floor plan that no longer has sections or
+// tables.
+func DeleteFloorPlan(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		floorPlanId := c.Param("floorPlanId")
+		sections, err := store.Sections.ListByFloorPlan(ctx, floorPlanId)
+		if err != nil {
+			slog.Error("Error while fetching sections", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if len(sections) > 0 {
+			utils.ApiError(c, http.StatusConflict, fmt.Errorf("floor plan has %d sections", len(sections)))
+			return
+		}
+		tables, err := floorPlanTables(ctx, store, floorPlanId)
+		if err != nil {
+			slog.Error("Error while fetching tables", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if len(tables) > 0 {
+			utils.ApiError(c, http.StatusConflict, fmt.Errorf("floor plan has %d tables", len(tables)))
+			return
+		}
+
+		err = store.FloorPlans.Delete(ctx, floorPlanId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("floor plan not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while deleting floor plan", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, nil, "Floor plan deleted successfully")
+	}
+}
+
+// GetFloorPlan returns a floor plan with its sections and tables.
+func GetFloorPlan(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		floorPlanId := c.Param("floorPlanId")
+		floorPlan, err := store.FloorPlans.Get(ctx, floorPlanId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("floor plan not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching floor plan", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		sections, err := store.Sections.ListByFloorPlan(ctx, floorPlanId)
+		if err != nil {
+			slog.Error("Error while fetching sections", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		tables, err := floorPlanTables(ctx, store, floorPlanId)
+		if err != nil {
+			slog.Error("Error while fetching tables", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		view := models.FloorPlanView{FloorPlan: floorPlan, Sections: sections, Tables: tables}
+		utils.ApiSuccess(c, http.StatusOK, view, "Floor plan fetched successfully")
+	}
+}
+
+func GetAllFloorPlans(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		floorPlans, err := store.FloorPlans.List(ctx)
+		if err != nil {
+			slog.Error("Error while fetching floor plans", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, floorPlans, "Floor plans fetched successfully")
+	}
+}
+
+// floorPlanTables lists the tables placed on a floor plan.
+func floorPlanTables(ctx context.Context, store *repository.Store, floorPlanId string) ([]models.Table, error) {
+	tables, err := store.Tables.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	placed := make([]models.Table, 0)
+	for _, table := range tables {
+		if table.FloorPlanId == floorPlanId {
+			placed = append(placed, table)
+		}
+	}
+	return placed, nil
+}
+
+// placeTable checks where update puts table and fills in what follows from
+// it: a section brings the table to its floor plan, and taking the table
+// off its floor plan takes it out of its section too.
+func placeTable(ctx context.Context, store *repository.Store, table models.Table, update *models.UpdateTableDto) (int, error) {
+	if update.FloorPlanId == nil && update.SectionId == nil && update.Layout == nil {
+		return http.StatusOK, nil
+	}
+
+	floorPlanId, sectionId, layout := table.FloorPlanId, table.SectionId, table.Layout
+	if update.FloorPlanId != nil {
+		floorPlanId = *update.FloorPlanId
+		if floorPlanId == "" && update.SectionId == nil {
+			sectionId = ""
+			update.SectionId = &sectionId
+		}
+	}
+	if update.SectionId != nil {
+		sectionId = *update.SectionId
+	}
+	if update.Layout != nil {
+		layout = update.Layout
+	}
+
+	if sectionId != "" {
+		section, err := store.Sections.Get(ctx, sectionId)
+		if errors.Is(err, repository.ErrNotFound) {
+			return http.StatusBadRequest, errors.New("section not found")
+		}
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if update.FloorPlanId == nil {
+			floorPlanId = section.FloorPlanId
+			update.FloorPlanId = &floorPlanId
+		}
+		if section.FloorPlanId != floorPlanId {
+			return http.StatusBadRequest, fmt.Errorf("section %s is not on floor plan %s", section.Name, floorPlanId)
+		}
+	}
+	if floorPlanId == "" {
+		if update.Layout != nil {
+			return http.StatusBadRequest, errors.New("a table has to be on a floor plan to be laid out")
+		}
+		return http.StatusOK, nil
+	}
+
+	floorPlan, err := store.FloorPlans.Get(ctx, floorPlanId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return http.StatusBadRequest, errors.New("floor plan not found")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if layout != nil {
+		if err := helpers.CheckTableLayout(*layout, floorPlan); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	return http.StatusOK, nil
+}
@@ -10,18 +10,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jrskg/go-restaurant/constants"
-	"github.com/jrskg/go-restaurant/database"
 	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+	"github.com/jrskg/go-restaurant/repository"
 	"github.com/jrskg/go-restaurant/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
-var foodCollection = database.OpenCollection(database.DBClient, constants.FOOD_COLLECTION)
-var menuCollection = database.OpenCollection(database.DBClient, constants.MENU_COLLECTION)
-
-func CreateFood() gin.HandlerFunc {
+func CreateFood(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -41,29 +37,31 @@ func CreateFood() gin.HandlerFunc {
 			utils.ApiError(c, http.StatusBadRequest, err)
 			return
 		}
-		count, err := menuCollection.CountDocuments(ctx, bson.M{"menuId": food.MenuId})
-		if err != nil || count < 1 {
+		exists, err := store.Menus.Exists(ctx, *food.MenuId)
+		if err != nil || !exists {
 			utils.ApiError(c, http.StatusBadRequest, errors.New("menu not found"))
 			return
 		}
+		if code, err := checkTaxRates(ctx, store, food.TaxRateIds); err != nil {
+			utils.ApiError(c, code, err)
+			return
+		}
 
 		food.CreatedAt = time.Now().UTC()
 		food.UpdatedAt = time.Now().UTC()
 		food.ID = bson.NewObjectID()
 		food.FoodId = food.ID.Hex()
-		var num = utils.ToFixed(*food.Price, 2)
-		food.Price = &num
-		result, insertErr := foodCollection.InsertOne(ctx, food)
-
-		if insertErr != nil || result.InsertedID == "" {
-			utils.ApiError(c, http.StatusInternalServerError, insertErr)
+		food.Currency = money.Currency()
+		if err := store.Foods.Create(ctx, food); err != nil {
+			slog.Error("Error while creating food", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
 		utils.ApiSuccess(c, http.StatusCreated, food, "Food created successfully")
 	}
 }
 
-func UpdateFood() gin.HandlerFunc {
+func UpdateFood(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -91,45 +89,33 @@ func UpdateFood() gin.HandlerFunc {
 		}
 
 		if updateFoodDto.MenuId != nil {
-			count, err := menuCollection.CountDocuments(ctx, bson.M{"menuId": updateFoodDto.MenuId})
-			if err != nil || count < 1 {
+			exists, err := store.Menus.Exists(ctx, *updateFoodDto.MenuId)
+			if err != nil || !exists {
 				utils.ApiError(c, http.StatusBadRequest, errors.New("invalid menuId"))
 				return
 			}
 		}
-
-		filter := bson.M{"foodId": foodId}
-		updateFields := bson.M{
-			"name":      updateFoodDto.Name,
-			"price":     updateFoodDto.Price,
-			"foodImage": updateFoodDto.FoodImage,
-			"menuId":    updateFoodDto.MenuId,
+		if code, err := checkTaxRates(ctx, store, updateFoodDto.TaxRateIds); err != nil {
+			utils.ApiError(c, code, err)
+			return
 		}
-		updateObj := bson.M{"updatedAt": time.Now().UTC()}
 
-		for k, v := range updateFields {
-			if !utils.IsNil(v) {
-				updateObj[k] = v
-			}
+		err := store.Foods.Update(ctx, foodId, updateFoodDto)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("food not found"))
+			return
 		}
-
-		result, err := foodCollection.UpdateOne(ctx, filter, bson.M{"$set": updateObj})
 		if err != nil {
 			slog.Error("Error while updating food", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		if result.MatchedCount == 0 {
-			utils.ApiError(c, http.StatusNotFound, errors.New("food not found"))
-			return
-		}
-
 		utils.ApiSuccess(c, http.StatusOK, nil, "Food updated successfully")
 	}
 }
 
-func DeleteFood() gin.HandlerFunc {
+func DeleteFood(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -140,29 +126,27 @@ func DeleteFood() gin.HandlerFunc {
 			return
 		}
 
-		result, err := foodCollection.DeleteOne(ctx, bson.M{"foodId": foodId})
+		err := store.Foods.Delete(ctx, foodId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("food not found"))
+			return
+		}
 		if err != nil {
 			slog.Error("Error while deleting food", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		if result.DeletedCount == 0 {
-			utils.ApiError(c, http.StatusNotFound, errors.New("food not found"))
-			return
-		}
-
 		utils.ApiSuccess(c, http.StatusOK, nil, "Food deleted successfully")
 	}
 }
 
-func GetFood() gin.HandlerFunc {
+func GetFood(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		foodId := c.Param("foodId")
-		var food models.Food
-		err := foodCollection.FindOne(ctx, bson.M{"foodId": foodId}).Decode(&food)
+		food, err := store.Foods.Get(ctx, foodId)
 		if err != nil {
 			slog.Error("Error while fetching food", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusNotFound, err)
@@ -172,7 +156,7 @@ func GetFood() gin.HandlerFunc {
 	}
 }
 
-func GetAllFoods() gin.HandlerFunc {
+func GetAllFoods(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -189,25 +173,12 @@ func GetAllFoods() gin.HandlerFunc {
 			cursorTime = pt
 		}
 
-		var allFoods []models.Food = make([]models.Food, 0)
-
-		filter := bson.M{}
-		if cursor != "" {
-			filter = bson.M{"createdAt": bson.M{"$gt": cursorTime}}
-		}
-		options := options.Find().SetLimit(limit + 1)
-		result, err := foodCollection.Find(ctx, filter, options)
+		allFoods, err := store.Foods.List(ctx, cursorTime, limit+1)
 		if err != nil {
 			slog.Error("Error while fetching food", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
-
-		if err := result.All(ctx, &allFoods); err != nil {
-			slog.Error("Error while saving to allFoods", slog.String("error", err.Error()))
-			utils.ApiError(c, http.StatusInternalServerError, err)
-			return
-		}
 		hasMore := false
 		nextCursor := time.Time{}
 		if len(allFoods) > int(limit) {
@@ -227,3 +198,17 @@ func GetAllFoods() gin.HandlerFunc {
 		)
 	}
 }
+
+func checkTaxRates(ctx context.Context, store *repository.Store, taxRateIds []string) (int, error) {
+	for _, taxRateId := range taxRateIds {
+		_, err := store.TaxRates.Get(ctx, taxRateId)
+		if errors.Is(err, repository.ErrNotFound) {
+			return http.StatusBadRequest, fmt.Errorf("tax rate not found: %s", taxRateId)
+		}
+		if err != nil {
+			slog.Error("Error while fetching tax rate", slog.String("error", err.Error()))
+			return http.StatusInternalServerError, err
+		}
+	}
+	return http.StatusOK, nil
+}
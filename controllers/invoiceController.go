@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -10,27 +11,31 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jrskg/go-restaurant/constants"
-	"github.com/jrskg/go-restaurant/database"
+	"github.com/jrskg/go-restaurant/helpers"
 	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+	"github.com/jrskg/go-restaurant/receipt"
+	"github.com/jrskg/go-restaurant/repository"
 	"github.com/jrskg/go-restaurant/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type InvoiceViewFromat struct {
-	InvoiceId      string    `json:"invoiceId"`
-	PaymentMethod  string    `json:"paymentMethod"`
-	OrderId        string    `json:"orderId"`
-	PaymentStatus  *string   `json:"paymentStatus"`
-	PaymentDue     any       `json:"paymentDue"`
-	TableNumber    any       `json:"tableNumber"`
-	PaymentDueDate time.Time `json:"paymentDueDate"`
-	OrderDetails   any       `json:"orderDetails"`
+	InvoiceId      string               `json:"invoiceId"`
+	InvoiceNumber  string               `json:"invoiceNumber"`
+	PaymentMethod  string               `json:"paymentMethod"`
+	OrderId        string               `json:"orderId"`
+	PaymentStatus  *string              `json:"paymentStatus"`
+	PaymentDue     any                  `json:"paymentDue"`
+	TableNumber    any                  `json:"tableNumber"`
+	PaymentDueDate time.Time            `json:"paymentDueDate"`
+	OrderDetails   any                  `json:"orderDetails"`
+	Totals         models.InvoiceTotals `json:"totals"`
+	AmountPaid     money.Amount         `json:"amountPaid"`
+	BalanceDue     money.Amount         `json:"balanceDue"`
 }
 
-var invoiceCollection = database.OpenCollection(database.DBClient, constants.INVOICE_COLLECTION)
-
-func CreateInvoice() gin.HandlerFunc {
+func CreateInvoice(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -51,36 +56,93 @@ func CreateInvoice() gin.HandlerFunc {
 			return
 		}
 
-		orderResult := orderCollection.FindOne(ctx, bson.M{"orderId": invoice.OrderId})
-		if err := orderResult.Err(); err != nil {
+		order, err := store.Orders.Get(ctx, invoice.OrderId)
+		if err != nil {
 			slog.Error("Error while fetching order", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusNotFound, err)
 			return
 		}
-
-		status := "PENDING"
-		if invoice.PaymentStatus == nil {
-			invoice.PaymentStatus = &status
+		invoice.GratuityRate, err = partyGratuity(ctx, store, order, invoice.GratuityRate)
+		if err != nil {
+			slog.Error("Error while fetching table", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
 		}
 
+		// A whole-order invoice; split invoices come from SplitInvoice.
+		invoice.OrderItemIds = nil
+		invoice.Seat = nil
+		invoice.SplitIndex, invoice.SplitCount = 0, 0
+
 		invoice.CreatedAt = time.Now().UTC()
-		invoice.UpdatedAt = time.Now().UTC()
+		invoice.UpdatedAt = invoice.CreatedAt
+		invoice.CouponCodes = helpers.NormalizeCoupons(invoice.CouponCodes)
+		coupons, code, err := couponPromotions(ctx, store, invoice.CouponCodes, invoice.CreatedAt)
+		if err != nil {
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		// The ledger is the only source of what was paid, tipped, refunded
+		// or voided.
+		invoice.AmountPaid = 0
+		invoice.AmountRefunded = 0
+		invoice.Tips = 0
+		invoice.Void = nil
 		invoice.PaymentDueDate = time.Now().Add(time.Hour * 24).UTC()
 		invoice.ID = bson.NewObjectID()
 		invoice.InvoiceId = invoice.ID.Hex()
+		requested := invoice.PaymentStatus
+
+		code = http.StatusInternalServerError
+		err = store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			if code, err = lockBillableOrder(ctx, store, invoice.OrderId); err != nil {
+				return err
+			}
+			code = http.StatusInternalServerError
+			totals, _, err := invoiceTotals(ctx, store, invoice)
+			if err != nil {
+				return err
+			}
+			invoice.Totals = &totals
 
-		_, err := invoiceCollection.InsertOne(ctx, invoice)
+			status, statusCode, err := ledgerStatus(requested, totals.Total, 0, 0)
+			if err != nil {
+				code = statusCode
+				return err
+			}
+			invoice.PaymentStatus = &status
+
+			if err := issueInvoiceNumber(ctx, store, &invoice); err != nil {
+				return err
+			}
+			for _, coupon := range coupons {
+				redeemed, err := store.Promotions.Redeem(ctx, coupon.PromotionId)
+				if err != nil {
+					return err
+				}
+				if !redeemed {
+					code = http.StatusConflict
+					return fmt.Errorf("coupon %s has been used up", coupon.CouponCode)
+				}
+			}
+			return store.Invoices.Create(ctx, invoice)
+		})
 		if err != nil {
-			slog.Error("Error while creating invoice", slog.String("error", err.Error()))
-			utils.ApiError(c, http.StatusInternalServerError, err)
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while creating invoice", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
 			return
 		}
+		refreshTable(ctx, store, order.TableId)
 
 		utils.ApiSuccess(c, http.StatusCreated, invoice, "Invoice created successfully")
 	}
 }
 
-func UpdateInvoice() gin.HandlerFunc {
+func UpdateInvoice(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -107,32 +169,79 @@ func UpdateInvoice() gin.HandlerFunc {
 			return
 		}
 
-		status := "PENDING"
-		if updateInvoiceDto.PaymentStatus == nil {
-			updateInvoiceDto.PaymentStatus = &status
+		invoice, err := store.Invoices.Get(ctx, invoiceId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("invoice not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching invoice", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if isInvoiceVoided(invoice) {
+			utils.ApiError(c, http.StatusConflict, errInvoiceVoided)
+			return
+		}
+		if code, err := checkBusinessDayOpen(ctx, store, invoice.CreatedAt); err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while checking business day", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+		if !isInvoicePaid(invoice) || invoice.Totals == nil {
+			totals, _, err := invoiceTotals(ctx, store, invoice)
+			if err != nil {
+				slog.Error("Error while computing invoice totals", slog.String("error", err.Error()))
+				utils.ApiError(c, http.StatusInternalServerError, err)
+				return
+			}
+			updateInvoiceDto.Totals = &totals
+		} else {
+			updateInvoiceDto.Totals = invoice.Totals
 		}
 
-		options := options.FindOneAndUpdate().SetReturnDocument(options.After)
-		filter := bson.M{"invoiceId": invoiceId}
-		update := bson.M{"$set": bson.M{
-			"paymentMethod": updateInvoiceDto.PaymentMethod,
-			"paymentStatus": updateInvoiceDto.PaymentStatus,
-			"updatedAt":     time.Now().UTC(),
-		}}
+		payments, err := store.Payments.ListByInvoice(ctx, invoiceId)
+		if err != nil {
+			slog.Error("Error while fetching payments", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		paid, refunded := helpers.LedgerAmounts(payments)
+		status, code, err := ledgerStatus(updateInvoiceDto.PaymentStatus, updateInvoiceDto.Totals.Total, paid, refunded)
+		if err != nil {
+			utils.ApiError(c, code, err)
+			return
+		}
+		updateInvoiceDto.PaymentStatus = &status
+		updateInvoiceDto.AmountPaid = &paid
+		updateInvoiceDto.AmountRefunded = &refunded
+		if updateInvoiceDto.PaymentMethod == nil {
+			updateInvoiceDto.PaymentMethod = invoice.PaymentMethod
+		}
 
-		updateInvoice := models.Invoice{}
-		err := invoiceCollection.FindOneAndUpdate(ctx, filter, update, options).Decode(&updateInvoice)
+		updateInvoice, err := store.Invoices.Update(ctx, invoiceId, updateInvoiceDto)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("invoice not found"))
+			return
+		}
 		if err != nil {
 			slog.Error("Error while updating invoice", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
+		if isInvoicePaid(updateInvoice) {
+			if unpaid, err := unpaidInvoices(ctx, store, updateInvoice.OrderId); err == nil && unpaid == 0 {
+				settleOrder(ctx, store, updateInvoice.OrderId, c.GetString("userId"))
+			}
+		}
 
 		utils.ApiSuccess(c, http.StatusOK, updateInvoice, "Invoice updated successfully")
 	}
 }
 
-func GetInvoice() gin.HandlerFunc {
+func GetInvoice(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -143,8 +252,17 @@ func GetInvoice() gin.HandlerFunc {
 			return
 		}
 
-		var invoice models.Invoice
-		err := invoiceCollection.FindOne(ctx, bson.M{"invoiceId": invoiceId}).Decode(&invoice)
+		format, code, err := receiptFormat(c)
+		if err != nil {
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		invoice, err := store.Invoices.Get(ctx, invoiceId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("invoice not found"))
+			return
+		}
 		if err != nil {
 			slog.Error("Error while fetching invoice", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
@@ -152,12 +270,21 @@ func GetInvoice() gin.HandlerFunc {
 		}
 
 		var invoiceView InvoiceViewFromat
-		allOrderItems, err := ItemsByOrder(invoice.OrderId)
+		totals, allOrderItems, err := invoiceTotals(ctx, store, invoice)
 		if err != nil {
-			slog.Error("Error while fetching order items", slog.String("error", err.Error()))
+			slog.Error("Error while computing invoice totals", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
+		if (isInvoicePaid(invoice) || isInvoiceVoided(invoice)) && invoice.Totals != nil {
+			totals = *invoice.Totals
+		}
+		invoiceView.Totals = totals
+		invoiceView.PaymentDue = totals.Total
+		invoiceView.AmountPaid = invoice.AmountPaid
+		if !isInvoiceVoided(invoice) {
+			invoiceView.BalanceDue = max(totals.Total-invoice.AmountPaid, 0)
+		}
 		invoiceView.OrderId = invoice.OrderId
 		invoiceView.PaymentDueDate = invoice.PaymentDueDate
 
@@ -167,30 +294,134 @@ func GetInvoice() gin.HandlerFunc {
 		}
 
 		invoiceView.InvoiceId = invoice.InvoiceId
+		invoiceView.InvoiceNumber = invoice.InvoiceNumber
 		invoiceView.PaymentStatus = invoice.PaymentStatus
-		invoiceView.PaymentDue = allOrderItems[0]["paymentDue"]
-		invoiceView.TableNumber = allOrderItems[0]["tableNumber"]
-		invoiceView.OrderDetails = allOrderItems[0]["orderItems"]
+		if len(allOrderItems) > 0 {
+			invoiceView.TableNumber = allOrderItems[0].TableNumber
+			invoiceView.OrderDetails = allOrderItems[0].OrderItems
+		}
+
+		if format != constants.RECEIPT_FORMAT_JSON {
+			payments, err := store.Payments.ListByInvoice(ctx, invoiceId)
+			if err != nil {
+				slog.Error("Error while fetching payments", slog.String("error", err.Error()))
+				utils.ApiError(c, http.StatusInternalServerError, err)
+				return
+			}
+			r := receipt.Receipt{
+				Invoice:    invoice,
+				Totals:     totals,
+				Payments:   payments,
+				BalanceDue: invoiceView.BalanceDue,
+			}
+			if len(allOrderItems) > 0 {
+				r.TableNumber = allOrderItems[0].TableNumber
+				r.Items = allOrderItems[0].OrderItems
+			}
+			writeReceipt(c, format, r)
+			return
+		}
 
 		utils.ApiSuccess(c, http.StatusOK, invoiceView, "Invoice fetched successfully")
 	}
 }
 
-func GetAllInvoices() gin.HandlerFunc {
+// SplitInvoice divides an order into one invoice per bill: by item, by seat,
+// or in equal shares between guests. All invoices are created or none are.
+func SplitInvoice(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		allInvoices := make([]models.Invoice, 0)
-		result, err := invoiceCollection.Find(ctx, bson.M{})
+		var splitDto models.SplitInvoiceDto
+		if err := c.BindJSON(&splitDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
 
+		if err := utils.Validate.Struct(splitDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		order, err := store.Orders.Get(ctx, splitDto.OrderId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("order not found"))
+			return
+		}
 		if err != nil {
-			slog.Error("Error while fetching invoice", slog.String("error", err.Error()))
+			slog.Error("Error while fetching order", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		gratuityRate, err := partyGratuity(ctx, store, order, splitDto.GratuityRate)
+		if err != nil {
+			slog.Error("Error while fetching table", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		var invoices []models.Invoice
+		code := http.StatusInternalServerError
+		err = store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			if code, err = lockBillableOrder(ctx, store, splitDto.OrderId); err != nil {
+				return err
+			}
+			if invoices, code, err = splitBills(ctx, store, splitDto, gratuityRate); err != nil {
+				return err
+			}
+			code = http.StatusInternalServerError
+			for i := range invoices {
+				if err := issueInvoiceNumber(ctx, store, &invoices[i]); err != nil {
+					return err
+				}
+				if err := store.Invoices.Create(ctx, invoices[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while creating invoices", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+		refreshTable(ctx, store, order.TableId)
+
+		utils.ApiSuccess(c, http.StatusCreated, invoices, "Invoices created successfully")
+	}
+}
+
+func GetOrderInvoices(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		invoices, err := store.Invoices.ListByOrder(ctx, c.Param("orderId"))
+		if err != nil {
+			slog.Error("Error while fetching invoices", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		if err = result.All(ctx, &allInvoices); err != nil {
+		utils.ApiSuccess(c, http.StatusOK, invoices, "Invoices fetched successfully")
+	}
+}
+
+func GetAllInvoices(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		allInvoices, err := store.Invoices.List(ctx)
+		if err != nil {
 			slog.Error("Error while fetching invoice", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
@@ -199,3 +430,333 @@ func GetAllInvoices() gin.HandlerFunc {
 		utils.ApiSuccess(c, http.StatusOK, allInvoices, "Invoice fetched successfully")
 	}
 }
+
+// isInvoicePaid reports whether the invoice is settled, paid exactly or
+// overpaid.
+func isInvoicePaid(invoice models.Invoice) bool {
+	return invoice.PaymentStatus != nil && helpers.IsInvoiceSettled(*invoice.PaymentStatus)
+}
+
+func isInvoiceVoided(invoice models.Invoice) bool {
+	return invoice.PaymentStatus != nil && *invoice.PaymentStatus == constants.INVOICE_STATUS_VOIDED
+}
+
+// ledgerStatus is the payment status of an invoice of total with paid and
+// refunded on its ledger. A requested status is only accepted when the
+// ledger agrees: PAID needs the total covered and PENDING needs no payments.
+func ledgerStatus(requested *string, total, paid, refunded money.Amount) (string, int, error) {
+	status := helpers.PaymentStatus(total, paid, refunded)
+	if requested == nil {
+		return status, http.StatusOK, nil
+	}
+
+	switch *requested {
+	case constants.INVOICE_STATUS_PAID:
+		if paid < total {
+			return "", http.StatusConflict, fmt.Errorf("payments of %s do not cover the invoice total of %s", paid, total)
+		}
+		if status == constants.INVOICE_STATUS_PENDING {
+			// Nothing to pay, so nothing was paid.
+			status = constants.INVOICE_STATUS_PAID
+		}
+	case constants.INVOICE_STATUS_PENDING:
+		if status != constants.INVOICE_STATUS_PENDING {
+			return "", http.StatusConflict, errors.New("invoice already has payments")
+		}
+	}
+	return status, http.StatusOK, nil
+}
+
+// invoiceTotals prices invoice with the current items of its order, the tax
+// rates of their foods and the invoice's service charge. It also returns the
+// order summary narrowed to the items on the invoice.
+func invoiceTotals(ctx context.Context, store *repository.Store, invoice models.Invoice) (models.InvoiceTotals, []models.OrderSummary, error) {
+	summaries, err := store.OrderItems.ItemsByOrder(ctx, invoice.OrderId)
+	if err != nil {
+		return models.InvoiceTotals{}, nil, err
+	}
+	return totalsFor(ctx, store, invoice, summaries)
+}
+
+// totalsFor is invoiceTotals for an order summary that is already loaded.
+func totalsFor(ctx context.Context, store *repository.Store, invoice models.Invoice, summaries []models.OrderSummary) (models.InvoiceTotals, []models.OrderSummary, error) {
+	summaries = helpers.SummaryForItems(summaries, invoice.OrderItemIds)
+	taxRates, err := store.TaxRates.List(ctx)
+	if err != nil {
+		return models.InvoiceTotals{}, nil, err
+	}
+
+	ratesById := map[string]models.TaxRate{}
+	var defaultRates []models.TaxRate
+	for _, taxRate := range taxRates {
+		ratesById[taxRate.TaxRateId] = taxRate
+		if taxRate.Default {
+			defaultRates = append(defaultRates, taxRate)
+		}
+	}
+
+	foodRates := map[string][]models.TaxRate{}
+	foodMenus := map[string]string{}
+	var lines []helpers.TaxedLine
+	var promotionLines []helpers.PromotionLine
+	var lineItems []*models.OrderItemDetail
+	for s := range summaries {
+		for i := range summaries[s].OrderItems {
+			item := &summaries[s].OrderItems[i]
+			if item.Amount == nil {
+				continue
+			}
+			rates, ok := foodRates[item.FoodId]
+			if !ok {
+				rates = defaultRates
+				food, err := store.Foods.Get(ctx, item.FoodId)
+				if err != nil && !errors.Is(err, repository.ErrNotFound) {
+					return models.InvoiceTotals{}, nil, err
+				}
+				if len(food.TaxRateIds) > 0 {
+					rates = nil
+					for _, taxRateId := range food.TaxRateIds {
+						taxRate, ok := ratesById[taxRateId]
+						if !ok {
+							return models.InvoiceTotals{}, nil, fmt.Errorf("food %s is charged tax rate %s, which does not exist", item.FoodId, taxRateId)
+						}
+						rates = append(rates, taxRate)
+					}
+				}
+				foodRates[item.FoodId] = rates
+				if food.MenuId != nil {
+					foodMenus[item.FoodId] = *food.MenuId
+				}
+			}
+			lines = append(lines, helpers.TaxedLine{Amount: *item.Amount, TaxRates: rates})
+			promotionLines = append(promotionLines, helpers.PromotionLine{
+				FoodId:    item.FoodId,
+				MenuId:    foodMenus[item.FoodId],
+				Count:     item.Count,
+				Amount:    *item.Amount,
+				OrderedAt: item.CreatedAt,
+			})
+			lineItems = append(lineItems, item)
+		}
+	}
+
+	promotions, err := store.Promotions.List(ctx)
+	if err != nil {
+		return models.InvoiceTotals{}, nil, err
+	}
+	at := invoice.CreatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	discounts := helpers.ApplyPromotions(promotionLines, promotions, invoice.CouponCodes, at)
+	for i, discount := range discounts.Lines {
+		lines[i].Discount = discount
+		if discount > 0 {
+			lineItems[i].Discount = &discount
+		}
+	}
+
+	var serviceChargeRate money.Rate
+	if invoice.ServiceChargeRate != nil {
+		serviceChargeRate = *invoice.ServiceChargeRate
+	}
+	var gratuityRate money.Rate
+	if invoice.GratuityRate != nil {
+		gratuityRate = *invoice.GratuityRate
+	}
+	totals := helpers.InvoiceTotals(lines, discounts.Promotions, serviceChargeRate, gratuityRate)
+	if invoice.SplitCount > 1 {
+		totals = helpers.ShareOfTotals(totals, invoice.SplitIndex, invoice.SplitCount)
+	}
+	return totals, summaries, nil
+}
+
+// issueInvoiceNumber gives invoice the next number of its series. It must
+// run in the transaction that creates the invoice, so that the number is
+// only used up when the invoice is.
+func issueInvoiceNumber(ctx context.Context, store *repository.Store, invoice *models.Invoice) error {
+	series := helpers.InvoiceSeries(invoice.CreatedAt)
+	seq, err := store.Sequences.Next(ctx, series)
+	if err != nil {
+		return err
+	}
+	invoice.InvoiceNumber = helpers.InvoiceNumber(series, seq)
+	invoice.FiscalYear = helpers.FiscalYear(invoice.CreatedAt)
+	return nil
+}
+
+// couponPromotions looks up the promotions of couponCodes and checks they
+// can be redeemed at at.
+func couponPromotions(ctx context.Context, store *repository.Store, couponCodes []string, at time.Time) ([]models.Promotion, int, error) {
+	coupons := make([]models.Promotion, 0, len(couponCodes))
+	for _, couponCode := range couponCodes {
+		promotion, err := store.Promotions.FindByCoupon(ctx, couponCode)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, http.StatusBadRequest, fmt.Errorf("unknown coupon %s", couponCode)
+		}
+		if err != nil {
+			slog.Error("Error while fetching promotion", slog.String("error", err.Error()))
+			return nil, http.StatusInternalServerError, err
+		}
+		if !helpers.PromotionLive(promotion, at) {
+			return nil, http.StatusBadRequest, fmt.Errorf("coupon %s is not valid now", couponCode)
+		}
+		if promotion.UsageLimit > 0 && promotion.UsageCount >= promotion.UsageLimit {
+			return nil, http.StatusBadRequest, fmt.Errorf("coupon %s has been used up", couponCode)
+		}
+		coupons = append(coupons, promotion)
+	}
+	return coupons, http.StatusOK, nil
+}
+
+// releaseCoupons gives back the uses of couponCodes counted when an invoice
+// was created.
+func releaseCoupons(ctx context.Context, store *repository.Store, couponCodes []string) error {
+	for _, couponCode := range couponCodes {
+		promotion, err := store.Promotions.FindByCoupon(ctx, couponCode)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := store.Promotions.Release(ctx, promotion.PromotionId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partyGratuity is the gratuity rate of a bill for order: the requested one
+// if any, otherwise the automatic gratuity when the party at the order's
+// table is large enough.
+func partyGratuity(ctx context.Context, store *repository.Store, order models.Order, requested *money.Rate) (*money.Rate, error) {
+	if requested != nil {
+		return requested, nil
+	}
+	table, err := store.Tables.Get(ctx, order.TableId)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && table.NumberOfGuests == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rate, ok := helpers.AutoGratuityRate(*table.NumberOfGuests); ok {
+		return &rate, nil
+	}
+	return nil, nil
+}
+
+// splitBills splits the items of an order into the invoices splitDto asks
+// for, with their totals.
+func splitBills(ctx context.Context, store *repository.Store, splitDto models.SplitInvoiceDto, gratuityRate *money.Rate) ([]models.Invoice, int, error) {
+	summaries, err := store.OrderItems.ItemsByOrder(ctx, splitDto.OrderId)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if len(summaries) == 0 {
+		return nil, http.StatusBadRequest, errors.New("order has no items to split")
+	}
+	items := summaries[0].OrderItems
+
+	var bills []helpers.Bill
+	switch splitDto.Mode {
+	case constants.SPLIT_BY_ITEM:
+		bills, err = helpers.BillsByItem(items, splitDto.Bills)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	case constants.SPLIT_BY_SEAT:
+		bills = helpers.BillsBySeat(items)
+	case constants.SPLIT_EVENLY:
+		bills = make([]helpers.Bill, splitDto.Guests)
+	}
+
+	now := time.Now().UTC()
+	invoices := make([]models.Invoice, 0, len(bills))
+	for i, bill := range bills {
+		status := constants.INVOICE_STATUS_PENDING
+		invoice := models.Invoice{
+			OrderId:           splitDto.OrderId,
+			PaymentMethod:     splitDto.PaymentMethod,
+			PaymentStatus:     &status,
+			ServiceChargeRate: splitDto.ServiceChargeRate,
+			GratuityRate:      gratuityRate,
+			OrderItemIds:      bill.OrderItemIds,
+			Seat:              bill.Seat,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+			PaymentDueDate:    now.Add(time.Hour * 24),
+		}
+		if splitDto.Mode == constants.SPLIT_EVENLY {
+			invoice.SplitIndex, invoice.SplitCount = i+1, len(bills)
+		}
+
+		totals, _, err := totalsFor(ctx, store, invoice, summaries)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		invoice.Totals = &totals
+		invoice.ID = bson.NewObjectID()
+		invoice.InvoiceId = invoice.ID.Hex()
+		invoices = append(invoices, invoice)
+	}
+	return invoices, http.StatusOK, nil
+}
+
+// lockBillableOrder claims an order for a transaction billing it, refusing
+// cancelled orders and orders billed already. Voided invoices do not count,
+// so a voided bill can be issued again.
+func lockBillableOrder(ctx context.Context, store *repository.Store, orderId string) (int, error) {
+	order, code, err := lockedOrder(ctx, store, orderId)
+	if err != nil {
+		return code, err
+	}
+	if helpers.OrderStatus(order) == constants.ORDER_STATUS_CANCELLED {
+		return http.StatusConflict, errors.New("cancelled orders cannot be invoiced")
+	}
+	invoices, err := store.Invoices.ListByOrder(ctx, orderId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	for _, invoice := range invoices {
+		if !isInvoiceVoided(invoice) {
+			return http.StatusConflict, errors.New("order already has invoices")
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// settleOrder marks an order as PAID once every invoice of the order is
+// paid and the order has been served, whichever comes last. It returns the
+// settled order.
+func settleOrder(ctx context.Context, store *repository.Store, orderId, userId string) (models.Order, bool) {
+	order, err := store.Orders.Get(ctx, orderId)
+	if err != nil || helpers.OrderStatus(order) != constants.ORDER_STATUS_SERVED {
+		return models.Order{}, false
+	}
+	order, code, err := transitionOrder(ctx, store, orderId, constants.ORDER_STATUS_PAID, userId)
+	if err != nil {
+		if code == http.StatusInternalServerError {
+			slog.Error("Error while settling order", slog.String("orderId", orderId), slog.String("error", err.Error()))
+		}
+		return models.Order{}, false
+	}
+	return order, true
+}
+
+// unpaidInvoices counts the invoices of an order that are neither paid nor
+// voided.
+func unpaidInvoices(ctx context.Context, store *repository.Store, orderId string) (int, error) {
+	invoices, err := store.Invoices.ListByOrder(ctx, orderId)
+	if err != nil {
+		return 0, err
+	}
+	unpaid := 0
+	for _, invoice := range invoices {
+		if !isInvoicePaid(invoice) && !isInvoiceVoided(invoice) {
+			unpaid++
+		}
+	}
+	return unpaid, nil
+}
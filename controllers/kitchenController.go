@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/events"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+)
+
+// KitchenItem is the payload of order item events on the kitchen feed.
+type KitchenItem struct {
+	OrderItem models.OrderItem `json:"orderItem"`
+	FoodName  *string          `json:"foodName"`
+	Station   *string          `json:"station"`
+}
+
+const kitchenHeartbeat = 15 * time.Second
+
+// KitchenFeed streams order and order item changes as Server-Sent Events.
+// Screens pick their station with ?station= and resume after a reconnect
+// with the standard Last-Event-ID header (or ?lastEventId=).
+func KitchenFeed(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		station := c.Query("station")
+
+		lastEventIdStr := c.GetHeader("Last-Event-ID")
+		if lastEventIdStr == "" {
+			lastEventIdStr = c.Query("lastEventId")
+		}
+		var lastEventId uint64
+		if lastEventIdStr != "" {
+			id, err := strconv.ParseUint(lastEventIdStr, 10, 64)
+			if err != nil {
+				utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("invalid last event id: %s", lastEventIdStr))
+				return
+			}
+			lastEventId = id
+		}
+
+		missed, complete, feed, unsubscribe := store.Events.Subscribe(lastEventId)
+		defer unsubscribe()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Status(http.StatusOK)
+
+		if !complete {
+			fmt.Fprint(c.Writer, "event: resync\ndata: {}\n\n")
+		}
+		for _, e := range missed {
+			if e.ForStation(station) {
+				writeEvent(c.Writer, e)
+			}
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(kitchenHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case e, ok := <-feed:
+				if !ok {
+					// Dropped for falling behind; the client reconnects and
+					// resumes from the last ID it received.
+					return
+				}
+				if e.ForStation(station) {
+					writeEvent(c.Writer, e)
+					c.Writer.Flush()
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(c.Writer, ": heartbeat\n\n")
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func writeEvent(w io.Writer, e events.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("Error while encoding event", slog.String("error", err.Error()))
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
+
+func publishOrder(store *repository.Store, eventType string, order models.Order) {
+	store.Events.Publish(events.Event{Type: eventType, OrderId: order.OrderID, Data: order})
+}
+
+// publishOrderItems emits one event per item, tagged with the station of its
+// food so that each kitchen screen only receives its own tickets.
+func publishOrderItems(ctx context.Context, store *repository.Store, eventType string, orderItems []models.OrderItem) {
+	for _, orderItem := range orderItems {
+		item := KitchenItem{OrderItem: orderItem}
+		var stations []string
+
+		food, err := store.Foods.Get(ctx, orderItem.FoodId)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			slog.Error("Error while fetching food", slog.String("error", err.Error()))
+		}
+		if err == nil {
+			item.FoodName = food.Name
+			item.Station = food.Station
+			if food.Station != nil {
+				stations = []string{*food.Station}
+			}
+		}
+
+		store.Events.Publish(events.Event{
+			Type:     eventType,
+			OrderId:  orderItem.OrderId,
+			Stations: stations,
+			Data:     item,
+		})
+	}
+}
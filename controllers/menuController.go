@@ -3,7 +3,6 @@ package controllers
 import (
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -11,11 +10,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
 	"github.com/jrskg/go-restaurant/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
-func CreateMenu() gin.HandlerFunc {
+func CreateMenu(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -40,8 +40,7 @@ func CreateMenu() gin.HandlerFunc {
 		menu.ID = bson.NewObjectID()
 		menu.MenuId = menu.ID.Hex()
 
-		_, err := menuCollection.InsertOne(ctx, menu)
-		if err != nil {
+		if err := store.Menus.Create(ctx, menu); err != nil {
 			slog.Error("Error while creating menu", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
@@ -54,7 +53,7 @@ func CreateMenu() gin.HandlerFunc {
 func inTimeSpan(start, end, check time.Time) bool {
 	return start.After(check) && end.After(start)
 }
-func UpdateMenu() gin.HandlerFunc {
+func UpdateMenu(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -87,33 +86,20 @@ func UpdateMenu() gin.HandlerFunc {
 			}
 		}
 
-		filter := bson.M{"menuId": menuId}
-		updateFields := bson.M{
-			"name":      updateDto.Name,
-			"category":  updateDto.Category,
-			"startDate": updateDto.StartDate,
-			"endDate":   updateDto.EndDate,
-		}
-		updateObj := bson.M{"updatedAt": time.Now().UTC()}
-		for k, v := range updateFields {
-			if !utils.IsNil(v) {
-				updateObj[k] = v
-			}
+		err := store.Menus.Update(ctx, menuId, updateDto)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("menu not found"))
+			return
 		}
-		result, err := menuCollection.UpdateOne(ctx, filter, bson.M{"$set": updateObj})
 		if err != nil {
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
-		if result.MatchedCount < 1 {
-			utils.ApiError(c, http.StatusNotFound, errors.New("menu not found"))
-			return
-		}
 		utils.ApiSuccess(c, http.StatusOK, updateDto, "Menu updated successfully")
 	}
 }
 
-func DeleteMenu() gin.HandlerFunc {
+func DeleteMenu(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -124,14 +110,13 @@ func DeleteMenu() gin.HandlerFunc {
 			return
 		}
 
-		result, err := menuCollection.DeleteOne(ctx, bson.M{"menuId": menuId})
-		if err != nil {
-			utils.ApiError(c, http.StatusInternalServerError, err)
+		err := store.Menus.Delete(ctx, menuId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("menu not found"))
 			return
 		}
-
-		if result.DeletedCount < 1 {
-			utils.ApiError(c, http.StatusNotFound, errors.New("menu not found"))
+		if err != nil {
+			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
 
@@ -139,7 +124,7 @@ func DeleteMenu() gin.HandlerFunc {
 	}
 }
 
-func GetMenu() gin.HandlerFunc {
+func GetMenu(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -150,8 +135,7 @@ func GetMenu() gin.HandlerFunc {
 			return
 		}
 
-		var menu models.Menu
-		err := menuCollection.FindOne(ctx, bson.M{"menuId": menuId}).Decode(&menu)
+		menu, err := store.Menus.Get(ctx, menuId)
 		if err != nil {
 			slog.Error("Error while fetching menu", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusNotFound, err)
@@ -162,26 +146,17 @@ func GetMenu() gin.HandlerFunc {
 	}
 }
 
-func GetAllMenus() gin.HandlerFunc {
+func GetAllMenus(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		var allMenus []models.Menu = make([]models.Menu, 0)
-
-		result, err := menuCollection.Find(ctx, bson.M{})
+		allMenus, err := store.Menus.List(ctx)
 		if err != nil {
 			slog.Error("Error while fetching menu", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
-
-		if err := result.All(ctx, &allMenus); err != nil {
-			slog.Error("Error while fetching menu", slog.String("error", err.Error()))
-			utils.ApiError(c, http.StatusInternalServerError, err)
-			return
-		}
-		fmt.Println(allMenus)
 		utils.ApiSuccess(c, http.StatusOK, allMenus, "Menus fetched successfully")
 	}
 }
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -10,17 +11,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jrskg/go-restaurant/constants"
-	"github.com/jrskg/go-restaurant/database"
+	"github.com/jrskg/go-restaurant/helpers"
 	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
 	"github.com/jrskg/go-restaurant/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
-	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
 )
 
-var orderCollection = database.OpenCollection(database.DBClient, constants.ORDER_COLLECTION)
-
-func CreateOrder() gin.HandlerFunc {
+func CreateOrder(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -40,8 +38,7 @@ func CreateOrder() gin.HandlerFunc {
 			return
 		}
 
-		var table models.Table
-		err := tableCollection.FindOne(ctx, bson.M{"tableId": order.TableId}).Decode(&table)
+		_, err := store.Tables.Get(ctx, order.TableId)
 		if err != nil {
 			slog.Error("Error while fetching table", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusNotFound, err)
@@ -58,19 +55,26 @@ func CreateOrder() gin.HandlerFunc {
 		order.ID = bson.NewObjectID()
 		order.OrderID = order.ID.Hex()
 		order.OrderDate = order.OrderDate.UTC()
+		openOrder(&order, c.GetString("userId"))
+		if err := creditOrder(ctx, store, &order, c.GetString("userId")); err != nil {
+			slog.Error("Error while crediting order", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
 
-		_, err = orderCollection.InsertOne(ctx, order)
-		if err != nil {
+		if err := store.Orders.Create(ctx, order); err != nil {
 			slog.Error("Error while creating order", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
+		publishOrder(store, constants.EVENT_ORDER_CREATED, order)
+		trackTableOrder(ctx, store, order)
 
 		utils.ApiSuccess(c, http.StatusCreated, order, "Order created successfully")
 	}
 }
 
-func UpdateOrder() gin.HandlerFunc {
+func UpdateOrder(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -101,32 +105,55 @@ func UpdateOrder() gin.HandlerFunc {
 			return
 		}
 
-		count, err := tableCollection.CountDocuments(ctx, bson.M{"tableId": updateOrderDto.TableId})
-		if err != nil || count < 1 {
-			utils.ApiError(c, http.StatusBadRequest, errors.New("table not found"))
+		order, err := store.Orders.Get(ctx, orderId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("order not found"))
 			return
 		}
-
-		filter := bson.M{"orderId": orderId}
-		update := bson.M{"tableId": updateOrderDto.TableId, "updatedAt": time.Now().UTC()}
-
-		result, err := orderCollection.UpdateOne(ctx, filter, bson.M{"$set": update})
 		if err != nil {
-			slog.Error("Error while updating order", slog.String("error", err.Error()))
+			slog.Error("Error while fetching order", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
+		if helpers.IsOrderClosed(order) {
+			utils.ApiError(c, http.StatusConflict, fmt.Errorf("order is %s", helpers.OrderStatus(order)))
+			return
+		}
+		if code, err := checkBusinessDayOpen(ctx, store, order.CreatedAt); err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while checking business day", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
 
-		if result.ModifiedCount == 0 {
+		exists, err := store.Tables.Exists(ctx, *updateOrderDto.TableId)
+		if err != nil || !exists {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("table not found"))
+			return
+		}
+
+		err = store.Orders.UpdateTable(ctx, orderId, *updateOrderDto.TableId)
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.ApiError(c, http.StatusNotFound, errors.New("order not found"))
 			return
 		}
+		if err != nil {
+			slog.Error("Error while updating order", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if previous := order.TableId; previous != *updateOrderDto.TableId {
+			untrackTableOrder(ctx, store, previous, orderId)
+			order.TableId = *updateOrderDto.TableId
+			trackTableOrder(ctx, store, order)
+		}
 
 		utils.ApiSuccess(c, http.StatusOK, nil, "Order updated successfully")
 	}
 }
 
-func DeleteOrder() gin.HandlerFunc {
+func DeleteOrder(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -137,43 +164,45 @@ func DeleteOrder() gin.HandlerFunc {
 			return
 		}
 
-		wc := writeconcern.Majority()
-		txnOptions := options.Transaction().SetWriteConcern(wc)
-
-		session, err := database.DBClient.StartSession()
-		if err != nil {
-			slog.Error("Error while starting session", slog.String("error", err.Error()))
-			utils.ApiError(c, http.StatusInternalServerError, err)
-			return
-		}
-		defer session.EndSession(context.Background())
-		callback := func(ctx context.Context) (any, error) {
-			_, err := orderCollection.DeleteOne(ctx, bson.M{"orderId": orderId})
-			if err != nil {
-				slog.Error("Error while deleting order", slog.String("error", err.Error()))
-				return nil, err
+		var order models.Order
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			if order, code, err = lockedOrder(ctx, store, orderId); err != nil {
+				return err
 			}
-
-			_, err = orderItemCollection.DeleteMany(ctx, bson.M{"orderId": orderId})
-			if err != nil {
-				slog.Error("Error while deleting order items", slog.String("error", err.Error()))
-				return nil, err
+			if helpers.OrderStatus(order) == constants.ORDER_STATUS_PAID {
+				code = http.StatusConflict
+				return errors.New("paid orders cannot be deleted")
+			}
+			if code, err = checkBusinessDayOpen(ctx, store, order.CreatedAt); err != nil {
+				return err
+			}
+			if code, err = checkNeverBilled(ctx, store, orderId); err != nil {
+				return err
 			}
-			return nil, nil
-		}
 
-		_, err = session.WithTransaction(ctx, callback, txnOptions)
+			code = http.StatusInternalServerError
+			if err := store.Orders.Delete(ctx, orderId); err != nil {
+				return err
+			}
+			return store.OrderItems.DeleteByOrder(ctx, orderId)
+		})
 		if err != nil {
-			slog.Error("Error while deleting order", slog.String("error", err.Error()))
-			utils.ApiError(c, http.StatusInternalServerError, err)
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while deleting order", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
 			return
 		}
+		publishOrder(store, constants.EVENT_ORDER_DELETED, order)
+		refreshTable(ctx, store, order.TableId)
 
 		utils.ApiSuccess(c, http.StatusOK, nil, "Order deleted successfully")
 	}
 }
 
-func GetOrder() gin.HandlerFunc {
+func GetOrder(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, candel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer candel()
@@ -184,8 +213,7 @@ func GetOrder() gin.HandlerFunc {
 			return
 		}
 
-		var order models.Order
-		err := orderCollection.FindOne(ctx, bson.M{"orderId": orderId}).Decode(&order)
+		order, err := store.Orders.Get(ctx, orderId)
 		if err != nil {
 			slog.Error("Error while fetching order", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusNotFound, err)
@@ -196,40 +224,246 @@ func GetOrder() gin.HandlerFunc {
 	}
 }
 
-func GetAllOrders() gin.HandlerFunc {
+func GetAllOrders(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		allOrders := make([]models.Order, 0)
-		result, err := orderCollection.Find(ctx, bson.M{})
+		allOrders, err := store.Orders.List(ctx)
 		if err != nil {
 			slog.Error("Error while fetching orders", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		if err := result.All(ctx, &allOrders); err != nil {
-			slog.Error("Error while fetching orders", slog.String("error", err.Error()))
-			utils.ApiError(c, http.StatusInternalServerError, err)
+		utils.ApiSuccess(c, http.StatusOK, allOrders, "Orders fetched successfully")
+	}
+}
+
+func UpdateOrderStatus(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		orderId := c.Param("orderId")
+		if orderId == "" {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid order id"))
 			return
 		}
 
-		utils.ApiSuccess(c, http.StatusOK, allOrders, "Orders fetched successfully")
+		var updateOrderStatusDto models.UpdateOrderStatusDto
+		if err := c.BindJSON(&updateOrderStatusDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(updateOrderStatusDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if role := c.GetString("role"); !helpers.CanSetOrderStatus(role, *updateOrderStatusDto.Status) {
+			utils.ApiError(c, http.StatusForbidden, fmt.Errorf("%s cannot move orders to %s", role, *updateOrderStatusDto.Status))
+			return
+		}
+
+		order, code, err := transitionOrder(ctx, store, orderId, *updateOrderStatusDto.Status, c.GetString("userId"))
+		if err != nil {
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, order, "Order status updated successfully")
+	}
+}
+
+func CancelOrder(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		orderId := c.Param("orderId")
+		if orderId == "" {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid order id"))
+			return
+		}
+
+		order, code, err := transitionOrder(ctx, store, orderId, constants.ORDER_STATUS_CANCELLED, c.GetString("userId"))
+		if err != nil {
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, order, "Order cancelled successfully")
 	}
 }
 
-func OrderItemOrderCreator(order models.Order) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// transitionOrder moves an order to status along the lifecycle graph and
+// returns the HTTP status to report when it cannot. An order paid in full
+// before it was served is settled as soon as it is.
+func transitionOrder(ctx context.Context, store *repository.Store, orderId, status, userId string) (models.Order, int, error) {
+	var order models.Order
+	code := http.StatusInternalServerError
+	err := store.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if order, code, err = lockedOrder(ctx, store, orderId); err != nil {
+			return err
+		}
+		if code, err = checkBusinessDayOpen(ctx, store, order.CreatedAt); err != nil {
+			return err
+		}
+
+		from := helpers.OrderStatus(order)
+		if !helpers.CanTransitionOrder(from, status) {
+			code = http.StatusConflict
+			return fmt.Errorf("cannot move order from %s to %s", from, status)
+		}
+		switch status {
+		case constants.ORDER_STATUS_PAID:
+			code, err = checkOrderSettled(ctx, store, orderId)
+		case constants.ORDER_STATUS_CANCELLED:
+			code, err = checkOrderUnbilled(ctx, store, orderId)
+		}
+		if err != nil {
+			return err
+		}
+
+		change := models.OrderStatusChange{Status: status, ChangedAt: time.Now().UTC(), ChangedBy: userId}
+		order, err = store.Orders.UpdateStatus(ctx, orderId, from, change)
+		if errors.Is(err, repository.ErrNotFound) {
+			code = http.StatusConflict
+			return errors.New("order status changed concurrently, retry")
+		}
+		code = http.StatusInternalServerError
+		return err
+	})
+	if err != nil {
+		if code == http.StatusInternalServerError {
+			slog.Error("Error while updating order status", slog.String("error", err.Error()))
+		}
+		return models.Order{}, code, err
+	}
+	publishOrder(store, constants.EVENT_ORDER_STATUS_CHANGED, order)
+	refreshTable(ctx, store, order.TableId)
+
+	if status == constants.ORDER_STATUS_SERVED {
+		if settled, ok := settleOrder(ctx, store, orderId, userId); ok {
+			order = settled
+		}
+	}
+	return order, http.StatusOK, nil
+}
+
+// lockedOrder fetches an order and claims it for the transaction.
+func lockedOrder(ctx context.Context, store *repository.Store, orderId string) (models.Order, int, error) {
+	err := store.Orders.Lock(ctx, orderId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.Order{}, http.StatusNotFound, errors.New("order not found")
+	}
+	if err != nil {
+		return models.Order{}, http.StatusInternalServerError, err
+	}
+	order, err := store.Orders.Get(ctx, orderId)
+	if err != nil {
+		return models.Order{}, http.StatusInternalServerError, err
+	}
+	return order, http.StatusOK, nil
+}
+
+// checkOrderSettled refuses to close an order that was never billed or
+// still has invoices to pay. An order billed in several invoices is settled
+// by the last of them.
+func checkOrderSettled(ctx context.Context, store *repository.Store, orderId string) (int, error) {
+	invoices, err := store.Invoices.ListByOrder(ctx, orderId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	billed, unpaid := 0, 0
+	for _, invoice := range invoices {
+		if isInvoiceVoided(invoice) {
+			continue
+		}
+		billed++
+		if !isInvoicePaid(invoice) {
+			unpaid++
+		}
+	}
+	if billed == 0 {
+		return http.StatusConflict, errors.New("order has no invoices")
+	}
+	if unpaid > 0 {
+		return http.StatusConflict, fmt.Errorf("order has %d unpaid invoices", unpaid)
+	}
+	return http.StatusOK, nil
+}
+
+// checkNeverBilled refuses to delete an order that was ever billed, voided
+// bills included, so that no invoice or ledger entry is left pointing at a
+// deleted order and no invoice number goes missing.
+func checkNeverBilled(ctx context.Context, store *repository.Store, orderId string) (int, error) {
+	invoices, err := store.Invoices.ListByOrder(ctx, orderId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	payments, err := store.Payments.ListByOrder(ctx, orderId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if len(invoices) > 0 || len(payments) > 0 {
+		return http.StatusConflict, fmt.Errorf("order %s has been billed; cancel it instead", orderId)
+	}
+	return http.StatusOK, nil
+}
+
+// checkOrderUnbilled refuses to cancel an order with a bill standing or
+// money still held against it.
+func checkOrderUnbilled(ctx context.Context, store *repository.Store, orderId string) (int, error) {
+	invoices, err := store.Invoices.ListByOrder(ctx, orderId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	for _, invoice := range invoices {
+		if !isInvoiceVoided(invoice) {
+			return http.StatusConflict, errors.New("order is billed, void its invoices first")
+		}
+	}
+	payments, err := store.Payments.ListByOrder(ctx, orderId)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if paid, refunded := helpers.LedgerAmounts(payments); paid != refunded {
+		return http.StatusConflict, errors.New("order has payments; refund them first")
+	}
+	return http.StatusOK, nil
+}
+
+// openOrder puts a new order at the start of its lifecycle.
+func openOrder(order *models.Order, userId string) {
+	order.Status = constants.ORDER_STATUS_OPEN
+	order.StatusHistory = []models.OrderStatusChange{
+		{Status: constants.ORDER_STATUS_OPEN, ChangedAt: order.CreatedAt, ChangedBy: userId},
+	}
+}
+
+// OrderItemOrderCreator stores the order that items being ordered go on.
+// Nothing is announced yet: the caller publishes the order once its items
+// are written and committed.
+func OrderItemOrderCreator(ctx context.Context, store *repository.Store, order models.Order, userId string) (models.Order, error) {
 	order.CreatedAt = time.Now().UTC()
 	order.UpdatedAt = time.Now().UTC()
 	order.ID = bson.NewObjectID()
 	order.OrderID = order.ID.Hex()
+	openOrder(&order, userId)
+	if err := creditOrder(ctx, store, &order, userId); err != nil {
+		return models.Order{}, err
+	}
 
-	_, err := orderCollection.InsertOne(ctx, order)
-	if err != nil {
-		return "", err
+	if err := store.Orders.Create(ctx, order); err != nil {
+		return models.Order{}, err
 	}
-	return order.OrderID, nil
+	return order, nil
 }
@@ -3,18 +3,21 @@ package controllers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jrskg/go-restaurant/constants"
-	"github.com/jrskg/go-restaurant/database"
+	"github.com/jrskg/go-restaurant/helpers"
 	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+	"github.com/jrskg/go-restaurant/repository"
 	"github.com/jrskg/go-restaurant/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 type OrderItemPack struct {
@@ -22,9 +25,7 @@ type OrderItemPack struct {
 	OrderItems []models.OrderItem `json:"orderItems"`
 }
 
-var orderItemCollection = database.OpenCollection(database.DBClient, constants.ORDER_ITEM_COLLECTION)
-
-func CreateOrderItem() gin.HandlerFunc {
+func CreateOrderItem(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -46,25 +47,24 @@ func CreateOrderItem() gin.HandlerFunc {
 			return
 		}
 
-		order.OrderDate = time.Now().UTC()
-		order.TableId = *orderItemPack.TableId
-		createdOrderId, err := OrderItemOrderCreator(order)
-		if err != nil {
-			slog.Error("Error while creating order", slog.String("error", err.Error()))
-			utils.ApiError(c, http.StatusInternalServerError, err)
-			return
-		}
-
 		orderItemsToBeInserted := make([]models.OrderItem, 0)
 
 		for _, orderItem := range orderItemPack.OrderItems {
-			orderItem.OrderId = createdOrderId
-			num := utils.ToFixed(*orderItem.UnitPrice, 2)
-			orderItem.UnitPrice = &num
-			if err := utils.Validate.Struct(orderItem); err != nil {
+			if err := utils.Validate.StructExcept(orderItem, "OrderId"); err != nil {
 				utils.ApiError(c, http.StatusBadRequest, err)
 				return
 			}
+			price, code, err := resolveItemPrice(ctx, store, orderItem.FoodId, *orderItem.Quantity)
+			if err != nil {
+				utils.ApiError(c, code, err)
+				return
+			}
+			orderItem.UnitPrice = &price
+			count := helpers.ItemCount(orderItem)
+			lineTotal := helpers.LineTotal(price, count)
+			orderItem.Count = &count
+			orderItem.LineTotal = &lineTotal
+			orderItem.Currency = money.Currency()
 
 			orderItem.ID = bson.NewObjectID()
 			orderItem.OrderItemId = orderItem.ID.Hex()
@@ -74,18 +74,33 @@ func CreateOrderItem() gin.HandlerFunc {
 			orderItemsToBeInserted = append(orderItemsToBeInserted, orderItem)
 		}
 
-		_, err = orderItemCollection.InsertMany(ctx, orderItemsToBeInserted)
+		order.OrderDate = time.Now().UTC()
+		order.TableId = *orderItemPack.TableId
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			order, err = OrderItemOrderCreator(ctx, store, order, c.GetString("userId"))
+			if err != nil {
+				return err
+			}
+			for i := range orderItemsToBeInserted {
+				orderItemsToBeInserted[i].OrderId = order.OrderID
+			}
+			return store.OrderItems.CreateMany(ctx, orderItemsToBeInserted)
+		})
 		if err != nil {
-			slog.Error("Error while inserting order items", slog.String("error", err.Error()))
+			slog.Error("Error while creating order items", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
+		publishOrder(store, constants.EVENT_ORDER_CREATED, order)
+		publishOrderItems(ctx, store, constants.EVENT_ORDER_ITEM_CREATED, orderItemsToBeInserted)
+		trackTableOrder(ctx, store, order)
 
 		utils.ApiSuccess(c, http.StatusCreated, orderItemsToBeInserted, "Order items created successfully")
 	}
 }
 
-func UpdateOrderItem() gin.HandlerFunc {
+func UpdateOrderItem(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -110,52 +125,131 @@ func UpdateOrderItem() gin.HandlerFunc {
 			utils.ApiError(c, http.StatusBadRequest, err)
 			return
 		}
-		if updateOrderItemDto.UnitPrice != nil {
-			num := utils.ToFixed(*updateOrderItemDto.UnitPrice, 2)
-			updateOrderItemDto.UnitPrice = &num
+		orderItem, err := store.OrderItems.Get(ctx, orderItemId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("order item not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching order item", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		if orderItem.Void != nil {
+			utils.ApiError(c, http.StatusConflict, errors.New("order item is void"))
+			return
+		}
+		if code, err := checkBusinessDayOpen(ctx, store, orderItem.CreatedAt); err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while checking business day", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		order, err := store.Orders.Get(ctx, orderItem.OrderId)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			slog.Error("Error while fetching order", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if err == nil && helpers.IsOrderClosed(order) {
+			utils.ApiError(c, http.StatusConflict, fmt.Errorf("order is %s", helpers.OrderStatus(order)))
+			return
 		}
-		fieldsToUpdate := bson.M{
-			"unitPrice": updateOrderItemDto.UnitPrice,
-			"quantity":  updateOrderItemDto.Quantity,
-			"foodId":    updateOrderItemDto.FoodId,
+
+		if updateOrderItemDto.Quantity != nil || updateOrderItemDto.FoodId != nil {
+			foodId, size := orderItem.FoodId, *orderItem.Quantity
+			if updateOrderItemDto.FoodId != nil {
+				foodId = *updateOrderItemDto.FoodId
+			}
+			if updateOrderItemDto.Quantity != nil {
+				size = *updateOrderItemDto.Quantity
+			}
+			price, code, err := resolveItemPrice(ctx, store, foodId, size)
+			if err != nil {
+				utils.ApiError(c, code, err)
+				return
+			}
+			updateOrderItemDto.UnitPrice = &price
 		}
-		updateObj := bson.M{"updatedAt": time.Now().UTC()}
-		for k, v := range fieldsToUpdate {
-			if !utils.IsNil(v) {
-				updateObj[k] = v
+		if updateOrderItemDto.UnitPrice != nil || updateOrderItemDto.Count != nil {
+			unitPrice, count := orderItem.UnitPrice, helpers.ItemCount(orderItem)
+			if updateOrderItemDto.UnitPrice != nil {
+				unitPrice = updateOrderItemDto.UnitPrice
+			}
+			if updateOrderItemDto.Count != nil {
+				count = *updateOrderItemDto.Count
+			}
+			if unitPrice != nil {
+				lineTotal := helpers.LineTotal(*unitPrice, count)
+				updateOrderItemDto.LineTotal = &lineTotal
 			}
 		}
-		filter := bson.M{"orderItemId": orderItemId}
 
-		restaurant, err := orderItemCollection.UpdateOne(ctx, filter, bson.M{"$set": updateObj})
+		err = store.OrderItems.Update(ctx, orderItemId, updateOrderItemDto)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("order item not found"))
+			return
+		}
 		if err != nil {
 			slog.Error("Error while updating order items", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
-		if restaurant.ModifiedCount != 1 {
-			utils.ApiError(c, http.StatusInternalServerError, errors.New("order item not found"))
-			return
+
+		updated, err := store.OrderItems.Get(ctx, orderItemId)
+		if err == nil {
+			publishOrderItems(ctx, store, constants.EVENT_ORDER_ITEM_UPDATED, []models.OrderItem{updated})
 		}
 
 		utils.ApiSuccess(c, http.StatusOK, nil, "Order item updated successfully")
 	}
 }
 
-func GetOrderItems() gin.HandlerFunc {
+// VoidOrderItem takes an item off an order before it is paid for. The item
+// drops out of the bill and a negative VOID entry is written to the ledger.
+func VoidOrderItem(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		result, err := orderItemCollection.Find(ctx, bson.M{})
+		var reversalDto models.ReversalDto
+		if !bindReversal(c, &reversalDto) {
+			return
+		}
+		reversal, code, err := approveReversal(ctx, store, c, reversalDto)
 		if err != nil {
-			slog.Error("Error while fetching order items", slog.String("error", err.Error()))
-			utils.ApiError(c, http.StatusInternalServerError, err)
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		var orderItem models.OrderItem
+		err = store.Transaction(ctx, func(ctx context.Context) error {
+			orderItem, code, err = voidOrderItem(ctx, store, c.Param("orderItemId"), reversal)
+			return err
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while voiding order item", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
 			return
 		}
 
-		allOrderItems := make([]models.OrderItem, 0)
-		if err := result.All(ctx, &allOrderItems); err != nil {
+		publishOrderItems(ctx, store, constants.EVENT_ORDER_ITEM_VOIDED, []models.OrderItem{orderItem})
+		utils.ApiSuccess(c, http.StatusOK, orderItem, "Order item voided successfully")
+	}
+}
+
+func GetOrderItems(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		allOrderItems, err := store.OrderItems.List(ctx)
+		if err != nil {
 			slog.Error("Error while fetching order items", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
@@ -165,15 +259,18 @@ func GetOrderItems() gin.HandlerFunc {
 	}
 }
 
-func GetOrderItemsByOrder() gin.HandlerFunc {
+func GetOrderItemsByOrder(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
 		orderId := c.Param("orderId")
 		if orderId == "" {
 			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid order id"))
 			return
 		}
 
-		allOrderItems, err := ItemsByOrder(orderId)
+		allOrderItems, err := store.OrderItems.ItemsByOrder(ctx, orderId)
 		if err != nil {
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
@@ -183,121 +280,7 @@ func GetOrderItemsByOrder() gin.HandlerFunc {
 	}
 }
 
-func ItemsByOrder(id string) (orderItems []bson.M, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	matchStage := bson.D{
-		{Key: "$match", Value: bson.D{{Key: "orderId", Value: id}}},
-	}
-	lookupFoodStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "food"},
-			{Key: "localField", Value: "foodId"},
-			{Key: "foreignField", Value: "foodId"},
-			{Key: "as", Value: "food"},
-		}},
-	}
-	unwindFoodStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$food"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
-	}
-	lookupOrderStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "order"},
-			{Key: "localField", Value: "orderId"},
-			{Key: "foreignField", Value: "orderId"},
-			{Key: "as", Value: "order"},
-		}},
-	}
-	unwindOrderStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$order"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
-	}
-	lookupTableStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "table"},
-			{Key: "localField", Value: "order.tableId"},
-			{Key: "foreignField", Value: "tableId"},
-			{Key: "as", Value: "table"},
-		}},
-	}
-	unwindTableStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$table"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
-	}
-	projectStage := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: 0},
-			{Key: "amount", Value: "$food.price"},
-			{Key: "foodName", Value: "$food.name"},
-			{Key: "foodImage", Value: "$food.foodImage"},
-			{Key: "totalCount", Value: 1},
-			{Key: "tableNumber", Value: "$table.tableNumber"},
-			{Key: "tableId", Value: "$table.tableId"},
-			{Key: "orderId", Value: "$order.orderId"},
-			{Key: "quantity", Value: 1},
-		}},
-	}
-	groupStage := bson.D{
-		{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "orderId", Value: "$orderId"},
-				{Key: "tableId", Value: "$tableId"},
-				{Key: "tableNumber", Value: "$tableNumber"},
-			}},
-			{Key: "paymentDue", Value: bson.D{
-				{Key: "$sum", Value: "$amount"},
-			}},
-			{Key: "totalCount", Value: bson.D{
-				{Key: "$sum", Value: 1},
-			}},
-			{Key: "orderItems", Value: bson.D{
-				{Key: "$push", Value: "$$ROOT"},
-			}},
-		}},
-	}
-	projectStage2 := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: 0},
-			{Key: "paymentDue", Value: 1},
-			{Key: "totalCount", Value: 1},
-			{Key: "tableNumber", Value: "$_id.tableNumber"},
-			{Key: "orderItems", Value: 1},
-		}},
-	}
-
-	cursor, err := orderItemCollection.Aggregate(ctx, mongo.Pipeline{
-		matchStage,
-		lookupFoodStage,
-		unwindFoodStage,
-		lookupOrderStage,
-		unwindOrderStage,
-		lookupTableStage,
-		unwindTableStage,
-		projectStage,
-		groupStage,
-		projectStage2,
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if err = cursor.All(ctx, &orderItems); err != nil {
-		return nil, err
-	}
-
-	return orderItems, nil
-}
-
-func GetOrderItem() gin.HandlerFunc {
+func GetOrderItem(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -308,8 +291,7 @@ func GetOrderItem() gin.HandlerFunc {
 			return
 		}
 
-		orderItem := models.OrderItem{}
-		err := orderItemCollection.FindOne(ctx, bson.M{"orderItemId": orderItemId}).Decode(&orderItem)
+		orderItem, err := store.OrderItems.Get(ctx, orderItemId)
 		if err != nil {
 			slog.Error("Error while fetching order item", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusNotFound, err)
@@ -319,3 +301,73 @@ func GetOrderItem() gin.HandlerFunc {
 		utils.ApiSuccess(c, http.StatusOK, orderItem, "Order item fetched successfully")
 	}
 }
+
+// resolveItemPrice prices one portion of foodId in size from the catalog,
+// returning the HTTP status to report when that is not possible.
+func resolveItemPrice(ctx context.Context, store *repository.Store, foodId, size string) (money.Amount, int, error) {
+	food, err := store.Foods.Get(ctx, foodId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, http.StatusBadRequest, fmt.Errorf("food not found: %s", foodId)
+	}
+	if err != nil {
+		slog.Error("Error while fetching food", slog.String("error", err.Error()))
+		return 0, http.StatusInternalServerError, err
+	}
+
+	price, err := helpers.ItemPrice(food, size)
+	if err != nil {
+		return 0, http.StatusBadRequest, err
+	}
+	return price, http.StatusOK, nil
+}
+
+func voidOrderItem(ctx context.Context, store *repository.Store, orderItemId string, reversal models.Reversal) (models.OrderItem, int, error) {
+	orderItem, err := store.OrderItems.Get(ctx, orderItemId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.OrderItem{}, http.StatusNotFound, errors.New("order item not found")
+	}
+	if err != nil {
+		return models.OrderItem{}, http.StatusInternalServerError, err
+	}
+	if orderItem.Void != nil {
+		return models.OrderItem{}, http.StatusConflict, errors.New("order item is already void")
+	}
+	if code, err := checkBusinessDayOpen(ctx, store, orderItem.CreatedAt); err != nil {
+		return models.OrderItem{}, code, err
+	}
+
+	order, err := store.Orders.Get(ctx, orderItem.OrderId)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return models.OrderItem{}, http.StatusInternalServerError, err
+	}
+	if err == nil && helpers.IsOrderClosed(order) {
+		return models.OrderItem{}, http.StatusConflict, fmt.Errorf("order is %s", helpers.OrderStatus(order))
+	}
+
+	invoices, err := store.Invoices.ListByOrder(ctx, orderItem.OrderId)
+	if err != nil {
+		return models.OrderItem{}, http.StatusInternalServerError, err
+	}
+	for _, invoice := range invoices {
+		onInvoice := len(invoice.OrderItemIds) == 0 || slices.Contains(invoice.OrderItemIds, orderItemId)
+		if onInvoice && !isInvoiceVoided(invoice) && (invoice.AmountPaid != 0 || isInvoicePaid(invoice)) {
+			return models.OrderItem{}, http.StatusConflict, errors.New("order item is on an invoice with payments; refund it instead")
+		}
+	}
+
+	var lineTotal money.Amount
+	if orderItem.LineTotal != nil {
+		lineTotal = *orderItem.LineTotal
+	}
+	entry := reversalEntry(constants.LEDGER_VOID, orderItem.OrderId, -lineTotal, reversal)
+	entry.OrderItemId = orderItemId
+	if err := store.Payments.Create(ctx, entry); err != nil {
+		return models.OrderItem{}, http.StatusInternalServerError, err
+	}
+	if err := store.OrderItems.Void(ctx, orderItemId, reversal); err != nil {
+		return models.OrderItem{}, http.StatusInternalServerError, err
+	}
+
+	orderItem.Void = &reversal
+	return orderItem, http.StatusOK, nil
+}
@@ -0,0 +1,497 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PaymentReceipt is a recorded payment with the invoice as it stands after
+// the payment.
+type PaymentReceipt struct {
+	Payment models.Payment `json:"payment"`
+	Invoice models.Invoice `json:"invoice"`
+}
+
+var (
+	errInvoiceSettled = errors.New("invoice is already paid")
+	errInvoiceVoided  = errors.New("invoice is void")
+)
+
+func CreatePayment(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		invoiceId := c.Param("invoiceId")
+		if invoiceId == "" {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid invoice id"))
+			return
+		}
+
+		payment := models.Payment{}
+		if err := c.BindJSON(&payment); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(payment); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+		if *payment.Method != constants.PAYMENT_METHOD_CASH && payment.Change != nil && *payment.Change != 0 {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("change is only given on cash payments"))
+			return
+		}
+		if payment.Change != nil && *payment.Change > *payment.Amount {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("change cannot exceed the amount tendered"))
+			return
+		}
+
+		var receipt PaymentReceipt
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			invoice, err := store.Invoices.Get(ctx, invoiceId)
+			if err != nil {
+				return err
+			}
+			if isInvoicePaid(invoice) {
+				return errInvoiceSettled
+			}
+			if isInvoiceVoided(invoice) {
+				return errInvoiceVoided
+			}
+
+			totals, _, err := invoiceTotals(ctx, store, invoice)
+			if err != nil {
+				return err
+			}
+			payments, err := store.Payments.ListByInvoice(ctx, invoiceId)
+			if err != nil {
+				return err
+			}
+			paid, refunded := helpers.LedgerAmounts(payments)
+
+			if payment.Change == nil {
+				change := money.Amount(0)
+				if *payment.Method == constants.PAYMENT_METHOD_CASH {
+					change = helpers.CashChange(*payment.Amount, totals.Total-paid)
+				}
+				payment.Change = &change
+			}
+			payment.Type = constants.LEDGER_PAYMENT
+			payment.OrderItemId = ""
+			payment.Reversal = nil
+			payment.InvoiceId = invoice.InvoiceId
+			payment.OrderId = invoice.OrderId
+			payment.Currency = money.Currency()
+			payment.ReceivedBy = c.GetString("userId")
+			payment.CreatedAt = time.Now().UTC()
+			payment.ID = bson.NewObjectID()
+			payment.PaymentId = payment.ID.Hex()
+			if err := store.Payments.Create(ctx, payment); err != nil {
+				return err
+			}
+
+			paid += *payment.Amount - *payment.Change
+			tips := helpers.LedgerTips(append(payments, payment))
+			status := helpers.PaymentStatus(totals.Total, paid, refunded)
+			method := invoice.PaymentMethod
+			if method == nil {
+				method = payment.Method
+			}
+			invoice, err = store.Invoices.Update(ctx, invoiceId, models.UpdateInvoiceDto{
+				PaymentMethod: method,
+				PaymentStatus: &status,
+				Totals:        &totals,
+				AmountPaid:    &paid,
+				Tips:          &tips,
+			})
+			if err != nil {
+				return err
+			}
+
+			receipt = PaymentReceipt{Payment: payment, Invoice: invoice}
+			return nil
+		})
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("invoice not found"))
+			return
+		}
+		if errors.Is(err, errInvoiceSettled) || errors.Is(err, errInvoiceVoided) {
+			utils.ApiError(c, http.StatusConflict, err)
+			return
+		}
+		if err != nil {
+			slog.Error("Error while recording payment", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		if isInvoicePaid(receipt.Invoice) {
+			if unpaid, err := unpaidInvoices(ctx, store, receipt.Invoice.OrderId); err == nil && unpaid == 0 {
+				settleOrder(ctx, store, receipt.Invoice.OrderId, c.GetString("userId"))
+			}
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, receipt, "Payment recorded successfully")
+	}
+}
+
+func GetInvoicePayments(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		invoiceId := c.Param("invoiceId")
+		if _, err := store.Invoices.Get(ctx, invoiceId); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.ApiError(c, http.StatusNotFound, errors.New("invoice not found"))
+				return
+			}
+			slog.Error("Error while fetching invoice", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		payments, err := store.Payments.ListByInvoice(ctx, invoiceId)
+		if err != nil {
+			slog.Error("Error while fetching payments", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, payments, "Payments fetched successfully")
+	}
+}
+
+// VoidInvoice cancels an invoice nobody has paid yet. The invoice is kept
+// with its totals as they were and a negative VOID entry is written to the
+// ledger.
+func VoidInvoice(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var reversalDto models.ReversalDto
+		if !bindReversal(c, &reversalDto) {
+			return
+		}
+		reversal, code, err := approveReversal(ctx, store, c, reversalDto)
+		if err != nil {
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		var invoice models.Invoice
+		err = store.Transaction(ctx, func(ctx context.Context) error {
+			invoice, code, err = voidInvoice(ctx, store, c.Param("invoiceId"), reversal)
+			return err
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while voiding invoice", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+		refreshOrderTable(ctx, store, invoice.OrderId)
+
+		utils.ApiSuccess(c, http.StatusOK, invoice, "Invoice voided successfully")
+	}
+}
+
+// RefundInvoice gives back money taken on an invoice, either an amount, the
+// line total of one item, or all of it, as a negative REFUND entry.
+func RefundInvoice(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var refundDto models.RefundDto
+		if !bindReversal(c, &refundDto) {
+			return
+		}
+		reversal, code, err := approveReversal(ctx, store, c, refundDto.ReversalDto)
+		if err != nil {
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		var receipt PaymentReceipt
+		err = store.Transaction(ctx, func(ctx context.Context) error {
+			receipt, code, err = refundInvoice(ctx, store, c.Param("invoiceId"), refundDto, reversal)
+			return err
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while refunding invoice", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, receipt, "Refund recorded successfully")
+	}
+}
+
+func GetOrderLedger(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		payments, err := store.Payments.ListByOrder(ctx, c.Param("orderId"))
+		if err != nil {
+			slog.Error("Error while fetching ledger", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, payments, "Ledger fetched successfully")
+	}
+}
+
+// bindReversal binds and validates the body of a refund or void, answering
+// the request itself when the body is unusable.
+func bindReversal(c *gin.Context, dto any) bool {
+	if err := c.BindJSON(dto); err != nil {
+		if errors.Is(err, io.EOF) {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+			return false
+		}
+		utils.ApiError(c, http.StatusBadRequest, err)
+		return false
+	}
+	if err := utils.Validate.Struct(dto); err != nil {
+		utils.ApiError(c, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// approveReversal checks that a manager approved a refund or void. Managers
+// and admins approve their own; anyone else has to name the manager who did,
+// with the manager's password as proof.
+func approveReversal(ctx context.Context, store *repository.Store, c *gin.Context, dto models.ReversalDto) (models.Reversal, int, error) {
+	reversal := models.Reversal{
+		ReasonCode: dto.ReasonCode,
+		Note:       dto.Note,
+		ApprovedBy: dto.ApprovedBy,
+		RecordedBy: c.GetString("userId"),
+		At:         time.Now().UTC(),
+	}
+
+	if reversal.ApprovedBy == "" {
+		role := c.GetString("role")
+		if role != constants.ROLE_MANAGER && role != constants.ROLE_ADMIN {
+			return models.Reversal{}, http.StatusBadRequest, errors.New("approvedBy is required: a manager must approve")
+		}
+		reversal.ApprovedBy = reversal.RecordedBy
+		return reversal, http.StatusOK, nil
+	}
+
+	approver, err := store.Users.Get(ctx, reversal.ApprovedBy)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.Reversal{}, http.StatusBadRequest, errors.New("approving user not found")
+	}
+	if err != nil {
+		slog.Error("Error while fetching user", slog.String("error", err.Error()))
+		return models.Reversal{}, http.StatusInternalServerError, err
+	}
+	if approver.Role == nil || (*approver.Role != constants.ROLE_MANAGER && *approver.Role != constants.ROLE_ADMIN) {
+		return models.Reversal{}, http.StatusForbidden, errors.New("approving user is not a manager")
+	}
+	if approver.Password == nil || !utils.VerifyPassword(dto.ApproverPassword, *approver.Password) {
+		return models.Reversal{}, http.StatusForbidden, errors.New("approval not confirmed: invalid approver password")
+	}
+	return reversal, http.StatusOK, nil
+}
+
+func voidInvoice(ctx context.Context, store *repository.Store, invoiceId string, reversal models.Reversal) (models.Invoice, int, error) {
+	invoice, err := store.Invoices.Get(ctx, invoiceId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.Invoice{}, http.StatusNotFound, errors.New("invoice not found")
+	}
+	if err != nil {
+		return models.Invoice{}, http.StatusInternalServerError, err
+	}
+	if isInvoiceVoided(invoice) {
+		return models.Invoice{}, http.StatusConflict, errInvoiceVoided
+	}
+	if code, err := checkBusinessDayOpen(ctx, store, invoice.CreatedAt); err != nil {
+		return models.Invoice{}, code, err
+	}
+
+	payments, err := store.Payments.ListByInvoice(ctx, invoiceId)
+	if err != nil {
+		return models.Invoice{}, http.StatusInternalServerError, err
+	}
+	if paid, _ := helpers.LedgerAmounts(payments); paid != 0 {
+		return models.Invoice{}, http.StatusConflict, errors.New("invoice has payments; refund it instead")
+	}
+
+	totals, _, err := invoiceTotals(ctx, store, invoice)
+	if err != nil {
+		return models.Invoice{}, http.StatusInternalServerError, err
+	}
+	entry := reversalEntry(constants.LEDGER_VOID, invoice.OrderId, -totals.Total, reversal)
+	entry.InvoiceId = invoice.InvoiceId
+	if err := store.Payments.Create(ctx, entry); err != nil {
+		return models.Invoice{}, http.StatusInternalServerError, err
+	}
+
+	status := constants.INVOICE_STATUS_VOIDED
+	invoice, err = store.Invoices.Update(ctx, invoiceId, models.UpdateInvoiceDto{
+		PaymentMethod: invoice.PaymentMethod,
+		PaymentStatus: &status,
+		Totals:        &totals,
+		Void:          &reversal,
+	})
+	if err != nil {
+		return models.Invoice{}, http.StatusInternalServerError, err
+	}
+	if err := releaseCoupons(ctx, store, invoice.CouponCodes); err != nil {
+		return models.Invoice{}, http.StatusInternalServerError, err
+	}
+	return invoice, http.StatusOK, nil
+}
+
+func refundInvoice(ctx context.Context, store *repository.Store, invoiceId string, refundDto models.RefundDto, reversal models.Reversal) (PaymentReceipt, int, error) {
+	invoice, err := store.Invoices.Get(ctx, invoiceId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return PaymentReceipt{}, http.StatusNotFound, errors.New("invoice not found")
+	}
+	if err != nil {
+		return PaymentReceipt{}, http.StatusInternalServerError, err
+	}
+	if isInvoiceVoided(invoice) {
+		return PaymentReceipt{}, http.StatusConflict, errInvoiceVoided
+	}
+
+	payments, err := store.Payments.ListByInvoice(ctx, invoiceId)
+	if err != nil {
+		return PaymentReceipt{}, http.StatusInternalServerError, err
+	}
+	paid, refunded := helpers.LedgerAmounts(payments)
+	if paid <= 0 {
+		return PaymentReceipt{}, http.StatusConflict, errors.New("invoice has no payments to refund; void it instead")
+	}
+	totals := invoice.Totals
+	if totals == nil {
+		computed, _, err := invoiceTotals(ctx, store, invoice)
+		if err != nil {
+			return PaymentReceipt{}, http.StatusInternalServerError, err
+		}
+		totals = &computed
+	}
+	// A refund settles the invoice for good, so the bill is paid in full
+	// first.
+	if paid < totals.Total {
+		return PaymentReceipt{}, http.StatusConflict, fmt.Errorf("invoice is not paid in full, %s of %s paid", paid, totals.Total)
+	}
+	refundable := paid - refunded
+	if refundable <= 0 {
+		return PaymentReceipt{}, http.StatusConflict, errors.New("invoice is already refunded in full")
+	}
+
+	amount := refundable
+	if refundDto.OrderItemId != "" {
+		lineTotal, code, err := refundableItem(ctx, store, invoice, payments, refundDto.OrderItemId)
+		if err != nil {
+			return PaymentReceipt{}, code, err
+		}
+		amount = lineTotal
+	}
+	if refundDto.Amount != nil {
+		amount = *refundDto.Amount
+	}
+	if amount > refundable {
+		return PaymentReceipt{}, http.StatusBadRequest, fmt.Errorf("refund of %s exceeds the %s still refundable", amount, refundable)
+	}
+
+	entry := reversalEntry(constants.LEDGER_REFUND, invoice.OrderId, -amount, reversal)
+	entry.InvoiceId = invoice.InvoiceId
+	entry.OrderItemId = refundDto.OrderItemId
+	entry.Reference = refundDto.Reference
+	entry.Method = refundDto.Method
+	for i := len(payments) - 1; i >= 0 && entry.Method == nil; i-- {
+		if payments[i].Type != constants.LEDGER_REFUND && payments[i].Type != constants.LEDGER_VOID {
+			entry.Method = payments[i].Method
+		}
+	}
+	if err := store.Payments.Create(ctx, entry); err != nil {
+		return PaymentReceipt{}, http.StatusInternalServerError, err
+	}
+
+	refunded += amount
+	status := helpers.PaymentStatus(totals.Total, paid, refunded)
+	invoice, err = store.Invoices.Update(ctx, invoiceId, models.UpdateInvoiceDto{
+		PaymentMethod:  invoice.PaymentMethod,
+		PaymentStatus:  &status,
+		Totals:         totals,
+		AmountPaid:     &paid,
+		AmountRefunded: &refunded,
+	})
+	if err != nil {
+		return PaymentReceipt{}, http.StatusInternalServerError, err
+	}
+	return PaymentReceipt{Payment: entry, Invoice: invoice}, http.StatusOK, nil
+}
+
+// refundableItem is the line total of an item on invoice that has not been
+// refunded yet.
+func refundableItem(ctx context.Context, store *repository.Store, invoice models.Invoice, payments []models.Payment, orderItemId string) (money.Amount, int, error) {
+	orderItem, err := store.OrderItems.Get(ctx, orderItemId)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return 0, http.StatusInternalServerError, err
+	}
+	onInvoice := len(invoice.OrderItemIds) == 0 || slices.Contains(invoice.OrderItemIds, orderItemId)
+	if err != nil || orderItem.OrderId != invoice.OrderId || !onInvoice || orderItem.Void != nil {
+		return 0, http.StatusBadRequest, errors.New("order item is not on this invoice")
+	}
+	for _, payment := range payments {
+		if payment.Type == constants.LEDGER_REFUND && payment.OrderItemId == orderItemId {
+			return 0, http.StatusConflict, errors.New("order item is already refunded")
+		}
+	}
+	if orderItem.LineTotal == nil {
+		return 0, http.StatusBadRequest, errors.New("order item has no price; give the amount to refund")
+	}
+	return *orderItem.LineTotal, http.StatusOK, nil
+}
+
+// reversalEntry is a ledger entry that takes amount, which is negative, off
+// an order.
+func reversalEntry(entryType, orderId string, amount money.Amount, reversal models.Reversal) models.Payment {
+	var change money.Amount
+	entry := models.Payment{
+		Type:       entryType,
+		OrderId:    orderId,
+		Amount:     &amount,
+		Change:     &change,
+		Currency:   money.Currency(),
+		ReceivedBy: reversal.RecordedBy,
+		CreatedAt:  reversal.At,
+		Reversal:   &reversal,
+	}
+	entry.ID = bson.NewObjectID()
+	entry.PaymentId = entry.ID.Hex()
+	return entry
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func CreatePromotion(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var promotion models.Promotion
+		if err := c.BindJSON(&promotion); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(promotion); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+		if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("endsAt must be after startsAt"))
+			return
+		}
+		if code, err := checkPromotionTargets(ctx, store, promotion); err != nil {
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		if promotion.CouponCode != "" {
+			promotion.CouponCode = strings.ToUpper(promotion.CouponCode)
+			_, err := store.Promotions.FindByCoupon(ctx, promotion.CouponCode)
+			if err == nil {
+				utils.ApiError(c, http.StatusConflict, fmt.Errorf("coupon %s already exists", promotion.CouponCode))
+				return
+			}
+			if !errors.Is(err, repository.ErrNotFound) {
+				slog.Error("Error while fetching promotion", slog.String("error", err.Error()))
+				utils.ApiError(c, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		if promotion.Kind == constants.PROMOTION_BUY_X_GET_Y {
+			promotion.Level = constants.PROMOTION_LEVEL_ITEM
+		}
+		if promotion.Active == nil {
+			active := true
+			promotion.Active = &active
+		}
+
+		promotion.UsageCount = 0
+		promotion.CreatedAt = time.Now().UTC()
+		promotion.UpdatedAt = time.Now().UTC()
+		promotion.ID = bson.NewObjectID()
+		promotion.PromotionId = promotion.ID.Hex()
+
+		if err := store.Promotions.Create(ctx, promotion); err != nil {
+			slog.Error("Error while creating promotion", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, promotion, "Promotion created successfully")
+	}
+}
+
+func UpdatePromotion(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		promotionId := c.Param("promotionId")
+		if promotionId == "" {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid promotion id"))
+			return
+		}
+
+		var updateDto models.UpdatePromotionDto
+		if err := c.BindJSON(&updateDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(updateDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err := store.Promotions.Update(ctx, promotionId, updateDto)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("promotion not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while updating promotion", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, updateDto, "Promotion updated successfully")
+	}
+}
+
+func DeletePromotion(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		promotionId := c.Param("promotionId")
+		if promotionId == "" {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid promotion id"))
+			return
+		}
+
+		err := store.Promotions.Delete(ctx, promotionId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("promotion not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while deleting promotion", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, nil, "Promotion deleted successfully")
+	}
+}
+
+func GetPromotion(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		promotion, err := store.Promotions.Get(ctx, c.Param("promotionId"))
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("promotion not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching promotion", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, promotion, "Promotion fetched successfully")
+	}
+}
+
+func GetAllPromotions(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		promotions, err := store.Promotions.List(ctx)
+		if err != nil {
+			slog.Error("Error while fetching promotions", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, promotions, "Promotions fetched successfully")
+	}
+}
+
+// checkPromotionTargets makes sure the foods and menus a promotion names
+// exist.
+func checkPromotionTargets(ctx context.Context, store *repository.Store, promotion models.Promotion) (int, error) {
+	for _, foodId := range promotion.FoodIds {
+		_, err := store.Foods.Get(ctx, foodId)
+		if errors.Is(err, repository.ErrNotFound) {
+			return http.StatusBadRequest, fmt.Errorf("food %s not found", foodId)
+		}
+		if err != nil {
+			slog.Error("Error while fetching food", slog.String("error", err.Error()))
+			return http.StatusInternalServerError, err
+		}
+	}
+	for _, menuId := range promotion.MenuIds {
+		_, err := store.Menus.Get(ctx, menuId)
+		if errors.Is(err, repository.ErrNotFound) {
+			return http.StatusBadRequest, fmt.Errorf("menu %s not found", menuId)
+		}
+		if err != nil {
+			slog.Error("Error while fetching menu", slog.String("error", err.Error()))
+			return http.StatusInternalServerError, err
+		}
+	}
+	return http.StatusOK, nil
+}
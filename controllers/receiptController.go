@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/receipt"
+)
+
+// receiptMimeTypes are the content types of the receipt formats, JSON first
+// so that it is what clients get when they accept anything.
+var receiptMimeTypes = []string{
+	constants.MIME_JSON,
+	constants.MIME_PDF,
+	constants.MIME_TEXT,
+	constants.MIME_ESCPOS,
+}
+
+var receiptFormats = map[string]string{
+	constants.MIME_JSON:   constants.RECEIPT_FORMAT_JSON,
+	constants.MIME_PDF:    constants.RECEIPT_FORMAT_PDF,
+	constants.MIME_TEXT:   constants.RECEIPT_FORMAT_TEXT,
+	constants.MIME_ESCPOS: constants.RECEIPT_FORMAT_ESCPOS,
+}
+
+// receiptFormat is the format an invoice is asked for in: the format query
+// parameter when given, otherwise the best match of the Accept header.
+func receiptFormat(c *gin.Context) (string, int, error) {
+	if format := strings.ToLower(c.Query("format")); format != "" {
+		for _, known := range receiptFormats {
+			if format == known {
+				return format, http.StatusOK, nil
+			}
+		}
+		return "", http.StatusBadRequest, fmt.Errorf("unknown format %q; use json, pdf, text or escpos", format)
+	}
+
+	mimeType := c.NegotiateFormat(receiptMimeTypes...)
+	if mimeType == "" {
+		return "", http.StatusNotAcceptable, fmt.Errorf("invoices are available as %s", strings.Join(receiptMimeTypes, ", "))
+	}
+	return receiptFormats[mimeType], http.StatusOK, nil
+}
+
+// writeReceipt renders r in format, which must not be JSON.
+func writeReceipt(c *gin.Context, format string, r receipt.Receipt) {
+	switch format {
+	case constants.RECEIPT_FORMAT_PDF:
+		name := r.Invoice.InvoiceNumber
+		if name == "" {
+			name = r.Invoice.InvoiceId
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="invoice-%s.pdf"`, name))
+		c.Data(http.StatusOK, constants.MIME_PDF, receipt.PDF(r))
+	case constants.RECEIPT_FORMAT_TEXT:
+		c.Data(http.StatusOK, constants.MIME_TEXT+"; charset=utf-8", receipt.Text(r))
+	case constants.RECEIPT_FORMAT_ESCPOS:
+		c.Data(http.StatusOK, constants.MIME_ESCPOS, receipt.ESCPOS(r))
+	}
+}
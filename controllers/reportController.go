@@ -0,0 +1,331 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+)
+
+// unassigned names the orders credited to no server or section.
+const unassigned = "Unassigned"
+
+// GetRevenueReport adds up settled invoices by day, week or month.
+func GetRevenueReport(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		query, from, to, err := bindReportQuery(c)
+		if err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		invoices, err := store.Invoices.ListBetween(ctx, from, to)
+		if err != nil {
+			slog.Error("Error while fetching invoices", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		report := models.RevenueReport{
+			From:     from,
+			To:       to,
+			Period:   query.Period,
+			Currency: money.Currency(),
+			Summary:  helpers.RevenueSummary(invoices),
+			Periods:  helpers.RevenueByPeriod(invoices, query.Period, from, to),
+		}
+		utils.ApiSuccess(c, http.StatusOK, report, "Revenue report fetched successfully")
+	}
+}
+
+// GetFoodSalesReport ranks foods by what they sold, best or worst first.
+func GetFoodSalesReport(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		query, from, to, err := bindReportQuery(c)
+		if err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		sold, err := store.OrderItems.SalesBetween(ctx, from, to)
+		if err != nil {
+			slog.Error("Error while fetching sales", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		foods, err := allFoods(ctx, store)
+		if err != nil {
+			slog.Error("Error while fetching foods", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		categories, err := menuCategories(ctx, store)
+		if err != nil {
+			slog.Error("Error while fetching menus", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		ranked := helpers.RankFoods(foods, categories, sold, query.Rank, query.By, query.Limit)
+		utils.ApiSuccess(c, http.StatusOK, ranked, "Food sales report fetched successfully")
+	}
+}
+
+// GetCategorySalesReport adds up sales by menu category.
+func GetCategorySalesReport(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		_, from, to, err := bindReportQuery(c)
+		if err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		sold, err := store.OrderItems.SalesBetween(ctx, from, to)
+		if err != nil {
+			slog.Error("Error while fetching sales", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		categories, err := menuCategories(ctx, store)
+		if err != nil {
+			slog.Error("Error while fetching menus", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, helpers.SalesByCategory(categories, sold), "Category sales report fetched successfully")
+	}
+}
+
+// GetSalesHeatmap spreads revenue over the hours of each weekday.
+func GetSalesHeatmap(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		_, from, to, err := bindReportQuery(c)
+		if err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		invoices, err := store.Invoices.ListBetween(ctx, from, to)
+		if err != nil {
+			slog.Error("Error while fetching invoices", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, helpers.SalesHeatmap(invoices), "Sales heatmap fetched successfully")
+	}
+}
+
+// GetServerSalesReport adds up sales and tips by the server each order was
+// credited to.
+func GetServerSalesReport(store *repository.Store) gin.HandlerFunc {
+	return serviceSalesReport(store, false)
+}
+
+// GetSectionSalesReport adds up sales and tips by the section each order
+// was credited to.
+func GetSectionSalesReport(store *repository.Store) gin.HandlerFunc {
+	return serviceSalesReport(store, true)
+}
+
+func serviceSalesReport(store *repository.Store, bySection bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		_, from, to, err := bindReportQuery(c)
+		if err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		invoices, err := store.Invoices.ListBetween(ctx, from, to)
+		if err != nil {
+			slog.Error("Error while fetching invoices", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		payments, err := store.Payments.ListBetween(ctx, from, to)
+		if err != nil {
+			slog.Error("Error while fetching payments", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		orderIds := make([]string, 0, len(invoices)+len(payments))
+		for _, invoice := range invoices {
+			orderIds = append(orderIds, invoice.OrderId)
+		}
+		for _, payment := range payments {
+			orderIds = append(orderIds, payment.OrderId)
+		}
+		servers, sections, err := creditedOrders(ctx, store, orderIds)
+		if err != nil {
+			slog.Error("Error while fetching orders", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		credited := servers
+		if bySection {
+			credited = sections
+		}
+		rows := helpers.SalesByService(invoices, payments, credited)
+		ids := make([]string, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.Id)
+		}
+		names, err := serviceNames(ctx, store, ids, bySection)
+		if err != nil {
+			slog.Error("Error while fetching names", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		for i := range rows {
+			rows[i].Name = names[rows[i].Id]
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, rows, "Service sales report fetched successfully")
+	}
+}
+
+// bindReportQuery reads the report query and returns the time range it
+// covers, from the start of From to the end of To.
+func bindReportQuery(c *gin.Context) (models.ReportQuery, time.Time, time.Time, error) {
+	var query models.ReportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return query, time.Time{}, time.Time{}, fmt.Errorf("invalid report query: %w", err)
+	}
+	query.Period = strings.ToUpper(query.Period)
+	query.Rank = strings.ToUpper(query.Rank)
+	query.By = strings.ToUpper(query.By)
+	if err := utils.Validate.Struct(query); err != nil {
+		return query, time.Time{}, time.Time{}, err
+	}
+
+	from := query.From
+	to := query.To.AddDate(0, 0, 1)
+	if to.After(from.AddDate(0, 0, constants.REPORT_MAX_RANGE_DAYS)) {
+		return query, time.Time{}, time.Time{}, fmt.Errorf("reports cover at most %d days", constants.REPORT_MAX_RANGE_DAYS)
+	}
+
+	if query.Period == "" {
+		query.Period = constants.REPORT_PERIOD_DAY
+	}
+	if query.Rank == "" {
+		query.Rank = constants.REPORT_RANK_TOP
+	}
+	if query.By == "" {
+		query.By = constants.REPORT_BY_QUANTITY
+	}
+	if query.Limit == 0 {
+		query.Limit = constants.REPORT_DEFAULT_LIMIT
+	}
+	return query, from, to, nil
+}
+
+// allFoods pages through the whole catalog.
+func allFoods(ctx context.Context, store *repository.Store) ([]models.Food, error) {
+	const page int64 = 500
+	var foods []models.Food
+	var after time.Time
+	for {
+		batch, err := store.Foods.List(ctx, after, page)
+		if err != nil {
+			return nil, err
+		}
+		foods = append(foods, batch...)
+		if int64(len(batch)) < page {
+			return foods, nil
+		}
+		after = batch[len(batch)-1].CreatedAt
+	}
+}
+
+// menuCategories maps menu ids to their category.
+func menuCategories(ctx context.Context, store *repository.Store) (map[string]string, error) {
+	menus, err := store.Menus.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories := make(map[string]string, len(menus))
+	for _, menu := range menus {
+		categories[menu.MenuId] = menu.Category
+	}
+	return categories, nil
+}
+
+// creditedOrders maps each of orderIds to the server and to the section the
+// order was credited to. Orders that are gone are left out.
+func creditedOrders(ctx context.Context, store *repository.Store, orderIds []string) (servers, sections map[string]string, err error) {
+	servers, sections = map[string]string{}, map[string]string{}
+	seen := map[string]bool{}
+	for _, orderId := range orderIds {
+		if seen[orderId] {
+			continue
+		}
+		seen[orderId] = true
+		order, err := store.Orders.Get(ctx, orderId)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		servers[orderId] = order.ServerId
+		sections[orderId] = order.SectionId
+	}
+	return servers, sections, nil
+}
+
+// serviceNames names the servers, or the sections, of ids. Orders credited
+// to nobody are unassigned; servers and sections since removed keep no
+// name.
+func serviceNames(ctx context.Context, store *repository.Store, ids []string, bySection bool) (map[string]string, error) {
+	names := map[string]string{"": unassigned}
+	for _, id := range ids {
+		if _, ok := names[id]; ok {
+			continue
+		}
+		if bySection {
+			section, err := store.Sections.Get(ctx, id)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+			names[id] = section.Name
+			continue
+		}
+		user, err := store.Users.Get(ctx, id)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+		names[id] = ""
+		if user.Name != nil {
+			names[id] = *user.Name
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func CreateReservation(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var reservationDto models.CreateReservationDto
+		if err := c.BindJSON(&reservationDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(reservationDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+		if !reservationDto.StartsAt.After(time.Now()) {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("startsAt must be in the future"))
+			return
+		}
+
+		duration := helpers.TurnTime(reservationDto.PartySize)
+		if reservationDto.DurationMinutes != 0 {
+			duration = time.Duration(reservationDto.DurationMinutes) * time.Minute
+		}
+		reservation := models.Reservation{
+			GuestName: reservationDto.GuestName,
+			Phone:     reservationDto.Phone,
+			Email:     reservationDto.Email,
+			PartySize: reservationDto.PartySize,
+			StartsAt:  reservationDto.StartsAt.UTC(),
+			EndsAt:    reservationDto.StartsAt.Add(duration).UTC(),
+			Notes:     reservationDto.Notes,
+			Status:    constants.RESERVATION_STATUS_BOOKED,
+			CreatedBy: c.GetString("userId"),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+		reservation.ID = bson.NewObjectID()
+		reservation.ReservationId = reservation.ID.Hex()
+
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			reservation.TableId, code, err = bookTable(ctx, store, reservation, reservationDto.TableId, "")
+			if err != nil {
+				return err
+			}
+			code = http.StatusInternalServerError
+			return store.Reservations.Create(ctx, reservation)
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while creating reservation", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, reservation, "Reservation created successfully")
+	}
+}
+
+// UpdateReservation changes a booked reservation, moving it to another
+// table when its own is no longer free and no table was asked for.
+func UpdateReservation(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var updateDto models.UpdateReservationDto
+		if err := c.BindJSON(&updateDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(updateDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+		if updateDto.StartsAt != nil && !updateDto.StartsAt.After(time.Now()) {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("startsAt must be in the future"))
+			return
+		}
+
+		var reservation models.Reservation
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			reservation, code, err = bookedReservation(ctx, store, c.Param("reservationId"))
+			if err != nil {
+				return err
+			}
+
+			duration := reservation.EndsAt.Sub(reservation.StartsAt)
+			if updateDto.PartySize != nil && *updateDto.PartySize != reservation.PartySize {
+				reservation.PartySize = *updateDto.PartySize
+				duration = helpers.TurnTime(reservation.PartySize)
+			}
+			if updateDto.DurationMinutes != nil {
+				duration = time.Duration(*updateDto.DurationMinutes) * time.Minute
+			}
+			if updateDto.StartsAt != nil {
+				reservation.StartsAt = updateDto.StartsAt.UTC()
+			}
+			reservation.EndsAt = reservation.StartsAt.Add(duration)
+
+			tableId := ""
+			if updateDto.TableId != nil {
+				tableId = *updateDto.TableId
+			}
+			reservation.TableId, code, err = bookTable(ctx, store, reservation, tableId, reservation.TableId)
+			if err != nil {
+				return err
+			}
+			code = http.StatusInternalServerError
+
+			updateDto.TableId = &reservation.TableId
+			updateDto.PartySize = &reservation.PartySize
+			updateDto.StartsAt = &reservation.StartsAt
+			updateDto.EndsAt = &reservation.EndsAt
+			if err := store.Reservations.Update(ctx, reservation.ReservationId, updateDto); err != nil {
+				return err
+			}
+			reservation, err = store.Reservations.Get(ctx, reservation.ReservationId)
+			return err
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while updating reservation", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, reservation, "Reservation updated successfully")
+	}
+}
+
+func CancelReservation(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		reservation, code, err := closeReservation(ctx, store, c.Param("reservationId"), constants.RESERVATION_STATUS_CANCELLED)
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while cancelling reservation", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, reservation, "Reservation cancelled successfully")
+	}
+}
+
+// MarkNoShow records that the party of a reservation did not turn up, which
+// frees its table for the rest of the booking.
+func MarkNoShow(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		reservation, code, err := closeReservation(ctx, store, c.Param("reservationId"), constants.RESERVATION_STATUS_NO_SHOW)
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while marking no-show", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, reservation, "Reservation marked as no-show")
+	}
+}
+
+func GetReservation(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		reservation, err := store.Reservations.Get(ctx, c.Param("reservationId"))
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("reservation not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching reservation", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, reservation, "Reservation fetched successfully")
+	}
+}
+
+// GetReservations lists the reservations of a day, whatever their status.
+func GetReservations(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var query models.ReservationListQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("invalid reservation query: %w", err))
+			return
+		}
+		if err := utils.Validate.Struct(query); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		reservations, err := store.Reservations.ListBetween(ctx, query.Date, query.Date.AddDate(0, 0, 1))
+		if err != nil {
+			slog.Error("Error while fetching reservations", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, reservations, "Reservations fetched successfully")
+	}
+}
+
+// GetAvailability lists the tables free for a party at a time, smallest
+// first.
+func GetAvailability(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var query models.AvailabilityQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("invalid availability query: %w", err))
+			return
+		}
+		if err := utils.Validate.Struct(query); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		duration := helpers.TurnTime(query.PartySize)
+		if query.DurationMinutes != 0 {
+			duration = time.Duration(query.DurationMinutes) * time.Minute
+		}
+		from, to := query.At, query.At.Add(duration)
+		buffer := helpers.ReservationBuffer()
+
+		tables, err := store.Tables.List(ctx)
+		if err != nil {
+			slog.Error("Error while fetching tables", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		reservations, err := store.Reservations.ListOverlapping(ctx, from.Add(-buffer), to.Add(buffer))
+		if err != nil {
+			slog.Error("Error while fetching reservations", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		free := helpers.FreeTables(tables, reservations, query.PartySize, from, to, time.Now(), "")
+		utils.ApiSuccess(c, http.StatusOK, free, "Available tables fetched successfully")
+	}
+}
+
+// bookTable picks the table reservation is held at and claims it for the
+// transaction. tableId is the table asked for, which has to be free.
+// Without one the reservation stays at current when it is free there, and
+// otherwise takes the smallest free table that seats the party.
+func bookTable(ctx context.Context, store *repository.Store, reservation models.Reservation, tableId, current string) (string, int, error) {
+	buffer := helpers.ReservationBuffer()
+	overlapping, err := store.Reservations.ListOverlapping(ctx, reservation.StartsAt.Add(-buffer), reservation.EndsAt.Add(buffer))
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+
+	tables, err := store.Tables.List(ctx)
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	now := time.Now()
+	free := helpers.FreeTables(tables, overlapping, reservation.PartySize, reservation.StartsAt, reservation.EndsAt, now, reservation.ReservationId)
+
+	if tableId != "" {
+		i := slices.IndexFunc(tables, func(t models.Table) bool { return t.TableId == tableId })
+		if i < 0 {
+			return "", http.StatusBadRequest, errors.New("table not found")
+		}
+		if seats := helpers.BookableSeats(tables[i], tables, reservation.StartsAt, now); seats < reservation.PartySize {
+			return "", http.StatusBadRequest, fmt.Errorf("table seats %d, not %d", seats, reservation.PartySize)
+		}
+		if !slices.ContainsFunc(free, func(t models.Table) bool { return t.TableId == tableId }) {
+			return "", http.StatusConflict, errors.New("table is already booked at that time")
+		}
+	} else {
+		if len(free) == 0 {
+			return "", http.StatusConflict, fmt.Errorf("no table is free for %d guests at that time", reservation.PartySize)
+		}
+		tableId = free[0].TableId
+		if slices.ContainsFunc(free, func(t models.Table) bool { return t.TableId == current }) {
+			tableId = current
+		}
+	}
+
+	if err := store.Tables.Lock(ctx, tableId); err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	return tableId, http.StatusOK, nil
+}
+
+// bookedReservation fetches a reservation that has to be still booked.
+func bookedReservation(ctx context.Context, store *repository.Store, reservationId string) (models.Reservation, int, error) {
+	reservation, err := store.Reservations.Get(ctx, reservationId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.Reservation{}, http.StatusNotFound, errors.New("reservation not found")
+	}
+	if err != nil {
+		return models.Reservation{}, http.StatusInternalServerError, err
+	}
+	if reservation.Status != constants.RESERVATION_STATUS_BOOKED {
+		return models.Reservation{}, http.StatusConflict, fmt.Errorf("reservation is %s", reservation.Status)
+	}
+	return reservation, http.StatusOK, nil
+}
+
+// closeReservation cancels a booked reservation or marks it a no-show,
+// which can only be done once its time has come.
+func closeReservation(ctx context.Context, store *repository.Store, reservationId, status string) (models.Reservation, int, error) {
+	reservation, code, err := bookedReservation(ctx, store, reservationId)
+	if err != nil {
+		return models.Reservation{}, code, err
+	}
+	if status == constants.RESERVATION_STATUS_NO_SHOW && time.Now().Before(reservation.StartsAt) {
+		return models.Reservation{}, http.StatusConflict, errors.New("the party is not due yet")
+	}
+	if err := store.Reservations.SetStatus(ctx, reservationId, status); err != nil {
+		return models.Reservation{}, http.StatusInternalServerError, err
+	}
+	reservation.Status = status
+	return reservation, http.StatusOK, nil
+}
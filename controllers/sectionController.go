@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func CreateSection(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var section models.Section
+		if err := c.BindJSON(&section); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(section); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		_, err := store.FloorPlans.Get(ctx, section.FloorPlanId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("floor plan not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching floor plan", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		section.CreatedAt = time.Now().UTC()
+		section.UpdatedAt = time.Now().UTC()
+		section.ID = bson.NewObjectID()
+		section.SectionId = section.ID.Hex()
+
+		if err := store.Sections.Create(ctx, section); err != nil {
+			slog.Error("Error while creating section", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, section, "Section created successfully")
+	}
+}
+
+func UpdateSection(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var updateDto models.UpdateSectionDto
+		if err := c.BindJSON(&updateDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(updateDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err := store.Sections.Update(ctx, c.Param("sectionId"), updateDto)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("section not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while updating section", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, updateDto, "Section updated successfully")
+	}
+}
+
+// DeleteSection removes a section once its tables have been moved out of
+// it. Orders keep the section they were credited to.
+func DeleteSection(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		sectionId := c.Param("sectionId")
+		tables, err := store.Tables.List(ctx)
+		if err != nil {
+			slog.Error("Error while fetching tables", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		count := 0
+		for _, table := range tables {
+			if table.SectionId == sectionId {
+				count++
+			}
+		}
+		if count > 0 {
+			utils.ApiError(c, http.StatusConflict, fmt.Errorf("section has %d tables", count))
+			return
+		}
+
+		err = store.Sections.Delete(ctx, sectionId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("section not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while deleting section", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, nil, "Section deleted successfully")
+	}
+}
+
+func GetSection(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		section, err := store.Sections.Get(ctx, c.Param("sectionId"))
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("section not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching section", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, section, "Section fetched successfully")
+	}
+}
+
+func GetAllSections(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		sections, err := store.Sections.List(ctx)
+		if err != nil {
+			slog.Error("Error while fetching sections", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, sections, "Sections fetched successfully")
+	}
+}
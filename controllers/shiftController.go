@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// CreateShift assigns a waiter, or a manager covering the floor, to a
+// section for a shift. A server cannot be given the same section twice at
+// once.
+func CreateShift(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var shiftDto models.CreateShiftDto
+		if err := c.BindJSON(&shiftDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(shiftDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+		if shiftDto.EndsAt.Sub(shiftDto.StartsAt) > helpers.MaxShiftLength {
+			utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("a shift lasts at most %s", helpers.MaxShiftLength))
+			return
+		}
+
+		_, err := store.Sections.Get(ctx, shiftDto.SectionId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("section not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching section", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		user, err := store.Users.Get(ctx, shiftDto.UserId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("user not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching user", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if user.Role == nil || (*user.Role != constants.ROLE_WAITER && *user.Role != constants.ROLE_MANAGER) {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("only waiters and managers can serve a section"))
+			return
+		}
+
+		shift := models.Shift{
+			SectionId: shiftDto.SectionId,
+			UserId:    shiftDto.UserId,
+			StartsAt:  shiftDto.StartsAt.UTC(),
+			EndsAt:    shiftDto.EndsAt.UTC(),
+			CreatedBy: c.GetString("userId"),
+			CreatedAt: time.Now().UTC(),
+		}
+		shift.ID = bson.NewObjectID()
+		shift.ShiftId = shift.ID.Hex()
+
+		code := http.StatusInternalServerError
+		err = store.Transaction(ctx, func(ctx context.Context) error {
+			overlapping, err := store.Shifts.ListOverlapping(ctx, shift.StartsAt, shift.EndsAt)
+			if err != nil {
+				return err
+			}
+			for _, other := range overlapping {
+				if other.SectionId == shift.SectionId && other.UserId == shift.UserId {
+					code = http.StatusConflict
+					return errors.New("the server already works this section at that time")
+				}
+			}
+			return store.Shifts.Create(ctx, shift)
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while creating shift", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, shift, "Shift created successfully")
+	}
+}
+
+func DeleteShift(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := store.Shifts.Delete(ctx, c.Param("shiftId"))
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("shift not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while deleting shift", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, nil, "Shift deleted successfully")
+	}
+}
+
+// GetShifts lists the shifts worked on a day, of every section or of one.
+func GetShifts(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var query models.ShiftListQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("invalid shift query: %w", err))
+			return
+		}
+		if err := utils.Validate.Struct(query); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		shifts, err := store.Shifts.ListOverlapping(ctx, query.Date, query.Date.AddDate(0, 0, 1))
+		if err != nil {
+			slog.Error("Error while fetching shifts", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if query.SectionId != "" {
+			matched := make([]models.Shift, 0)
+			for _, shift := range shifts {
+				if shift.SectionId == query.SectionId {
+					matched = append(matched, shift)
+				}
+			}
+			shifts = matched
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, shifts, "Shifts fetched successfully")
+	}
+}
+
+// creditOrder credits a new order to the section of its table and to the
+// server working it, or to userId, who placed the order, when nobody is.
+func creditOrder(ctx context.Context, store *repository.Store, order *models.Order, userId string) error {
+	order.SectionId, order.ServerId = "", userId
+	table, err := store.Tables.Get(ctx, order.TableId)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && table.SectionId == "") {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	order.SectionId = table.SectionId
+	shifts, err := store.Shifts.ListOverlapping(ctx, order.CreatedAt, order.CreatedAt.Add(time.Second))
+	if err != nil {
+		return err
+	}
+	if server := helpers.SectionServer(shifts, table.SectionId, order.CreatedAt, userId); server != "" {
+		order.ServerId = server
+	}
+	return nil
+}
@@ -3,22 +3,23 @@ package controllers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jrskg/go-restaurant/constants"
-	"github.com/jrskg/go-restaurant/database"
+	"github.com/jrskg/go-restaurant/helpers"
 	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
 	"github.com/jrskg/go-restaurant/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
-var tableCollection = database.OpenCollection(database.DBClient, constants.TABLE_COLLECTION)
-
-func CreateTable() gin.HandlerFunc {
+func CreateTable(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -38,14 +39,33 @@ func CreateTable() gin.HandlerFunc {
 			utils.ApiError(c, http.StatusBadRequest, err)
 			return
 		}
+		placement := models.UpdateTableDto{Layout: table.Layout}
+		if table.FloorPlanId != "" {
+			placement.FloorPlanId = &table.FloorPlanId
+		}
+		if table.SectionId != "" {
+			placement.SectionId = &table.SectionId
+		}
+		if code, err := placeTable(ctx, store, models.Table{}, &placement); err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while placing table", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+		if placement.FloorPlanId != nil {
+			table.FloorPlanId = *placement.FloorPlanId
+		}
 
 		table.ID = bson.NewObjectID()
 		table.TableId = table.ID.Hex()
 		table.CreatedAt = time.Now()
 		table.UpdatedAt = time.Now()
+		table.Status = constants.TABLE_STATUS_FREE
+		table.StatusSince = table.CreatedAt
+		table.Occupancy = nil
 
-		_, err := tableCollection.InsertOne(ctx, table)
-		if err != nil {
+		if err := store.Tables.Create(ctx, table); err != nil {
 			slog.Error("Error while creating table", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
@@ -55,7 +75,7 @@ func CreateTable() gin.HandlerFunc {
 	}
 }
 
-func UpdateTable() gin.HandlerFunc {
+func UpdateTable(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -76,33 +96,45 @@ func UpdateTable() gin.HandlerFunc {
 			return
 		}
 
-		updateObj := bson.M{"updatedAt": time.Now().UTC()}
-		if updateTableDto.NumberOfGuests != nil {
-			updateObj["numberOfGuests"] = updateTableDto.NumberOfGuests
-		}
-		if updateTableDto.TableNumber != nil {
-			updateObj["tableNumber"] = updateTableDto.TableNumber
+		if err := utils.Validate.Struct(updateTableDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
 		}
 
-		filter := bson.M{"tableId": tableId}
-		result, err := tableCollection.UpdateOne(ctx, filter, bson.M{"$set": updateObj})
-
+		table, err := store.Tables.Get(ctx, tableId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("table not found"))
+			return
+		}
 		if err != nil {
-			slog.Error("Error while updating table", slog.String("error", err.Error()))
+			slog.Error("Error while fetching table", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
+		if code, err := placeTable(ctx, store, table, &updateTableDto); err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while placing table", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
 
-		if result.MatchedCount == 0 {
+		err = store.Tables.Update(ctx, tableId, updateTableDto)
+		if errors.Is(err, repository.ErrNotFound) {
 			utils.ApiError(c, http.StatusNotFound, errors.New("table not found"))
 			return
 		}
+		if err != nil {
+			slog.Error("Error while updating table", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
 
 		utils.ApiSuccess(c, http.StatusOK, nil, "Table updated successfully")
 	}
 }
 
-func GetTable() gin.HandlerFunc {
+func GetTable(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -112,9 +144,7 @@ func GetTable() gin.HandlerFunc {
 			utils.ApiError(c, http.StatusBadRequest, errors.New("tableId is empty"))
 			return
 		}
-		table := models.Table{}
-
-		err := tableCollection.FindOne(ctx, bson.M{"tableId": tableId}).Decode(&table)
+		table, err := store.Tables.Get(ctx, tableId)
 		if err != nil {
 			slog.Error("Error while fetching table", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusNotFound, err)
@@ -125,25 +155,499 @@ func GetTable() gin.HandlerFunc {
 	}
 }
 
-func GetAllTables() gin.HandlerFunc {
+func GetAllTables(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		tables := make([]models.Table, 0)
-		result, err := tableCollection.Find(ctx, bson.M{})
+		tables, err := store.Tables.List(ctx)
 		if err != nil {
 			slog.Error("Error while fetching tables", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		if err := result.All(ctx, &tables); err != nil {
+		utils.ApiSuccess(c, http.StatusOK, tables, "Tables fetched successfully")
+	}
+}
+
+// SeatTable seats a party, a walk-in or a reservation, at a free table.
+func SeatTable(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var seatDto models.SeatTableDto
+		if err := c.BindJSON(&seatDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(seatDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		tableId := c.Param("tableId")
+		var table models.Table
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			table, code, err = seatTable(ctx, store, tableId, seatDto)
+			return err
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while seating table", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, table, "Table seated successfully")
+	}
+}
+
+// ClearTable frees a table once its party has paid, or has left without
+// ordering, splits the tables combined for it and calls the first party on
+// the waitlist that it seats.
+func ClearTable(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		tableId := c.Param("tableId")
+		var table models.Table
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			table, code, err = lockedTable(ctx, store, tableId)
+			if err != nil {
+				return err
+			}
+			switch status := helpers.TableStatus(table); status {
+			case constants.TABLE_STATUS_SEATED, constants.TABLE_STATUS_CLEANING:
+			case constants.TABLE_STATUS_FREE:
+				code = http.StatusConflict
+				return errors.New("table is already free")
+			case constants.TABLE_STATUS_COMBINED:
+				code = http.StatusConflict
+				return fmt.Errorf("table is combined into table %s, clear that one", table.CombinedInto)
+			default:
+				code = http.StatusConflict
+				return fmt.Errorf("table is %s, its orders are not paid", status)
+			}
+
+			table.Status = constants.TABLE_STATUS_FREE
+			table.StatusSince = time.Now().UTC()
+			table.Occupancy = nil
+			code = http.StatusInternalServerError
+			if err := store.Tables.SetStatus(ctx, tableId, table.Status, table.StatusSince, nil); err != nil {
+				return err
+			}
+			if table.Combination == nil {
+				return nil
+			}
+			table.Combination = nil
+			return splitTables(ctx, store, tableId)
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while clearing table", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+		notifyNextParty(ctx, store, tableId)
+
+		utils.ApiSuccess(c, http.StatusOK, table, "Table cleared successfully")
+	}
+}
+
+// CombineTables pushes free tables together with a table for a party none
+// of them seats alone. The tables stay COMBINED until the table is cleared
+// or split.
+func CombineTables(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var combineDto models.CombineTablesDto
+		if err := c.BindJSON(&combineDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(combineDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		tableId := c.Param("tableId")
+		var table models.Table
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			table, code, err = lockedTable(ctx, store, tableId)
+			if err != nil {
+				return err
+			}
+			if table.CombinedInto != "" {
+				code = http.StatusConflict
+				return fmt.Errorf("table is combined into table %s", table.CombinedInto)
+			}
+			if status := helpers.TableStatus(table); status == constants.TABLE_STATUS_CLEANING {
+				code = http.StatusConflict
+				return fmt.Errorf("table is %s", status)
+			}
+
+			combination := models.TableCombination{TableIds: []string{}, Seats: helpers.TableSeats(table)}
+			if table.Combination != nil {
+				combination = *table.Combination
+			}
+			now := time.Now().UTC()
+			for _, otherId := range combineDto.TableIds {
+				if otherId == tableId {
+					code = http.StatusBadRequest
+					return errors.New("a table cannot be combined with itself")
+				}
+				var other models.Table
+				other, code, err = lockedTable(ctx, store, otherId)
+				if code == http.StatusNotFound {
+					code = http.StatusBadRequest
+					return fmt.Errorf("table %s not found", otherId)
+				}
+				if err != nil {
+					return err
+				}
+				if status := helpers.TableStatus(other); status != constants.TABLE_STATUS_FREE {
+					code = http.StatusConflict
+					return fmt.Errorf("table %s is %s", otherId, status)
+				}
+				if other.Combination != nil {
+					code = http.StatusConflict
+					return fmt.Errorf("table %s has tables combined with it", otherId)
+				}
+				if table.FloorPlanId != "" && other.FloorPlanId != "" && other.FloorPlanId != table.FloorPlanId {
+					code = http.StatusBadRequest
+					return fmt.Errorf("table %s is on another floor plan", otherId)
+				}
+				code = http.StatusInternalServerError
+				if err := store.Tables.SetStatus(ctx, otherId, constants.TABLE_STATUS_COMBINED, now, nil); err != nil {
+					return err
+				}
+				if err := store.Tables.SetCombination(ctx, otherId, nil, tableId); err != nil {
+					return err
+				}
+				combination.TableIds = append(combination.TableIds, otherId)
+				combination.Seats += helpers.TableSeats(other)
+			}
+
+			table.Combination = &combination
+			return store.Tables.SetCombination(ctx, tableId, table.Combination, "")
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while combining tables", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, table, "Tables combined successfully")
+	}
+}
+
+// SplitTable separates the tables combined with a free table again.
+func SplitTable(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		tableId := c.Param("tableId")
+		var table models.Table
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			table, code, err = lockedTable(ctx, store, tableId)
+			if err != nil {
+				return err
+			}
+			if table.Combination == nil {
+				code = http.StatusConflict
+				return errors.New("table is not combined")
+			}
+			if status := helpers.TableStatus(table); status != constants.TABLE_STATUS_FREE {
+				code = http.StatusConflict
+				return fmt.Errorf("table is %s, clear it first", status)
+			}
+			table.Combination = nil
+			code = http.StatusInternalServerError
+			return splitTables(ctx, store, tableId)
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while splitting tables", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, table, "Tables split successfully")
+	}
+}
+
+// GetFloor returns every table with its current status, how long it has
+// been in it and its next reservation.
+func GetFloor(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		tables, err := store.Tables.List(ctx)
+		if err != nil {
 			slog.Error("Error while fetching tables", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
+		now := time.Now().UTC()
+		reservations, err := store.Reservations.ListOverlapping(ctx, now, now.Add(24*time.Hour))
+		if err != nil {
+			slog.Error("Error while fetching reservations", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
 
-		utils.ApiSuccess(c, http.StatusOK, tables, "Tables fetched successfully")
+		utils.ApiSuccess(c, http.StatusOK, helpers.FloorView(tables, reservations, now), "Floor fetched successfully")
+	}
+}
+
+// seatTable seats the party of seatDto at a free table, taking the table of
+// a seated reservation along with it.
+func seatTable(ctx context.Context, store *repository.Store, tableId string, seatDto models.SeatTableDto) (models.Table, int, error) {
+	table, code, err := lockedTable(ctx, store, tableId)
+	if err != nil {
+		return models.Table{}, code, err
+	}
+	if status := helpers.TableStatus(table); status != constants.TABLE_STATUS_FREE {
+		return models.Table{}, http.StatusConflict, fmt.Errorf("table is %s", status)
+	}
+
+	occupancy := models.TableOccupancy{
+		PartySize:     seatDto.PartySize,
+		SeatedAt:      time.Now().UTC(),
+		ReservationId: seatDto.ReservationId,
+		OrderIds:      []string{},
+	}
+	if seatDto.ReservationId != "" {
+		reservation, code, err := bookedReservation(ctx, store, seatDto.ReservationId)
+		if err != nil {
+			return models.Table{}, code, err
+		}
+		if occupancy.PartySize == 0 {
+			occupancy.PartySize = reservation.PartySize
+		}
+		if reservation.TableId != tableId {
+			if err := store.Reservations.Update(ctx, reservation.ReservationId, models.UpdateReservationDto{TableId: &tableId}); err != nil {
+				return models.Table{}, http.StatusInternalServerError, err
+			}
+		}
+		if err := store.Reservations.SetStatus(ctx, reservation.ReservationId, constants.RESERVATION_STATUS_SEATED); err != nil {
+			return models.Table{}, http.StatusInternalServerError, err
+		}
+	}
+	if seats := helpers.TableCapacity(table); seats < occupancy.PartySize {
+		return models.Table{}, http.StatusBadRequest, fmt.Errorf("table seats %d, not %d", seats, occupancy.PartySize)
+	}
+
+	table.Status = constants.TABLE_STATUS_SEATED
+	table.StatusSince = occupancy.SeatedAt
+	table.Occupancy = &occupancy
+	if err := store.Tables.SetStatus(ctx, tableId, table.Status, table.StatusSince, table.Occupancy); err != nil {
+		return models.Table{}, http.StatusInternalServerError, err
+	}
+	return table, http.StatusOK, nil
+}
+
+// splitTables frees the tables combined with tableId.
+func splitTables(ctx context.Context, store *repository.Store, tableId string) error {
+	table, err := store.Tables.Get(ctx, tableId)
+	if err != nil || table.Combination == nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, otherId := range table.Combination.TableIds {
+		err := store.Tables.SetCombination(ctx, otherId, nil, "")
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := store.Tables.SetStatus(ctx, otherId, constants.TABLE_STATUS_FREE, now, nil); err != nil {
+			return err
+		}
+	}
+	return store.Tables.SetCombination(ctx, tableId, nil, "")
+}
+
+// partyTable locks the table the party at tableId is tracked at: the table
+// itself, or the one it is combined into.
+func partyTable(ctx context.Context, store *repository.Store, tableId string) (models.Table, error) {
+	table, _, err := lockedTable(ctx, store, tableId)
+	if err != nil || table.CombinedInto == "" {
+		return table, err
+	}
+	table, _, err = lockedTable(ctx, store, table.CombinedInto)
+	return table, err
+}
+
+// lockedTable fetches a table and claims it for the transaction.
+func lockedTable(ctx context.Context, store *repository.Store, tableId string) (models.Table, int, error) {
+	err := store.Tables.Lock(ctx, tableId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.Table{}, http.StatusNotFound, errors.New("table not found")
+	}
+	if err != nil {
+		return models.Table{}, http.StatusInternalServerError, err
+	}
+	table, err := store.Tables.Get(ctx, tableId)
+	if err != nil {
+		return models.Table{}, http.StatusInternalServerError, err
+	}
+	return table, http.StatusOK, nil
+}
+
+// trackTableOrder adds a new order to the party at its table. An order
+// placed at a free table, or one still being cleaned, seats a walk-in party.
+func trackTableOrder(ctx context.Context, store *repository.Store, order models.Order) {
+	err := store.Transaction(ctx, func(ctx context.Context) error {
+		table, err := partyTable(ctx, store, order.TableId)
+		if err != nil {
+			return err
+		}
+		occupancy := table.Occupancy
+		switch helpers.TableStatus(table) {
+		case constants.TABLE_STATUS_FREE, constants.TABLE_STATUS_CLEANING:
+			occupancy = nil
+		}
+		if occupancy == nil {
+			occupancy = &models.TableOccupancy{SeatedAt: order.CreatedAt, OrderIds: []string{}}
+			table.Status = constants.TABLE_STATUS_SEATED
+			table.StatusSince = order.CreatedAt
+		}
+		if !slices.Contains(occupancy.OrderIds, order.OrderID) {
+			occupancy.OrderIds = append(occupancy.OrderIds, order.OrderID)
+		}
+		return updateTableStatus(ctx, store, table, occupancy)
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		slog.Error("Error while updating table status", slog.String("tableId", order.TableId), slog.String("error", err.Error()))
+	}
+}
+
+// refreshTable derives the status of a table again after one of its
+// party's orders was billed, paid or cancelled, along with the tables that
+// share a check with it since they were merged. Free tables are left alone.
+func refreshTable(ctx context.Context, store *repository.Store, tableId string) {
+	err := store.Transaction(ctx, func(ctx context.Context) error {
+		table, err := partyTable(ctx, store, tableId)
+		if err != nil || table.Occupancy == nil {
+			return err
+		}
+		if err := updateTableStatus(ctx, store, table, table.Occupancy); err != nil {
+			return err
+		}
+
+		tables, err := store.Tables.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, other := range tables {
+			if other.TableId == table.TableId || other.Occupancy == nil {
+				continue
+			}
+			shared := slices.ContainsFunc(other.Occupancy.OrderIds, func(id string) bool {
+				return slices.Contains(table.Occupancy.OrderIds, id)
+			})
+			if !shared {
+				continue
+			}
+			if err := store.Tables.Lock(ctx, other.TableId); err != nil {
+				return err
+			}
+			if err := updateTableStatus(ctx, store, other, other.Occupancy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		slog.Error("Error while updating table status", slog.String("tableId", tableId), slog.String("error", err.Error()))
+	}
+}
+
+// untrackTableOrder takes an order moved to another table off the party
+// at tableId.
+func untrackTableOrder(ctx context.Context, store *repository.Store, tableId, orderId string) {
+	err := store.Transaction(ctx, func(ctx context.Context) error {
+		table, err := partyTable(ctx, store, tableId)
+		if err != nil || table.Occupancy == nil {
+			return err
+		}
+		occupancy := *table.Occupancy
+		occupancy.OrderIds = slices.DeleteFunc(slices.Clone(occupancy.OrderIds), func(id string) bool { return id == orderId })
+		return updateTableStatus(ctx, store, table, &occupancy)
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		slog.Error("Error while updating table status", slog.String("tableId", tableId), slog.String("error", err.Error()))
+	}
+}
+
+// refreshOrderTable refreshes the table an order was placed at.
+func refreshOrderTable(ctx context.Context, store *repository.Store, orderId string) {
+	order, err := store.Orders.Get(ctx, orderId)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			slog.Error("Error while fetching order", slog.String("error", err.Error()))
+		}
+		return
+	}
+	refreshTable(ctx, store, order.TableId)
+}
+
+// updateTableStatus stores the status the orders of occupancy put table
+// in. The time in state only restarts when the status changes.
+func updateTableStatus(ctx context.Context, store *repository.Store, table models.Table, occupancy *models.TableOccupancy) error {
+	orders := make([]models.Order, 0, len(occupancy.OrderIds))
+	billed := map[string]bool{}
+	for _, orderId := range occupancy.OrderIds {
+		order, err := store.Orders.Get(ctx, orderId)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		invoices, err := store.Invoices.ListByOrder(ctx, orderId)
+		if err != nil {
+			return err
+		}
+		orders = append(orders, order)
+		billed[orderId] = slices.ContainsFunc(invoices, func(i models.Invoice) bool { return !isInvoiceVoided(i) })
+	}
+
+	status, since := helpers.OccupiedTableStatus(orders, billed), table.StatusSince
+	if status != helpers.TableStatus(table) || since.IsZero() {
+		since = time.Now().UTC()
 	}
+	return store.Tables.SetStatus(ctx, table.TableId, status, since, occupancy)
 }
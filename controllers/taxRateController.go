@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func CreateTaxRate(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var taxRate models.TaxRate
+		if err := c.BindJSON(&taxRate); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(taxRate); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		taxRate.CreatedAt = time.Now().UTC()
+		taxRate.UpdatedAt = time.Now().UTC()
+		taxRate.ID = bson.NewObjectID()
+		taxRate.TaxRateId = taxRate.ID.Hex()
+
+		if err := store.TaxRates.Create(ctx, taxRate); err != nil {
+			slog.Error("Error while creating tax rate", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, taxRate, "Tax rate created successfully")
+	}
+}
+
+func UpdateTaxRate(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		taxRateId := c.Param("taxRateId")
+		if taxRateId == "" {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid tax rate id"))
+			return
+		}
+
+		var updateDto models.UpdateTaxRateDto
+		if err := c.BindJSON(&updateDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(updateDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err := store.TaxRates.Update(ctx, taxRateId, updateDto)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("tax rate not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while updating tax rate", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, updateDto, "Tax rate updated successfully")
+	}
+}
+
+func DeleteTaxRate(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		taxRateId := c.Param("taxRateId")
+		if taxRateId == "" {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid tax rate id"))
+			return
+		}
+
+		// Foods charged a rate would silently go untaxed without it.
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			foods, err := store.Foods.CountByTaxRate(ctx, taxRateId)
+			if err != nil {
+				return err
+			}
+			if foods > 0 {
+				code = http.StatusConflict
+				return fmt.Errorf("tax rate is charged on %d foods; take it off them first", foods)
+			}
+			return store.TaxRates.Delete(ctx, taxRateId)
+		})
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("tax rate not found"))
+			return
+		}
+		if code == http.StatusConflict {
+			utils.ApiError(c, code, err)
+			return
+		}
+		if err != nil {
+			slog.Error("Error while deleting tax rate", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, nil, "Tax rate deleted successfully")
+	}
+}
+
+func GetTaxRate(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		taxRate, err := store.TaxRates.Get(ctx, c.Param("taxRateId"))
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("tax rate not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching tax rate", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, taxRate, "Tax rate fetched successfully")
+	}
+}
+
+func GetAllTaxRates(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		taxRates, err := store.TaxRates.List(ctx)
+		if err != nil {
+			slog.Error("Error while fetching tax rates", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, taxRates, "Tax rates fetched successfully")
+	}
+}
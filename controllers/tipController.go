@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+)
+
+// TipPool shares the tips taken during a shift among the staff who worked
+// it, by hours, role points or both.
+func TipPool(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var poolDto models.TipPoolDto
+		if err := c.BindJSON(&poolDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(poolDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		rolePoints := maps.Clone(helpers.DefaultTipPoints)
+		maps.Copy(rolePoints, poolDto.RolePoints)
+
+		report := models.TipPoolReport{
+			From:     poolDto.From,
+			To:       poolDto.To,
+			Rule:     poolDto.Rule,
+			Currency: money.Currency(),
+			Shares:   make([]models.TipShare, 0, len(poolDto.Staff)),
+		}
+		weights := make([]int64, 0, len(poolDto.Staff))
+		seen := map[string]bool{}
+		for _, member := range poolDto.Staff {
+			if seen[member.UserId] {
+				utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("user %s is listed twice", member.UserId))
+				return
+			}
+			seen[member.UserId] = true
+
+			user, err := store.Users.Get(ctx, member.UserId)
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("user %s not found", member.UserId))
+				return
+			}
+			if err != nil {
+				slog.Error("Error while fetching user", slog.String("error", err.Error()))
+				utils.ApiError(c, http.StatusInternalServerError, err)
+				return
+			}
+
+			share := models.TipShare{UserId: user.UserId, Hours: member.Hours}
+			if user.Name != nil {
+				share.Name = *user.Name
+			}
+			if user.Role != nil {
+				share.Role = *user.Role
+			}
+			share.Points = rolePoints[share.Role]
+			report.Shares = append(report.Shares, share)
+			weights = append(weights, helpers.TipWeight(poolDto.Rule, member.Hours, share.Points))
+		}
+
+		payments, err := store.Payments.ListBetween(ctx, poolDto.From, poolDto.To)
+		if err != nil {
+			slog.Error("Error while fetching payments", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		report.TotalTips = helpers.LedgerTips(payments)
+
+		orderIds := make([]string, 0, len(payments))
+		for _, payment := range payments {
+			orderIds = append(orderIds, payment.OrderId)
+		}
+		servers, sections, err := creditedOrders(ctx, store, orderIds)
+		if err != nil {
+			slog.Error("Error while fetching orders", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		report.ByServer = helpers.TipsByService(payments, servers)
+		report.BySection = helpers.TipsByService(payments, sections)
+		if err := nameServiceTips(ctx, store, report.ByServer, false); err != nil {
+			slog.Error("Error while fetching users", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+		if err := nameServiceTips(ctx, store, report.BySection, true); err != nil {
+			slog.Error("Error while fetching sections", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		for i, amount := range helpers.DistributeTips(report.TotalTips, weights) {
+			report.Shares[i].Amount = amount
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, report, "Tip pool computed successfully")
+	}
+}
+
+// nameServiceTips names the servers, or the sections, tips were left for.
+func nameServiceTips(ctx context.Context, store *repository.Store, tips []models.ServiceTips, bySection bool) error {
+	ids := make([]string, 0, len(tips))
+	for _, t := range tips {
+		ids = append(ids, t.Id)
+	}
+	names, err := serviceNames(ctx, store, ids, bySection)
+	if err != nil {
+		return err
+	}
+	for i := range tips {
+		tips[i].Name = names[tips[i].Id]
+	}
+	return nil
+}
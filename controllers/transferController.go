@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+)
+
+// TransferOrderItems moves items from one open order to another, such as
+// a guest's dishes onto the check of another party.
+func TransferOrderItems(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var transferDto models.TransferOrderItemsDto
+		if err := c.BindJSON(&transferDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(transferDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		orderId := c.Param("orderId")
+		if transferDto.ToOrderId == orderId {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("items are already on that order"))
+			return
+		}
+
+		var from, to models.Order
+		var moved []models.OrderItem
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			if from, code, err = openCheck(ctx, store, orderId); err != nil {
+				return err
+			}
+			if to, code, err = openCheck(ctx, store, transferDto.ToOrderId); err != nil {
+				return err
+			}
+			for _, orderItemId := range transferDto.OrderItemIds {
+				orderItem, err := store.OrderItems.Get(ctx, orderItemId)
+				if errors.Is(err, repository.ErrNotFound) || (err == nil && orderItem.OrderId != orderId) {
+					code = http.StatusBadRequest
+					return fmt.Errorf("order item %s is not on order %s", orderItemId, orderId)
+				}
+				if err != nil {
+					code = http.StatusInternalServerError
+					return err
+				}
+				if orderItem.Void != nil {
+					code = http.StatusConflict
+					return fmt.Errorf("order item %s is voided", orderItemId)
+				}
+			}
+
+			code = http.StatusInternalServerError
+			moved, err = store.OrderItems.MoveToOrder(ctx, orderId, to.OrderID, transferDto.OrderItemIds)
+			return err
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while transferring order items", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+		publishOrderItems(ctx, store, constants.EVENT_ORDER_ITEM_UPDATED, moved)
+		refreshTable(ctx, store, from.TableId)
+		if to.TableId != from.TableId {
+			refreshTable(ctx, store, to.TableId)
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, moved, "Order items transferred successfully")
+	}
+}
+
+// MergeOrders merges open orders into one check: their items move onto the
+// order merged into and the emptied orders are deleted, as DeleteOrder
+// does. The parties the merged orders belonged to follow the check.
+func MergeOrders(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var mergeDto models.MergeOrdersDto
+		if err := c.BindJSON(&mergeDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(mergeDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		orderId := c.Param("orderId")
+		if slices.Contains(mergeDto.OrderIds, orderId) {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("an order cannot be merged into itself"))
+			return
+		}
+
+		var check models.Order
+		var sources []models.Order
+		var moved []models.OrderItem
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			if check, code, err = openCheck(ctx, store, orderId); err != nil {
+				return err
+			}
+			sources = make([]models.Order, 0, len(mergeDto.OrderIds))
+			for _, sourceId := range mergeDto.OrderIds {
+				var source models.Order
+				if source, code, err = openCheck(ctx, store, sourceId); err != nil {
+					return err
+				}
+				sources = append(sources, source)
+			}
+			moved, code, err = mergeIntoCheck(ctx, store, check, sources)
+			return err
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while merging orders", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+		publishOrderItems(ctx, store, constants.EVENT_ORDER_ITEM_UPDATED, moved)
+		for _, source := range sources {
+			publishOrder(store, constants.EVENT_ORDER_DELETED, source)
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, check, "Orders merged successfully")
+	}
+}
+
+// MergeTables puts the open orders of the parties at two tables on one
+// check, held at the table merged into. Both tables follow the check until
+// it is paid.
+func MergeTables(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var mergeDto models.MergeTablesDto
+		if err := c.BindJSON(&mergeDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(mergeDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		tableId := c.Param("tableId")
+		if mergeDto.FromTableId == tableId {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("a table cannot be merged into itself"))
+			return
+		}
+
+		var check models.Order
+		var merged []models.Order
+		var moved []models.OrderItem
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var orders, fromOrders []models.Order
+			var err error
+			if orders, code, err = partyOrders(ctx, store, tableId); err != nil {
+				return err
+			}
+			if fromOrders, code, err = partyOrders(ctx, store, mergeDto.FromTableId); err != nil {
+				return err
+			}
+			// Tables combined for one party share its orders.
+			for _, order := range fromOrders {
+				if !slices.ContainsFunc(orders, func(o models.Order) bool { return o.OrderID == order.OrderID }) {
+					orders = append(orders, order)
+				}
+			}
+			if len(orders) == 0 {
+				code = http.StatusConflict
+				return errors.New("neither table has open orders")
+			}
+			for _, order := range orders {
+				if _, code, err = openCheck(ctx, store, order.OrderID); err != nil {
+					return err
+				}
+			}
+
+			// The check is the first order of the table merged into, or the
+			// first one of the other table brought over.
+			check = orders[0]
+			code = http.StatusInternalServerError
+			if check.TableId != tableId {
+				if err := store.Orders.UpdateTable(ctx, check.OrderID, tableId); err != nil {
+					return err
+				}
+				check.TableId = tableId
+			}
+			merged = orders[1:]
+			if moved, code, err = mergeIntoCheck(ctx, store, check, merged); err != nil {
+				return err
+			}
+			return followCheck(ctx, store, mergeDto.FromTableId, nil, check.OrderID, true)
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while merging tables", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+		publishOrderItems(ctx, store, constants.EVENT_ORDER_ITEM_UPDATED, moved)
+		for _, order := range merged {
+			publishOrder(store, constants.EVENT_ORDER_DELETED, order)
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, check, "Tables merged successfully")
+	}
+}
+
+// openCheck fetches an order whose items can still be moved: it is neither
+// paid nor cancelled, has no bill that is not voided and was taken on a
+// business day still open. The order is claimed for the transaction, so
+// that items do not move while it is being billed.
+func openCheck(ctx context.Context, store *repository.Store, orderId string) (models.Order, int, error) {
+	order, code, err := lockedOrder(ctx, store, orderId)
+	if code == http.StatusNotFound {
+		return models.Order{}, code, fmt.Errorf("order %s not found", orderId)
+	}
+	if err != nil {
+		return models.Order{}, code, err
+	}
+	if helpers.IsOrderClosed(order) {
+		return models.Order{}, http.StatusConflict, fmt.Errorf("order %s is %s", orderId, helpers.OrderStatus(order))
+	}
+	if code, err := checkBusinessDayOpen(ctx, store, order.CreatedAt); err != nil {
+		return models.Order{}, code, err
+	}
+	invoices, err := store.Invoices.ListByOrder(ctx, orderId)
+	if err != nil {
+		return models.Order{}, http.StatusInternalServerError, err
+	}
+	if slices.ContainsFunc(invoices, func(i models.Invoice) bool { return !isInvoiceVoided(i) }) {
+		return models.Order{}, http.StatusConflict, fmt.Errorf("order %s is billed, void its invoice first", orderId)
+	}
+	return order, http.StatusOK, nil
+}
+
+// partyOrders returns the open orders of the party at a table, oldest
+// first.
+func partyOrders(ctx context.Context, store *repository.Store, tableId string) ([]models.Order, int, error) {
+	table, err := partyTable(ctx, store, tableId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, http.StatusNotFound, fmt.Errorf("table %s not found", tableId)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if table.Occupancy == nil {
+		return nil, http.StatusConflict, fmt.Errorf("table %s has no party", tableId)
+	}
+	orders := make([]models.Order, 0, len(table.Occupancy.OrderIds))
+	for _, orderId := range table.Occupancy.OrderIds {
+		order, err := store.Orders.Get(ctx, orderId)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if !helpers.IsOrderClosed(order) {
+			orders = append(orders, order)
+		}
+	}
+	return orders, http.StatusOK, nil
+}
+
+// mergeIntoCheck moves the items of sources onto check and deletes them.
+// Orders that were ever billed are refused, voided bills included, so that
+// no invoice or ledger entry is left pointing at a deleted order.
+func mergeIntoCheck(ctx context.Context, store *repository.Store, check models.Order, sources []models.Order) ([]models.OrderItem, int, error) {
+	moved := make([]models.OrderItem, 0)
+	merged := map[string]bool{}
+	for _, source := range sources {
+		invoices, err := store.Invoices.ListByOrder(ctx, source.OrderID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		payments, err := store.Payments.ListByOrder(ctx, source.OrderID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if len(invoices) > 0 || len(payments) > 0 {
+			return nil, http.StatusConflict, fmt.Errorf("order %s has been billed and cannot be merged away", source.OrderID)
+		}
+
+		items, err := store.OrderItems.MoveToOrder(ctx, source.OrderID, check.OrderID, nil)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		moved = append(moved, items...)
+		if err := store.Orders.Delete(ctx, source.OrderID); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		merged[source.OrderID] = true
+	}
+
+	tableIds := []string{check.TableId}
+	for _, source := range sources {
+		if !slices.Contains(tableIds, source.TableId) {
+			tableIds = append(tableIds, source.TableId)
+		}
+	}
+	for _, tableId := range tableIds {
+		if err := followCheck(ctx, store, tableId, merged, check.OrderID, tableId == check.TableId); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+	return moved, http.StatusOK, nil
+}
+
+// followCheck points the party at tableId to the check the orders merged
+// were merged into, and derives the table's status again. join adds the
+// check to the party even when none of its orders were merged.
+func followCheck(ctx context.Context, store *repository.Store, tableId string, merged map[string]bool, checkId string, join bool) error {
+	table, err := partyTable(ctx, store, tableId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil || table.Occupancy == nil {
+		return err
+	}
+	occupancy := *table.Occupancy
+	occupancy.OrderIds = slices.DeleteFunc(slices.Clone(occupancy.OrderIds), func(id string) bool { return merged[id] })
+	if (join || len(occupancy.OrderIds) < len(table.Occupancy.OrderIds)) && !slices.Contains(occupancy.OrderIds, checkId) {
+		occupancy.OrderIds = append(occupancy.OrderIds, checkId)
+	}
+	return updateTableStatus(ctx, store, table, &occupancy)
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -10,18 +11,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jrskg/go-restaurant/constants"
-	"github.com/jrskg/go-restaurant/database"
 	"github.com/jrskg/go-restaurant/helpers"
 	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
 	"github.com/jrskg/go-restaurant/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
-var userCollection = database.OpenCollection(database.DBClient, constants.USER_COLLECTION)
-
-func Signup() gin.HandlerFunc {
+func Signup(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -41,17 +38,11 @@ func Signup() gin.HandlerFunc {
 			return
 		}
 
-		result := userCollection.FindOne(ctx, bson.M{"email": user.Email})
-		if err := result.Err(); err == nil {
+		_, err := store.Users.FindByEmail(ctx, *user.Email)
+		if err == nil {
 			utils.ApiError(c, http.StatusBadRequest, errors.New("email already exist"))
 			return
-		} else if err != mongo.ErrNoDocuments {
-			utils.ApiError(c, http.StatusInternalServerError, err)
-			return
-		}
-
-		token, refreshToken, err := helpers.GetTokens(user.UserId, *user.Name, *user.Email, *user.Avatar)
-		if err != nil {
+		} else if !errors.Is(err, repository.ErrNotFound) {
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
@@ -61,16 +52,45 @@ func Signup() gin.HandlerFunc {
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
+
 		now := time.Now().UTC()
 		user.ID = bson.NewObjectID()
+		user.UserId = user.ID.Hex()
 		user.CreatedAt = now
 		user.UpdatedAt = now
-		user.Token = &token
-		user.RefreshToken = &refreshToken
-		user.UserId = user.ID.Hex()
 		user.Password = &hashedPassword
 
-		_, err = userCollection.InsertOne(ctx, user)
+		err = store.Transaction(ctx, func(ctx context.Context) error {
+			// Roles are granted by an admin, never chosen at signup. The very
+			// first account becomes the admin so that someone can grant the
+			// others. Of concurrent first signups, only the one taking the
+			// first number of the bootstrap series does. Databases with
+			// users from before roles get their admin from cmd/grant-admin.
+			userCount, err := store.Users.Count(ctx)
+			if err != nil {
+				return err
+			}
+			role := constants.ROLE_WAITER
+			if userCount == 0 {
+				seq, err := store.Sequences.Next(ctx, constants.ADMIN_BOOTSTRAP_SERIES)
+				if err != nil {
+					return err
+				}
+				if seq == 1 {
+					role = constants.ROLE_ADMIN
+				}
+			}
+			user.Role = &role
+
+			sessionId, token, refreshToken, err := helpers.GetTokens(user.UserId, *user.Name, *user.Email, avatarOf(user), roleOf(user))
+			if err != nil {
+				return err
+			}
+			user.Token = &token
+			user.RefreshToken = &refreshToken
+			user.Sessions = []models.UserSession{newSession(sessionId, refreshToken)}
+			return store.Users.Create(ctx, user)
+		})
 		if err != nil {
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
@@ -80,7 +100,7 @@ func Signup() gin.HandlerFunc {
 	}
 }
 
-func Login() gin.HandlerFunc {
+func Login(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -100,8 +120,7 @@ func Login() gin.HandlerFunc {
 			return
 		}
 
-		var user models.User
-		err := userCollection.FindOne(ctx, bson.M{"email": credentials.Email}).Decode(&user)
+		user, err := store.Users.FindByEmail(ctx, *credentials.Email)
 		if err != nil {
 			slog.Error("Error while fetching user", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusNotFound, err)
@@ -113,45 +132,321 @@ func Login() gin.HandlerFunc {
 			return
 		}
 
-		token, refreshToken, err := helpers.GetTokens(user.UserId, *user.Name, *user.Email, *user.Avatar)
+		sessionId, token, refreshToken, err := helpers.GetTokens(user.UserId, *user.Name, *user.Email, avatarOf(user), roleOf(user))
 		if err != nil {
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
 
-		updates := bson.M{
-			"$set": bson.M{
-				"token":        &token,
-				"refreshToken": &refreshToken,
-				"updatedAt":    time.Now().UTC(),
-			},
+		user, err = store.Users.UpdateTokens(ctx, *credentials.Email, token, newSession(sessionId, refreshToken))
+		if err != nil {
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, user, "User logged in successfully")
+	}
+}
+
+func RefreshToken(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var refreshTokenDto models.RefreshTokenDto
+		if err := c.BindJSON(&refreshTokenDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(refreshTokenDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		presented := *refreshTokenDto.RefreshToken
+		claims, err := helpers.ValidateAndDecodeToken(presented)
+		if err != nil {
+			utils.ApiError(c, http.StatusUnauthorized, err)
+			return
+		}
+		if claims.TokenType != helpers.RefreshToken {
+			utils.ApiError(c, http.StatusUnauthorized, errors.New("invalid refresh token"))
+			return
 		}
 
-		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-		err = userCollection.FindOneAndUpdate(ctx, bson.M{"email": credentials.Email}, updates, opts).Decode(&user)
+		revoked, err := store.RevokedTokens.IsRevoked(ctx, claims.SessionId)
 		if err != nil {
+			slog.Error("Error while checking token revocation", slog.String("error", err.Error()))
 			utils.ApiError(c, http.StatusInternalServerError, err)
 			return
 		}
+		if revoked {
+			utils.ApiError(c, http.StatusUnauthorized, errors.New("token has been revoked"))
+			return
+		}
 
-		utils.ApiSuccess(c, http.StatusOK, user, "User logged in successfully")
+		user, err := store.Users.Get(ctx, claims.UserId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusUnauthorized, errors.New("invalid refresh token"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching user", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		token, refreshToken, err := helpers.RotateTokens(claims.SessionId, user.UserId, *user.Name, *user.Email, avatarOf(user), roleOf(user))
+		if err != nil {
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		err = store.Users.RotateTokens(ctx, user.UserId, presented, token, newSession(claims.SessionId, refreshToken))
+		if errors.Is(err, repository.ErrNotFound) {
+			// The presented token is genuine but no longer the current one of
+			// its session, so it has been used before. Assume it leaked and
+			// end the session.
+			slog.Warn("Refresh token reuse detected", slog.String("userId", user.UserId), slog.String("sessionId", claims.SessionId))
+			expiresAt := time.Now().Add(helpers.RefreshTokenTTL)
+			if err := store.RevokedTokens.Revoke(ctx, claims.SessionId, expiresAt); err != nil {
+				slog.Error("Error while revoking session", slog.String("error", err.Error()))
+				utils.ApiError(c, http.StatusInternalServerError, err)
+				return
+			}
+			if err := store.Users.ClearTokens(ctx, user.UserId, claims.SessionId); err != nil && !errors.Is(err, repository.ErrNotFound) {
+				slog.Error("Error while clearing user tokens", slog.String("error", err.Error()))
+				utils.ApiError(c, http.StatusInternalServerError, err)
+				return
+			}
+			utils.ApiError(c, http.StatusUnauthorized, errors.New("refresh token reuse detected"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while rotating tokens", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(
+			c,
+			http.StatusOK,
+			bson.M{"token": token, "refreshToken": refreshToken},
+			"Token refreshed successfully",
+		)
+	}
+}
+
+func Logout(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userId := c.GetString("userId")
+		sessionId := c.GetString("sessionId")
+
+		// Revoking the session covers both the access token and the refresh
+		// token of the pair, so it has to outlive the refresh token.
+		expiresAt := time.Now().Add(helpers.RefreshTokenTTL)
+		if err := store.RevokedTokens.Revoke(ctx, sessionId, expiresAt); err != nil {
+			slog.Error("Error while revoking session", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		err := store.Users.ClearTokens(ctx, userId, sessionId)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			slog.Error("Error while clearing user tokens", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, nil, "User logged out successfully")
 	}
 }
 
-func Logout() gin.HandlerFunc {
+func GetAllUsers(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		const limit int64 = 50
+		cursor := c.Query("cursor")
+		var cursorTime time.Time
+		if cursor != "" {
+			pt, err := utils.ValidateAndParseTime(cursor)
+			if err != nil {
+				utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("invalid cursor format: %s", cursor))
+				return
+			}
+			cursorTime = pt
+		}
+
+		users, err := store.Users.List(ctx, cursorTime, limit+1)
+		if err != nil {
+			slog.Error("Error while fetching users", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		hasMore := false
+		nextCursor := time.Time{}
+		if len(users) > int(limit) {
+			hasMore = true
+			users = users[:len(users)-1]
+			nextCursor = users[len(users)-1].CreatedAt
+		}
 
+		allUsers := make([]models.UserView, 0, len(users))
+		for _, user := range users {
+			allUsers = append(allUsers, userView(user))
+		}
+
+		utils.ApiSuccess(
+			c,
+			http.StatusOK,
+			bson.M{
+				"hasMore":    hasMore,
+				"users":      allUsers,
+				"nextCursor": nextCursor.UTC(),
+			},
+			"Users fetched successfully",
+		)
 	}
 }
 
-func GetAllUsers() gin.HandlerFunc {
+func GetUser(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userId := c.Param("userId")
+		if userId == "" {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid user id"))
+			return
+		}
+
+		user, err := store.Users.Get(ctx, userId)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("user not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching user", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
 
+		utils.ApiSuccess(c, http.StatusOK, userView(user), "User fetched successfully")
 	}
 }
 
-func GetUser() gin.HandlerFunc {
+func UpdateUserRole(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		userId := c.Param("userId")
+		if userId == "" {
+			utils.ApiError(c, http.StatusBadRequest, errors.New("invalid user id"))
+			return
+		}
+
+		var updateRoleDto models.UpdateRoleDto
+		if err := c.BindJSON(&updateRoleDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(updateRoleDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		user, err := ChangeRole(ctx, store, userId, *updateRoleDto.Role)
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("user not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while updating user role", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, userView(user), "User role updated successfully")
+	}
+}
+
+// ChangeRole gives the user userId role. Tokens carry the role they were
+// issued with, so the user's sessions are ended and the new role takes
+// effect at their next login.
+func ChangeRole(ctx context.Context, store *repository.Store, userId, role string) (models.User, error) {
+	var user models.User
+	err := store.Transaction(ctx, func(ctx context.Context) error {
+		previous, err := store.Users.Get(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if user, err = store.Users.UpdateRole(ctx, userId, role); err != nil {
+			return err
+		}
+		if roleOf(previous) == role {
+			return nil
+		}
+		for _, session := range previous.Sessions {
+			if err := store.RevokedTokens.Revoke(ctx, session.SessionId, session.ExpiresAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return user, err
+}
+
+// newSession records the refresh token just issued to session sessionId.
+func newSession(sessionId, refreshToken string) models.UserSession {
+	return models.UserSession{
+		SessionId:    sessionId,
+		RefreshToken: refreshToken,
+		ExpiresAt:    time.Now().Add(helpers.RefreshTokenTTL).UTC(),
+	}
+}
+
+func avatarOf(user models.User) string {
+	if user.Avatar == nil {
+		return ""
+	}
+	return *user.Avatar
+}
+
+// roleOf treats accounts created before roles existed as waiters, the role
+// with the fewest privileges.
+func roleOf(user models.User) string {
+	if user.Role == nil {
+		return constants.ROLE_WAITER
+	}
+	return *user.Role
+}
 
+func userView(user models.User) models.UserView {
+	return models.UserView{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Avatar:    user.Avatar,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		UserId:    user.UserId,
 	}
 }
@@ -0,0 +1,457 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// CreateWaitlistEntry puts a walk-in party at the end of the waitlist and
+// quotes it the wait for a table.
+func CreateWaitlistEntry(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var entryDto models.CreateWaitlistEntryDto
+		if err := c.BindJSON(&entryDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(entryDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		quote, code, err := quoteWait(ctx, store, entryDto.PartySize, time.Now().UTC())
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while quoting wait", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		entry := models.WaitlistEntry{
+			GuestName:         entryDto.GuestName,
+			Phone:             entryDto.Phone,
+			PartySize:         entryDto.PartySize,
+			Notes:             entryDto.Notes,
+			Status:            constants.WAITLIST_STATUS_WAITING,
+			QuotedWaitMinutes: quote.WaitMinutes,
+			CreatedBy:         c.GetString("userId"),
+			CreatedAt:         time.Now().UTC(),
+			UpdatedAt:         time.Now().UTC(),
+		}
+		entry.ID = bson.NewObjectID()
+		entry.EntryId = entry.ID.Hex()
+
+		if err := store.Waitlist.Create(ctx, entry); err != nil {
+			slog.Error("Error while creating waitlist entry", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusCreated, entry, "Party added to the waitlist successfully")
+	}
+}
+
+// GetWaitQuote tells how long a party would wait for a table if it joined
+// the waitlist now.
+func GetWaitQuote(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var query models.WaitQuoteQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("invalid quote query: %w", err))
+			return
+		}
+		if err := utils.Validate.Struct(query); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		quote, code, err := quoteWait(ctx, store, query.PartySize, time.Now().UTC())
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while quoting wait", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, quote, "Wait quoted successfully")
+	}
+}
+
+func GetWaitlistEntry(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		entry, err := store.Waitlist.Get(ctx, c.Param("entryId"))
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.ApiError(c, http.StatusNotFound, errors.New("waitlist entry not found"))
+			return
+		}
+		if err != nil {
+			slog.Error("Error while fetching waitlist entry", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, entry, "Waitlist entry fetched successfully")
+	}
+}
+
+// GetWaitlist lists the parties still waiting in line order, or every
+// party that joined the waitlist on the day asked for.
+func GetWaitlist(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var query models.WaitlistListQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, fmt.Errorf("invalid waitlist query: %w", err))
+			return
+		}
+
+		var entries []models.WaitlistEntry
+		var err error
+		if query.Date.IsZero() {
+			entries, err = store.Waitlist.ListActive(ctx)
+		} else {
+			entries, err = store.Waitlist.ListBetween(ctx, query.Date, query.Date.AddDate(0, 0, 1))
+		}
+		if err != nil {
+			slog.Error("Error while fetching waitlist", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, entries, "Waitlist fetched successfully")
+	}
+}
+
+// NotifyWaitlistEntry tells a waiting party that a free table is ready for
+// it. A party can be called again, to the same table or another one.
+func NotifyWaitlistEntry(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var notifyDto models.NotifyWaitlistDto
+		if err := c.BindJSON(&notifyDto); err != nil {
+			if errors.Is(err, io.EOF) {
+				utils.ApiError(c, http.StatusBadRequest, errors.New("request body is empty"))
+				return
+			}
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := utils.Validate.Struct(notifyDto); err != nil {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		var entry models.WaitlistEntry
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var table models.Table
+			var err error
+			entry, code, err = activeWaitlistEntry(ctx, store, c.Param("entryId"))
+			if err != nil {
+				return err
+			}
+			table, code, err = lockedTable(ctx, store, notifyDto.TableId)
+			if err != nil {
+				return err
+			}
+			entry, code, err = notifyParty(ctx, store, entry, table)
+			return err
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while notifying waitlist party", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, entry, "Party notified successfully")
+	}
+}
+
+// SeatWaitlistEntry seats a waiting party, at the table it was called to
+// unless another one is given, and opens its order.
+func SeatWaitlistEntry(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var seatDto models.SeatWaitlistDto
+		if err := c.ShouldBindJSON(&seatDto); err != nil && !errors.Is(err, io.EOF) {
+			utils.ApiError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		userId := c.GetString("userId")
+		var seated models.SeatedParty
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var entry models.WaitlistEntry
+			var table models.Table
+			var err error
+			entry, code, err = activeWaitlistEntry(ctx, store, c.Param("entryId"))
+			if err != nil {
+				return err
+			}
+			tableId := seatDto.TableId
+			if tableId == "" {
+				tableId = entry.TableId
+			}
+			if tableId == "" {
+				code = http.StatusBadRequest
+				return errors.New("tableId is required, the party was not called to a table")
+			}
+
+			table, code, err = seatTable(ctx, store, tableId, models.SeatTableDto{PartySize: entry.PartySize})
+			if err != nil {
+				return err
+			}
+
+			code = http.StatusInternalServerError
+			now := time.Now().UTC()
+			order := models.Order{OrderDate: now, TableId: tableId, CreatedAt: now, UpdatedAt: now}
+			order.ID = bson.NewObjectID()
+			order.OrderID = order.ID.Hex()
+			openOrder(&order, userId)
+			if err := creditOrder(ctx, store, &order, userId); err != nil {
+				return err
+			}
+			if err := store.Orders.Create(ctx, order); err != nil {
+				return err
+			}
+			table.Occupancy.OrderIds = append(table.Occupancy.OrderIds, order.OrderID)
+			if err := updateTableStatus(ctx, store, table, table.Occupancy); err != nil {
+				return err
+			}
+
+			status := constants.WAITLIST_STATUS_SEATED
+			update := models.WaitlistUpdate{Status: &status, TableId: &tableId, OrderId: &order.OrderID, SeatedAt: &now}
+			if err := store.Waitlist.Update(ctx, entry.EntryId, update); err != nil {
+				return err
+			}
+			entry.Status, entry.TableId, entry.OrderId, entry.SeatedAt = status, tableId, order.OrderID, &now
+
+			if table, err = store.Tables.Get(ctx, tableId); err != nil {
+				return err
+			}
+			seated = models.SeatedParty{Entry: entry, Table: table, Order: order}
+			return nil
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while seating waitlist party", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+		publishOrder(store, constants.EVENT_ORDER_CREATED, seated.Order)
+
+		utils.ApiSuccess(c, http.StatusOK, seated, "Party seated successfully")
+	}
+}
+
+// CancelWaitlistEntry takes a party that left off the waitlist.
+func CancelWaitlistEntry(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var entry models.WaitlistEntry
+		code := http.StatusInternalServerError
+		err := store.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			entry, code, err = activeWaitlistEntry(ctx, store, c.Param("entryId"))
+			if err != nil {
+				return err
+			}
+			entry.Status = constants.WAITLIST_STATUS_CANCELLED
+			code = http.StatusInternalServerError
+			return store.Waitlist.Update(ctx, entry.EntryId, models.WaitlistUpdate{Status: &entry.Status})
+		})
+		if err != nil {
+			if code == http.StatusInternalServerError {
+				slog.Error("Error while cancelling waitlist entry", slog.String("error", err.Error()))
+			}
+			utils.ApiError(c, code, err)
+			return
+		}
+
+		utils.ApiSuccess(c, http.StatusOK, entry, "Waitlist entry cancelled successfully")
+	}
+}
+
+// quoteWait estimates the wait of a party of partySize joining the end of
+// the waitlist at now.
+func quoteWait(ctx context.Context, store *repository.Store, partySize int, now time.Time) (models.WaitQuote, int, error) {
+	tables, err := store.Tables.List(ctx)
+	if err != nil {
+		return models.WaitQuote{}, http.StatusInternalServerError, err
+	}
+	reservations, err := store.Reservations.ListOverlapping(ctx, now, now.Add(24*time.Hour))
+	if err != nil {
+		return models.WaitQuote{}, http.StatusInternalServerError, err
+	}
+	entries, err := store.Waitlist.ListActive(ctx)
+	if err != nil {
+		return models.WaitQuote{}, http.StatusInternalServerError, err
+	}
+	history, err := turnHistory(ctx, store, tables, now)
+	if err != nil {
+		return models.WaitQuote{}, http.StatusInternalServerError, err
+	}
+
+	ahead := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		ahead = append(ahead, entry.PartySize)
+	}
+	readyAt, ok := helpers.QuoteWait(tables, reservations, history, ahead, partySize, now)
+	if !ok {
+		return models.WaitQuote{}, http.StatusBadRequest, fmt.Errorf("no table seats a party of %d", partySize)
+	}
+	return models.WaitQuote{
+		PartySize:    partySize,
+		WaitMinutes:  helpers.WaitMinutes(readyAt, now),
+		ReadyAt:      readyAt,
+		PartiesAhead: len(entries),
+	}, http.StatusOK, nil
+}
+
+// turnHistory works out how long parties held each size of table from the
+// orders settled over the last WAITLIST_HISTORY_DAYS days.
+func turnHistory(ctx context.Context, store *repository.Store, tables []models.Table, now time.Time) (helpers.TurnHistory, error) {
+	seats := map[string]int{}
+	for _, table := range tables {
+		seats[table.TableId] = helpers.TableSeats(table)
+	}
+	invoices, err := store.Invoices.ListBetween(ctx, now.AddDate(0, 0, -constants.WAITLIST_HISTORY_DAYS), now)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := map[int][]time.Duration{}
+	seen := map[string]bool{}
+	for _, invoice := range invoices {
+		if !isInvoicePaid(invoice) || seen[invoice.OrderId] {
+			continue
+		}
+		seen[invoice.OrderId] = true
+		order, err := store.Orders.Get(ctx, invoice.OrderId)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		n, ok := seats[order.TableId]
+		if !ok {
+			continue
+		}
+		if turn, ok := helpers.OrderTurnTime(order, invoice); ok {
+			samples[n] = append(samples[n], turn)
+		}
+	}
+	return helpers.NewTurnHistory(samples), nil
+}
+
+// activeWaitlistEntry fetches an entry whose party is still waiting or has
+// been called to a table.
+func activeWaitlistEntry(ctx context.Context, store *repository.Store, entryId string) (models.WaitlistEntry, int, error) {
+	entry, err := store.Waitlist.Get(ctx, entryId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.WaitlistEntry{}, http.StatusNotFound, errors.New("waitlist entry not found")
+	}
+	if err != nil {
+		return models.WaitlistEntry{}, http.StatusInternalServerError, err
+	}
+	switch entry.Status {
+	case constants.WAITLIST_STATUS_WAITING, constants.WAITLIST_STATUS_NOTIFIED:
+		return entry, http.StatusOK, nil
+	}
+	return models.WaitlistEntry{}, http.StatusConflict, fmt.Errorf("waitlist entry is %s", entry.Status)
+}
+
+// notifyParty calls the party of entry to table, which has to be free and
+// seat it. Nothing is recorded when the party could not be reached.
+func notifyParty(ctx context.Context, store *repository.Store, entry models.WaitlistEntry, table models.Table) (models.WaitlistEntry, int, error) {
+	if status := helpers.TableStatus(table); status != constants.TABLE_STATUS_FREE {
+		return models.WaitlistEntry{}, http.StatusConflict, fmt.Errorf("table is %s", status)
+	}
+	if seats := helpers.TableCapacity(table); seats < entry.PartySize {
+		return models.WaitlistEntry{}, http.StatusBadRequest, fmt.Errorf("table seats %d, not %d", seats, entry.PartySize)
+	}
+
+	now := time.Now().UTC()
+	entry.Status, entry.TableId, entry.NotifiedAt = constants.WAITLIST_STATUS_NOTIFIED, table.TableId, &now
+	update := models.WaitlistUpdate{Status: &entry.Status, TableId: &entry.TableId, NotifiedAt: &now}
+	if err := store.Waitlist.Update(ctx, entry.EntryId, update); err != nil {
+		return models.WaitlistEntry{}, http.StatusInternalServerError, err
+	}
+	if err := helpers.NotifyWaitlistParty(ctx, entry, table); err != nil {
+		return models.WaitlistEntry{}, http.StatusBadGateway, fmt.Errorf("could not notify party: %w", err)
+	}
+	return entry, http.StatusOK, nil
+}
+
+// notifyNextParty calls the first waiting party that table seats to it,
+// unless a party was already called there.
+func notifyNextParty(ctx context.Context, store *repository.Store, tableId string) {
+	err := store.Transaction(ctx, func(ctx context.Context) error {
+		table, _, err := lockedTable(ctx, store, tableId)
+		if err != nil {
+			return err
+		}
+		entries, err := store.Waitlist.ListActive(ctx)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Status == constants.WAITLIST_STATUS_NOTIFIED && entry.TableId == tableId {
+				return nil
+			}
+		}
+		for _, entry := range entries {
+			if entry.Status == constants.WAITLIST_STATUS_WAITING && entry.PartySize <= helpers.TableCapacity(table) {
+				_, _, err := notifyParty(ctx, store, entry, table)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		slog.Error("Error while notifying waitlist party", slog.String("tableId", tableId), slog.String("error", err.Error()))
+	}
+}
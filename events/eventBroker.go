@@ -0,0 +1,113 @@
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Event is a change pushed to live screens such as the kitchen display.
+// IDs increase by one per event so clients can resume after a reconnect.
+type Event struct {
+	ID        uint64    `json:"id"`
+	Type      string    `json:"type"`
+	OrderId   string    `json:"orderId"`
+	Stations  []string  `json:"stations,omitempty"`
+	Data      any       `json:"data"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ForStation reports whether a screen subscribed to station should see the
+// event. Events without stations concern the whole order and go to everyone,
+// as does a subscription without a station.
+func (e Event) ForStation(station string) bool {
+	return station == "" || len(e.Stations) == 0 || slices.Contains(e.Stations, station)
+}
+
+const subscriberBuffer = 64
+
+// Broker fans events out to subscribers in this process and keeps the most
+// recent ones so reconnecting clients can catch up.
+type Broker struct {
+	mu          sync.Mutex
+	lastId      uint64
+	history     []Event
+	historySize int
+	subscribers map[chan Event]struct{}
+}
+
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		historySize: historySize,
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+// Publish assigns the next ID to e and delivers it. A subscriber that is too
+// slow to keep up is disconnected rather than allowed to block publishers;
+// it can resume from the last ID it saw.
+func (b *Broker) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastId++
+	e.ID = b.lastId
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.history = slices.Clone(b.history[len(b.history)-b.historySize:])
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return e
+}
+
+// Subscribe returns the retained events published after lastEventId and a
+// channel of everything published from now on. complete is false when some
+// events after lastEventId are no longer retained and the client must
+// reload its state. The channel is closed by unsubscribe or when the
+// subscriber falls behind.
+func (b *Broker) Subscribe(lastEventId uint64) (missed []Event, complete bool, ch <-chan Event, unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	switch {
+	case lastEventId > b.lastId:
+		// The client saw IDs from before a restart of this process.
+		complete = false
+	case lastEventId > 0:
+		for _, e := range b.history {
+			if e.ID > lastEventId {
+				missed = append(missed, e)
+			}
+		}
+		if len(b.history) == 0 || b.history[0].ID > lastEventId+1 {
+			complete = false
+		}
+	}
+
+	sub := make(chan Event, subscriberBuffer)
+	b.subscribers[sub] = struct{}{}
+
+	unsubscribe = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[sub]; ok {
+			delete(b.subscribers, sub)
+			close(sub)
+		}
+	}
+	return missed, complete, sub, unsubscribe
+}
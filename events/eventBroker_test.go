@@ -0,0 +1,56 @@
+package events
+
+import "testing"
+
+func TestBrokerResume(t *testing.T) {
+	b := NewBroker(3)
+	for range 5 {
+		b.Publish(Event{Type: "test"})
+	}
+
+	missed, complete, _, unsubscribe := b.Subscribe(3)
+	defer unsubscribe()
+	if !complete || len(missed) != 2 || missed[0].ID != 4 {
+		t.Fatalf("resume from 3: complete=%v missed=%+v", complete, missed)
+	}
+
+	missed, complete, _, unsubscribe2 := b.Subscribe(1)
+	defer unsubscribe2()
+	if complete || len(missed) != 3 {
+		t.Fatalf("resume from 1: complete=%v len=%d", complete, len(missed))
+	}
+
+	_, complete, _, unsubscribe3 := b.Subscribe(10)
+	defer unsubscribe3()
+	if complete {
+		t.Fatal("resume from an ID never issued should be incomplete")
+	}
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	b := NewBroker(10)
+	_, _, ch, unsubscribe := b.Subscribe(0)
+	defer unsubscribe()
+
+	for range subscriberBuffer + 1 {
+		b.Publish(Event{Type: "test"})
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Fatalf("received %d events before close, want %d", received, subscriberBuffer)
+	}
+}
+
+func TestEventForStation(t *testing.T) {
+	grill := Event{Stations: []string{"grill"}}
+	if !grill.ForStation("grill") || grill.ForStation("bar") || !grill.ForStation("") {
+		t.Fatal("station filter on tagged event")
+	}
+	if !(Event{}).ForStation("bar") {
+		t.Fatal("untagged events go to every station")
+	}
+}
@@ -0,0 +1,191 @@
+package helpers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// businessDayLayout is how business days are named, as in 2026-10-17.
+const businessDayLayout = "2006-01-02"
+
+// businessDayStart is the local hour business days start at. Sales made
+// after midnight but before it belong to the day before, so a bar open
+// until 2 a.m. closes a single day.
+var businessDayStart atomic.Int32
+
+// SetBusinessDayStart makes business days start at hour, from 0 to 23.
+func SetBusinessDayStart(hour int) {
+	businessDayStart.Store(int32(hour))
+}
+
+// ConfigureBusinessDay applies the hour business days start at from
+// configuration, such as "4". An empty value keeps midnight.
+func ConfigureBusinessDay(startHour string) error {
+	if startHour == "" {
+		SetBusinessDayStart(0)
+		return nil
+	}
+	hour, err := strconv.Atoi(startHour)
+	if err != nil || hour < 0 || hour > 23 {
+		return fmt.Errorf("invalid business day start hour %q", startHour)
+	}
+	SetBusinessDayStart(hour)
+	return nil
+}
+
+// BusinessDay is the business day at falls in, in the restaurant's local
+// time.
+func BusinessDay(at time.Time) string {
+	local := at.In(time.Local)
+	if local.Hour() < int(businessDayStart.Load()) {
+		local = local.AddDate(0, 0, -1)
+	}
+	return local.Format(businessDayLayout)
+}
+
+// BusinessDayRange is the time from the start of businessDay up to but not
+// including the start of the next one.
+func BusinessDayRange(businessDay string) (time.Time, time.Time, error) {
+	date, err := time.ParseInLocation(businessDayLayout, businessDay, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid business day %q", businessDay)
+	}
+	hour := int(businessDayStart.Load())
+	from := time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, time.Local)
+	to := time.Date(date.Year(), date.Month(), date.Day()+1, hour, 0, 0, 0, time.Local)
+	return from, to, nil
+}
+
+// TallyDrawer works out the cash drawer should hold from payments, the
+// ledger entries recorded while it was open.
+func TallyDrawer(drawer models.Drawer, payments []models.Payment) models.DrawerTally {
+	var tally models.DrawerTally
+	for _, payment := range payments {
+		if payment.Method == nil || *payment.Method != constants.PAYMENT_METHOD_CASH || payment.Amount == nil {
+			continue
+		}
+		switch payment.Type {
+		case constants.LEDGER_PAYMENT, "":
+			tally.CashSales += *payment.Amount
+			if payment.Change != nil {
+				tally.CashSales -= *payment.Change
+			}
+			if payment.Tip != nil {
+				tally.CashTips += *payment.Tip
+			}
+		case constants.LEDGER_REFUND:
+			tally.CashRefunds -= *payment.Amount
+		}
+	}
+	for _, movement := range drawer.Movements {
+		switch movement.Type {
+		case constants.DRAWER_PAY_IN:
+			tally.PayIns += movement.Amount
+		case constants.DRAWER_PAY_OUT:
+			tally.PayOuts += movement.Amount
+		}
+	}
+	tally.Expected = drawer.OpeningFloat + tally.CashSales + tally.CashTips - tally.CashRefunds + tally.PayIns - tally.PayOuts
+	return tally
+}
+
+// BuildZReport is the Z-report of businessDay, which runs from from to to,
+// out of the invoices issued, the ledger entries recorded and the drawers
+// opened during it. Drawers still open are listed but left out of the cash
+// totals.
+func BuildZReport(businessDay string, from, to time.Time, invoices []models.Invoice, payments []models.Payment, drawers []models.Drawer) models.ZReport {
+	report := models.ZReport{
+		BusinessDay: businessDay,
+		From:        from,
+		To:          to,
+		Currency:    money.Currency(),
+		Sales:       RevenueSummary(invoices),
+		Taxes:       make([]models.TaxLine, 0),
+		Discounts:   make([]models.DiscountLine, 0),
+		Payments:    make([]models.PaymentMethodTotal, 0),
+		Drawers:     drawers,
+	}
+
+	for _, invoice := range invoices {
+		if invoice.PaymentStatus == nil || invoice.Totals == nil {
+			continue
+		}
+		if !IsInvoiceSettled(*invoice.PaymentStatus) {
+			if *invoice.PaymentStatus != constants.INVOICE_STATUS_VOIDED {
+				report.OpenInvoices++
+			}
+			continue
+		}
+		for _, tax := range invoice.Totals.Taxes {
+			i := slices.IndexFunc(report.Taxes, func(t models.TaxLine) bool {
+				return t.TaxRateId == tax.TaxRateId && t.Rate == tax.Rate && t.Inclusive == tax.Inclusive
+			})
+			if i < 0 {
+				report.Taxes = append(report.Taxes, models.TaxLine{TaxRateId: tax.TaxRateId, Name: tax.Name, Rate: tax.Rate, Inclusive: tax.Inclusive})
+				i = len(report.Taxes) - 1
+			}
+			report.Taxes[i].Taxable += tax.Taxable
+			report.Taxes[i].Amount += tax.Amount
+		}
+		for _, discount := range invoice.Totals.Discounts {
+			i := slices.IndexFunc(report.Discounts, func(d models.DiscountLine) bool { return d.PromotionId == discount.PromotionId })
+			if i < 0 {
+				report.Discounts = append(report.Discounts, models.DiscountLine{PromotionId: discount.PromotionId, Name: discount.Name, CouponCode: discount.CouponCode})
+				i = len(report.Discounts) - 1
+			}
+			report.Discounts[i].Amount += discount.Amount
+		}
+	}
+
+	for _, payment := range payments {
+		if payment.Amount == nil {
+			continue
+		}
+		switch payment.Type {
+		case constants.LEDGER_PAYMENT, "":
+			method := ""
+			if payment.Method != nil {
+				method = *payment.Method
+			}
+			i := slices.IndexFunc(report.Payments, func(p models.PaymentMethodTotal) bool { return p.Method == method })
+			if i < 0 {
+				report.Payments = append(report.Payments, models.PaymentMethodTotal{Method: method})
+				i = len(report.Payments) - 1
+			}
+			total := &report.Payments[i]
+			total.Count++
+			total.Amount += *payment.Amount
+			if payment.Change != nil {
+				total.Amount -= *payment.Change
+			}
+			if payment.Tip != nil {
+				total.Tips += *payment.Tip
+			}
+		case constants.LEDGER_REFUND:
+			report.Refunds.Count++
+			report.Refunds.Amount -= *payment.Amount
+		case constants.LEDGER_VOID:
+			report.Voids.Count++
+			report.Voids.Amount -= *payment.Amount
+		}
+	}
+	slices.SortFunc(report.Payments, func(a, b models.PaymentMethodTotal) int { return cmp.Compare(a.Method, b.Method) })
+
+	for _, drawer := range drawers {
+		if drawer.Status != constants.DRAWER_STATUS_CLOSED || drawer.Tally == nil || drawer.Closing == nil {
+			continue
+		}
+		report.CashExpected += drawer.Tally.Expected
+		report.CashCounted += drawer.Closing.Counted
+	}
+	report.OverShort = report.CashCounted - report.CashExpected
+	return report
+}
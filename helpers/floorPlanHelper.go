@@ -0,0 +1,40 @@
+package helpers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+)
+
+// MaxShiftLength is the longest a server can be assigned to a section in
+// one go.
+const MaxShiftLength = 16 * time.Hour
+
+// CheckTableLayout makes sure a table is drawn inside its floor plan.
+func CheckTableLayout(layout models.TableLayout, floorPlan models.FloorPlan) error {
+	if layout.X > floorPlan.Width || layout.Y > floorPlan.Height {
+		return fmt.Errorf("table at %g,%g is outside %s, which is %g by %g", layout.X, layout.Y, floorPlan.Name, floorPlan.Width, floorPlan.Height)
+	}
+	return nil
+}
+
+// SectionServer is the server an order placed at a table of sectionId at
+// is credited to: userId, who placed it, when they are on shift in the
+// section, and otherwise the server whose shift there started first. It is
+// empty when nobody works the section then.
+func SectionServer(shifts []models.Shift, sectionId string, at time.Time, userId string) string {
+	server := ""
+	for _, shift := range shifts {
+		if shift.SectionId != sectionId || at.Before(shift.StartsAt) || !at.Before(shift.EndsAt) {
+			continue
+		}
+		if shift.UserId == userId {
+			return userId
+		}
+		if server == "" {
+			server = shift.UserId
+		}
+	}
+	return server
+}
@@ -0,0 +1,201 @@
+package helpers
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// TaxedLine is one line of a bill with the tax rates charged on it and the
+// discount promotions took off it.
+type TaxedLine struct {
+	Amount   money.Amount
+	Discount money.Amount
+	TaxRates []models.TaxRate
+}
+
+// InvoiceTotals itemizes the taxes of lines and adds them up with the service
+// charge and gratuity, both charged on the discounted subtotal. Inclusive
+// rates are backed out of the discounted line price first and every rate is
+// then applied to what remains, so a line priced 11.00 with an inclusive 10%
+// rate is 10.00 plus 1.00 tax. discounts itemizes the line discounts.
+func InvoiceTotals(lines []TaxedLine, discounts []models.DiscountLine, serviceChargeRate, gratuityRate money.Rate) models.InvoiceTotals {
+	totals := models.InvoiceTotals{Currency: money.Currency(), Discounts: discounts}
+	taxIndex := map[string]int{}
+	var exclusiveTax money.Amount
+
+	for _, line := range lines {
+		totals.Subtotal += line.Amount
+		totals.Discount += line.Discount
+
+		var inclusiveRate money.Rate
+		for _, taxRate := range line.TaxRates {
+			if taxRate.Inclusive {
+				inclusiveRate += *taxRate.Rate
+			}
+		}
+		net, included := (line.Amount - line.Discount).ExcludeRate(inclusiveRate)
+
+		lastInclusive := -1
+		for i, taxRate := range line.TaxRates {
+			if taxRate.Inclusive {
+				lastInclusive = i
+			}
+		}
+
+		for i, taxRate := range line.TaxRates {
+			tax := net.MulRate(*taxRate.Rate)
+			if taxRate.Inclusive {
+				// The last inclusive rate takes the rounding difference so the
+				// net and the included taxes still add up to the menu price.
+				if i == lastInclusive {
+					tax = included
+				}
+				included -= tax
+			} else {
+				exclusiveTax += tax
+			}
+
+			idx, ok := taxIndex[taxRate.TaxRateId]
+			if !ok {
+				idx = len(totals.Taxes)
+				taxIndex[taxRate.TaxRateId] = idx
+				totals.Taxes = append(totals.Taxes, models.TaxLine{
+					TaxRateId: taxRate.TaxRateId,
+					Name:      *taxRate.Name,
+					Rate:      *taxRate.Rate,
+					Inclusive: taxRate.Inclusive,
+				})
+			}
+			totals.Taxes[idx].Taxable += net
+			totals.Taxes[idx].Amount += tax
+		}
+	}
+
+	if totals.Taxes == nil {
+		totals.Taxes = []models.TaxLine{}
+	}
+	if totals.Discounts == nil {
+		totals.Discounts = []models.DiscountLine{}
+	}
+	discounted := totals.Subtotal - totals.Discount
+	totals.ServiceCharge = discounted.MulRate(serviceChargeRate)
+	totals.Gratuity = discounted.MulRate(gratuityRate)
+	totals.Total = discounted + exclusiveTax + totals.ServiceCharge + totals.Gratuity
+	return totals
+}
+
+// ShareOfTotals is share index (from 1) of totals divided evenly in count.
+// Every amount is split so that the shares add up to totals exactly, the
+// first shares carrying the leftover cents.
+func ShareOfTotals(totals models.InvoiceTotals, index, count int) models.InvoiceTotals {
+	share := models.InvoiceTotals{
+		Currency:      totals.Currency,
+		Subtotal:      totals.Subtotal.Split(count)[index-1],
+		ServiceCharge: totals.ServiceCharge.Split(count)[index-1],
+		Gratuity:      totals.Gratuity.Split(count)[index-1],
+		Taxes:         make([]models.TaxLine, 0, len(totals.Taxes)),
+		Discounts:     make([]models.DiscountLine, 0, len(totals.Discounts)),
+	}
+
+	// The share of the discount is that of its lines so they still add up.
+	for _, discount := range totals.Discounts {
+		discount.Amount = discount.Amount.Split(count)[index-1]
+		share.Discount += discount.Amount
+		share.Discounts = append(share.Discounts, discount)
+	}
+	share.Total = share.Subtotal - share.Discount + share.ServiceCharge + share.Gratuity
+	for _, tax := range totals.Taxes {
+		tax.Taxable = tax.Taxable.Split(count)[index-1]
+		tax.Amount = tax.Amount.Split(count)[index-1]
+		if !tax.Inclusive {
+			share.Total += tax.Amount
+		}
+		share.Taxes = append(share.Taxes, tax)
+	}
+	return share
+}
+
+// SummaryForItems narrows order summaries to the given order items, keeping
+// them whole when orderItemIds is empty.
+func SummaryForItems(summaries []models.OrderSummary, orderItemIds []string) []models.OrderSummary {
+	if len(orderItemIds) == 0 {
+		return summaries
+	}
+
+	narrowed := make([]models.OrderSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		summary.PaymentDue, summary.TotalCount = 0, 0
+		items := summary.OrderItems
+		summary.OrderItems = nil
+		for _, item := range items {
+			if !slices.Contains(orderItemIds, item.OrderItemId) {
+				continue
+			}
+			if item.Amount != nil {
+				summary.PaymentDue += *item.Amount
+			}
+			summary.TotalCount += item.Count
+			summary.OrderItems = append(summary.OrderItems, item)
+		}
+		narrowed = append(narrowed, summary)
+	}
+	return narrowed
+}
+
+// Bill is the share of an order that one invoice of a split covers.
+type Bill struct {
+	OrderItemIds []string
+	Seat         *int
+}
+
+// BillsByItem checks that bills name every item of the order exactly once.
+func BillsByItem(items []models.OrderItemDetail, bills [][]string) ([]Bill, error) {
+	unbilled := map[string]bool{}
+	for _, item := range items {
+		unbilled[item.OrderItemId] = true
+	}
+
+	result := make([]Bill, 0, len(bills))
+	for _, orderItemIds := range bills {
+		for _, orderItemId := range orderItemIds {
+			if !unbilled[orderItemId] {
+				return nil, fmt.Errorf("order item %s is not on the order or is on more than one bill", orderItemId)
+			}
+			delete(unbilled, orderItemId)
+		}
+		result = append(result, Bill{OrderItemIds: orderItemIds})
+	}
+	for _, item := range items {
+		if unbilled[item.OrderItemId] {
+			return nil, fmt.Errorf("order item %s is not on any bill", item.OrderItemId)
+		}
+	}
+	return result, nil
+}
+
+// BillsBySeat makes one bill per seat in seat order. Items without a seat
+// are shared by the table and go on a final bill of their own.
+func BillsBySeat(items []models.OrderItemDetail) []Bill {
+	bySeat := map[int][]string{}
+	var shared []string
+	for _, item := range items {
+		if item.Seat == nil {
+			shared = append(shared, item.OrderItemId)
+			continue
+		}
+		bySeat[*item.Seat] = append(bySeat[*item.Seat], item.OrderItemId)
+	}
+
+	bills := make([]Bill, 0, len(bySeat)+1)
+	for _, seat := range slices.Sorted(maps.Keys(bySeat)) {
+		bills = append(bills, Bill{OrderItemIds: bySeat[seat], Seat: &seat})
+	}
+	if len(shared) > 0 {
+		bills = append(bills, Bill{OrderItemIds: shared})
+	}
+	return bills
+}
@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+)
+
+// InvoiceNumbering is how invoice numbers are made: the prefix, the branch
+// when the series is per branch and the fiscal year when it is per year,
+// then the invoice's place in that series, as in INV-DT-2026-000042.
+type InvoiceNumbering struct {
+	Prefix string
+	Series string
+	Branch string
+	// FiscalYearStart is the month fiscal years begin in. A fiscal year is
+	// named after the calendar year it begins in.
+	FiscalYearStart time.Month
+}
+
+var invoiceNumbering atomic.Pointer[InvoiceNumbering]
+
+func init() {
+	invoiceNumbering.Store(&InvoiceNumbering{
+		Prefix:          "INV",
+		Series:          constants.INVOICE_SERIES_YEAR,
+		FiscalYearStart: time.January,
+	})
+}
+
+// SetInvoiceNumbering replaces the invoice numbering policy.
+func SetInvoiceNumbering(n InvoiceNumbering) {
+	invoiceNumbering.Store(&n)
+}
+
+var invoiceCodePattern = regexp.MustCompile(`^[A-Z0-9]{1,10}$`)
+
+// ConfigureInvoiceNumbering applies the invoice numbering policy from
+// configuration: a prefix such as "INV", a series of YEAR, BRANCH or
+// BRANCH_YEAR, the branch code the per-branch series need, and the month
+// (1 to 12) fiscal years start in. Empty values keep the defaults.
+func ConfigureInvoiceNumbering(prefix, series, branch, fiscalYearStart string) error {
+	n := *invoiceNumbering.Load()
+	if prefix != "" {
+		n.Prefix = strings.ToUpper(prefix)
+	}
+	if !invoiceCodePattern.MatchString(n.Prefix) {
+		return fmt.Errorf("invalid invoice number prefix %q", prefix)
+	}
+
+	if series != "" {
+		n.Series = strings.ToUpper(series)
+	}
+	switch n.Series {
+	case constants.INVOICE_SERIES_YEAR:
+	case constants.INVOICE_SERIES_BRANCH, constants.INVOICE_SERIES_BRANCH_YEAR:
+		n.Branch = strings.ToUpper(branch)
+		if !invoiceCodePattern.MatchString(n.Branch) {
+			return fmt.Errorf("invalid branch code %q for invoice series %s", branch, n.Series)
+		}
+	default:
+		return fmt.Errorf("invalid invoice series %q", series)
+	}
+
+	if fiscalYearStart != "" {
+		month, err := strconv.Atoi(fiscalYearStart)
+		if err != nil || month < 1 || month > 12 {
+			return fmt.Errorf("invalid fiscal year start month %q", fiscalYearStart)
+		}
+		n.FiscalYearStart = time.Month(month)
+	}
+
+	SetInvoiceNumbering(n)
+	return nil
+}
+
+// FiscalYear is the fiscal year at falls in, in the restaurant's local time.
+func FiscalYear(at time.Time) int {
+	local := at.In(time.Local)
+	if local.Month() < invoiceNumbering.Load().FiscalYearStart {
+		return local.Year() - 1
+	}
+	return local.Year()
+}
+
+// InvoiceSeries is the series an invoice issued at is numbered in. It is
+// also the start of the invoice number.
+func InvoiceSeries(at time.Time) string {
+	n := invoiceNumbering.Load()
+	parts := []string{n.Prefix}
+	if n.Series == constants.INVOICE_SERIES_BRANCH || n.Series == constants.INVOICE_SERIES_BRANCH_YEAR {
+		parts = append(parts, n.Branch)
+	}
+	if n.Series == constants.INVOICE_SERIES_YEAR || n.Series == constants.INVOICE_SERIES_BRANCH_YEAR {
+		parts = append(parts, strconv.Itoa(FiscalYear(at)))
+	}
+	return strings.Join(parts, "-")
+}
+
+// InvoiceNumber is invoice seq of series.
+func InvoiceNumber(series string, seq int64) string {
+	return fmt.Sprintf("%s-%06d", series, seq)
+}
@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"slices"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+// orderTransitions is the lifecycle every order follows. An order can be
+// cancelled until the kitchen has finished it; PAID and CANCELLED are final.
+var orderTransitions = map[string][]string{
+	constants.ORDER_STATUS_OPEN:            {constants.ORDER_STATUS_SENT_TO_KITCHEN, constants.ORDER_STATUS_CANCELLED},
+	constants.ORDER_STATUS_SENT_TO_KITCHEN: {constants.ORDER_STATUS_PREPARING, constants.ORDER_STATUS_CANCELLED},
+	constants.ORDER_STATUS_PREPARING:       {constants.ORDER_STATUS_READY, constants.ORDER_STATUS_CANCELLED},
+	constants.ORDER_STATUS_READY:           {constants.ORDER_STATUS_SERVED},
+	constants.ORDER_STATUS_SERVED:          {constants.ORDER_STATUS_PAID},
+}
+
+// orderStatusesByRole is what each role may move an order to through the
+// order status endpoint. Only those who may cancel orders can do it there
+// too, and only those who take payments can close them. Admins may set any
+// status.
+var orderStatusesByRole = map[string][]string{
+	constants.ROLE_MANAGER: {
+		constants.ORDER_STATUS_SENT_TO_KITCHEN, constants.ORDER_STATUS_PREPARING, constants.ORDER_STATUS_READY,
+		constants.ORDER_STATUS_SERVED, constants.ORDER_STATUS_PAID, constants.ORDER_STATUS_CANCELLED,
+	},
+	constants.ROLE_WAITER: {
+		constants.ORDER_STATUS_SENT_TO_KITCHEN, constants.ORDER_STATUS_PREPARING, constants.ORDER_STATUS_READY,
+		constants.ORDER_STATUS_SERVED, constants.ORDER_STATUS_CANCELLED,
+	},
+	constants.ROLE_KITCHEN: {constants.ORDER_STATUS_PREPARING, constants.ORDER_STATUS_READY, constants.ORDER_STATUS_SERVED},
+	constants.ROLE_CASHIER: {constants.ORDER_STATUS_PAID},
+}
+
+// CanSetOrderStatus reports whether role may move an order to status.
+func CanSetOrderStatus(role, status string) bool {
+	return role == constants.ROLE_ADMIN || slices.Contains(orderStatusesByRole[role], status)
+}
+
+// OrderStatus returns the status of order, treating orders stored before
+// statuses existed as OPEN.
+func OrderStatus(order models.Order) string {
+	if order.Status == "" {
+		return constants.ORDER_STATUS_OPEN
+	}
+	return order.Status
+}
+
+func CanTransitionOrder(from, to string) bool {
+	return slices.Contains(orderTransitions[from], to)
+}
+
+// IsOrderClosed reports whether the order can no longer be changed.
+func IsOrderClosed(order models.Order) bool {
+	status := OrderStatus(order)
+	return status == constants.ORDER_STATUS_PAID || status == constants.ORDER_STATUS_CANCELLED
+}
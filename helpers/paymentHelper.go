@@ -0,0 +1,80 @@
+package helpers
+
+import (
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// LedgerAmounts adds up a ledger into what was paid, net of the change
+// given back, and what was refunded since. Voids move no money.
+func LedgerAmounts(payments []models.Payment) (paid, refunded money.Amount) {
+	for _, payment := range payments {
+		if payment.Amount == nil {
+			continue
+		}
+		switch payment.Type {
+		case constants.LEDGER_PAYMENT, "":
+			paid += *payment.Amount
+			if payment.Change != nil {
+				paid -= *payment.Change
+			}
+		case constants.LEDGER_REFUND:
+			refunded -= *payment.Amount
+		}
+	}
+	return paid, refunded
+}
+
+// LedgerTips adds up the tips left on a ledger's payments.
+func LedgerTips(payments []models.Payment) money.Amount {
+	var tips money.Amount
+	for _, payment := range payments {
+		if payment.Tip != nil {
+			tips += *payment.Tip
+		}
+	}
+	return tips
+}
+
+// PaymentStatus is the status of an invoice of total that has been paid
+// paid so far, of which refunded was given back.
+func PaymentStatus(total, paid, refunded money.Amount) string {
+	switch {
+	case refunded > 0 && refunded >= paid:
+		return constants.INVOICE_STATUS_REFUNDED
+	case refunded > 0:
+		return constants.INVOICE_STATUS_PARTIALLY_REFUNDED
+	case paid > total:
+		return constants.INVOICE_STATUS_OVERPAID
+	case paid == total && paid > 0:
+		return constants.INVOICE_STATUS_PAID
+	case paid > 0:
+		return constants.INVOICE_STATUS_PARTIALLY_PAID
+	default:
+		return constants.INVOICE_STATUS_PENDING
+	}
+}
+
+// IsInvoiceSettled reports whether an invoice in status was paid in full and
+// so needs no more payments. Refunded invoices stay settled.
+func IsInvoiceSettled(status string) bool {
+	switch status {
+	case constants.INVOICE_STATUS_PAID, constants.INVOICE_STATUS_OVERPAID,
+		constants.INVOICE_STATUS_PARTIALLY_REFUNDED, constants.INVOICE_STATUS_REFUNDED:
+		return true
+	}
+	return false
+}
+
+// CashChange is the change due on a cash tender of amount against balance,
+// the part of the invoice still unpaid.
+func CashChange(amount, balance money.Amount) money.Amount {
+	if balance < 0 {
+		balance = 0
+	}
+	if amount <= balance {
+		return 0
+	}
+	return amount - balance
+}
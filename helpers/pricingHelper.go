@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// sizeMultipliers scale the catalog price of a food that has no explicit
+// price for the requested size.
+var sizeMultipliers = map[string]money.Rate{
+	constants.SIZE_SMALL:  750_000,
+	constants.SIZE_MEDIUM: 1_000_000,
+	constants.SIZE_LARGE:  1_500_000,
+}
+
+// ItemPrice resolves what one portion of food costs in size, preferring a
+// per-size price from the catalog over the multiplied base price.
+func ItemPrice(food models.Food, size string) (money.Amount, error) {
+	if price, ok := food.SizePrices[size]; ok {
+		return price, nil
+	}
+	multiplier, ok := sizeMultipliers[size]
+	if !ok {
+		return 0, fmt.Errorf("unknown size: %s", size)
+	}
+	if food.Price == nil {
+		return 0, fmt.Errorf("food %s has no price", food.FoodId)
+	}
+	return food.Price.MulRate(multiplier), nil
+}
+
+// ItemCount is the number of portions on an order item; items stored before
+// counts existed are a single portion.
+func ItemCount(orderItem models.OrderItem) int {
+	if orderItem.Count == nil {
+		return 1
+	}
+	return *orderItem.Count
+}
+
+func LineTotal(unitPrice money.Amount, count int) money.Amount {
+	return unitPrice.Mul(count)
+}
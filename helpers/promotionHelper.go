@@ -0,0 +1,267 @@
+package helpers
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// PromotionLine is one line of a bill as promotions see it. OrderedAt is
+// when the item was ordered, which decides item-level happy hours.
+type PromotionLine struct {
+	FoodId    string
+	MenuId    string
+	Count     int
+	Amount    money.Amount
+	OrderedAt time.Time
+}
+
+// Discounts is what promotions took off a bill: Lines[i] off line i, and
+// the same amounts itemized by promotion.
+type Discounts struct {
+	Lines      []money.Amount
+	Promotions []models.DiscountLine
+}
+
+// Total is the whole discount.
+func (d Discounts) Total() money.Amount {
+	var total money.Amount
+	for _, line := range d.Promotions {
+		total += line.Amount
+	}
+	return total
+}
+
+// add records off, the discount of promotion per line.
+func (d *Discounts) add(promotion models.Promotion, off []money.Amount) {
+	var amount money.Amount
+	for i, a := range off {
+		d.Lines[i] += a
+		amount += a
+	}
+	if amount == 0 {
+		return
+	}
+	d.Promotions = append(d.Promotions, models.DiscountLine{
+		PromotionId: promotion.PromotionId,
+		Name:        *promotion.Name,
+		CouponCode:  promotion.CouponCode,
+		Amount:      amount,
+	})
+}
+
+// PromotionLive reports whether promotion is switched on and within its
+// dates at.
+func PromotionLive(promotion models.Promotion, at time.Time) bool {
+	if promotion.Active != nil && !*promotion.Active {
+		return false
+	}
+	if promotion.StartsAt != nil && at.Before(*promotion.StartsAt) {
+		return false
+	}
+	if promotion.EndsAt != nil && !at.Before(*promotion.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// InHappyHour reports whether at falls in the daily window of happyHour, or
+// true when there is none. The hour after midnight of a window that runs
+// past it belongs to the day the window started.
+func InHappyHour(happyHour *models.HappyHour, at time.Time) bool {
+	if happyHour == nil {
+		return true
+	}
+	local := at.In(time.Local)
+	now := local.Hour()*60 + local.Minute()
+	start, end := clockMinutes(happyHour.Start), clockMinutes(happyHour.End)
+	day := int(local.Weekday())
+
+	switch {
+	case start <= end:
+		if now < start || now >= end {
+			return false
+		}
+	case now >= start:
+	case now < end:
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	return len(happyHour.Days) == 0 || slices.Contains(happyHour.Days, day)
+}
+
+func clockMinutes(clock string) int {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// ApplyPromotions works out the discounts of a bill made of lines at time at.
+// Promotions that are not live, or that need a coupon not in couponCodes,
+// are skipped. Stackable promotions apply one after the other to what is
+// left of each line, item-level ones before bill-level ones; a promotion
+// that does not stack is used alone when it saves more than all stackable
+// ones together. No line is ever discounted below zero.
+func ApplyPromotions(lines []PromotionLine, promotions []models.Promotion, couponCodes []string, at time.Time) Discounts {
+	var stackable, exclusive []models.Promotion
+	for _, promotion := range promotions {
+		if !PromotionLive(promotion, at) {
+			continue
+		}
+		if promotion.CouponCode != "" && !slices.Contains(couponCodes, promotion.CouponCode) {
+			continue
+		}
+		if promotion.Stackable {
+			stackable = append(stackable, promotion)
+		} else {
+			exclusive = append(exclusive, promotion)
+		}
+	}
+	byLevel := func(a, b models.Promotion) int {
+		return cmp.Or(
+			cmp.Compare(promotionOrder(a), promotionOrder(b)),
+			a.CreatedAt.Compare(b.CreatedAt),
+			cmp.Compare(a.PromotionId, b.PromotionId),
+		)
+	}
+	slices.SortFunc(stackable, byLevel)
+	slices.SortFunc(exclusive, byLevel)
+
+	amounts := make([]money.Amount, len(lines))
+	for i, line := range lines {
+		amounts[i] = line.Amount
+	}
+
+	stacked := Discounts{Lines: make([]money.Amount, len(lines))}
+	remaining := slices.Clone(amounts)
+	for _, promotion := range stackable {
+		off := applyPromotion(promotion, lines, remaining, at)
+		for i := range remaining {
+			remaining[i] -= off[i]
+		}
+		stacked.add(promotion, off)
+	}
+
+	best := Discounts{Lines: make([]money.Amount, len(lines))}
+	for _, promotion := range exclusive {
+		alone := Discounts{Lines: make([]money.Amount, len(lines))}
+		alone.add(promotion, applyPromotion(promotion, lines, amounts, at))
+		if alone.Total() > best.Total() {
+			best = alone
+		}
+	}
+
+	if best.Total() > stacked.Total() {
+		return best
+	}
+	return stacked
+}
+
+// promotionOrder puts item-level promotions before bill-level ones.
+func promotionOrder(promotion models.Promotion) int {
+	if promotion.Level == constants.PROMOTION_LEVEL_BILL {
+		return 1
+	}
+	return 0
+}
+
+// applyPromotion is the discount of promotion on each line when remaining
+// is what is left to pay of it.
+func applyPromotion(promotion models.Promotion, lines []PromotionLine, remaining []money.Amount, at time.Time) []money.Amount {
+	off := make([]money.Amount, len(lines))
+	billLevel := promotion.Kind != constants.PROMOTION_BUY_X_GET_Y && promotion.Level == constants.PROMOTION_LEVEL_BILL
+	if billLevel && !InHappyHour(promotion.HappyHour, at) {
+		return off
+	}
+
+	var matching []int
+	for i, line := range lines {
+		if remaining[i] <= 0 || !promotionMatches(promotion, line) {
+			continue
+		}
+		if !billLevel && !InHappyHour(promotion.HappyHour, line.OrderedAt) {
+			continue
+		}
+		matching = append(matching, i)
+	}
+	if len(matching) == 0 {
+		return off
+	}
+
+	switch {
+	case promotion.Kind == constants.PROMOTION_BUY_X_GET_Y:
+		// Every BuyCount+GetCount units, dearest first, the cheapest GetCount
+		// are free.
+		type unit struct {
+			line  int
+			price money.Amount
+		}
+		var units []unit
+		for _, i := range matching {
+			for _, price := range remaining[i].Split(max(lines[i].Count, 1)) {
+				units = append(units, unit{line: i, price: price})
+			}
+		}
+		slices.SortStableFunc(units, func(a, b unit) int { return cmp.Compare(b.price, a.price) })
+		group := promotion.BuyCount + promotion.GetCount
+		for start := 0; start+group <= len(units); start += group {
+			for _, free := range units[start+promotion.BuyCount : start+group] {
+				off[free.line] += free.price
+			}
+		}
+	case billLevel:
+		var base money.Amount
+		weights := make([]int64, len(matching))
+		for k, i := range matching {
+			base += remaining[i]
+			weights[k] = int64(remaining[i])
+		}
+		discount := base
+		if promotion.Kind == constants.PROMOTION_PERCENT {
+			discount = base.MulRate(*promotion.Rate)
+		} else if *promotion.Amount < base {
+			discount = *promotion.Amount
+		}
+		for k, share := range discount.Allocate(weights) {
+			off[matching[k]] = share
+		}
+	default:
+		for _, i := range matching {
+			if promotion.Kind == constants.PROMOTION_PERCENT {
+				off[i] = remaining[i].MulRate(*promotion.Rate)
+			} else {
+				off[i] = min(promotion.Amount.Mul(max(lines[i].Count, 1)), remaining[i])
+			}
+		}
+	}
+	return off
+}
+
+// promotionMatches reports whether promotion applies to the food of line.
+func promotionMatches(promotion models.Promotion, line PromotionLine) bool {
+	if len(promotion.FoodIds) == 0 && len(promotion.MenuIds) == 0 {
+		return true
+	}
+	return slices.Contains(promotion.FoodIds, line.FoodId) ||
+		(line.MenuId != "" && slices.Contains(promotion.MenuIds, line.MenuId))
+}
+
+// NormalizeCoupons upper-cases coupon codes and drops repeats.
+func NormalizeCoupons(couponCodes []string) []string {
+	var normalized []string
+	for _, couponCode := range couponCodes {
+		couponCode = strings.ToUpper(strings.TrimSpace(couponCode))
+		if !slices.Contains(normalized, couponCode) {
+			normalized = append(normalized, couponCode)
+		}
+	}
+	return normalized
+}
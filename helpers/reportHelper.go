@@ -0,0 +1,244 @@
+package helpers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// uncategorized is the category of foods whose menu is gone.
+const uncategorized = "Uncategorized"
+
+// PeriodStart is the start of the day, ISO week (from Monday) or month that
+// t falls in, in the restaurant's local time.
+func PeriodStart(t time.Time, period string) time.Time {
+	t = t.In(time.Local)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	switch period {
+	case constants.REPORT_PERIOD_WEEK:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case constants.REPORT_PERIOD_MONTH:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+func nextPeriod(start time.Time, period string) time.Time {
+	switch period {
+	case constants.REPORT_PERIOD_WEEK:
+		return start.AddDate(0, 0, 7)
+	case constants.REPORT_PERIOD_MONTH:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func periodLabel(start time.Time, period string) string {
+	switch period {
+	case constants.REPORT_PERIOD_WEEK:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case constants.REPORT_PERIOD_MONTH:
+		return start.Format("2006-01")
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// RevenueSummary adds up the settled invoices among invoices; pending,
+// partly paid and voided ones are left out.
+func RevenueSummary(invoices []models.Invoice) models.RevenueSummary {
+	var summary models.RevenueSummary
+	orders := map[string]bool{}
+	for _, invoice := range invoices {
+		if invoice.PaymentStatus == nil || !IsInvoiceSettled(*invoice.PaymentStatus) || invoice.Totals == nil {
+			continue
+		}
+		totals := invoice.Totals
+		summary.Invoices++
+		orders[invoice.OrderId] = true
+		summary.Sales += totals.Subtotal - totals.Discount
+		summary.Discount += totals.Discount
+		for _, tax := range totals.Taxes {
+			summary.Tax += tax.Amount
+		}
+		summary.ServiceCharge += totals.ServiceCharge
+		summary.Gratuity += totals.Gratuity
+		summary.Total += totals.Total
+		summary.Refunded += invoice.AmountRefunded
+	}
+	summary.Tickets = len(orders)
+	summary.Net = summary.Total - summary.Refunded
+	if summary.Tickets > 0 {
+		summary.AverageTicket = summary.Net.Split(summary.Tickets)[summary.Tickets-1]
+	}
+	return summary
+}
+
+// RevenueByPeriod is the revenue summary of every period from the one from
+// falls in up to to, empty periods included.
+func RevenueByPeriod(invoices []models.Invoice, period string, from, to time.Time) []models.RevenuePeriod {
+	byPeriod := map[time.Time][]models.Invoice{}
+	for _, invoice := range invoices {
+		start := PeriodStart(invoice.CreatedAt, period)
+		byPeriod[start] = append(byPeriod[start], invoice)
+	}
+
+	var periods []models.RevenuePeriod
+	for start := PeriodStart(from, period); start.Before(to); start = nextPeriod(start, period) {
+		periods = append(periods, models.RevenuePeriod{
+			Period:         periodLabel(start, period),
+			Start:          start,
+			RevenueSummary: RevenueSummary(byPeriod[start]),
+		})
+	}
+	return periods
+}
+
+// RankFoods ranks foods by what they sold, by quantity or by revenue, best
+// first for TOP and worst first for BOTTOM, and keeps the first limit.
+// Foods that sold nothing rank too; categories maps menu ids to their
+// category.
+func RankFoods(foods []models.Food, categories map[string]string, sold []models.SoldItem, rank, by string, limit int) []models.FoodSales {
+	byFood := map[string]*models.FoodSales{}
+	sales := func(foodId string, name *string, menuId *string) *models.FoodSales {
+		if s, ok := byFood[foodId]; ok {
+			return s
+		}
+		s := &models.FoodSales{FoodId: foodId, Category: uncategorized}
+		if name != nil {
+			s.Name = *name
+		}
+		if menuId != nil {
+			if category, ok := categories[*menuId]; ok {
+				s.Category = category
+			}
+		}
+		byFood[foodId] = s
+		return s
+	}
+	for _, food := range foods {
+		sales(food.FoodId, food.Name, food.MenuId)
+	}
+	for _, item := range sold {
+		s := sales(item.FoodId, item.FoodName, item.MenuId)
+		s.Quantity += item.Count
+		s.Revenue += item.Amount
+	}
+
+	ranked := make([]models.FoodSales, 0, len(byFood))
+	for _, s := range byFood {
+		ranked = append(ranked, *s)
+	}
+	slices.SortFunc(ranked, func(a, b models.FoodSales) int {
+		c := cmp.Compare(b.Revenue, a.Revenue)
+		if by == constants.REPORT_BY_QUANTITY {
+			c = cmp.Compare(b.Quantity, a.Quantity)
+		}
+		if rank == constants.REPORT_RANK_BOTTOM {
+			c = -c
+		}
+		return cmp.Or(c, cmp.Compare(a.Name, b.Name), cmp.Compare(a.FoodId, b.FoodId))
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// SalesByCategory adds up sold by menu category, biggest revenue first.
+// Every category in categories is listed, even without sales.
+func SalesByCategory(categories map[string]string, sold []models.SoldItem) []models.CategorySales {
+	byCategory := map[string]*models.CategorySales{}
+	for _, category := range categories {
+		byCategory[category] = &models.CategorySales{Category: category}
+	}
+	var total money.Amount
+	for _, item := range sold {
+		category := uncategorized
+		if item.Category != nil {
+			category = *item.Category
+		}
+		s, ok := byCategory[category]
+		if !ok {
+			s = &models.CategorySales{Category: category}
+			byCategory[category] = s
+		}
+		s.Quantity += item.Count
+		s.Revenue += item.Amount
+		total += item.Amount
+	}
+
+	result := make([]models.CategorySales, 0, len(byCategory))
+	for _, s := range byCategory {
+		if total > 0 {
+			s.Share = money.Rate(int64(s.Revenue) * 1_000_000 / int64(total))
+		}
+		result = append(result, *s)
+	}
+	slices.SortFunc(result, func(a, b models.CategorySales) int {
+		return cmp.Or(cmp.Compare(b.Revenue, a.Revenue), cmp.Compare(a.Category, b.Category))
+	})
+	return result
+}
+
+// SalesHeatmap spreads the settled invoices among invoices over the weekday
+// and hour they were issued at, in the restaurant's local time. Tickets
+// counts orders.
+func SalesHeatmap(invoices []models.Invoice) []models.HeatmapRow {
+	rows := make([]models.HeatmapRow, 7)
+	for day := range rows {
+		rows[day] = models.HeatmapRow{Weekday: day, Name: time.Weekday(day).String()}
+	}
+
+	seen := map[string]bool{}
+	for _, invoice := range invoices {
+		if invoice.PaymentStatus == nil || !IsInvoiceSettled(*invoice.PaymentStatus) || invoice.Totals == nil {
+			continue
+		}
+		local := invoice.CreatedAt.In(time.Local)
+		cell := &rows[local.Weekday()].Hours[local.Hour()]
+		cell.Revenue += invoice.Totals.Total - invoice.AmountRefunded
+		if key := fmt.Sprintf("%s/%d/%d", invoice.OrderId, local.Weekday(), local.Hour()); !seen[key] {
+			seen[key] = true
+			cell.Tickets++
+		}
+	}
+	return rows
+}
+
+// SalesByService adds up the settled invoices among invoices and the tips
+// of payments by the server or section credited maps their order to, best
+// net sales first. Orders missing from credited go under an empty id.
+func SalesByService(invoices []models.Invoice, payments []models.Payment, credited map[string]string) []models.ServiceSales {
+	invoicesOf := map[string][]models.Invoice{}
+	for _, invoice := range invoices {
+		id := credited[invoice.OrderId]
+		invoicesOf[id] = append(invoicesOf[id], invoice)
+	}
+	tipsOf := map[string]money.Amount{}
+	for _, tips := range TipsByService(payments, credited) {
+		tipsOf[tips.Id] = tips.Tips
+	}
+
+	result := make([]models.ServiceSales, 0, len(invoicesOf))
+	for id, invoices := range invoicesOf {
+		result = append(result, models.ServiceSales{Id: id, RevenueSummary: RevenueSummary(invoices), Tips: tipsOf[id]})
+	}
+	for id, tips := range tipsOf {
+		if _, ok := invoicesOf[id]; !ok {
+			result = append(result, models.ServiceSales{Id: id, Tips: tips})
+		}
+	}
+	slices.SortFunc(result, func(a, b models.ServiceSales) int {
+		return cmp.Or(cmp.Compare(b.Net, a.Net), cmp.Compare(a.Id, b.Id))
+	})
+	return result
+}
@@ -0,0 +1,175 @@
+package helpers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+)
+
+// TurnTimeBand is how long parties of up to MaxPartySize guests hold a
+// table.
+type TurnTimeBand struct {
+	MaxPartySize int
+	Duration     time.Duration
+}
+
+// TurnTimes is how long reservations hold their table. Bands are ordered by
+// party size; parties larger than the last band get its turn time. Buffer
+// is kept free between one party leaving and the next one arriving, to
+// reset the table.
+type TurnTimes struct {
+	Bands  []TurnTimeBand
+	Buffer time.Duration
+}
+
+var turnTimes atomic.Pointer[TurnTimes]
+
+func init() {
+	turnTimes.Store(&TurnTimes{
+		Bands: []TurnTimeBand{
+			{MaxPartySize: 2, Duration: 75 * time.Minute},
+			{MaxPartySize: 4, Duration: 90 * time.Minute},
+			{MaxPartySize: 6, Duration: 120 * time.Minute},
+		},
+		Buffer: 15 * time.Minute,
+	})
+}
+
+// SetTurnTimes replaces the reservation turn times.
+func SetTurnTimes(t TurnTimes) {
+	turnTimes.Store(&t)
+}
+
+// ConfigureTurnTimes applies the reservation turn times from
+// configuration: bands such as "2:75,4:90,6:120", party sizes with their
+// minutes, and the buffer between parties in minutes. Empty values keep the
+// defaults.
+func ConfigureTurnTimes(bands, bufferMinutes string) error {
+	t := *turnTimes.Load()
+	if bands != "" {
+		t.Bands = nil
+		for _, band := range strings.Split(bands, ",") {
+			size, minutes, ok := strings.Cut(strings.TrimSpace(band), ":")
+			n, err := strconv.Atoi(size)
+			m, merr := strconv.Atoi(minutes)
+			if !ok || err != nil || merr != nil || n < 1 || m < 1 {
+				return fmt.Errorf("invalid turn time %q", band)
+			}
+			if len(t.Bands) > 0 && n <= t.Bands[len(t.Bands)-1].MaxPartySize {
+				return fmt.Errorf("turn times must be in increasing party size: %q", bands)
+			}
+			t.Bands = append(t.Bands, TurnTimeBand{MaxPartySize: n, Duration: time.Duration(m) * time.Minute})
+		}
+	}
+	if bufferMinutes != "" {
+		m, err := strconv.Atoi(bufferMinutes)
+		if err != nil || m < 0 {
+			return fmt.Errorf("invalid reservation buffer %q", bufferMinutes)
+		}
+		t.Buffer = time.Duration(m) * time.Minute
+	}
+	SetTurnTimes(t)
+	return nil
+}
+
+// TurnTime is how long a party of partySize holds its table.
+func TurnTime(partySize int) time.Duration {
+	bands := turnTimes.Load().Bands
+	for _, band := range bands {
+		if partySize <= band.MaxPartySize {
+			return band.Duration
+		}
+	}
+	return bands[len(bands)-1].Duration
+}
+
+// ReservationBuffer is the time kept free between two parties at a table.
+func ReservationBuffer() time.Duration {
+	return turnTimes.Load().Buffer
+}
+
+// ReservationConflicts reports whether reservation holds its table at some
+// time between from and to, the buffer between parties included.
+func ReservationConflicts(reservation models.Reservation, from, to time.Time) bool {
+	buffer := ReservationBuffer()
+	return reservation.StartsAt.Before(to.Add(buffer)) && reservation.EndsAt.Add(buffer).After(from)
+}
+
+// FreeTables are the tables that seat partySize and that none of
+// reservations holds between from and to, smallest first so that large
+// tables are kept for large parties. The reservation exceptId, the one
+// being moved, is not counted. Seats are counted as BookableSeats does.
+func FreeTables(tables []models.Table, reservations []models.Reservation, partySize int, from, to, now time.Time, exceptId string) []models.Table {
+	taken := map[string]bool{}
+	for _, reservation := range reservations {
+		if reservation.ReservationId != exceptId && ReservationConflicts(reservation, from, to) {
+			taken[reservation.TableId] = true
+		}
+	}
+
+	seats := map[string]int{}
+	free := make([]models.Table, 0)
+	for _, table := range tables {
+		seats[table.TableId] = BookableSeats(table, tables, from, now)
+		if !taken[table.TableId] && seats[table.TableId] >= partySize {
+			free = append(free, table)
+		}
+	}
+	slices.SortStableFunc(free, func(a, b models.Table) int {
+		return cmp.Or(cmp.Compare(seats[a.TableId], seats[b.TableId]), cmp.Compare(tableNumber(a), tableNumber(b)))
+	})
+	return free
+}
+
+// BookableSeats is how many guests table seats for a party arriving at
+// from. Tables are combined for the party at them now: until it is expected
+// to leave, the table combined into seats the guests of them all and the
+// others none, and afterwards each table seats its own. tables holds the
+// table combined into.
+func BookableSeats(table models.Table, tables []models.Table, from, now time.Time) int {
+	if table.Combination != nil && from.Before(combinationEnds(table, now)) {
+		return TableCapacity(table)
+	}
+	if table.CombinedInto != "" {
+		i := slices.IndexFunc(tables, func(t models.Table) bool { return t.TableId == table.CombinedInto })
+		if i >= 0 && tables[i].Combination != nil && from.Before(combinationEnds(tables[i], now)) {
+			return 0
+		}
+	}
+	return TableSeats(table)
+}
+
+// combinationEnds is when the tables combined into head are expected to be
+// free again: once the party at head has had its turn and the tables are
+// reset, counting from now when the party is not seated yet.
+func combinationEnds(head models.Table, now time.Time) time.Time {
+	seatedAt, partySize := now, head.Combination.Seats
+	if head.Occupancy != nil {
+		seatedAt = head.Occupancy.SeatedAt
+		if head.Occupancy.PartySize > 0 {
+			partySize = head.Occupancy.PartySize
+		}
+	}
+	return later(seatedAt.Add(TurnTime(partySize)), now).Add(ReservationBuffer())
+}
+
+// TableSeats is how many guests table seats.
+func TableSeats(table models.Table) int {
+	if table.NumberOfGuests == nil {
+		return 0
+	}
+	return *table.NumberOfGuests
+}
+
+func tableNumber(table models.Table) int {
+	if table.TableNumber == nil {
+		return 0
+	}
+	return *table.TableNumber
+}
@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+// TableStatus returns the status of table, treating tables stored before
+// statuses existed as FREE.
+func TableStatus(table models.Table) string {
+	if table.Status == "" {
+		return constants.TABLE_STATUS_FREE
+	}
+	return table.Status
+}
+
+// TableStatusSince returns when table got into its status; tables stored
+// before statuses existed have been free since they were created.
+func TableStatusSince(table models.Table) time.Time {
+	if table.StatusSince.IsZero() {
+		return table.CreatedAt
+	}
+	return table.StatusSince
+}
+
+// TableCapacity is how many guests can be seated at table now: its own
+// seats, or those of all the tables combined with it.
+func TableCapacity(table models.Table) int {
+	if table.Combination != nil {
+		return table.Combination.Seats
+	}
+	return TableSeats(table)
+}
+
+// OccupiedTableStatus derives the status of an occupied table from the
+// orders its party placed. billed tells which orders have a bill that is
+// not voided. Cancelled orders do not count, so a party whose orders were
+// all cancelled is back to SEATED.
+func OccupiedTableStatus(orders []models.Order, billed map[string]bool) string {
+	open, unbilled := 0, 0
+	for _, order := range orders {
+		switch OrderStatus(order) {
+		case constants.ORDER_STATUS_CANCELLED, constants.ORDER_STATUS_PAID:
+			continue
+		}
+		open++
+		if !billed[order.OrderID] {
+			unbilled++
+		}
+	}
+
+	active := slices.ContainsFunc(orders, func(o models.Order) bool {
+		return OrderStatus(o) != constants.ORDER_STATUS_CANCELLED
+	})
+	switch {
+	case !active:
+		return constants.TABLE_STATUS_SEATED
+	case open == 0:
+		return constants.TABLE_STATUS_CLEANING
+	case unbilled == 0:
+		return constants.TABLE_STATUS_BILLED
+	default:
+		return constants.TABLE_STATUS_ORDERED
+	}
+}
+
+// FloorView lays out tables by number with how long each has been in its
+// status at now and the next booked reservation held at it.
+func FloorView(tables []models.Table, reservations []models.Reservation, now time.Time) []models.FloorTable {
+	next := map[string]models.Reservation{}
+	for _, reservation := range reservations {
+		if reservation.Status != constants.RESERVATION_STATUS_BOOKED || !reservation.EndsAt.After(now) {
+			continue
+		}
+		if current, ok := next[reservation.TableId]; !ok || reservation.StartsAt.Before(current.StartsAt) {
+			next[reservation.TableId] = reservation
+		}
+	}
+
+	floor := make([]models.FloorTable, 0, len(tables))
+	for _, table := range tables {
+		table.Status = TableStatus(table)
+		table.StatusSince = TableStatusSince(table)
+		entry := models.FloorTable{
+			Table:          table,
+			SecondsInState: int64(max(now.Sub(table.StatusSince), 0) / time.Second),
+		}
+		if reservation, ok := next[table.TableId]; ok {
+			entry.NextReservation = &reservation
+		}
+		floor = append(floor, entry)
+	}
+	slices.SortStableFunc(floor, func(a, b models.FloorTable) int {
+		return cmp.Compare(tableNumber(a.Table), tableNumber(b.Table))
+	})
+	return floor
+}
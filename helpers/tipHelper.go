@@ -0,0 +1,113 @@
+package helpers
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// AutoGratuity adds Rate of the subtotal to the bills of parties of at least
+// MinGuests. A zero MinGuests turns it off.
+type AutoGratuity struct {
+	MinGuests int
+	Rate      money.Rate
+}
+
+var autoGratuity atomic.Pointer[AutoGratuity]
+
+func init() {
+	autoGratuity.Store(&AutoGratuity{})
+}
+
+// SetAutoGratuity replaces the automatic gratuity policy.
+func SetAutoGratuity(g AutoGratuity) {
+	autoGratuity.Store(&g)
+}
+
+// ConfigureAutoGratuity applies the automatic gratuity policy from
+// configuration, such as "8" guests and an "18" percent rate. Empty values
+// leave it off.
+func ConfigureAutoGratuity(minGuests, rate string) error {
+	if minGuests == "" && rate == "" {
+		SetAutoGratuity(AutoGratuity{})
+		return nil
+	}
+	n, err := strconv.Atoi(minGuests)
+	if err != nil || n < 1 {
+		return fmt.Errorf("invalid auto gratuity party size %q", minGuests)
+	}
+	r, err := money.ParseRate(rate)
+	if err != nil || r < 0 {
+		return fmt.Errorf("invalid auto gratuity rate %q", rate)
+	}
+	SetAutoGratuity(AutoGratuity{MinGuests: n, Rate: r})
+	return nil
+}
+
+// AutoGratuityRate is the gratuity rate charged to a party of guests, and
+// false when the party is too small or the policy is off.
+func AutoGratuityRate(guests int) (money.Rate, bool) {
+	g := autoGratuity.Load()
+	if g.MinGuests == 0 || guests < g.MinGuests {
+		return 0, false
+	}
+	return g.Rate, true
+}
+
+// DefaultTipPoints are the pool points of each role when a report does not
+// give its own. Managers and admins do not share in the pool by default.
+var DefaultTipPoints = map[string]int{
+	constants.ROLE_ADMIN:   0,
+	constants.ROLE_MANAGER: 0,
+	constants.ROLE_WAITER:  10,
+	constants.ROLE_CASHIER: 5,
+	constants.ROLE_KITCHEN: 5,
+}
+
+// TipWeight is how much of the pool a member who worked hours with points
+// earns under rule, relative to the others.
+func TipWeight(rule string, hours float64, points int) int64 {
+	centiHours := int64(math.Round(hours * 100))
+	switch rule {
+	case constants.TIP_RULE_HOURS:
+		return centiHours
+	case constants.TIP_RULE_POINTS:
+		return int64(points)
+	default:
+		return centiHours * int64(points)
+	}
+}
+
+// DistributeTips divides total in proportion to weights so that the shares
+// add up to total exactly.
+func DistributeTips(total money.Amount, weights []int64) []money.Amount {
+	return total.Allocate(weights)
+}
+
+// TipsByService adds up the tips of payments by the server or section
+// credited maps their order to, biggest first. Orders missing from credited
+// go under an empty id.
+func TipsByService(payments []models.Payment, credited map[string]string) []models.ServiceTips {
+	tips := map[string]money.Amount{}
+	for _, payment := range payments {
+		if payment.Tip != nil && *payment.Tip != 0 {
+			tips[credited[payment.OrderId]] += *payment.Tip
+		}
+	}
+
+	result := make([]models.ServiceTips, 0, len(tips))
+	for id, amount := range tips {
+		result = append(result, models.ServiceTips{Id: id, Tips: amount})
+	}
+	slices.SortFunc(result, func(a, b models.ServiceTips) int {
+		return cmp.Or(cmp.Compare(b.Tips, a.Tips), cmp.Compare(a.Id, b.Id))
+	})
+	return result
+}
@@ -6,41 +6,80 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+
+	AccessTokenTTL  = 24 * time.Hour
+	RefreshTokenTTL = 15 * 24 * time.Hour
+)
+
+// SignedDetails are the claims of both token kinds. Every token pair issued
+// by GetTokens shares one SessionId, which is what Logout revokes.
 type SignedDetails struct {
-	UserId string
-	Name   string
-	Email  string
-	Avatar string
+	UserId    string
+	Name      string
+	Email     string
+	Avatar    string
+	Role      string
+	TokenType string
+	SessionId string
 	jwt.RegisteredClaims
 }
 
-var secretKey = os.Getenv("JWT_SECRET")
+// secretKey is read on every call because the .env file is loaded by main,
+// after package variables have been initialised.
+func secretKey() []byte {
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// GetTokens issues a token pair that starts a new session.
+func GetTokens(userId, name, email, avatar, role string) (sessionId, token, refreshToken string, err error) {
+	sessionId = bson.NewObjectID().Hex()
+	token, refreshToken, err = RotateTokens(sessionId, userId, name, email, avatar, role)
+	return sessionId, token, refreshToken, err
+}
+
+// RotateTokens issues a token pair for an existing session, so that
+// revoking the session also revokes every pair rotated from it.
+func RotateTokens(sessionId, userId, name, email, avatar, role string) (token, refreshToken string, err error) {
+	now := time.Now()
 
-func GetTokens(userId, name, email, avatar string) (token, refreshToken string, err error) {
 	tokenClaims := &SignedDetails{
-		UserId: userId,
-		Name:   name,
-		Email:  email,
-		Avatar: avatar,
+		UserId:    userId,
+		Name:      name,
+		Email:     email,
+		Avatar:    avatar,
+		Role:      role,
+		TokenType: AccessToken,
+		SessionId: sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        bson.NewObjectID().Hex(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
 		},
 	}
 
 	refreshTokenClaims := &SignedDetails{
+		UserId:    userId,
+		TokenType: RefreshToken,
+		SessionId: sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * 24 * time.Hour)),
+			ID:        bson.NewObjectID().Hex(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(RefreshTokenTTL)),
 		},
 	}
 
-	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims).SignedString([]byte(secretKey))
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims).SignedString(secretKey())
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, refreshTokenClaims).SignedString([]byte(secretKey))
+	refreshToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, refreshTokenClaims).SignedString(secretKey())
 	if err != nil {
 		return "", "", err
 	}
@@ -53,7 +92,7 @@ func ValidateAndDecodeToken(signedString string) (*SignedDetails, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrInvalidKey
 		}
-		return []byte(secretKey), nil
+		return secretKey(), nil
 	})
 
 	if err != nil || !token.Valid {
@@ -0,0 +1,191 @@
+package helpers
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+// Turns shorter than minTurnSample or longer than maxTurnSample are orders
+// keyed in by mistake or bills left open, not parties at a table.
+const (
+	minTurnSample = 10 * time.Minute
+	maxTurnSample = 6 * time.Hour
+	waitQuoteStep = 5 * time.Minute
+)
+
+// WaitlistNotifier tells a waiting party that its table is ready, by text
+// message or pager.
+type WaitlistNotifier func(ctx context.Context, entry models.WaitlistEntry, table models.Table) error
+
+var waitlistNotifier atomic.Pointer[WaitlistNotifier]
+
+func init() {
+	var logOnly WaitlistNotifier = func(ctx context.Context, entry models.WaitlistEntry, table models.Table) error {
+		slog.Info("Table ready for waiting party",
+			slog.String("entryId", entry.EntryId),
+			slog.String("guestName", entry.GuestName),
+			slog.String("tableId", table.TableId))
+		return nil
+	}
+	waitlistNotifier.Store(&logOnly)
+}
+
+// SetWaitlistNotifier replaces how waiting parties are told their table is
+// ready. The default only logs it.
+func SetWaitlistNotifier(notifier WaitlistNotifier) {
+	waitlistNotifier.Store(&notifier)
+}
+
+// NotifyWaitlistParty tells the party of entry that table is ready for it.
+func NotifyWaitlistParty(ctx context.Context, entry models.WaitlistEntry, table models.Table) error {
+	return (*waitlistNotifier.Load())(ctx, entry, table)
+}
+
+// OrderTurnTime is how long the party of order held its table: from the
+// order being placed to it being paid, or to invoice being settled when
+// the order has no PAID status change. Implausibly short or long turns are
+// not reported.
+func OrderTurnTime(order models.Order, invoice models.Invoice) (time.Duration, bool) {
+	end := invoice.UpdatedAt
+	for _, change := range order.StatusHistory {
+		if change.Status == constants.ORDER_STATUS_PAID {
+			end = change.ChangedAt
+		}
+	}
+	turn := end.Sub(order.CreatedAt)
+	return turn, turn >= minTurnSample && turn <= maxTurnSample
+}
+
+// TurnHistory is the median time parties held tables, by how many guests
+// the tables seat.
+type TurnHistory map[int]time.Duration
+
+// NewTurnHistory takes the median of the turn times samples of each table
+// size, leaving out sizes with fewer than WAITLIST_MIN_TURN_SAMPLES.
+func NewTurnHistory(samples map[int][]time.Duration) TurnHistory {
+	history := TurnHistory{}
+	for seats, turns := range samples {
+		if len(turns) < constants.WAITLIST_MIN_TURN_SAMPLES {
+			continue
+		}
+		turns = slices.Sorted(slices.Values(turns))
+		mid := len(turns) / 2
+		if len(turns)%2 == 0 {
+			history[seats] = (turns[mid-1] + turns[mid]) / 2
+		} else {
+			history[seats] = turns[mid]
+		}
+	}
+	return history
+}
+
+// TurnTime is how long a party of partySize is expected to hold a table of
+// seats, falling back to the reservation turn times for table sizes with
+// too little history.
+func (h TurnHistory) TurnTime(seats, partySize int) time.Duration {
+	if turn, ok := h[seats]; ok {
+		return turn
+	}
+	if partySize == 0 {
+		partySize = seats
+	}
+	return TurnTime(partySize)
+}
+
+// TableFreeAt estimates when table is ready for the next party: now when
+// it is free, once reset when it is being cleaned, and once its party's
+// turn is over and the table reset when it is occupied. Parties staying
+// past their turn are expected to leave any moment.
+func TableFreeAt(table models.Table, history TurnHistory, now time.Time) time.Time {
+	buffer := ReservationBuffer()
+	switch TableStatus(table) {
+	case constants.TABLE_STATUS_FREE:
+		return now
+	case constants.TABLE_STATUS_CLEANING:
+		return later(TableStatusSince(table).Add(buffer), now)
+	}
+	if table.Occupancy == nil {
+		return now.Add(buffer)
+	}
+	turn := history.TurnTime(TableCapacity(table), table.Occupancy.PartySize)
+	return later(table.Occupancy.SeatedAt.Add(turn), now).Add(buffer)
+}
+
+// QuoteWait estimates when a party of partySize gets a table, once the
+// parties ahead of it in line, given by size, are seated. Parties are
+// seated in line order at the table seating them that is ready first,
+// smallest on a tie, and keep it for their turn time and the buffer after.
+// Tables are not given to a party that would still hold them when a booked
+// reservation arrives. Tables combined into another one only seat parties
+// through it. ok is false when no table seats the party.
+func QuoteWait(tables []models.Table, reservations []models.Reservation, history TurnHistory, ahead []int, partySize int, now time.Time) (time.Time, bool) {
+	buffer := ReservationBuffer()
+	tables = slices.DeleteFunc(slices.Clone(tables), func(t models.Table) bool { return t.CombinedInto != "" })
+	freeAt := map[string]time.Time{}
+	for _, table := range tables {
+		freeAt[table.TableId] = TableFreeAt(table, history, now)
+	}
+	booked := map[string][]models.Reservation{}
+	for _, reservation := range reservations {
+		if reservation.Status == constants.RESERVATION_STATUS_BOOKED {
+			booked[reservation.TableId] = append(booked[reservation.TableId], reservation)
+		}
+	}
+	for _, held := range booked {
+		slices.SortFunc(held, func(a, b models.Reservation) int { return a.StartsAt.Compare(b.StartsAt) })
+	}
+
+	seat := func(size int) (models.Table, time.Time, bool) {
+		var best models.Table
+		var bestAt time.Time
+		found := false
+		for _, table := range tables {
+			seats := TableCapacity(table)
+			if seats < size {
+				continue
+			}
+			at, turn := freeAt[table.TableId], history.TurnTime(seats, size)
+			for _, reservation := range booked[table.TableId] {
+				if ReservationConflicts(reservation, at, at.Add(turn)) {
+					at = reservation.EndsAt.Add(buffer)
+				}
+			}
+			if !found || at.Before(bestAt) || (at.Equal(bestAt) && seats < TableCapacity(best)) {
+				best, bestAt, found = table, at, true
+			}
+		}
+		return best, bestAt, found
+	}
+
+	for _, size := range ahead {
+		if table, at, ok := seat(size); ok {
+			freeAt[table.TableId] = at.Add(history.TurnTime(TableCapacity(table), size) + buffer)
+		}
+	}
+	_, at, ok := seat(partySize)
+	return at, ok
+}
+
+// WaitMinutes is the wait from now until readyAt as quoted to guests, in
+// whole minutes rounded up to the next five.
+func WaitMinutes(readyAt, now time.Time) int {
+	wait := readyAt.Sub(now)
+	if wait <= 0 {
+		return 0
+	}
+	steps := (wait + waitQuoteStep - 1) / waitQuoteStep
+	return int(steps * waitQuoteStep / time.Minute)
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -2,25 +2,65 @@ package main
 
 import (
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 
 	"github.com/gin-gonic/gin"
+	"github.com/joho/godotenv"
+	"github.com/jrskg/go-restaurant/constants"
 	"github.com/jrskg/go-restaurant/database"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/money"
+	"github.com/jrskg/go-restaurant/receipt"
+	"github.com/jrskg/go-restaurant/repository"
 	"github.com/jrskg/go-restaurant/routes"
 	"github.com/jrskg/go-restaurant/utils"
 )
 
 func main() {
-	defer func() {
-		database.DisconnectDB(database.DBClient)
-	}()
+	if err := godotenv.Load(".env"); err != nil {
+		slog.Warn("No .env file loaded, using process environment")
+	}
+
+	if err := money.Configure(os.Getenv("CURRENCY"), os.Getenv("MONEY_ROUNDING")); err != nil {
+		log.Fatal(err)
+	}
+	if err := helpers.ConfigureAutoGratuity(os.Getenv("AUTO_GRATUITY_MIN_GUESTS"), os.Getenv("AUTO_GRATUITY_RATE")); err != nil {
+		log.Fatal(err)
+	}
+	if err := helpers.ConfigureInvoiceNumbering(os.Getenv("INVOICE_NUMBER_PREFIX"), os.Getenv("INVOICE_NUMBER_SERIES"), os.Getenv("BRANCH_CODE"), os.Getenv("FISCAL_YEAR_START_MONTH")); err != nil {
+		log.Fatal(err)
+	}
+	if err := helpers.ConfigureBusinessDay(os.Getenv("BUSINESS_DAY_START_HOUR")); err != nil {
+		log.Fatal(err)
+	}
+	if err := helpers.ConfigureTurnTimes(os.Getenv("RESERVATION_TURN_TIMES"), os.Getenv("RESERVATION_BUFFER_MINUTES")); err != nil {
+		log.Fatal(err)
+	}
+	receipt.Configure(os.Getenv("RESTAURANT_NAME"), os.Getenv("RESTAURANT_ADDRESS"), os.Getenv("RESTAURANT_PHONE"), os.Getenv("RESTAURANT_TAX_ID"))
+
 	port := os.Getenv("PORT")
 
 	if port == "" {
 		port = "3000"
 	}
 
+	var store *repository.Store
+	switch backend := os.Getenv("STORE_BACKEND"); backend {
+	case constants.MEMORY_STORE:
+		slog.Info("Using in-memory store")
+		store = repository.NewMemoryStore()
+	case constants.MONGO_STORE, "":
+		client := database.ConnectDB()
+		defer func() {
+			database.DisconnectDB(client)
+		}()
+		store = repository.NewMongoStore(client)
+	default:
+		log.Fatalf("Unknown STORE_BACKEND %q", backend)
+	}
+
 	router := gin.New()
 	router.Use(gin.Logger())
 
@@ -28,13 +68,24 @@ func main() {
 		utils.ApiSuccess(c, http.StatusOK, nil, "Server health is fine and running")
 	})
 
-	routes.UserRoute(router)
-	routes.FoodRoute(router)
-	routes.InvoiceRoute(router)
-	routes.MenuRoute(router)
-	routes.OrderItemRoute(router)
-	routes.OrderRoute(router)
-	routes.TableRoute(router)
+	routes.UserRoute(router, store)
+	routes.FoodRoute(router, store)
+	routes.InvoiceRoute(router, store)
+	routes.MenuRoute(router, store)
+	routes.OrderItemRoute(router, store)
+	routes.OrderRoute(router, store)
+	routes.TableRoute(router, store)
+	routes.KitchenRoute(router, store)
+	routes.TaxRateRoute(router, store)
+	routes.TipRoute(router, store)
+	routes.PromotionRoute(router, store)
+	routes.ReportRoute(router, store)
+	routes.DrawerRoute(router, store)
+	routes.ReservationRoute(router, store)
+	routes.FloorPlanRoute(router, store)
+	routes.SectionRoute(router, store)
+	routes.ShiftRoute(router, store)
+	routes.WaitlistRoute(router, store)
 
 	err := router.Run(":" + port)
 	if err != nil {
@@ -1,17 +1,24 @@
 package middlewares
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/repository"
 	"github.com/jrskg/go-restaurant/utils"
 )
 
-func Authenticate() gin.HandlerFunc {
+func Authenticate(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			utils.ApiError(c, http.StatusUnauthorized, errors.New("unauthorized"))
@@ -35,10 +42,31 @@ func Authenticate() gin.HandlerFunc {
 			return
 		}
 
+		if claims.TokenType != helpers.AccessToken {
+			utils.ApiError(c, http.StatusUnauthorized, errors.New("unauthorized"))
+			c.Abort()
+			return
+		}
+
+		revoked, err := store.RevokedTokens.IsRevoked(ctx, claims.SessionId)
+		if err != nil {
+			slog.Error("Error while checking token revocation", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		}
+		if revoked {
+			utils.ApiError(c, http.StatusUnauthorized, errors.New("token has been revoked"))
+			c.Abort()
+			return
+		}
+
 		c.Set("email", claims.Email)
 		c.Set("userId", claims.UserId)
 		c.Set("name", claims.Name)
 		c.Set("avatar", claims.Avatar)
+		c.Set("role", claims.Role)
+		c.Set("sessionId", claims.SessionId)
 
 		c.Next()
 	}
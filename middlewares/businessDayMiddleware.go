@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/repository"
+	"github.com/jrskg/go-restaurant/utils"
+)
+
+// BusinessDayOpen refuses requests once today's business day has been
+// closed by its Z-report, so that nothing more is recorded in it.
+func BusinessDayOpen(store *repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		businessDay := helpers.BusinessDay(time.Now())
+		closed, err := store.BusinessDays.IsClosed(ctx, businessDay)
+		if err != nil {
+			slog.Error("Error while checking business day", slog.String("error", err.Error()))
+			utils.ApiError(c, http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		}
+		if closed {
+			utils.ApiError(c, http.StatusConflict, fmt.Errorf("business day %s is closed", businessDay))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
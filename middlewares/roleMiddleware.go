@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/utils"
+)
+
+// Authorize lets the request through only when the role set by Authenticate
+// is one of roles. Admins are allowed everywhere, so they never need to be
+// listed.
+func Authorize(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role != constants.ROLE_ADMIN && !slices.Contains(roles, role) {
+			utils.ApiError(c, http.StatusForbidden, errors.New("forbidden"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
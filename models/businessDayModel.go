@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// ZReport is the end-of-day report of a business day. Sales, taxes and
+// discounts come from the invoices issued during the day; payments, refunds
+// and voids from the ledger entries recorded during it. Once a day is
+// closed its Z-report is stored and nothing dated in the day can change.
+type ZReport struct {
+	// BusinessDay, such as 2026-10-17, is also the id of the stored report.
+	BusinessDay string    `bson:"_id" json:"businessDay"`
+	From        time.Time `bson:"from" json:"from"`
+	To          time.Time `bson:"to" json:"to"`
+	Currency    string    `bson:"currency" json:"currency"`
+
+	Sales     RevenueSummary       `bson:"sales" json:"sales"`
+	Taxes     []TaxLine            `bson:"taxes" json:"taxes"`
+	Discounts []DiscountLine       `bson:"discounts" json:"discounts"`
+	Payments  []PaymentMethodTotal `bson:"payments" json:"payments"`
+	Refunds   LedgerTotal          `bson:"refunds" json:"refunds"`
+	Voids     LedgerTotal          `bson:"voids" json:"voids"`
+	// OpenInvoices counts the invoices of the day still waiting on payment.
+	OpenInvoices int `bson:"openInvoices" json:"openInvoices"`
+
+	// Drawers are the cash drawers opened during the day. CashExpected,
+	// CashCounted and OverShort add up the closed ones.
+	Drawers      []Drawer     `bson:"drawers" json:"drawers"`
+	CashExpected money.Amount `bson:"cashExpected" json:"cashExpected"`
+	CashCounted  money.Amount `bson:"cashCounted" json:"cashCounted"`
+	OverShort    money.Amount `bson:"overShort" json:"overShort"`
+
+	// Closed is false for a preview of a day still open.
+	Closed   bool       `bson:"closed" json:"closed"`
+	ClosedBy string     `bson:"closedBy,omitempty" json:"closedBy,omitempty"`
+	ClosedAt *time.Time `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+}
+
+// PaymentMethodTotal is what was taken by one payment method, net of
+// change, with the tips left on top.
+type PaymentMethodTotal struct {
+	Method string       `bson:"method" json:"method"`
+	Count  int          `bson:"count" json:"count"`
+	Amount money.Amount `bson:"amount" json:"amount"`
+	Tips   money.Amount `bson:"tips" json:"tips"`
+}
+
+// LedgerTotal counts refunds or voids and adds up what they took off.
+type LedgerTotal struct {
+	Count  int          `bson:"count" json:"count"`
+	Amount money.Amount `bson:"amount" json:"amount"`
+}
+
+// CloseBusinessDayDto names the day to close, today's business day when
+// left out.
+type CloseBusinessDayDto struct {
+	BusinessDay string `json:"businessDay" validate:"omitempty,datetime=2006-01-02"`
+}
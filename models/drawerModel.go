@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jrskg/go-restaurant/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Drawer is a cash drawer session. It is opened with a float, takes the
+// cash of every payment and refund recorded while it is open, and is closed
+// by counting what is in it. Only one drawer is open at a time.
+type Drawer struct {
+	ID           bson.ObjectID    `bson:"_id" json:"_id"`
+	DrawerId     string           `bson:"drawerId" json:"drawerId"`
+	Status       string           `bson:"status" json:"status"`
+	OpeningFloat money.Amount     `bson:"openingFloat" json:"openingFloat"`
+	Movements    []DrawerMovement `bson:"movements" json:"movements"`
+	Currency     string           `bson:"currency" json:"currency"`
+	OpenedBy     string           `bson:"openedBy" json:"openedBy"`
+	OpenedAt     time.Time        `bson:"openedAt" json:"openedAt"`
+	// Tally is what the drawer should hold. It is kept when the drawer is
+	// closed and worked out afresh while it is open.
+	Tally   *DrawerTally   `bson:"tally,omitempty" json:"tally,omitempty"`
+	Closing *DrawerClosing `bson:"closing,omitempty" json:"closing,omitempty"`
+}
+
+// DrawerMovement is cash put in or taken out of a drawer for anything but a
+// sale: change brought from the bank, a delivery paid at the door.
+type DrawerMovement struct {
+	MovementId string       `bson:"movementId" json:"movementId"`
+	Type       string       `bson:"type" json:"type"`
+	Amount     money.Amount `bson:"amount" json:"amount"`
+	Reason     string       `bson:"reason" json:"reason"`
+	RecordedBy string       `bson:"recordedBy" json:"recordedBy"`
+	CreatedAt  time.Time    `bson:"createdAt" json:"createdAt"`
+}
+
+// DrawerTally is the cash a drawer should hold: the float, cash taken on
+// payments net of change, cash tips, which stay in the drawer until they are
+// paid out, less cash refunds, plus pay-ins less pay-outs.
+type DrawerTally struct {
+	CashSales   money.Amount `bson:"cashSales" json:"cashSales"`
+	CashTips    money.Amount `bson:"cashTips" json:"cashTips"`
+	CashRefunds money.Amount `bson:"cashRefunds" json:"cashRefunds"`
+	PayIns      money.Amount `bson:"payIns" json:"payIns"`
+	PayOuts     money.Amount `bson:"payOuts" json:"payOuts"`
+	Expected    money.Amount `bson:"expected" json:"expected"`
+}
+
+// DrawerClosing is the count taken when a drawer was closed. OverShort is
+// Counted less the expected cash: positive when over, negative when short.
+type DrawerClosing struct {
+	Counted   money.Amount `bson:"counted" json:"counted"`
+	OverShort money.Amount `bson:"overShort" json:"overShort"`
+	Note      string       `bson:"note,omitempty" json:"note,omitempty"`
+	ClosedBy  string       `bson:"closedBy" json:"closedBy"`
+	ClosedAt  time.Time    `bson:"closedAt" json:"closedAt"`
+}
+
+type OpenDrawerDto struct {
+	OpeningFloat *money.Amount `json:"openingFloat" validate:"required,gte=0"`
+}
+
+type DrawerMovementDto struct {
+	Type   string        `json:"type" validate:"required,eq=PAY_IN|eq=PAY_OUT"`
+	Amount *money.Amount `json:"amount" validate:"required,gt=0"`
+	Reason string        `json:"reason" validate:"required,max=200"`
+}
+
+type CloseDrawerDto struct {
+	Counted *money.Amount `json:"counted" validate:"required,gte=0"`
+	Note    string        `json:"note" validate:"max=200"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// FloorPlan is a room of the restaurant, such as the dining room, the
+// terrace or the bar, drawn Width by Height units large.
+type FloorPlan struct {
+	ID          bson.ObjectID `bson:"_id" json:"_id"`
+	FloorPlanId string        `bson:"floorPlanId" json:"floorPlanId"`
+	Name        string        `bson:"name" json:"name" validate:"required,max=100"`
+	Width       float64       `bson:"width" json:"width" validate:"required,gt=0,lte=10000"`
+	Height      float64       `bson:"height" json:"height" validate:"required,gt=0,lte=10000"`
+	CreatedAt   time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
+
+type UpdateFloorPlanDto struct {
+	Name   *string  `json:"name" validate:"omitempty,max=100"`
+	Width  *float64 `json:"width" validate:"omitempty,gt=0,lte=10000"`
+	Height *float64 `json:"height" validate:"omitempty,gt=0,lte=10000"`
+}
+
+// Section groups tables of a floor plan that one server looks after.
+type Section struct {
+	ID          bson.ObjectID `bson:"_id" json:"_id"`
+	SectionId   string        `bson:"sectionId" json:"sectionId"`
+	FloorPlanId string        `bson:"floorPlanId" json:"floorPlanId" validate:"required"`
+	Name        string        `bson:"name" json:"name" validate:"required,max=100"`
+	CreatedAt   time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
+
+type UpdateSectionDto struct {
+	Name *string `json:"name" validate:"omitempty,max=100"`
+}
+
+// FloorPlanView is a floor plan with its sections and the tables placed on
+// it.
+type FloorPlanView struct {
+	FloorPlan
+	Sections []Section `json:"sections"`
+	Tables   []Table   `json:"tables"`
+}
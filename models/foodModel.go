@@ -3,23 +3,35 @@ package models
 import (
 	"time"
 
+	"github.com/jrskg/go-restaurant/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type Food struct {
 	ID        bson.ObjectID `bson:"_id" json:"_id"`
 	Name      *string       `bson:"name" json:"name" validate:"required,min=2,max=50"`
-	Price     *float64      `bson:"price" json:"price" validate:"required"`
+	Price     *money.Amount `bson:"price" json:"price" validate:"required,gte=0"`
 	FoodImage *string       `bson:"foodImage" json:"foodImage" validate:"required"`
 	CreatedAt time.Time     `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time     `bson:"updatedAt" json:"updatedAt"`
 	FoodId    string        `bson:"foodId" json:"foodId"`
 	MenuId    *string       `bson:"menuId" json:"menuId" validate:"required"`
+	Station   *string       `bson:"station" json:"station"`
+	// SizePrices overrides the size multiplier for the sizes it lists.
+	SizePrices map[string]money.Amount `bson:"sizePrices,omitempty" json:"sizePrices,omitempty" validate:"omitempty,dive,keys,eq=S|eq=M|eq=L,endkeys,gt=0"`
+	// TaxRateIds are the taxes charged on this food; when empty the default
+	// tax rates apply.
+	TaxRateIds []string `bson:"taxRateIds,omitempty" json:"taxRateIds,omitempty"`
+	// Currency is the ISO 4217 code of the prices, always the store currency.
+	Currency string `bson:"currency" json:"currency"`
 }
 
 type UpdateFoodDto struct {
-	Name      *string  `json:"name,omitempty" validate:"omitempty,required,min=2,max=50"`
-	Price     *float64 `json:"price,omitempty" validate:"omitempty,required"`
-	FoodImage *string  `json:"foodImage,omitempty" validate:"omitempty,required"`
-	MenuId    *string  `json:"menuId,omitempty" validate:"omitempty,required"`
+	Name       *string                 `json:"name,omitempty" validate:"omitempty,required,min=2,max=50"`
+	Price      *money.Amount           `json:"price,omitempty" validate:"omitempty,gte=0"`
+	FoodImage  *string                 `json:"foodImage,omitempty" validate:"omitempty,required"`
+	MenuId     *string                 `json:"menuId,omitempty" validate:"omitempty,required"`
+	Station    *string                 `json:"station,omitempty"`
+	SizePrices map[string]money.Amount `json:"sizePrices,omitempty" validate:"omitempty,dive,keys,eq=S|eq=M|eq=L,endkeys,gt=0"`
+	TaxRateIds []string                `json:"taxRateIds,omitempty"`
 }
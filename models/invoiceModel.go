@@ -3,21 +3,103 @@ package models
 import (
 	"time"
 
+	"github.com/jrskg/go-restaurant/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type Invoice struct {
-	ID             bson.ObjectID `bson:"_id" json:"_id"`
-	InvoiceId      string        `bson:"invoiceId" json:"invoiceId"`
-	PaymentMethod  *string       `bson:"paymentMethod" json:"paymentMethod" validate:"eq=CASH|eq=CARD|eq="`
-	PaymentStatus  *string       `bson:"paymentStatus" json:"paymentStatus" validate:"eq=PAID|eq=PENDING"`
-	PaymentDueDate time.Time     `bson:"paymentDueDate" json:"paymentDueDate"`
-	CreatedAt      time.Time     `bson:"createdAt" json:"createdAt"`
-	UpdatedAt      time.Time     `bson:"updatedAt" json:"updatedAt"`
-	OrderId        string        `bson:"orderId" json:"orderId" validate:"required"`
+	ID        bson.ObjectID `bson:"_id" json:"_id"`
+	InvoiceId string        `bson:"invoiceId" json:"invoiceId"`
+	// InvoiceNumber is the invoice's place in its numbering series, such as
+	// INV-2026-000042. It is issued when the invoice is created, never
+	// reused and never changed; FiscalYear is the year it was issued in.
+	InvoiceNumber  string    `bson:"invoiceNumber" json:"invoiceNumber"`
+	FiscalYear     int       `bson:"fiscalYear" json:"fiscalYear"`
+	PaymentMethod  *string   `bson:"paymentMethod" json:"paymentMethod" validate:"omitempty,eq=CASH|eq=CARD|eq=VOUCHER|eq=WALLET"`
+	PaymentStatus  *string   `bson:"paymentStatus" json:"paymentStatus" validate:"omitempty,eq=PAID|eq=PENDING"`
+	PaymentDueDate time.Time `bson:"paymentDueDate" json:"paymentDueDate"`
+	CreatedAt      time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time `bson:"updatedAt" json:"updatedAt"`
+	OrderId        string    `bson:"orderId" json:"orderId" validate:"required"`
+	// ServiceChargeRate is an optional percentage of the subtotal added to
+	// the bill.
+	ServiceChargeRate *money.Rate `bson:"serviceChargeRate" json:"serviceChargeRate" validate:"omitempty,gte=0,lte=1000000"`
+	// GratuityRate is a percentage of the subtotal added as a tip. Large
+	// parties get the automatic gratuity when none is given; 0 waives it.
+	GratuityRate *money.Rate `bson:"gratuityRate" json:"gratuityRate" validate:"omitempty,gte=0,lte=1000000"`
+	// CouponCodes are the coupons presented for the bill.
+	CouponCodes []string `bson:"couponCodes,omitempty" json:"couponCodes,omitempty" validate:"omitempty,max=5,dive,required"`
+	// Totals are recomputed from the order while the invoice is unpaid and
+	// frozen once it is paid.
+	Totals *InvoiceTotals `bson:"totals" json:"totals"`
+	// OrderItemIds limit the invoice to these items of the order when the
+	// bill was split by item or by seat.
+	OrderItemIds []string `bson:"orderItemIds,omitempty" json:"orderItemIds,omitempty"`
+	Seat         *int     `bson:"seat,omitempty" json:"seat,omitempty"`
+	// SplitIndex (from 1) and SplitCount make the invoice one of SplitCount
+	// equal shares of the order.
+	SplitIndex int `bson:"splitIndex,omitempty" json:"splitIndex,omitempty"`
+	SplitCount int `bson:"splitCount,omitempty" json:"splitCount,omitempty"`
+	// AmountPaid is the sum of the invoice's payments net of change, and
+	// AmountRefunded what was given back since.
+	AmountPaid     money.Amount `bson:"amountPaid" json:"amountPaid"`
+	AmountRefunded money.Amount `bson:"amountRefunded" json:"amountRefunded"`
+	// Tips is the sum of the tips left on the invoice's payments, on top of
+	// the total.
+	Tips money.Amount `bson:"tips" json:"tips"`
+	Void *Reversal    `bson:"void,omitempty" json:"void,omitempty"`
 }
 
+// SplitInvoiceDto divides an order into several invoices. Bills lists the
+// order item ids of each bill when splitting by item and must cover every
+// item once; Guests is the number of equal shares when splitting evenly.
+type SplitInvoiceDto struct {
+	OrderId           string      `json:"orderId" validate:"required"`
+	Mode              string      `json:"mode" validate:"required,eq=ITEM|eq=SEAT|eq=EVEN"`
+	Bills             [][]string  `json:"bills" validate:"required_if=Mode ITEM,omitempty,dive,min=1"`
+	Guests            int         `json:"guests" validate:"required_if=Mode EVEN,omitempty,min=2,max=50"`
+	PaymentMethod     *string     `json:"paymentMethod" validate:"omitempty,eq=CASH|eq=CARD|eq=VOUCHER|eq=WALLET"`
+	ServiceChargeRate *money.Rate `json:"serviceChargeRate" validate:"omitempty,gte=0,lte=1000000"`
+	GratuityRate      *money.Rate `json:"gratuityRate" validate:"omitempty,gte=0,lte=1000000"`
+}
+
+// TaxLine is the tax charged at one rate across the whole bill.
+type TaxLine struct {
+	TaxRateId string       `bson:"taxRateId" json:"taxRateId"`
+	Name      string       `bson:"name" json:"name"`
+	Rate      money.Rate   `bson:"rate" json:"rate"`
+	Inclusive bool         `bson:"inclusive" json:"inclusive"`
+	Taxable   money.Amount `bson:"taxable" json:"taxable"`
+	Amount    money.Amount `bson:"amount" json:"amount"`
+}
+
+type InvoiceTotals struct {
+	// Subtotal is the sum of the line totals at menu prices, which include
+	// inclusive taxes.
+	Subtotal money.Amount `bson:"subtotal" json:"subtotal"`
+	// Discount is what promotions took off the subtotal, itemized in
+	// Discounts. Taxes and charges are on the discounted prices.
+	Discount      money.Amount   `bson:"discount" json:"discount"`
+	Discounts     []DiscountLine `bson:"discounts" json:"discounts"`
+	Taxes         []TaxLine      `bson:"taxes" json:"taxes"`
+	ServiceCharge money.Amount   `bson:"serviceCharge" json:"serviceCharge"`
+	Gratuity      money.Amount   `bson:"gratuity" json:"gratuity"`
+	// Total is the subtotal less the discount, plus exclusive taxes, the
+	// service charge and the gratuity.
+	Total money.Amount `bson:"total" json:"total"`
+	// Currency is the ISO 4217 code of every amount above.
+	Currency string `bson:"currency" json:"currency"`
+}
+
+// UpdateInvoiceDto changes the payment method. The payment status follows
+// the payments ledger; asking for PAID or PENDING only succeeds when the
+// ledger agrees.
 type UpdateInvoiceDto struct {
-	PaymentMethod *string `json:"paymentMethod" validate:"eq=CASH|eq=CARD|eq="`
-	PaymentStatus *string `json:"paymentStatus" validate:"eq=PAID|eq=PENDING"`
+	PaymentMethod  *string        `json:"paymentMethod" validate:"omitempty,eq=CASH|eq=CARD|eq=VOUCHER|eq=WALLET"`
+	PaymentStatus  *string        `json:"paymentStatus" validate:"omitempty,eq=PAID|eq=PENDING"`
+	Totals         *InvoiceTotals `json:"-"`
+	AmountPaid     *money.Amount  `json:"-"`
+	AmountRefunded *money.Amount  `json:"-"`
+	Tips           *money.Amount  `json:"-"`
+	Void           *Reversal      `json:"-"`
 }
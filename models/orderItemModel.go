@@ -3,22 +3,66 @@ package models
 import (
 	"time"
 
+	"github.com/jrskg/go-restaurant/money"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type OrderItem struct {
-	ID          bson.ObjectID `bson:"_id" json:"_id"`
-	Quantity    *string       `bson:"quantity" json:"quantity" validate:"required,eq=S|eq=M|eq=L"`
-	UnitPrice   *float64      `bson:"unitPrice" json:"unitPrice" validate:"required"`
+	ID       bson.ObjectID `bson:"_id" json:"_id"`
+	Quantity *string       `bson:"quantity" json:"quantity" validate:"required,eq=S|eq=M|eq=L"`
+	// Count is how many portions of that size were ordered; it defaults to 1.
+	Count *int `bson:"count" json:"count" validate:"omitempty,min=1,max=99"`
+	// Seat is the seat number of the guest the item is for, used to split
+	// the bill by seat.
+	Seat *int `bson:"seat,omitempty" json:"seat,omitempty" validate:"omitempty,min=1,max=99"`
+	// UnitPrice is resolved from the food catalog when the item is created
+	// and kept as a snapshot; any value sent by the client is ignored.
+	UnitPrice *money.Amount `bson:"unitPrice" json:"unitPrice"`
+	// LineTotal is UnitPrice times Count.
+	LineTotal   *money.Amount `bson:"lineTotal" json:"lineTotal"`
 	CreatedAt   time.Time     `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time     `bson:"updatedAt" json:"updatedAt"`
 	OrderItemId string        `bson:"orderItemId" json:"orderItemId"`
 	OrderId     string        `bson:"orderId" json:"orderId" validate:"required"`
 	FoodId      string        `bson:"foodId" json:"foodId" validate:"required"`
+	// Currency is the ISO 4217 code of the prices.
+	Currency string `bson:"currency" json:"currency"`
+	// Void is set when the item was taken off the order; voided items are
+	// left out of the bill.
+	Void *Reversal `bson:"void,omitempty" json:"void,omitempty"`
 }
 
 type UpdateOrderItemDto struct {
-	Quantity  *string  `json:"quantity,omitempty" validate:"omitempty,required,eq=S|eq=M|eq=L"`
-	UnitPrice *float64 `json:"unitPrice,omitempty" validate:"omitempty,required"`
-	FoodId    *string  `json:"foodId,omitempty" validate:"omitempty,required"`
+	Quantity  *string       `json:"quantity,omitempty" validate:"omitempty,required,eq=S|eq=M|eq=L"`
+	Count     *int          `json:"count,omitempty" validate:"omitempty,min=1,max=99"`
+	Seat      *int          `json:"seat,omitempty" validate:"omitempty,min=1,max=99"`
+	UnitPrice *money.Amount `json:"-"`
+	LineTotal *money.Amount `json:"-"`
+	FoodId    *string       `json:"foodId,omitempty" validate:"omitempty,required"`
+}
+
+type OrderItemDetail struct {
+	// Amount is the line total, UnitPrice times Count.
+	Amount      *money.Amount `bson:"amount" json:"amount"`
+	UnitPrice   *money.Amount `bson:"unitPrice" json:"unitPrice"`
+	Count       int           `bson:"count" json:"count"`
+	FoodId      string        `bson:"foodId" json:"foodId"`
+	OrderItemId string        `bson:"orderItemId" json:"orderItemId"`
+	Seat        *int          `bson:"seat" json:"seat"`
+	FoodName    *string       `bson:"foodName" json:"foodName"`
+	FoodImage   *string       `bson:"foodImage" json:"foodImage"`
+	TableNumber *int          `bson:"tableNumber" json:"tableNumber"`
+	TableId     string        `bson:"tableId" json:"tableId"`
+	OrderId     string        `bson:"orderId" json:"orderId"`
+	Quantity    *string       `bson:"quantity" json:"quantity"`
+	CreatedAt   time.Time     `bson:"createdAt" json:"createdAt"`
+	// Discount is what promotions took off the line on an invoice.
+	Discount *money.Amount `bson:"-" json:"discount,omitempty"`
+}
+
+type OrderSummary struct {
+	PaymentDue  money.Amount      `bson:"paymentDue" json:"paymentDue"`
+	TotalCount  int               `bson:"totalCount" json:"totalCount"`
+	TableNumber *int              `bson:"tableNumber" json:"tableNumber"`
+	OrderItems  []OrderItemDetail `bson:"orderItems" json:"orderItems"`
 }
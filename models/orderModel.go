@@ -7,14 +7,41 @@ import (
 )
 
 type Order struct {
-	ID        bson.ObjectID `bson:"_id" json:"_id"`
-	OrderDate time.Time     `bson:"orderDate" json:"orderDate" validate:"required"`
-	CreatedAt time.Time     `bson:"createdAt" json:"createdAt"`
-	UpdatedAt time.Time     `bson:"updatedAt" json:"updatedAt"`
-	OrderID   string        `bson:"orderId" json:"orderId"`
-	TableId   string        `bson:"tableId" json:"tableId" validate:"required"`
+	ID            bson.ObjectID       `bson:"_id" json:"_id"`
+	OrderDate     time.Time           `bson:"orderDate" json:"orderDate" validate:"required"`
+	CreatedAt     time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time           `bson:"updatedAt" json:"updatedAt"`
+	OrderID       string              `bson:"orderId" json:"orderId"`
+	TableId       string              `bson:"tableId" json:"tableId" validate:"required"`
+	Status        string              `bson:"status" json:"status"`
+	StatusHistory []OrderStatusChange `bson:"statusHistory" json:"statusHistory"`
+	// SectionId and ServerId credit the order to the section its table was
+	// in and to the server who looked after it when it was placed.
+	SectionId string `bson:"sectionId,omitempty" json:"sectionId,omitempty"`
+	ServerId  string `bson:"serverId,omitempty" json:"serverId,omitempty"`
+}
+
+type OrderStatusChange struct {
+	Status    string    `bson:"status" json:"status"`
+	ChangedAt time.Time `bson:"changedAt" json:"changedAt"`
+	ChangedBy string    `bson:"changedBy" json:"changedBy"`
 }
 
 type UpdateOrderDto struct {
 	TableId *string `json:"tableId,omitempty" validate:"omitempty,required"`
 }
+
+// TransferOrderItemsDto moves items of an order to the order ToOrderId.
+type TransferOrderItemsDto struct {
+	ToOrderId    string   `json:"toOrderId" validate:"required"`
+	OrderItemIds []string `json:"orderItemIds" validate:"required,min=1,unique,dive,required"`
+}
+
+// MergeOrdersDto merges the orders OrderIds into one check.
+type MergeOrdersDto struct {
+	OrderIds []string `json:"orderIds" validate:"required,min=1,unique,dive,required"`
+}
+
+type UpdateOrderStatusDto struct {
+	Status *string `json:"status" validate:"required,eq=OPEN|eq=SENT_TO_KITCHEN|eq=PREPARING|eq=READY|eq=SERVED|eq=PAID|eq=CANCELLED"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jrskg/go-restaurant/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Payment is one entry of the ledger: a tender recorded against an invoice,
+// or a refund or void, which carry a negative Amount. The entries of an
+// invoice decide its payment status.
+type Payment struct {
+	ID        bson.ObjectID `bson:"_id" json:"_id"`
+	PaymentId string        `bson:"paymentId" json:"paymentId"`
+	// Type is PAYMENT, REFUND or VOID. Entries recorded before refunds
+	// existed have no type and are payments.
+	Type string `bson:"type" json:"type"`
+	// InvoiceId is empty for voided order items, which come off the order
+	// rather than off one invoice.
+	InvoiceId   string  `bson:"invoiceId" json:"invoiceId"`
+	OrderId     string  `bson:"orderId" json:"orderId"`
+	OrderItemId string  `bson:"orderItemId,omitempty" json:"orderItemId,omitempty"`
+	Method      *string `bson:"method" json:"method" validate:"required,eq=CASH|eq=CARD|eq=VOUCHER|eq=WALLET"`
+	// Amount is what the guest handed over. Change is what was given back,
+	// so Amount less Change is applied to the invoice.
+	Amount *money.Amount `bson:"amount" json:"amount" validate:"required,gt=0"`
+	Change *money.Amount `bson:"change" json:"change" validate:"omitempty,gte=0"`
+	// Tip is left for the staff on top of Amount and does not count
+	// towards the invoice.
+	Tip *money.Amount `bson:"tip,omitempty" json:"tip,omitempty" validate:"omitempty,gte=0"`
+	// Reference identifies the tender outside the till: a card slip,
+	// voucher code or wallet transaction id. Only cash may go without.
+	Reference  string    `bson:"reference" json:"reference" validate:"required_unless=Method CASH,max=100"`
+	Currency   string    `bson:"currency" json:"currency"`
+	ReceivedBy string    `bson:"receivedBy" json:"receivedBy"`
+	CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
+	// Reversal says why and on whose approval a refund or void was made.
+	Reversal *Reversal `bson:"reversal,omitempty" json:"reversal,omitempty"`
+}
+
+// Reversal records a refund or void: the reason, the manager who approved
+// it and the user who made it.
+type Reversal struct {
+	ReasonCode string    `bson:"reasonCode" json:"reasonCode"`
+	Note       string    `bson:"note,omitempty" json:"note,omitempty"`
+	ApprovedBy string    `bson:"approvedBy" json:"approvedBy"`
+	RecordedBy string    `bson:"recordedBy" json:"recordedBy"`
+	At         time.Time `bson:"at" json:"at"`
+}
+
+// ReversalDto is the body of a void. ApprovedBy is the user id of the
+// approving manager, who confirms with ApproverPassword; both may be left
+// out when a manager makes the request.
+type ReversalDto struct {
+	ReasonCode       string `json:"reasonCode" validate:"required,eq=CUSTOMER_COMPLAINT|eq=WRONG_ITEM|eq=QUALITY|eq=DUPLICATE|eq=PRICING_ERROR|eq=OTHER"`
+	Note             string `json:"note" validate:"required_if=ReasonCode OTHER,max=200"`
+	ApprovedBy       string `json:"approvedBy"`
+	ApproverPassword string `json:"approverPassword" validate:"required_with=ApprovedBy"`
+}
+
+// RefundDto gives money back on a paid invoice. Without an Amount the
+// refund is the line total of OrderItemId, or everything still refundable
+// when no item is named. Method defaults to that of the last payment.
+type RefundDto struct {
+	ReversalDto
+	Amount      *money.Amount `json:"amount" validate:"omitempty,gt=0"`
+	OrderItemId string        `json:"orderItemId"`
+	Method      *string       `json:"method" validate:"omitempty,eq=CASH|eq=CARD|eq=VOUCHER|eq=WALLET"`
+	Reference   string        `json:"reference" validate:"max=100"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jrskg/go-restaurant/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Promotion reduces the price of a bill. PERCENT and FIXED promotions take
+// Rate or Amount off each matching item (ITEM level) or off the whole bill
+// (BILL level); BUY_X_GET_Y gives GetCount of every BuyCount+GetCount
+// matching units free, the cheapest ones first. Items match when their food
+// is in FoodIds or on a menu in MenuIds; a promotion naming neither matches
+// every item.
+type Promotion struct {
+	ID          bson.ObjectID `bson:"_id" json:"_id"`
+	PromotionId string        `bson:"promotionId" json:"promotionId"`
+	Name        *string       `bson:"name" json:"name" validate:"required,min=2,max=50"`
+	Kind        string        `bson:"kind" json:"kind" validate:"required,eq=PERCENT|eq=FIXED|eq=BUY_X_GET_Y"`
+	Level       string        `bson:"level" json:"level" validate:"required_unless=Kind BUY_X_GET_Y,omitempty,eq=ITEM|eq=BILL"`
+	// Rate is a percentage, so 15 takes 15% off.
+	Rate     *money.Rate   `bson:"rate,omitempty" json:"rate,omitempty" validate:"required_if=Kind PERCENT,omitempty,gt=0,lte=1000000"`
+	Amount   *money.Amount `bson:"amount,omitempty" json:"amount,omitempty" validate:"required_if=Kind FIXED,omitempty,gt=0"`
+	BuyCount int           `bson:"buyCount,omitempty" json:"buyCount,omitempty" validate:"required_if=Kind BUY_X_GET_Y,omitempty,min=1,max=99"`
+	GetCount int           `bson:"getCount,omitempty" json:"getCount,omitempty" validate:"required_if=Kind BUY_X_GET_Y,omitempty,min=1,max=99"`
+	FoodIds  []string      `bson:"foodIds,omitempty" json:"foodIds,omitempty"`
+	MenuIds  []string      `bson:"menuIds,omitempty" json:"menuIds,omitempty"`
+	// CouponCode, when set, limits the promotion to invoices that present
+	// the code. UsageLimit caps how many invoices may redeem it; 0 is no
+	// cap.
+	CouponCode string `bson:"couponCode,omitempty" json:"couponCode,omitempty" validate:"omitempty,alphanum,min=3,max=32"`
+	UsageLimit int    `bson:"usageLimit" json:"usageLimit" validate:"gte=0"`
+	UsageCount int    `bson:"usageCount" json:"usageCount"`
+	// StartsAt and EndsAt bound when the promotion can be used at all;
+	// HappyHour narrows it to a time of day.
+	StartsAt  *time.Time `bson:"startsAt,omitempty" json:"startsAt,omitempty"`
+	EndsAt    *time.Time `bson:"endsAt,omitempty" json:"endsAt,omitempty"`
+	HappyHour *HappyHour `bson:"happyHour,omitempty" json:"happyHour,omitempty"`
+	// Stackable promotions combine with each other. A promotion that does
+	// not stack is only used when it alone saves more than all stackable
+	// ones together.
+	Stackable bool      `bson:"stackable" json:"stackable"`
+	Active    *bool     `bson:"active" json:"active"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// HappyHour is a daily window, in the restaurant's local time, such as
+// 17:00 to 19:00. Days are weekdays from 0 for Sunday and default to every
+// day. A window whose End is before its Start runs past midnight.
+type HappyHour struct {
+	Days  []int  `bson:"days,omitempty" json:"days,omitempty" validate:"omitempty,dive,min=0,max=6"`
+	Start string `bson:"start" json:"start" validate:"required,datetime=15:04"`
+	End   string `bson:"end" json:"end" validate:"required,datetime=15:04"`
+}
+
+type UpdatePromotionDto struct {
+	Name       *string    `json:"name,omitempty" validate:"omitempty,min=2,max=50"`
+	Active     *bool      `json:"active,omitempty"`
+	Stackable  *bool      `json:"stackable,omitempty"`
+	UsageLimit *int       `json:"usageLimit,omitempty" validate:"omitempty,gte=0"`
+	EndsAt     *time.Time `json:"endsAt,omitempty"`
+}
+
+// DiscountLine is what one promotion took off a bill.
+type DiscountLine struct {
+	PromotionId string       `bson:"promotionId" json:"promotionId"`
+	Name        string       `bson:"name" json:"name"`
+	CouponCode  string       `bson:"couponCode,omitempty" json:"couponCode,omitempty"`
+	Amount      money.Amount `bson:"amount" json:"amount"`
+}
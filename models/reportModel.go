@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// ReportQuery selects the days a report covers, From to To inclusive in the
+// restaurant's local time. Period buckets the revenue report; Rank, By and
+// Limit pick the foods of the sellers report.
+type ReportQuery struct {
+	From   time.Time `form:"from" time_format:"2006-01-02" validate:"required"`
+	To     time.Time `form:"to" time_format:"2006-01-02" validate:"required,gtefield=From"`
+	Period string    `form:"period" validate:"omitempty,eq=DAY|eq=WEEK|eq=MONTH"`
+	Rank   string    `form:"rank" validate:"omitempty,eq=TOP|eq=BOTTOM"`
+	By     string    `form:"by" validate:"omitempty,eq=QUANTITY|eq=REVENUE"`
+	Limit  int       `form:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// SoldItem is an item of a paid order with its food and menu category.
+type SoldItem struct {
+	OrderItemId string       `bson:"orderItemId" json:"orderItemId"`
+	OrderId     string       `bson:"orderId" json:"orderId"`
+	FoodId      string       `bson:"foodId" json:"foodId"`
+	FoodName    *string      `bson:"foodName" json:"foodName"`
+	MenuId      *string      `bson:"menuId" json:"menuId"`
+	Category    *string      `bson:"category" json:"category"`
+	Count       int          `bson:"count" json:"count"`
+	Amount      money.Amount `bson:"amount" json:"amount"`
+	CreatedAt   time.Time    `bson:"createdAt" json:"createdAt"`
+}
+
+// RevenueSummary adds up settled invoices. Sales is what was sold after
+// discounts, inclusive taxes included; Net is Total less refunds. A ticket
+// is an order, however many invoices it was split into, and AverageTicket
+// is Net per ticket.
+type RevenueSummary struct {
+	Tickets       int          `json:"tickets"`
+	Invoices      int          `json:"invoices"`
+	Sales         money.Amount `json:"sales"`
+	Discount      money.Amount `json:"discount"`
+	Tax           money.Amount `json:"tax"`
+	ServiceCharge money.Amount `json:"serviceCharge"`
+	Gratuity      money.Amount `json:"gratuity"`
+	Total         money.Amount `json:"total"`
+	Refunded      money.Amount `json:"refunded"`
+	Net           money.Amount `json:"net"`
+	AverageTicket money.Amount `json:"averageTicket"`
+}
+
+// RevenuePeriod is the revenue of the day, ISO week or month starting at
+// Start, labelled like 2026-10-17, 2026-W42 or 2026-10.
+type RevenuePeriod struct {
+	Period string    `json:"period"`
+	Start  time.Time `json:"start"`
+	RevenueSummary
+}
+
+type RevenueReport struct {
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"`
+	Period   string          `json:"period"`
+	Currency string          `json:"currency"`
+	Summary  RevenueSummary  `json:"summary"`
+	Periods  []RevenuePeriod `json:"periods"`
+}
+
+type FoodSales struct {
+	FoodId   string       `json:"foodId"`
+	Name     string       `json:"name"`
+	Category string       `json:"category"`
+	Quantity int          `json:"quantity"`
+	Revenue  money.Amount `json:"revenue"`
+}
+
+// CategorySales is what one menu category sold; Share is its part of all
+// revenue.
+type CategorySales struct {
+	Category string       `json:"category"`
+	Quantity int          `json:"quantity"`
+	Revenue  money.Amount `json:"revenue"`
+	Share    money.Rate   `json:"share"`
+}
+
+type HeatmapCell struct {
+	Tickets int          `json:"tickets"`
+	Revenue money.Amount `json:"revenue"`
+}
+
+// HeatmapRow is one weekday, from 0 for Sunday, of the sales heatmap, with
+// a cell per hour of the day.
+type HeatmapRow struct {
+	Weekday int             `json:"weekday"`
+	Name    string          `json:"name"`
+	Hours   [24]HeatmapCell `json:"hours"`
+}
+
+// ServiceSales is what the orders credited to one server or section sold
+// and the tips left on them. Id is empty for orders credited to nobody.
+type ServiceSales struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	RevenueSummary
+	Tips money.Amount `json:"tips"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Reservation books a table for a party from StartsAt to EndsAt, the turn
+// time of the party's size unless a duration was asked for.
+type Reservation struct {
+	ID            bson.ObjectID `bson:"_id" json:"_id"`
+	ReservationId string        `bson:"reservationId" json:"reservationId"`
+	TableId       string        `bson:"tableId" json:"tableId"`
+	GuestName     string        `bson:"guestName" json:"guestName"`
+	Phone         string        `bson:"phone" json:"phone"`
+	Email         string        `bson:"email,omitempty" json:"email,omitempty"`
+	PartySize     int           `bson:"partySize" json:"partySize"`
+	StartsAt      time.Time     `bson:"startsAt" json:"startsAt"`
+	EndsAt        time.Time     `bson:"endsAt" json:"endsAt"`
+	Notes         string        `bson:"notes,omitempty" json:"notes,omitempty"`
+	Status        string        `bson:"status" json:"status"`
+	CreatedBy     string        `bson:"createdBy" json:"createdBy"`
+	CreatedAt     time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
+
+// CreateReservationDto books a table. Without a TableId the smallest free
+// table that seats the party is booked; DurationMinutes overrides the turn
+// time of the party's size.
+type CreateReservationDto struct {
+	TableId         string    `json:"tableId"`
+	GuestName       string    `json:"guestName" validate:"required,max=100"`
+	Phone           string    `json:"phone" validate:"required,max=30"`
+	Email           string    `json:"email" validate:"omitempty,email"`
+	PartySize       int       `json:"partySize" validate:"required,min=1,max=100"`
+	StartsAt        time.Time `json:"startsAt" validate:"required"`
+	DurationMinutes int       `json:"durationMinutes" validate:"omitempty,min=15,max=720"`
+	Notes           string    `json:"notes" validate:"max=500"`
+}
+
+// UpdateReservationDto changes a booked reservation. A new start time keeps
+// the booking's length; a new party size takes the turn time of its size.
+// DurationMinutes overrides both.
+type UpdateReservationDto struct {
+	TableId         *string    `json:"tableId"`
+	GuestName       *string    `json:"guestName" validate:"omitempty,max=100"`
+	Phone           *string    `json:"phone" validate:"omitempty,max=30"`
+	Email           *string    `json:"email" validate:"omitempty,email"`
+	PartySize       *int       `json:"partySize" validate:"omitempty,min=1,max=100"`
+	StartsAt        *time.Time `json:"startsAt"`
+	DurationMinutes *int       `json:"durationMinutes" validate:"omitempty,min=15,max=720"`
+	Notes           *string    `json:"notes" validate:"omitempty,max=500"`
+	EndsAt          *time.Time `json:"-"`
+}
+
+// AvailabilityQuery looks for the tables free for a party at a time.
+type AvailabilityQuery struct {
+	PartySize       int       `form:"partySize" validate:"required,min=1,max=100"`
+	At              time.Time `form:"at" time_format:"2006-01-02T15:04:05Z07:00" validate:"required"`
+	DurationMinutes int       `form:"durationMinutes" validate:"omitempty,min=15,max=720"`
+}
+
+// ReservationListQuery lists the reservations starting on a day, in the
+// restaurant's local time.
+type ReservationListQuery struct {
+	Date time.Time `form:"date" time_format:"2006-01-02" validate:"required"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Shift assigns a server to a section from StartsAt to EndsAt. Orders
+// placed at the section's tables during the shift are credited to them.
+type Shift struct {
+	ID        bson.ObjectID `bson:"_id" json:"_id"`
+	ShiftId   string        `bson:"shiftId" json:"shiftId"`
+	SectionId string        `bson:"sectionId" json:"sectionId"`
+	UserId    string        `bson:"userId" json:"userId"`
+	StartsAt  time.Time     `bson:"startsAt" json:"startsAt"`
+	EndsAt    time.Time     `bson:"endsAt" json:"endsAt"`
+	CreatedBy string        `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time     `bson:"createdAt" json:"createdAt"`
+}
+
+type CreateShiftDto struct {
+	SectionId string    `json:"sectionId" validate:"required"`
+	UserId    string    `json:"userId" validate:"required"`
+	StartsAt  time.Time `json:"startsAt" validate:"required"`
+	EndsAt    time.Time `json:"endsAt" validate:"required,gtfield=StartsAt"`
+}
+
+// ShiftListQuery lists the shifts worked on a day, in the restaurant's
+// local time, of one section when SectionId is given.
+type ShiftListQuery struct {
+	Date      time.Time `form:"date" time_format:"2006-01-02" validate:"required"`
+	SectionId string    `form:"sectionId"`
+}
@@ -13,9 +13,84 @@ type Table struct {
 	CreatedAt      time.Time     `bson:"createdAt" json:"createdAt"`
 	UpdatedAt      time.Time     `bson:"updatedAt" json:"updatedAt"`
 	TableId        string        `bson:"tableId" json:"tableId"`
+	// Status is where the table is in its service, since StatusSince.
+	// Occupancy is the party at the table until it is cleared.
+	Status      string          `bson:"status" json:"status"`
+	StatusSince time.Time       `bson:"statusSince" json:"statusSince"`
+	Occupancy   *TableOccupancy `bson:"occupancy" json:"occupancy,omitempty"`
+	// FloorPlanId and SectionId place the table in a room and in the
+	// section of a server; Layout draws it on the room's plan.
+	FloorPlanId string       `bson:"floorPlanId,omitempty" json:"floorPlanId,omitempty"`
+	SectionId   string       `bson:"sectionId,omitempty" json:"sectionId,omitempty"`
+	Layout      *TableLayout `bson:"layout,omitempty" json:"layout,omitempty"`
+	// Combination is the tables pushed together with this one for a large
+	// party; each of them has CombinedInto set to this table.
+	Combination  *TableCombination `bson:"combination,omitempty" json:"combination,omitempty"`
+	CombinedInto string            `bson:"combinedInto,omitempty" json:"combinedInto,omitempty"`
 }
 
+// TableCombination is the tables combined with a table and how many guests
+// they all seat together.
+type TableCombination struct {
+	TableIds []string `bson:"tableIds" json:"tableIds"`
+	Seats    int      `bson:"seats" json:"seats"`
+}
+
+// TableLayout draws a table on its floor plan: X and Y are its centre,
+// Width and Height its size in plan units before it is turned by Rotation
+// degrees clockwise.
+type TableLayout struct {
+	X        float64 `bson:"x" json:"x" validate:"gte=0"`
+	Y        float64 `bson:"y" json:"y" validate:"gte=0"`
+	Width    float64 `bson:"width" json:"width" validate:"required,gt=0"`
+	Height   float64 `bson:"height" json:"height" validate:"required,gt=0"`
+	Rotation float64 `bson:"rotation" json:"rotation" validate:"gte=0,lt=360"`
+	Shape    string  `bson:"shape" json:"shape" validate:"required,eq=ROUND|eq=SQUARE|eq=RECTANGLE|eq=BOOTH"`
+}
+
+// TableOccupancy is a party at a table, from seating until the table is
+// cleared, with the orders placed during its stay.
+type TableOccupancy struct {
+	PartySize     int       `bson:"partySize,omitempty" json:"partySize,omitempty"`
+	SeatedAt      time.Time `bson:"seatedAt" json:"seatedAt"`
+	ReservationId string    `bson:"reservationId,omitempty" json:"reservationId,omitempty"`
+	OrderIds      []string  `bson:"orderIds" json:"orderIds"`
+}
+
+// UpdateTableDto changes a table. Giving a SectionId also moves the table
+// to the section's floor plan; an empty FloorPlanId or SectionId takes the
+// table off its plan or out of its section.
 type UpdateTableDto struct {
-	NumberOfGuests *int `json:"numberOfGuests"`
-	TableNumber    *int `json:"tableNumber"`
+	NumberOfGuests *int         `json:"numberOfGuests"`
+	TableNumber    *int         `json:"tableNumber"`
+	FloorPlanId    *string      `json:"floorPlanId"`
+	SectionId      *string      `json:"sectionId"`
+	Layout         *TableLayout `json:"layout"`
+}
+
+// SeatTableDto seats a party at a free table. Seating a reservation takes
+// its party size unless one is given.
+type SeatTableDto struct {
+	PartySize     int    `json:"partySize" validate:"required_without=ReservationId,omitempty,min=1,max=100"`
+	ReservationId string `json:"reservationId"`
+}
+
+// CombineTablesDto pushes free tables together with a table, to seat a
+// party none of them seats alone.
+type CombineTablesDto struct {
+	TableIds []string `json:"tableIds" validate:"required,min=1,max=10,unique,dive,required"`
+}
+
+// MergeTablesDto moves the open orders of the party at FromTableId onto
+// the check of the party at the table merged into.
+type MergeTablesDto struct {
+	FromTableId string `json:"fromTableId" validate:"required"`
+}
+
+// FloorTable is a table on the floor view: its status, how long it has been
+// in it and the next reservation booked at it.
+type FloorTable struct {
+	Table
+	SecondsInState  int64        `json:"secondsInState"`
+	NextReservation *Reservation `json:"nextReservation,omitempty"`
 }
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jrskg/go-restaurant/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TaxRate is a tax charged on food. Foods name the rates they are subject to;
+// foods that name none are charged the default rates.
+type TaxRate struct {
+	ID   bson.ObjectID `bson:"_id" json:"_id"`
+	Name *string       `bson:"name" json:"name" validate:"required,min=2,max=50"`
+	// Rate is a percentage, so 8.875 charges 8.875%.
+	Rate *money.Rate `bson:"rate" json:"rate" validate:"required,gte=0,lte=1000000"`
+	// Inclusive rates are already part of the menu price and are only
+	// itemized on the invoice; exclusive rates are added on top.
+	Inclusive bool      `bson:"inclusive" json:"inclusive"`
+	Default   bool      `bson:"default" json:"default"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+	TaxRateId string    `bson:"taxRateId" json:"taxRateId"`
+}
+
+type UpdateTaxRateDto struct {
+	Name      *string     `json:"name,omitempty" validate:"omitempty,required,min=2,max=50"`
+	Rate      *money.Rate `json:"rate,omitempty" validate:"omitempty,gte=0,lte=1000000"`
+	Inclusive *bool       `json:"inclusive,omitempty"`
+	Default   *bool       `json:"default,omitempty"`
+}
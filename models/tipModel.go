@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// TipPoolDto asks how the tips taken between From and To are shared among
+// Staff. Rule weighs each member by hours worked, by the points of their
+// role, or by both multiplied; RolePoints overrides the default points.
+type TipPoolDto struct {
+	From       time.Time          `json:"from" validate:"required"`
+	To         time.Time          `json:"to" validate:"required,gtfield=From"`
+	Rule       string             `json:"rule" validate:"required,eq=HOURS|eq=POINTS|eq=HOURS_AND_POINTS"`
+	RolePoints map[string]int     `json:"rolePoints" validate:"omitempty,dive,keys,eq=ADMIN|eq=MANAGER|eq=WAITER|eq=KITCHEN|eq=CASHIER,endkeys,gte=0,lte=100"`
+	Staff      []TipPoolMemberDto `json:"staff" validate:"required,min=1,dive"`
+}
+
+type TipPoolMemberDto struct {
+	UserId string  `json:"userId" validate:"required"`
+	Hours  float64 `json:"hours" validate:"gte=0,lte=24"`
+}
+
+type TipShare struct {
+	UserId string       `json:"userId"`
+	Name   string       `json:"name"`
+	Role   string       `json:"role"`
+	Hours  float64      `json:"hours"`
+	Points int          `json:"points"`
+	Amount money.Amount `json:"amount"`
+}
+
+type TipPoolReport struct {
+	From      time.Time    `json:"from"`
+	To        time.Time    `json:"to"`
+	Rule      string       `json:"rule"`
+	TotalTips money.Amount `json:"totalTips"`
+	Currency  string       `json:"currency"`
+	Shares    []TipShare   `json:"shares"`
+	// ByServer and BySection tell whose orders and which sections the
+	// tips in the pool were left on.
+	ByServer  []ServiceTips `json:"byServer"`
+	BySection []ServiceTips `json:"bySection"`
+}
+
+// ServiceTips are the tips left on the orders credited to one server or
+// section. Id is empty for orders credited to nobody.
+type ServiceTips struct {
+	Id   string       `json:"id"`
+	Name string       `json:"name"`
+	Tips money.Amount `json:"tips"`
+}
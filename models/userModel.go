@@ -12,14 +12,45 @@ type User struct {
 	Email        *string       `bson:"email" json:"email" validate:"email,required"`
 	Password     *string       `bson:"password" json:"password" validate:"required,min=6"`
 	Avatar       *string       `bson:"avatar" json:"avatar"`
+	Role         *string       `bson:"role" json:"role"`
 	Token        *string       `bson:"token" json:"token"`
 	RefreshToken *string       `bson:"refreshToken" json:"refreshToken"`
+	Sessions     []UserSession `bson:"sessions" json:"-"`
 	CreatedAt    time.Time     `bson:"createdAt" json:"createdAt"`
 	UpdatedAt    time.Time     `bson:"updatedAt" json:"updatedAt"`
 	UserId       string        `bson:"userId" json:"userId"`
 }
 
+// UserSession is a device the user is logged in on, along with the refresh
+// token last issued to it. Each device rotates its own refresh token.
+type UserSession struct {
+	SessionId    string    `bson:"sessionId"`
+	RefreshToken string    `bson:"refreshToken"`
+	ExpiresAt    time.Time `bson:"expiresAt"`
+}
+
 type LoginDto struct {
 	Email    *string `bson:"email" json:"email" validate:"email,required"`
 	Password *string `bson:"password" json:"password" validate:"required"`
 }
+
+type UpdateRoleDto struct {
+	Role *string `json:"role" validate:"required,eq=ADMIN|eq=MANAGER|eq=WAITER|eq=KITCHEN|eq=CASHIER"`
+}
+
+type RefreshTokenDto struct {
+	RefreshToken *string `json:"refreshToken" validate:"required"`
+}
+
+// UserView is the public shape of a user. It never carries the password hash
+// or the issued tokens.
+type UserView struct {
+	ID        bson.ObjectID `json:"_id"`
+	Name      *string       `json:"name"`
+	Email     *string       `json:"email"`
+	Avatar    *string       `json:"avatar"`
+	Role      *string       `json:"role"`
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	UserId    string        `json:"userId"`
+}
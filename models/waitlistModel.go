@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// WaitlistEntry is a walk-in party queued for a table. QuotedWaitMinutes is
+// the wait the party was told when it joined. TableId is the table the
+// party was called to, then seated at with OrderId.
+type WaitlistEntry struct {
+	ID                bson.ObjectID `bson:"_id" json:"_id"`
+	EntryId           string        `bson:"entryId" json:"entryId"`
+	GuestName         string        `bson:"guestName" json:"guestName"`
+	Phone             string        `bson:"phone" json:"phone"`
+	PartySize         int           `bson:"partySize" json:"partySize"`
+	Notes             string        `bson:"notes,omitempty" json:"notes,omitempty"`
+	Status            string        `bson:"status" json:"status"`
+	QuotedWaitMinutes int           `bson:"quotedWaitMinutes" json:"quotedWaitMinutes"`
+	TableId           string        `bson:"tableId,omitempty" json:"tableId,omitempty"`
+	OrderId           string        `bson:"orderId,omitempty" json:"orderId,omitempty"`
+	NotifiedAt        *time.Time    `bson:"notifiedAt,omitempty" json:"notifiedAt,omitempty"`
+	SeatedAt          *time.Time    `bson:"seatedAt,omitempty" json:"seatedAt,omitempty"`
+	CreatedBy         string        `bson:"createdBy" json:"createdBy"`
+	CreatedAt         time.Time     `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time     `bson:"updatedAt" json:"updatedAt"`
+}
+
+type CreateWaitlistEntryDto struct {
+	GuestName string `json:"guestName" validate:"required,max=100"`
+	Phone     string `json:"phone" validate:"required,max=30"`
+	PartySize int    `json:"partySize" validate:"required,min=1,max=100"`
+	Notes     string `json:"notes" validate:"max=500"`
+}
+
+// WaitlistUpdate moves an entry along; only the fields set are written.
+type WaitlistUpdate struct {
+	Status     *string
+	TableId    *string
+	OrderId    *string
+	NotifiedAt *time.Time
+	SeatedAt   *time.Time
+}
+
+// NotifyWaitlistDto calls a waiting party to a free table.
+type NotifyWaitlistDto struct {
+	TableId string `json:"tableId" validate:"required"`
+}
+
+// SeatWaitlistDto seats a waiting party, at the table it was called to
+// unless another is given.
+type SeatWaitlistDto struct {
+	TableId string `json:"tableId"`
+}
+
+type WaitQuoteQuery struct {
+	PartySize int `form:"partySize" validate:"required,min=1,max=100"`
+}
+
+// WaitQuote is how long a party would wait for a table if it joined the
+// waitlist now, behind PartiesAhead parties.
+type WaitQuote struct {
+	PartySize    int       `json:"partySize"`
+	WaitMinutes  int       `json:"waitMinutes"`
+	ReadyAt      time.Time `json:"readyAt"`
+	PartiesAhead int       `json:"partiesAhead"`
+}
+
+// WaitlistListQuery lists the parties that joined the waitlist on Date, or
+// the ones still waiting without one.
+type WaitlistListQuery struct {
+	Date time.Time `form:"date" time_format:"2006-01-02"`
+}
+
+// SeatedParty is a waitlist party seated at its table with a new order.
+type SeatedParty struct {
+	Entry WaitlistEntry `json:"entry"`
+	Table Table         `json:"table"`
+	Order Order         `json:"order"`
+}
@@ -0,0 +1,59 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// currencies are the ISO 4217 codes the store can price in. Amounts are kept
+// in hundredths, so only currencies with two minor digits are listed.
+var currencies = map[string]bool{
+	"AED": true, "AUD": true, "BRL": true, "CAD": true, "CHF": true,
+	"CNY": true, "CZK": true, "DKK": true, "EUR": true, "GBP": true,
+	"HKD": true, "INR": true, "MXN": true, "MYR": true, "NOK": true,
+	"NPR": true, "NZD": true, "PHP": true, "PLN": true, "SAR": true,
+	"SEK": true, "SGD": true, "THB": true, "TRY": true, "USD": true,
+	"ZAR": true,
+}
+
+// DefaultCurrencyCode is used until SetCurrency is called.
+const DefaultCurrencyCode = "USD"
+
+var currency atomic.Value
+
+func init() {
+	currency.Store(DefaultCurrencyCode)
+}
+
+// SetCurrency sets the ISO 4217 code every price of the store is in. It is
+// meant to be called once at startup.
+func SetCurrency(code string) error {
+	code = strings.ToUpper(code)
+	if !currencies[code] {
+		return fmt.Errorf("unsupported currency %q", code)
+	}
+	currency.Store(code)
+	return nil
+}
+
+// Currency returns the ISO 4217 code of the store currency.
+func Currency() string {
+	return currency.Load().(string)
+}
+
+// Configure applies the currency and rounding policy from configuration;
+// empty values keep the defaults.
+func Configure(currencyCode, roundingName string) error {
+	if currencyCode != "" {
+		if err := SetCurrency(currencyCode); err != nil {
+			return err
+		}
+	}
+	r, err := ParseRounding(roundingName)
+	if err != nil {
+		return err
+	}
+	SetRounding(r)
+	return nil
+}
@@ -0,0 +1,250 @@
+// Package money does price arithmetic in integer minor units so totals never
+// pick up binary floating point artifacts.
+package money
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a sum of money in minor units (cents) of the store currency. It
+// is stored as an integer and encoded in JSON as an exact decimal number
+// such as 12.30; decoding also accepts a quoted decimal string.
+type Amount int64
+
+const amountScale = 2
+
+// Rate is a percentage with up to four decimal places, stored in millionths
+// so that 8.875% is 88750.
+type Rate int64
+
+const rateScale = 4
+
+// rateUnit is 100%.
+const rateUnit = 1_000_000
+
+// ParseAmount reads a decimal such as "12.5" or "-0.05". Digits beyond the
+// cent are rounded with the current rounding policy.
+func ParseAmount(s string) (Amount, error) {
+	v, err := parseDecimal(s, amountScale)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return Amount(v), nil
+}
+
+// FromFloat converts a float price using its shortest decimal
+// representation, so 4.35 becomes 435 cents rather than 434.
+func FromFloat(f float64) Amount {
+	a, err := ParseAmount(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Amount(math.Round(f * 100))
+	}
+	return a
+}
+
+func (a Amount) String() string {
+	return formatDecimal(int64(a), amountScale)
+}
+
+// Mul multiplies a unit price by a count.
+func (a Amount) Mul(n int) Amount {
+	return a * Amount(n)
+}
+
+// MulRate returns the share of a given by r.
+func (a Amount) MulRate(r Rate) Amount {
+	return Amount(divRound(int64(a)*int64(r), rateUnit))
+}
+
+// ExcludeRate splits a price that already includes r into the price before
+// r and the part of it that r accounts for.
+func (a Amount) ExcludeRate(r Rate) (net, included Amount) {
+	net = Amount(divRound(int64(a)*rateUnit, rateUnit+int64(r)))
+	return net, a - net
+}
+
+// Split divides a into n parts that differ by at most one cent and add up to
+// a exactly; the earlier parts carry the remainder.
+func (a Amount) Split(n int) []Amount {
+	parts := make([]Amount, n)
+	share, remainder := a/Amount(n), a%Amount(n)
+	for i := range parts {
+		parts[i] = share
+		switch {
+		case remainder > 0 && Amount(i) < remainder:
+			parts[i]++
+		case remainder < 0 && Amount(i) < -remainder:
+			parts[i]--
+		}
+	}
+	return parts
+}
+
+// Allocate divides a, which must not be negative, in proportion to weights.
+// Each part is rounded down and the cents left over go to the largest
+// remainders, the earlier part winning a tie, so the parts add up to a
+// exactly. Nothing is allocated when the weights add up to zero.
+func (a Amount) Allocate(weights []int64) []Amount {
+	parts := make([]Amount, len(weights))
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+	if sum <= 0 || a <= 0 {
+		return parts
+	}
+
+	remainders := make([]int64, len(weights))
+	left := a
+	for i, w := range weights {
+		parts[i] = Amount(int64(a) * w / sum)
+		remainders[i] = int64(a) * w % sum
+		left -= parts[i]
+	}
+	for ; left > 0; left-- {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		parts[best]++
+		remainders[best] = -1
+	}
+	return parts
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	v, err := ParseAmount(string(bytes.Trim(data, `"`)))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// ParseRate reads a percentage such as "7.5".
+func ParseRate(s string) (Rate, error) {
+	v, err := parseDecimal(s, rateScale)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	return Rate(v), nil
+}
+
+func (r Rate) String() string {
+	s := formatDecimal(int64(r), rateScale)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	v, err := ParseRate(string(bytes.Trim(data, `"`)))
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
+
+// parseDecimal returns s scaled by 10^scale, rounding the digits that do not
+// fit.
+func parseDecimal(s string, scale int) (int64, error) {
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, strconv.ErrSyntax
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	var dropped string
+	if len(frac) > scale {
+		frac, dropped = frac[:scale], frac[scale:]
+		for _, d := range dropped {
+			if d < '0' || d > '9' {
+				return 0, strconv.ErrSyntax
+			}
+		}
+	}
+	frac += strings.Repeat("0", scale-len(frac))
+
+	v, err := strconv.ParseUint(whole+frac, 10, 63)
+	if err != nil {
+		return 0, err
+	}
+	if dropped != "" {
+		half := 0
+		switch {
+		case dropped[0] > '5' || (dropped[0] == '5' && strings.TrimRight(dropped[1:], "0") != ""):
+			half = 1
+		case dropped[0] == '5':
+			half = 0
+		default:
+			half = -1
+		}
+		if roundAway(half, v%2 == 1) {
+			v++
+		}
+	}
+	if negative {
+		return -int64(v), nil
+	}
+	return int64(v), nil
+}
+
+func formatDecimal(v int64, scale int) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	unit := int64(math.Pow10(scale))
+	if scale == 0 {
+		return fmt.Sprintf("%s%d", sign, v)
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, v/unit, scale, v%unit)
+}
+
+// divRound divides n by a positive d, rounding with the current policy.
+func divRound(n, d int64) int64 {
+	q, r := n/d, n%d
+	if r == 0 {
+		return q
+	}
+
+	half := 0
+	switch twice := 2 * abs(r); {
+	case twice > d:
+		half = 1
+	case twice < d:
+		half = -1
+	}
+	if roundAway(half, q%2 != 0) {
+		if n < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,100 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]Amount{"12": 1200, "12.5": 1250, "0.105": 11, "-0.105": -11, "19.999": 2000, ".5": 50}
+	for in, want := range cases {
+		got, err := ParseAmount(in)
+		if err != nil || got != want {
+			t.Errorf("ParseAmount(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "-", "1.2.3", "abc", "1e3"} {
+		if _, err := ParseAmount(in); err == nil {
+			t.Errorf("ParseAmount(%q) succeeded", in)
+		}
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	if got := FromFloat(0.1 + 0.2); got != 30 {
+		t.Errorf("FromFloat(0.1+0.2) = %d", got)
+	}
+	if got := FromFloat(4.35); got != 435 {
+		t.Errorf("FromFloat(4.35) = %d", got)
+	}
+}
+
+func TestRates(t *testing.T) {
+	rate, err := ParseRate("8.875")
+	if err != nil || rate != 88750 || rate.String() != "8.875" {
+		t.Fatalf("ParseRate = %d %q, %v", rate, rate.String(), err)
+	}
+	if tax := Amount(1000).MulRate(rate); tax != 89 {
+		t.Errorf("10.00 at 8.875%% = %s", tax)
+	}
+
+	vat, _ := ParseRate("10")
+	net, included := Amount(1100).ExcludeRate(vat)
+	if net != 1000 || included != 100 {
+		t.Errorf("ExcludeRate = %s, %s", net, included)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	parts := Amount(1000).Allocate([]int64{600, 400, 500})
+	if parts[0] != 400 || parts[1] != 267 || parts[2] != 333 {
+		t.Errorf("Allocate = %v", parts)
+	}
+	if parts := Amount(100).Allocate([]int64{0, 0}); parts[0] != 0 || parts[1] != 0 {
+		t.Errorf("Allocate with no weight = %v", parts)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	var v struct {
+		A Amount `json:"a"`
+		B Amount `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(`{"a": 12.3, "b": "-0.5"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := json.Marshal(v)
+	if string(out) != `{"a":12.30,"b":-0.50}` {
+		t.Fatalf("marshal = %s", out)
+	}
+}
+
+func TestHalfEvenRounding(t *testing.T) {
+	SetRounding(HalfEven)
+	defer SetRounding(HalfUp)
+
+	cases := map[string]Amount{"0.125": 12, "0.135": 14, "0.1251": 13, "-0.125": -12}
+	for in, want := range cases {
+		if got, _ := ParseAmount(in); got != want {
+			t.Errorf("ParseAmount(%q) = %d, want %d", in, got, want)
+		}
+	}
+	// 2.50 at 5% is 0.125 exactly.
+	if got := Amount(250).MulRate(50_000); got != 12 {
+		t.Errorf("MulRate half-even = %d, want 12", got)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(DefaultCurrencyCode, "")
+
+	if err := Configure("eur", "half_even"); err != nil || Currency() != "EUR" || CurrentRounding() != HalfEven {
+		t.Fatalf("Configure = %v, currency %s, rounding %d", err, Currency(), CurrentRounding())
+	}
+	if err := Configure("JPY", ""); err == nil {
+		t.Error("JPY has no minor units and should be rejected")
+	}
+	if err := Configure("", "up"); err == nil {
+		t.Error("unknown rounding policy accepted")
+	}
+}
@@ -0,0 +1,59 @@
+package money
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Rounding decides which way amounts that fall exactly between two cents
+// go. Amounts that are not exactly halfway always go to the nearest cent.
+type Rounding int32
+
+const (
+	// HalfUp rounds halves away from zero: 0.125 becomes 0.13.
+	HalfUp Rounding = iota
+	// HalfEven rounds halves to the even cent, known as banker's rounding:
+	// 0.125 becomes 0.12 and 0.135 becomes 0.14.
+	HalfEven
+)
+
+var rounding atomic.Int32
+
+// SetRounding changes the rounding policy of the whole process. It is meant
+// to be called once at startup.
+func SetRounding(r Rounding) {
+	rounding.Store(int32(r))
+}
+
+func CurrentRounding() Rounding {
+	return Rounding(rounding.Load())
+}
+
+// ParseRounding reads a policy name as used in configuration. An empty
+// name selects HalfUp.
+func ParseRounding(s string) (Rounding, error) {
+	switch s {
+	case "", "half_up":
+		return HalfUp, nil
+	case "half_even":
+		return HalfEven, nil
+	}
+	return 0, fmt.Errorf("unknown rounding policy %q, want half_up or half_even", s)
+}
+
+// roundAway reports whether a value that was truncated towards zero should
+// be moved one unit away from zero. half is 1 when the dropped part was more
+// than half a unit, 0 when exactly half and -1 when less; odd tells whether
+// the truncated value is odd.
+func roundAway(half int, odd bool) bool {
+	switch {
+	case half > 0:
+		return true
+	case half < 0:
+		return false
+	case CurrentRounding() == HalfEven:
+		return odd
+	default:
+		return true
+	}
+}
@@ -0,0 +1,47 @@
+package receipt
+
+import (
+	"bytes"
+	"strings"
+)
+
+// ESC/POS commands understood by practically every thermal receipt printer.
+var (
+	escInit        = []byte{0x1b, '@'}
+	escAlignLeft   = []byte{0x1b, 'a', 0}
+	escAlignCenter = []byte{0x1b, 'a', 1}
+	escBoldOn      = []byte{0x1b, 'E', 1}
+	escBoldOff     = []byte{0x1b, 'E', 0}
+	escDoubleSize  = []byte{0x1d, '!', 0x11}
+	escNormalSize  = []byte{0x1d, '!', 0}
+	escFeedAndCut  = []byte{0x1d, 'V', 'B', 3}
+)
+
+// ESCPOS renders r as a byte stream for an ESC/POS printer. Titles print
+// double size and are centered by the printer; text outside printable ASCII
+// is replaced, as the printer's default code page cannot be relied on.
+func ESCPOS(r Receipt) []byte {
+	var buf bytes.Buffer
+	buf.Write(escInit)
+	for _, line := range r.Lines() {
+		switch line.Style {
+		case Title:
+			buf.Write(escAlignCenter)
+			buf.Write(escBoldOn)
+			buf.Write(escDoubleSize)
+			buf.WriteString(ascii(strings.TrimSpace(line.Text)))
+			buf.Write(escNormalSize)
+			buf.Write(escBoldOff)
+			buf.Write(escAlignLeft)
+		case Bold:
+			buf.Write(escBoldOn)
+			buf.WriteString(ascii(line.Text))
+			buf.Write(escBoldOff)
+		default:
+			buf.WriteString(ascii(line.Text))
+		}
+		buf.WriteByte('\n')
+	}
+	buf.Write(escFeedAndCut)
+	return buf.Bytes()
+}
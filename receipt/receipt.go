@@ -0,0 +1,264 @@
+// Package receipt lays invoices out for printing: as fixed-width text, as an
+// ESC/POS stream for thermal receipt printers and as a PDF. All three share
+// the same column layout.
+package receipt
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+// Width is the number of columns of a receipt, what an 80 mm thermal printer
+// fits in its standard font.
+const Width = 42
+
+// Header is the restaurant printed at the top of every receipt.
+type Header struct {
+	Name    string
+	Address string
+	Phone   string
+	TaxId   string
+}
+
+var header atomic.Pointer[Header]
+
+func init() {
+	header.Store(&Header{Name: "Restaurant"})
+}
+
+// SetHeader replaces the restaurant printed on receipts.
+func SetHeader(h Header) {
+	header.Store(&h)
+}
+
+// Configure applies the restaurant details from configuration; an empty
+// name keeps the default.
+func Configure(name, address, phone, taxId string) {
+	if name == "" {
+		name = header.Load().Name
+	}
+	SetHeader(Header{Name: name, Address: address, Phone: phone, TaxId: taxId})
+}
+
+// Receipt is an invoice with everything printed on it.
+type Receipt struct {
+	Invoice models.Invoice
+	// Items are the order items on the invoice, with their discounts.
+	Items       []models.OrderItemDetail
+	TableNumber *int
+	Totals      models.InvoiceTotals
+	// Payments are the invoice's ledger entries: payments, refunds and the
+	// void.
+	Payments   []models.Payment
+	BalanceDue money.Amount
+}
+
+// Style is how a line of a receipt is printed.
+type Style int
+
+const (
+	Plain Style = iota
+	// Title is centered and printed large where the output allows.
+	Title
+	Centered
+	Bold
+)
+
+// Line is one line of a receipt, already padded to Width.
+type Line struct {
+	Text  string
+	Style Style
+}
+
+// Lines lays r out in Width columns.
+func (r Receipt) Lines() []Line {
+	var b builder
+	h := header.Load()
+
+	b.add(Title, h.Name)
+	if h.Address != "" {
+		b.add(Centered, h.Address)
+	}
+	if h.Phone != "" {
+		b.add(Centered, "Tel "+h.Phone)
+	}
+	if h.TaxId != "" {
+		b.add(Centered, "Tax ID "+h.TaxId)
+	}
+	if r.Invoice.Void != nil {
+		b.add(Title, "*** VOID ***")
+	}
+	b.rule()
+
+	if r.Invoice.InvoiceNumber != "" {
+		b.row(Plain, "Invoice", r.Invoice.InvoiceNumber)
+	} else {
+		b.row(Plain, "Invoice", r.Invoice.InvoiceId)
+	}
+	b.row(Plain, "Date", r.Invoice.CreatedAt.In(time.Local).Format("2006-01-02 15:04"))
+	if r.TableNumber != nil {
+		b.row(Plain, "Table", fmt.Sprint(*r.TableNumber))
+	}
+	if r.Invoice.Seat != nil {
+		b.row(Plain, "Seat", fmt.Sprint(*r.Invoice.Seat))
+	}
+	if r.Invoice.SplitCount > 1 {
+		b.row(Plain, "Share", fmt.Sprintf("%d of %d", r.Invoice.SplitIndex, r.Invoice.SplitCount))
+	}
+	b.rule()
+
+	for _, item := range r.Items {
+		name := "Item"
+		if item.FoodName != nil {
+			name = *item.FoodName
+		}
+		if item.Quantity != nil && *item.Quantity != "" {
+			name += " (" + *item.Quantity + ")"
+		}
+		b.add(Plain, name)
+		var unitPrice, amount money.Amount
+		if item.UnitPrice != nil {
+			unitPrice = *item.UnitPrice
+		}
+		if item.Amount != nil {
+			amount = *item.Amount
+		}
+		b.row(Plain, fmt.Sprintf("  %d x %s", item.Count, unitPrice), amount.String())
+		if item.Discount != nil {
+			b.row(Plain, "  Discount", (-*item.Discount).String())
+		}
+	}
+	b.rule()
+
+	totals := r.Totals
+	b.row(Plain, "Subtotal", totals.Subtotal.String())
+	for _, discount := range totals.Discounts {
+		name := discount.Name
+		if discount.CouponCode != "" {
+			name += " " + discount.CouponCode
+		}
+		b.row(Plain, name, (-discount.Amount).String())
+	}
+	for _, tax := range totals.Taxes {
+		name := fmt.Sprintf("%s %s%%", tax.Name, tax.Rate)
+		if tax.Inclusive {
+			name += " incl."
+		}
+		b.row(Plain, name, tax.Amount.String())
+	}
+	if totals.ServiceCharge != 0 {
+		b.row(Plain, "Service charge", totals.ServiceCharge.String())
+	}
+	if totals.Gratuity != 0 {
+		b.row(Plain, "Gratuity", totals.Gratuity.String())
+	}
+	b.row(Bold, "TOTAL "+totals.Currency, totals.Total.String())
+
+	if len(r.Payments) > 0 {
+		b.rule()
+		for _, payment := range r.Payments {
+			label := strings.TrimSpace(payment.Type + " " + deref(payment.Method))
+			if payment.Type == "" || payment.Type == constants.LEDGER_PAYMENT {
+				label = deref(payment.Method)
+			}
+			if payment.Reference != "" {
+				label += " " + payment.Reference
+			}
+			var amount money.Amount
+			if payment.Amount != nil {
+				amount = *payment.Amount
+			}
+			b.row(Plain, label, amount.String())
+			if payment.Change != nil && *payment.Change != 0 {
+				b.row(Plain, "  Change", (-*payment.Change).String())
+			}
+			if payment.Tip != nil && *payment.Tip != 0 {
+				b.row(Plain, "  Tip", payment.Tip.String())
+			}
+		}
+		b.row(Plain, "Paid", r.Invoice.AmountPaid.String())
+		if r.Invoice.AmountRefunded != 0 {
+			b.row(Plain, "Refunded", r.Invoice.AmountRefunded.String())
+		}
+		if r.Invoice.Tips != 0 {
+			b.row(Plain, "Tips", r.Invoice.Tips.String())
+		}
+	}
+	b.row(Bold, "Balance due", r.BalanceDue.String())
+	b.rule()
+
+	if r.Invoice.PaymentStatus != nil {
+		b.add(Centered, *r.Invoice.PaymentStatus)
+	}
+	b.add(Centered, "Thank you!")
+	return b.lines
+}
+
+type builder struct {
+	lines []Line
+}
+
+// add appends text, cut to Width, centering Title and Centered lines.
+func (b *builder) add(style Style, text string) {
+	text = truncate(text, Width)
+	if style == Title || style == Centered {
+		pad := (Width - utf8.RuneCountInString(text)) / 2
+		text = strings.Repeat(" ", pad) + text
+	}
+	b.lines = append(b.lines, Line{Text: text, Style: style})
+}
+
+// row appends left and right flushed to the two edges, cutting left short
+// when they do not fit together.
+func (b *builder) row(style Style, left, right string) {
+	right = truncate(right, Width)
+	room := Width - utf8.RuneCountInString(right) - 1
+	left = truncate(left, max(room, 0))
+	gap := Width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	b.lines = append(b.lines, Line{Text: left + strings.Repeat(" ", gap) + right, Style: style})
+}
+
+func (b *builder) rule() {
+	b.lines = append(b.lines, Line{Text: strings.Repeat("-", Width)})
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ascii replaces what single-byte printers and fonts cannot show.
+func ascii(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// Text renders r as plain text, one line per receipt line.
+func Text(r Receipt) []byte {
+	var sb strings.Builder
+	for _, line := range r.Lines() {
+		sb.WriteString(strings.TrimRight(line.Text, " "))
+		sb.WriteByte('\n')
+	}
+	return []byte(sb.String())
+}
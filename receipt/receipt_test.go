@@ -0,0 +1,113 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+func testReceipt() Receipt {
+	name, size, method, status := "Crème brûlée (with a very long description)", "M", "CARD", "PAID"
+	unitPrice, amount, discount := money.Amount(650), money.Amount(1300), money.Amount(130)
+	paid, tip, table := money.Amount(1257), money.Amount(200), 7
+	return Receipt{
+		Invoice: models.Invoice{
+			InvoiceId:     "inv-1",
+			CreatedAt:     time.Date(2026, 3, 1, 19, 30, 0, 0, time.UTC),
+			PaymentStatus: &status,
+			AmountPaid:    paid,
+			Tips:          tip,
+		},
+		Items: []models.OrderItemDetail{{
+			FoodName: &name, Quantity: &size, Count: 2,
+			UnitPrice: &unitPrice, Amount: &amount, Discount: &discount,
+		}},
+		TableNumber: &table,
+		Totals: models.InvoiceTotals{
+			Subtotal:  1300,
+			Discount:  130,
+			Discounts: []models.DiscountLine{{Name: "Desserts", CouponCode: "SWEET10", Amount: 130}},
+			Taxes:     []models.TaxLine{{Name: "Sales tax", Rate: 75000, Taxable: 1170, Amount: 87}},
+			Total:     1257,
+			Currency:  "USD",
+		},
+		Payments: []models.Payment{{Method: &method, Amount: &paid, Tip: &tip, Reference: "slip-9"}},
+	}
+}
+
+func TestText(t *testing.T) {
+	SetHeader(Header{Name: "Chez (Test)", Phone: "555-0100"})
+	defer SetHeader(Header{Name: "Restaurant"})
+
+	text := string(Text(testReceipt()))
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if n := utf8.RuneCountInString(line); n > Width {
+			t.Errorf("line %q is %d columns wide", line, n)
+		}
+	}
+	for _, want := range []string{
+		"Chez (Test)",
+		"Tel 555-0100",
+		"Table                                    7",
+		"  2 x 6.50                           13.00",
+		"  Discount                           -1.30",
+		"Desserts SWEET10                     -1.30",
+		"Sales tax 7.5%                        0.87",
+		"TOTAL USD                            12.57",
+		"CARD slip-9                          12.57",
+		"  Tip                                 2.00",
+		"Balance due                           0.00",
+	} {
+		if !strings.Contains(text, want+"\n") {
+			t.Errorf("receipt has no line %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestESCPOS(t *testing.T) {
+	out := ESCPOS(testReceipt())
+	if !bytes.HasPrefix(out, escInit) || !bytes.HasSuffix(out, escFeedAndCut) {
+		t.Fatalf("stream does not start with init and end with a cut: % x", out)
+	}
+	if !bytes.Contains(out, append(escDoubleSize, "Restaurant"...)) {
+		t.Error("title is not printed double size")
+	}
+	if !bytes.Contains(out, []byte("Cr?me br?l?e")) {
+		t.Error("non-ASCII text is not replaced")
+	}
+}
+
+func TestPDF(t *testing.T) {
+	out := PDF(testReceipt())
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF:\n%s", out)
+	}
+	if !bytes.Contains(out, []byte(`(TOTAL USD                            12.57) Tj`)) {
+		t.Error("total line missing from the content stream")
+	}
+
+	// Every cross-reference entry points at its object.
+	xref := bytes.LastIndex(out, []byte("\nxref\n")) + 1
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if startxref == nil || string(startxref[1]) != strconv.Itoa(xref) {
+		t.Fatalf("startxref = %q, want %d", startxref, xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	if len(entries) != 6 {
+		t.Fatalf("%d xref entries, want 6", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, out[offset:offset+10])
+		}
+	}
+}
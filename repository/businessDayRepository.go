@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// BusinessDayRepository keeps the Z-reports of closed business days, keyed
+// by the day. A day is closed for good once its report is stored.
+type BusinessDayRepository interface {
+	// Close stores the Z-report of a day. It returns false, and stores
+	// nothing, when the day is already closed.
+	Close(ctx context.Context, report models.ZReport) (bool, error)
+	Get(ctx context.Context, businessDay string) (models.ZReport, error)
+	IsClosed(ctx context.Context, businessDay string) (bool, error)
+}
+
+type mongoBusinessDayRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoBusinessDayRepository) Close(ctx context.Context, report models.ZReport) (bool, error) {
+	_, err := r.collection.InsertOne(ctx, report)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *mongoBusinessDayRepository) Get(ctx context.Context, businessDay string) (models.ZReport, error) {
+	var report models.ZReport
+	err := r.collection.FindOne(ctx, bson.M{"_id": businessDay}).Decode(&report)
+	return report, mongoErr(err)
+}
+
+func (r *mongoBusinessDayRepository) IsClosed(ctx context.Context, businessDay string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": businessDay})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+type memoryBusinessDayRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryBusinessDayRepository) Close(ctx context.Context, report models.ZReport) (bool, error) {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.businessDays[report.BusinessDay]; ok {
+		return false, nil
+	}
+	r.db.businessDays[report.BusinessDay] = report
+	return true, nil
+}
+
+func (r *memoryBusinessDayRepository) Get(ctx context.Context, businessDay string) (models.ZReport, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	report, ok := r.db.businessDays[businessDay]
+	if !ok {
+		return models.ZReport{}, ErrNotFound
+	}
+	return report, nil
+}
+
+func (r *memoryBusinessDayRepository) IsClosed(ctx context.Context, businessDay string) (bool, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	_, ok := r.db.businessDays[businessDay]
+	return ok, nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type DrawerRepository interface {
+	Create(ctx context.Context, drawer models.Drawer) error
+	Get(ctx context.Context, drawerId string) (models.Drawer, error)
+	// Current returns the open drawer, or ErrNotFound when none is open.
+	Current(ctx context.Context) (models.Drawer, error)
+	// AddMovement records a pay-in or pay-out on an open drawer. It returns
+	// ErrNotFound when the drawer does not exist or is closed.
+	AddMovement(ctx context.Context, drawerId string, movement models.DrawerMovement) error
+	// Close closes an open drawer with its tally and count. It returns
+	// ErrNotFound when the drawer does not exist or is already closed.
+	Close(ctx context.Context, drawerId string, tally models.DrawerTally, closing models.DrawerClosing) error
+	// ListBetween returns the drawers opened from from up to but not
+	// including to, oldest first.
+	ListBetween(ctx context.Context, from, to time.Time) ([]models.Drawer, error)
+}
+
+type mongoDrawerRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoDrawerRepository) Create(ctx context.Context, drawer models.Drawer) error {
+	_, err := r.collection.InsertOne(ctx, drawer)
+	return err
+}
+
+func (r *mongoDrawerRepository) Get(ctx context.Context, drawerId string) (models.Drawer, error) {
+	var drawer models.Drawer
+	err := r.collection.FindOne(ctx, bson.M{"drawerId": drawerId}).Decode(&drawer)
+	return drawer, mongoErr(err)
+}
+
+func (r *mongoDrawerRepository) Current(ctx context.Context) (models.Drawer, error) {
+	var drawer models.Drawer
+	err := r.collection.FindOne(ctx, bson.M{"status": constants.DRAWER_STATUS_OPEN}).Decode(&drawer)
+	return drawer, mongoErr(err)
+}
+
+func (r *mongoDrawerRepository) AddMovement(ctx context.Context, drawerId string, movement models.DrawerMovement) error {
+	filter := bson.M{"drawerId": drawerId, "status": constants.DRAWER_STATUS_OPEN}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$push": bson.M{"movements": movement}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoDrawerRepository) Close(ctx context.Context, drawerId string, tally models.DrawerTally, closing models.DrawerClosing) error {
+	filter := bson.M{"drawerId": drawerId, "status": constants.DRAWER_STATUS_OPEN}
+	update := bson.M{"$set": bson.M{
+		"status":  constants.DRAWER_STATUS_CLOSED,
+		"tally":   tally,
+		"closing": closing,
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoDrawerRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.Drawer, error) {
+	filter := bson.M{"openedAt": bson.M{"$gte": from, "$lt": to}}
+	opts := options.Find().SetSort(bson.D{{Key: "openedAt", Value: 1}, {Key: "_id", Value: 1}})
+	result, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	drawers := make([]models.Drawer, 0)
+	if err := result.All(ctx, &drawers); err != nil {
+		return nil, err
+	}
+	return drawers, nil
+}
+
+type memoryDrawerRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryDrawerRepository) Create(ctx context.Context, drawer models.Drawer) error {
+	defer r.db.lock(ctx)()
+
+	r.db.drawers[drawer.DrawerId] = drawer
+	return nil
+}
+
+func (r *memoryDrawerRepository) Get(ctx context.Context, drawerId string) (models.Drawer, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	drawer, ok := r.db.drawers[drawerId]
+	if !ok {
+		return models.Drawer{}, ErrNotFound
+	}
+	return drawer, nil
+}
+
+func (r *memoryDrawerRepository) Current(ctx context.Context) (models.Drawer, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, drawer := range r.db.drawers {
+		if drawer.Status == constants.DRAWER_STATUS_OPEN {
+			return drawer, nil
+		}
+	}
+	return models.Drawer{}, ErrNotFound
+}
+
+func (r *memoryDrawerRepository) AddMovement(ctx context.Context, drawerId string, movement models.DrawerMovement) error {
+	defer r.db.lock(ctx)()
+
+	drawer, ok := r.db.drawers[drawerId]
+	if !ok || drawer.Status != constants.DRAWER_STATUS_OPEN {
+		return ErrNotFound
+	}
+	drawer.Movements = append(slices.Clone(drawer.Movements), movement)
+	r.db.drawers[drawerId] = drawer
+	return nil
+}
+
+func (r *memoryDrawerRepository) Close(ctx context.Context, drawerId string, tally models.DrawerTally, closing models.DrawerClosing) error {
+	defer r.db.lock(ctx)()
+
+	drawer, ok := r.db.drawers[drawerId]
+	if !ok || drawer.Status != constants.DRAWER_STATUS_OPEN {
+		return ErrNotFound
+	}
+	drawer.Status = constants.DRAWER_STATUS_CLOSED
+	drawer.Tally = &tally
+	drawer.Closing = &closing
+	r.db.drawers[drawerId] = drawer
+	return nil
+}
+
+func (r *memoryDrawerRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.Drawer, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	drawers := make([]models.Drawer, 0)
+	for _, drawer := range sortedByCreation(r.db.drawers, func(d models.Drawer) time.Time { return d.OpenedAt }) {
+		if !drawer.OpenedAt.Before(from) && drawer.OpenedAt.Before(to) {
+			drawers = append(drawers, drawer)
+		}
+	}
+	return drawers, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type FloorPlanRepository interface {
+	Create(ctx context.Context, floorPlan models.FloorPlan) error
+	Update(ctx context.Context, floorPlanId string, update models.UpdateFloorPlanDto) error
+	Delete(ctx context.Context, floorPlanId string) error
+	Get(ctx context.Context, floorPlanId string) (models.FloorPlan, error)
+	List(ctx context.Context) ([]models.FloorPlan, error)
+}
+
+type mongoFloorPlanRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoFloorPlanRepository) Create(ctx context.Context, floorPlan models.FloorPlan) error {
+	_, err := r.collection.InsertOne(ctx, floorPlan)
+	return err
+}
+
+func (r *mongoFloorPlanRepository) Update(ctx context.Context, floorPlanId string, update models.UpdateFloorPlanDto) error {
+	updateFields := bson.M{
+		"name":   update.Name,
+		"width":  update.Width,
+		"height": update.Height,
+	}
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+	for k, v := range updateFields {
+		if !utils.IsNil(v) {
+			updateObj[k] = v
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"floorPlanId": floorPlanId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoFloorPlanRepository) Delete(ctx context.Context, floorPlanId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"floorPlanId": floorPlanId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoFloorPlanRepository) Get(ctx context.Context, floorPlanId string) (models.FloorPlan, error) {
+	var floorPlan models.FloorPlan
+	err := r.collection.FindOne(ctx, bson.M{"floorPlanId": floorPlanId}).Decode(&floorPlan)
+	return floorPlan, mongoErr(err)
+}
+
+func (r *mongoFloorPlanRepository) List(ctx context.Context) ([]models.FloorPlan, error) {
+	result, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	floorPlans := make([]models.FloorPlan, 0)
+	if err := result.All(ctx, &floorPlans); err != nil {
+		return nil, err
+	}
+	return floorPlans, nil
+}
+
+type memoryFloorPlanRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryFloorPlanRepository) Create(ctx context.Context, floorPlan models.FloorPlan) error {
+	defer r.db.lock(ctx)()
+
+	r.db.floorPlans[floorPlan.FloorPlanId] = floorPlan
+	return nil
+}
+
+func (r *memoryFloorPlanRepository) Update(ctx context.Context, floorPlanId string, update models.UpdateFloorPlanDto) error {
+	defer r.db.lock(ctx)()
+
+	floorPlan, ok := r.db.floorPlans[floorPlanId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.Name != nil {
+		floorPlan.Name = *update.Name
+	}
+	if update.Width != nil {
+		floorPlan.Width = *update.Width
+	}
+	if update.Height != nil {
+		floorPlan.Height = *update.Height
+	}
+	floorPlan.UpdatedAt = time.Now().UTC()
+
+	r.db.floorPlans[floorPlanId] = floorPlan
+	return nil
+}
+
+func (r *memoryFloorPlanRepository) Delete(ctx context.Context, floorPlanId string) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.floorPlans[floorPlanId]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.floorPlans, floorPlanId)
+	return nil
+}
+
+func (r *memoryFloorPlanRepository) Get(ctx context.Context, floorPlanId string) (models.FloorPlan, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	floorPlan, ok := r.db.floorPlans[floorPlanId]
+	if !ok {
+		return models.FloorPlan{}, ErrNotFound
+	}
+	return floorPlan, nil
+}
+
+func (r *memoryFloorPlanRepository) List(ctx context.Context) ([]models.FloorPlan, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedByCreation(r.db.floorPlans, func(f models.FloorPlan) time.Time { return f.CreatedAt }), nil
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type FoodRepository interface {
+	Create(ctx context.Context, food models.Food) error
+	Update(ctx context.Context, foodId string, update models.UpdateFoodDto) error
+	Delete(ctx context.Context, foodId string) error
+	Get(ctx context.Context, foodId string) (models.Food, error)
+	// List returns up to limit foods created strictly after the given time,
+	// oldest first. A zero time lists from the beginning.
+	List(ctx context.Context, after time.Time, limit int64) ([]models.Food, error)
+	// CountByTaxRate counts the foods charged the tax rate taxRateId.
+	CountByTaxRate(ctx context.Context, taxRateId string) (int64, error)
+}
+
+type mongoFoodRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoFoodRepository) Create(ctx context.Context, food models.Food) error {
+	_, err := r.collection.InsertOne(ctx, food)
+	return err
+}
+
+func (r *mongoFoodRepository) Update(ctx context.Context, foodId string, update models.UpdateFoodDto) error {
+	updateFields := bson.M{
+		"name":       update.Name,
+		"price":      update.Price,
+		"foodImage":  update.FoodImage,
+		"menuId":     update.MenuId,
+		"station":    update.Station,
+		"sizePrices": update.SizePrices,
+		"taxRateIds": update.TaxRateIds,
+	}
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+
+	for k, v := range updateFields {
+		if !utils.IsNil(v) {
+			updateObj[k] = v
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"foodId": foodId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoFoodRepository) Delete(ctx context.Context, foodId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"foodId": foodId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoFoodRepository) Get(ctx context.Context, foodId string) (models.Food, error) {
+	var food models.Food
+	err := r.collection.FindOne(ctx, bson.M{"foodId": foodId}).Decode(&food)
+	return food, mongoErr(err)
+}
+
+func (r *mongoFoodRepository) List(ctx context.Context, after time.Time, limit int64) ([]models.Food, error) {
+	filter := bson.M{}
+	if !after.IsZero() {
+		filter = bson.M{"createdAt": bson.M{"$gt": after}}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetLimit(limit)
+
+	result, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	foods := make([]models.Food, 0)
+	if err := result.All(ctx, &foods); err != nil {
+		return nil, err
+	}
+	return foods, nil
+}
+
+func (r *mongoFoodRepository) CountByTaxRate(ctx context.Context, taxRateId string) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"taxRateIds": taxRateId})
+}
+
+type memoryFoodRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryFoodRepository) Create(ctx context.Context, food models.Food) error {
+	defer r.db.lock(ctx)()
+
+	r.db.foods[food.FoodId] = food
+	return nil
+}
+
+func (r *memoryFoodRepository) Update(ctx context.Context, foodId string, update models.UpdateFoodDto) error {
+	defer r.db.lock(ctx)()
+
+	food, ok := r.db.foods[foodId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.Name != nil {
+		food.Name = update.Name
+	}
+	if update.Price != nil {
+		food.Price = update.Price
+	}
+	if update.FoodImage != nil {
+		food.FoodImage = update.FoodImage
+	}
+	if update.MenuId != nil {
+		food.MenuId = update.MenuId
+	}
+	if update.Station != nil {
+		food.Station = update.Station
+	}
+	if update.SizePrices != nil {
+		food.SizePrices = update.SizePrices
+	}
+	if update.TaxRateIds != nil {
+		food.TaxRateIds = update.TaxRateIds
+	}
+	food.UpdatedAt = time.Now().UTC()
+
+	r.db.foods[foodId] = food
+	return nil
+}
+
+func (r *memoryFoodRepository) Delete(ctx context.Context, foodId string) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.foods[foodId]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.foods, foodId)
+	return nil
+}
+
+func (r *memoryFoodRepository) Get(ctx context.Context, foodId string) (models.Food, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	food, ok := r.db.foods[foodId]
+	if !ok {
+		return models.Food{}, ErrNotFound
+	}
+	return food, nil
+}
+
+func (r *memoryFoodRepository) List(ctx context.Context, after time.Time, limit int64) ([]models.Food, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	foods := make([]models.Food, 0)
+	for _, food := range sortedByCreation(r.db.foods, func(f models.Food) time.Time { return f.CreatedAt }) {
+		if int64(len(foods)) == limit {
+			break
+		}
+		if food.CreatedAt.After(after) {
+			foods = append(foods, food)
+		}
+	}
+	return foods, nil
+}
+
+func (r *memoryFoodRepository) CountByTaxRate(ctx context.Context, taxRateId string) (int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var count int64
+	for _, food := range r.db.foods {
+		if slices.Contains(food.TaxRateIds, taxRateId) {
+			count++
+		}
+	}
+	return count, nil
+}
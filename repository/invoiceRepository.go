@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type InvoiceRepository interface {
+	Create(ctx context.Context, invoice models.Invoice) error
+	// Update overwrites the payment method and status, and the totals,
+	// ledger amounts and void when given, and returns the invoice as stored after the update.
+	Update(ctx context.Context, invoiceId string, update models.UpdateInvoiceDto) (models.Invoice, error)
+	Get(ctx context.Context, invoiceId string) (models.Invoice, error)
+	List(ctx context.Context) ([]models.Invoice, error)
+	// ListByOrder returns the invoices of an order, oldest first.
+	ListByOrder(ctx context.Context, orderId string) ([]models.Invoice, error)
+	// ListBetween returns the invoices created from from up to but not
+	// including to, oldest first.
+	ListBetween(ctx context.Context, from, to time.Time) ([]models.Invoice, error)
+}
+
+type mongoInvoiceRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoInvoiceRepository) Create(ctx context.Context, invoice models.Invoice) error {
+	_, err := r.collection.InsertOne(ctx, invoice)
+	return err
+}
+
+func (r *mongoInvoiceRepository) Update(ctx context.Context, invoiceId string, update models.UpdateInvoiceDto) (models.Invoice, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	fields := bson.M{
+		"paymentMethod": update.PaymentMethod,
+		"paymentStatus": update.PaymentStatus,
+		"updatedAt":     time.Now().UTC(),
+	}
+	if update.Totals != nil {
+		fields["totals"] = update.Totals
+	}
+	if update.AmountPaid != nil {
+		fields["amountPaid"] = update.AmountPaid
+	}
+	if update.AmountRefunded != nil {
+		fields["amountRefunded"] = update.AmountRefunded
+	}
+	if update.Tips != nil {
+		fields["tips"] = update.Tips
+	}
+	if update.Void != nil {
+		fields["void"] = update.Void
+	}
+	updateObj := bson.M{"$set": fields}
+
+	var invoice models.Invoice
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"invoiceId": invoiceId}, updateObj, opts).Decode(&invoice)
+	return invoice, mongoErr(err)
+}
+
+func (r *mongoInvoiceRepository) Get(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.collection.FindOne(ctx, bson.M{"invoiceId": invoiceId}).Decode(&invoice)
+	return invoice, mongoErr(err)
+}
+
+func (r *mongoInvoiceRepository) List(ctx context.Context) ([]models.Invoice, error) {
+	result, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	invoices := make([]models.Invoice, 0)
+	if err := result.All(ctx, &invoices); err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (r *mongoInvoiceRepository) ListByOrder(ctx context.Context, orderId string) ([]models.Invoice, error) {
+	return r.find(ctx, bson.M{"orderId": orderId})
+}
+
+func (r *mongoInvoiceRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.Invoice, error) {
+	return r.find(ctx, bson.M{"createdAt": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (r *mongoInvoiceRepository) find(ctx context.Context, filter bson.M) ([]models.Invoice, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	result, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices := make([]models.Invoice, 0)
+	if err := result.All(ctx, &invoices); err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+type memoryInvoiceRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryInvoiceRepository) Create(ctx context.Context, invoice models.Invoice) error {
+	defer r.db.lock(ctx)()
+
+	r.db.invoices[invoice.InvoiceId] = invoice
+	return nil
+}
+
+func (r *memoryInvoiceRepository) Update(ctx context.Context, invoiceId string, update models.UpdateInvoiceDto) (models.Invoice, error) {
+	defer r.db.lock(ctx)()
+
+	invoice, ok := r.db.invoices[invoiceId]
+	if !ok {
+		return models.Invoice{}, ErrNotFound
+	}
+	invoice.PaymentMethod = update.PaymentMethod
+	invoice.PaymentStatus = update.PaymentStatus
+	if update.Totals != nil {
+		invoice.Totals = update.Totals
+	}
+	if update.AmountPaid != nil {
+		invoice.AmountPaid = *update.AmountPaid
+	}
+	if update.AmountRefunded != nil {
+		invoice.AmountRefunded = *update.AmountRefunded
+	}
+	if update.Tips != nil {
+		invoice.Tips = *update.Tips
+	}
+	if update.Void != nil {
+		invoice.Void = update.Void
+	}
+	invoice.UpdatedAt = time.Now().UTC()
+
+	r.db.invoices[invoiceId] = invoice
+	return invoice, nil
+}
+
+func (r *memoryInvoiceRepository) Get(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	invoice, ok := r.db.invoices[invoiceId]
+	if !ok {
+		return models.Invoice{}, ErrNotFound
+	}
+	return invoice, nil
+}
+
+func (r *memoryInvoiceRepository) List(ctx context.Context) ([]models.Invoice, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedByCreation(r.db.invoices, func(i models.Invoice) time.Time { return i.CreatedAt }), nil
+}
+
+func (r *memoryInvoiceRepository) ListByOrder(ctx context.Context, orderId string) ([]models.Invoice, error) {
+	return r.filter(func(i models.Invoice) bool { return i.OrderId == orderId }), nil
+}
+
+func (r *memoryInvoiceRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.Invoice, error) {
+	return r.filter(func(i models.Invoice) bool { return !i.CreatedAt.Before(from) && i.CreatedAt.Before(to) }), nil
+}
+
+func (r *memoryInvoiceRepository) filter(keep func(models.Invoice) bool) []models.Invoice {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	invoices := make([]models.Invoice, 0)
+	for _, invoice := range sortedByCreation(r.db.invoices, func(i models.Invoice) time.Time { return i.CreatedAt }) {
+		if keep(invoice) {
+			invoices = append(invoices, invoice)
+		}
+	}
+	return invoices
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jrskg/go-restaurant/events"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+// memoryDB holds every collection of the in-memory backend behind a single
+// lock so that joins such as ItemsByOrder see a consistent view. Writes
+// outside a transaction wait for the running one on txMu, so that rolling
+// it back cannot discard them.
+type memoryDB struct {
+	mu   sync.RWMutex
+	txMu sync.Mutex
+
+	foods         map[string]models.Food
+	menus         map[string]models.Menu
+	tables        map[string]models.Table
+	orders        map[string]models.Order
+	orderItems    map[string]models.OrderItem
+	invoices      map[string]models.Invoice
+	users         map[string]models.User
+	revokedTokens map[string]time.Time
+	taxRates      map[string]models.TaxRate
+	payments      map[string]models.Payment
+	promotions    map[string]models.Promotion
+	sequences     map[string]int64
+	drawers       map[string]models.Drawer
+	businessDays  map[string]models.ZReport
+	reservations  map[string]models.Reservation
+	floorPlans    map[string]models.FloorPlan
+	sections      map[string]models.Section
+	shifts        map[string]models.Shift
+	waitlist      map[string]models.WaitlistEntry
+}
+
+// memoryTxKey marks the context of a running in-memory transaction.
+type memoryTxKey struct{}
+
+// lock takes the write lock for a write made with ctx and returns its
+// release.
+func (db *memoryDB) lock(ctx context.Context) func() {
+	inTransaction := ctx.Value(memoryTxKey{}) == db
+	if !inTransaction {
+		db.txMu.Lock()
+	}
+	db.mu.Lock()
+	return func() {
+		db.mu.Unlock()
+		if !inTransaction {
+			db.txMu.Unlock()
+		}
+	}
+}
+
+func (db *memoryDB) snapshot() *memoryDB {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return &memoryDB{
+		foods:         maps.Clone(db.foods),
+		menus:         maps.Clone(db.menus),
+		tables:        maps.Clone(db.tables),
+		orders:        maps.Clone(db.orders),
+		orderItems:    maps.Clone(db.orderItems),
+		invoices:      maps.Clone(db.invoices),
+		users:         maps.Clone(db.users),
+		revokedTokens: maps.Clone(db.revokedTokens),
+		taxRates:      maps.Clone(db.taxRates),
+		payments:      maps.Clone(db.payments),
+		promotions:    maps.Clone(db.promotions),
+		sequences:     maps.Clone(db.sequences),
+		drawers:       maps.Clone(db.drawers),
+		businessDays:  maps.Clone(db.businessDays),
+		reservations:  maps.Clone(db.reservations),
+		floorPlans:    maps.Clone(db.floorPlans),
+		sections:      maps.Clone(db.sections),
+		shifts:        maps.Clone(db.shifts),
+		waitlist:      maps.Clone(db.waitlist),
+	}
+}
+
+func (db *memoryDB) restore(s *memoryDB) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.foods = s.foods
+	db.menus = s.menus
+	db.tables = s.tables
+	db.orders = s.orders
+	db.orderItems = s.orderItems
+	db.invoices = s.invoices
+	db.users = s.users
+	db.revokedTokens = s.revokedTokens
+	db.taxRates = s.taxRates
+	db.payments = s.payments
+	db.promotions = s.promotions
+	db.sequences = s.sequences
+	db.drawers = s.drawers
+	db.businessDays = s.businessDays
+	db.reservations = s.reservations
+	db.floorPlans = s.floorPlans
+	db.sections = s.sections
+	db.shifts = s.shifts
+	db.waitlist = s.waitlist
+}
+
+// NewMemoryStore returns a Store that keeps everything in process memory.
+// It is meant for tests and for running the service without MongoDB.
+func NewMemoryStore() *Store {
+	db := &memoryDB{
+		foods:         map[string]models.Food{},
+		menus:         map[string]models.Menu{},
+		tables:        map[string]models.Table{},
+		orders:        map[string]models.Order{},
+		orderItems:    map[string]models.OrderItem{},
+		invoices:      map[string]models.Invoice{},
+		users:         map[string]models.User{},
+		revokedTokens: map[string]time.Time{},
+		taxRates:      map[string]models.TaxRate{},
+		payments:      map[string]models.Payment{},
+		promotions:    map[string]models.Promotion{},
+		sequences:     map[string]int64{},
+		drawers:       map[string]models.Drawer{},
+		businessDays:  map[string]models.ZReport{},
+		reservations:  map[string]models.Reservation{},
+		floorPlans:    map[string]models.FloorPlan{},
+		sections:      map[string]models.Section{},
+		shifts:        map[string]models.Shift{},
+		waitlist:      map[string]models.WaitlistEntry{},
+	}
+
+	return &Store{
+		Foods:         &memoryFoodRepository{db: db},
+		Menus:         &memoryMenuRepository{db: db},
+		Tables:        &memoryTableRepository{db: db},
+		Orders:        &memoryOrderRepository{db: db},
+		OrderItems:    &memoryOrderItemRepository{db: db},
+		Invoices:      &memoryInvoiceRepository{db: db},
+		Users:         &memoryUserRepository{db: db},
+		RevokedTokens: &memoryRevokedTokenRepository{db: db},
+		TaxRates:      &memoryTaxRateRepository{db: db},
+		Payments:      &memoryPaymentRepository{db: db},
+		Promotions:    &memoryPromotionRepository{db: db},
+		Sequences:     &memorySequenceRepository{db: db},
+		Drawers:       &memoryDrawerRepository{db: db},
+		BusinessDays:  &memoryBusinessDayRepository{db: db},
+		Reservations:  &memoryReservationRepository{db: db},
+		FloorPlans:    &memoryFloorPlanRepository{db: db},
+		Sections:      &memorySectionRepository{db: db},
+		Shifts:        &memoryShiftRepository{db: db},
+		Waitlist:      &memoryWaitlistRepository{db: db},
+		Events:        events.NewBroker(eventHistorySize),
+
+		withTransaction: func(ctx context.Context, fn func(ctx context.Context) error) error {
+			db.txMu.Lock()
+			defer db.txMu.Unlock()
+
+			saved := db.snapshot()
+			if err := fn(context.WithValue(ctx, memoryTxKey{}, db)); err != nil {
+				db.restore(saved)
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// sortedByCreation returns the values of m ordered the way Mongo returns
+// documents inserted one after another. Keys are ObjectID hex strings, so
+// they break ties between documents created within the same instant.
+func sortedByCreation[T any](m map[string]T, createdAt func(T) time.Time) []T {
+	keys := slices.Collect(maps.Keys(m))
+	slices.SortFunc(keys, func(a, b string) int {
+		if c := createdAt(m[a]).Compare(createdAt(m[b])); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+
+	values := make([]T, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, m[k])
+	}
+	return values
+}
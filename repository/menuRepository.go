@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type MenuRepository interface {
+	Create(ctx context.Context, menu models.Menu) error
+	Update(ctx context.Context, menuId string, update models.MenuUpdateDto) error
+	Delete(ctx context.Context, menuId string) error
+	Get(ctx context.Context, menuId string) (models.Menu, error)
+	List(ctx context.Context) ([]models.Menu, error)
+	Exists(ctx context.Context, menuId string) (bool, error)
+}
+
+type mongoMenuRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoMenuRepository) Create(ctx context.Context, menu models.Menu) error {
+	_, err := r.collection.InsertOne(ctx, menu)
+	return err
+}
+
+func (r *mongoMenuRepository) Update(ctx context.Context, menuId string, update models.MenuUpdateDto) error {
+	updateFields := bson.M{
+		"name":      update.Name,
+		"category":  update.Category,
+		"startDate": update.StartDate,
+		"endDate":   update.EndDate,
+	}
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+	for k, v := range updateFields {
+		if !utils.IsNil(v) {
+			updateObj[k] = v
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"menuId": menuId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount < 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoMenuRepository) Delete(ctx context.Context, menuId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"menuId": menuId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount < 1 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoMenuRepository) Get(ctx context.Context, menuId string) (models.Menu, error) {
+	var menu models.Menu
+	err := r.collection.FindOne(ctx, bson.M{"menuId": menuId}).Decode(&menu)
+	return menu, mongoErr(err)
+}
+
+func (r *mongoMenuRepository) List(ctx context.Context) ([]models.Menu, error) {
+	result, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	menus := make([]models.Menu, 0)
+	if err := result.All(ctx, &menus); err != nil {
+		return nil, err
+	}
+	return menus, nil
+}
+
+func (r *mongoMenuRepository) Exists(ctx context.Context, menuId string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"menuId": menuId})
+	return count > 0, err
+}
+
+type memoryMenuRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryMenuRepository) Create(ctx context.Context, menu models.Menu) error {
+	defer r.db.lock(ctx)()
+
+	r.db.menus[menu.MenuId] = menu
+	return nil
+}
+
+func (r *memoryMenuRepository) Update(ctx context.Context, menuId string, update models.MenuUpdateDto) error {
+	defer r.db.lock(ctx)()
+
+	menu, ok := r.db.menus[menuId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.Name != nil {
+		menu.Name = *update.Name
+	}
+	if update.Category != nil {
+		menu.Category = *update.Category
+	}
+	if update.StartDate != nil {
+		menu.StartDate = update.StartDate
+	}
+	if update.EndDate != nil {
+		menu.EndDate = update.EndDate
+	}
+	menu.UpdatedAt = time.Now().UTC()
+
+	r.db.menus[menuId] = menu
+	return nil
+}
+
+func (r *memoryMenuRepository) Delete(ctx context.Context, menuId string) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.menus[menuId]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.menus, menuId)
+	return nil
+}
+
+func (r *memoryMenuRepository) Get(ctx context.Context, menuId string) (models.Menu, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	menu, ok := r.db.menus[menuId]
+	if !ok {
+		return models.Menu{}, ErrNotFound
+	}
+	return menu, nil
+}
+
+func (r *memoryMenuRepository) List(ctx context.Context) ([]models.Menu, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedByCreation(r.db.menus, func(m models.Menu) time.Time { return m.CreatedAt }), nil
+}
+
+func (r *memoryMenuRepository) Exists(ctx context.Context, menuId string) (bool, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	_, ok := r.db.menus[menuId]
+	return ok, nil
+}
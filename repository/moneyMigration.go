@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/database"
+	"github.com/jrskg/go-restaurant/money"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// moneyFields are the price fields of each collection that older versions
+// stored as floating point numbers. A field ending in ".*" is a map of
+// prices.
+var moneyFields = map[string][]string{
+	constants.FOOD_COLLECTION:       {"price", "sizePrices.*"},
+	constants.ORDER_ITEM_COLLECTION: {"unitPrice", "lineTotal"},
+}
+
+// MigrateMoney rewrites prices stored as floats into integer cents of the
+// store currency and stamps documents without a currency with it. Floats are
+// converted through their shortest decimal form, so 4.35 becomes 435 rather
+// than 434. Documents already migrated are skipped, so it is safe to run
+// again. It returns how many documents were updated per collection.
+func MigrateMoney(ctx context.Context, client *mongo.Client) (map[string]int, error) {
+	migrated := map[string]int{}
+	for collectionName, fields := range moneyFields {
+		collection := database.OpenCollection(client, collectionName)
+
+		cursor, err := collection.Find(ctx, bson.M{"currency": bson.M{"$exists": false}})
+		if err != nil {
+			return migrated, err
+		}
+
+		for cursor.Next(ctx) {
+			var doc bson.M
+			if err := cursor.Decode(&doc); err != nil {
+				cursor.Close(ctx)
+				return migrated, err
+			}
+
+			set := bson.M{"currency": money.Currency()}
+			for _, field := range fields {
+				if mapField, ok := strings.CutSuffix(field, ".*"); ok {
+					prices, _ := doc[mapField].(bson.M)
+					for size, price := range prices {
+						if f, ok := price.(float64); ok {
+							set[mapField+"."+size] = money.FromFloat(f)
+						}
+					}
+					continue
+				}
+				if f, ok := doc[field].(float64); ok {
+					set[field] = money.FromFloat(f)
+				}
+			}
+
+			if _, err := collection.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, bson.M{"$set": set}); err != nil {
+				cursor.Close(ctx)
+				return migrated, err
+			}
+			migrated[collectionName]++
+		}
+		if err := cursor.Err(); err != nil {
+			cursor.Close(ctx)
+			return migrated, err
+		}
+		cursor.Close(ctx)
+	}
+	return migrated, nil
+}
@@ -0,0 +1,594 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type OrderItemRepository interface {
+	CreateMany(ctx context.Context, orderItems []models.OrderItem) error
+	Update(ctx context.Context, orderItemId string, update models.UpdateOrderItemDto) error
+	Get(ctx context.Context, orderItemId string) (models.OrderItem, error)
+	List(ctx context.Context) ([]models.OrderItem, error)
+	DeleteByOrder(ctx context.Context, orderId string) error
+	// MoveToOrder moves the items orderItemIds of order fromOrderId, or all
+	// of its items, voided ones included, when none are given, to order
+	// toOrderId and returns them as moved. It returns ErrNotFound when one
+	// of orderItemIds is not an item of fromOrderId.
+	MoveToOrder(ctx context.Context, fromOrderId, toOrderId string, orderItemIds []string) ([]models.OrderItem, error)
+	// Void takes an item off its order. Voided items are kept but left out
+	// of ItemsByOrder.
+	Void(ctx context.Context, orderItemId string, void models.Reversal) error
+	// ItemsByOrder joins the items of an order that are not voided with
+	// their food, order and table. The result is empty when the order has
+	// no such items.
+	ItemsByOrder(ctx context.Context, orderId string) ([]models.OrderSummary, error)
+	// SalesBetween joins the items created from from up to but not
+	// including to that are not voided and whose order is paid with their
+	// food and its menu, oldest first.
+	SalesBetween(ctx context.Context, from, to time.Time) ([]models.SoldItem, error)
+}
+
+type mongoOrderItemRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoOrderItemRepository) CreateMany(ctx context.Context, orderItems []models.OrderItem) error {
+	_, err := r.collection.InsertMany(ctx, orderItems)
+	return err
+}
+
+func (r *mongoOrderItemRepository) Update(ctx context.Context, orderItemId string, update models.UpdateOrderItemDto) error {
+	fieldsToUpdate := bson.M{
+		"unitPrice": update.UnitPrice,
+		"count":     update.Count,
+		"seat":      update.Seat,
+		"lineTotal": update.LineTotal,
+		"quantity":  update.Quantity,
+		"foodId":    update.FoodId,
+	}
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+	for k, v := range fieldsToUpdate {
+		if !utils.IsNil(v) {
+			updateObj[k] = v
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"orderItemId": orderItemId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoOrderItemRepository) Get(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	var orderItem models.OrderItem
+	err := r.collection.FindOne(ctx, bson.M{"orderItemId": orderItemId}).Decode(&orderItem)
+	return orderItem, mongoErr(err)
+}
+
+func (r *mongoOrderItemRepository) List(ctx context.Context) ([]models.OrderItem, error) {
+	result, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	orderItems := make([]models.OrderItem, 0)
+	if err := result.All(ctx, &orderItems); err != nil {
+		return nil, err
+	}
+	return orderItems, nil
+}
+
+func (r *mongoOrderItemRepository) DeleteByOrder(ctx context.Context, orderId string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"orderId": orderId})
+	return err
+}
+
+func (r *mongoOrderItemRepository) MoveToOrder(ctx context.Context, fromOrderId, toOrderId string, orderItemIds []string) ([]models.OrderItem, error) {
+	filter := bson.M{"orderId": fromOrderId}
+	if len(orderItemIds) > 0 {
+		filter["orderItemId"] = bson.M{"$in": orderItemIds}
+	}
+	result, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	moved := make([]models.OrderItem, 0)
+	if err := result.All(ctx, &moved); err != nil {
+		return nil, err
+	}
+	if len(orderItemIds) > 0 && len(moved) != len(orderItemIds) {
+		return nil, ErrNotFound
+	}
+	if len(moved) == 0 {
+		return moved, nil
+	}
+
+	now := time.Now().UTC()
+	ids := make([]string, 0, len(moved))
+	for i := range moved {
+		moved[i].OrderId = toOrderId
+		moved[i].UpdatedAt = now
+		ids = append(ids, moved[i].OrderItemId)
+	}
+	update := bson.M{"$set": bson.M{"orderId": toOrderId, "updatedAt": now}}
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"orderItemId": bson.M{"$in": ids}}, update); err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+func (r *mongoOrderItemRepository) Void(ctx context.Context, orderItemId string, void models.Reversal) error {
+	updateObj := bson.M{"$set": bson.M{"void": void, "updatedAt": time.Now().UTC()}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"orderItemId": orderItemId}, updateObj)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoOrderItemRepository) ItemsByOrder(ctx context.Context, orderId string) ([]models.OrderSummary, error) {
+	matchStage := bson.D{
+		{Key: "$match", Value: bson.D{
+			{Key: "orderId", Value: orderId},
+			{Key: "void", Value: bson.D{{Key: "$exists", Value: false}}},
+		}},
+	}
+	lookupFoodStage := bson.D{
+		{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: constants.FOOD_COLLECTION},
+			{Key: "localField", Value: "foodId"},
+			{Key: "foreignField", Value: "foodId"},
+			{Key: "as", Value: "food"},
+		}},
+	}
+	unwindFoodStage := bson.D{
+		{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$food"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}},
+	}
+	lookupOrderStage := bson.D{
+		{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: constants.ORDER_COLLECTION},
+			{Key: "localField", Value: "orderId"},
+			{Key: "foreignField", Value: "orderId"},
+			{Key: "as", Value: "order"},
+		}},
+	}
+	unwindOrderStage := bson.D{
+		{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$order"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}},
+	}
+	lookupTableStage := bson.D{
+		{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: constants.TABLE_COLLECTION},
+			{Key: "localField", Value: "order.tableId"},
+			{Key: "foreignField", Value: "tableId"},
+			{Key: "as", Value: "table"},
+		}},
+	}
+	unwindTableStage := bson.D{
+		{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$table"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}},
+	}
+	// Items carry the price they were sold at; the catalog price is only a
+	// fallback for items stored before prices were snapshotted, and items
+	// stored before counts existed are one portion each.
+	unitPrice := bson.D{{Key: "$ifNull", Value: bson.A{"$unitPrice", "$food.price"}}}
+	count := bson.D{{Key: "$ifNull", Value: bson.A{"$count", 1}}}
+	projectStage := bson.D{
+		{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "amount", Value: bson.D{{Key: "$ifNull", Value: bson.A{
+				"$lineTotal",
+				bson.D{{Key: "$multiply", Value: bson.A{unitPrice, count}}},
+			}}}},
+			{Key: "unitPrice", Value: unitPrice},
+			{Key: "count", Value: count},
+			{Key: "foodId", Value: 1},
+			{Key: "orderItemId", Value: 1},
+			{Key: "seat", Value: 1},
+			{Key: "foodName", Value: "$food.name"},
+			{Key: "foodImage", Value: "$food.foodImage"},
+			{Key: "totalCount", Value: 1},
+			{Key: "tableNumber", Value: "$table.tableNumber"},
+			{Key: "tableId", Value: "$table.tableId"},
+			{Key: "orderId", Value: "$order.orderId"},
+			{Key: "quantity", Value: 1},
+			{Key: "createdAt", Value: 1},
+		}},
+	}
+	groupStage := bson.D{
+		{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "orderId", Value: "$orderId"},
+				{Key: "tableId", Value: "$tableId"},
+				{Key: "tableNumber", Value: "$tableNumber"},
+			}},
+			{Key: "paymentDue", Value: bson.D{
+				{Key: "$sum", Value: "$amount"},
+			}},
+			{Key: "totalCount", Value: bson.D{
+				{Key: "$sum", Value: "$count"},
+			}},
+			{Key: "orderItems", Value: bson.D{
+				{Key: "$push", Value: "$$ROOT"},
+			}},
+		}},
+	}
+	// Items are pushed in the order they were taken, as the memory backend
+	// lists them.
+	sortStage := bson.D{
+		{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}},
+	}
+	projectStage2 := bson.D{
+		{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "paymentDue", Value: 1},
+			{Key: "totalCount", Value: 1},
+			{Key: "tableNumber", Value: "$_id.tableNumber"},
+			{Key: "orderItems", Value: 1},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		matchStage,
+		lookupFoodStage,
+		unwindFoodStage,
+		lookupOrderStage,
+		unwindOrderStage,
+		lookupTableStage,
+		unwindTableStage,
+		sortStage,
+		projectStage,
+		groupStage,
+		projectStage2,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]models.OrderSummary, 0)
+	if err = cursor.All(ctx, &summaries); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+func (r *mongoOrderItemRepository) SalesBetween(ctx context.Context, from, to time.Time) ([]models.SoldItem, error) {
+	matchStage := bson.D{
+		{Key: "$match", Value: bson.D{
+			{Key: "createdAt", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}},
+			{Key: "void", Value: bson.D{{Key: "$exists", Value: false}}},
+		}},
+	}
+	lookupOrderStage := bson.D{
+		{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: constants.ORDER_COLLECTION},
+			{Key: "localField", Value: "orderId"},
+			{Key: "foreignField", Value: "orderId"},
+			{Key: "as", Value: "order"},
+		}},
+	}
+	unwindOrderStage := bson.D{{Key: "$unwind", Value: "$order"}}
+	matchPaidStage := bson.D{
+		{Key: "$match", Value: bson.D{{Key: "order.status", Value: constants.ORDER_STATUS_PAID}}},
+	}
+	lookupFoodStage := bson.D{
+		{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: constants.FOOD_COLLECTION},
+			{Key: "localField", Value: "foodId"},
+			{Key: "foreignField", Value: "foodId"},
+			{Key: "as", Value: "food"},
+		}},
+	}
+	unwindFoodStage := bson.D{
+		{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$food"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}},
+	}
+	lookupMenuStage := bson.D{
+		{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: constants.MENU_COLLECTION},
+			{Key: "localField", Value: "food.menuId"},
+			{Key: "foreignField", Value: "menuId"},
+			{Key: "as", Value: "menu"},
+		}},
+	}
+	unwindMenuStage := bson.D{
+		{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$menu"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}},
+	}
+	unitPrice := bson.D{{Key: "$ifNull", Value: bson.A{"$unitPrice", "$food.price"}}}
+	count := bson.D{{Key: "$ifNull", Value: bson.A{"$count", 1}}}
+	projectStage := bson.D{
+		{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "orderItemId", Value: 1},
+			{Key: "orderId", Value: 1},
+			{Key: "foodId", Value: 1},
+			{Key: "foodName", Value: "$food.name"},
+			{Key: "menuId", Value: "$food.menuId"},
+			{Key: "category", Value: "$menu.category"},
+			{Key: "count", Value: count},
+			{Key: "amount", Value: bson.D{{Key: "$ifNull", Value: bson.A{
+				"$lineTotal",
+				bson.D{{Key: "$multiply", Value: bson.A{unitPrice, count}}},
+			}}}},
+			{Key: "createdAt", Value: 1},
+		}},
+	}
+	sortStage := bson.D{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: 1}, {Key: "orderItemId", Value: 1}}}}
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		matchStage,
+		lookupOrderStage,
+		unwindOrderStage,
+		matchPaidStage,
+		lookupFoodStage,
+		unwindFoodStage,
+		lookupMenuStage,
+		unwindMenuStage,
+		projectStage,
+		sortStage,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sold := make([]models.SoldItem, 0)
+	if err = cursor.All(ctx, &sold); err != nil {
+		return nil, err
+	}
+	return sold, nil
+}
+
+type memoryOrderItemRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryOrderItemRepository) CreateMany(ctx context.Context, orderItems []models.OrderItem) error {
+	defer r.db.lock(ctx)()
+
+	for _, orderItem := range orderItems {
+		r.db.orderItems[orderItem.OrderItemId] = orderItem
+	}
+	return nil
+}
+
+func (r *memoryOrderItemRepository) Update(ctx context.Context, orderItemId string, update models.UpdateOrderItemDto) error {
+	defer r.db.lock(ctx)()
+
+	orderItem, ok := r.db.orderItems[orderItemId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.UnitPrice != nil {
+		orderItem.UnitPrice = update.UnitPrice
+	}
+	if update.Count != nil {
+		orderItem.Count = update.Count
+	}
+	if update.Seat != nil {
+		orderItem.Seat = update.Seat
+	}
+	if update.LineTotal != nil {
+		orderItem.LineTotal = update.LineTotal
+	}
+	if update.Quantity != nil {
+		orderItem.Quantity = update.Quantity
+	}
+	if update.FoodId != nil {
+		orderItem.FoodId = *update.FoodId
+	}
+	orderItem.UpdatedAt = time.Now().UTC()
+
+	r.db.orderItems[orderItemId] = orderItem
+	return nil
+}
+
+func (r *memoryOrderItemRepository) Get(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	orderItem, ok := r.db.orderItems[orderItemId]
+	if !ok {
+		return models.OrderItem{}, ErrNotFound
+	}
+	return orderItem, nil
+}
+
+func (r *memoryOrderItemRepository) List(ctx context.Context) ([]models.OrderItem, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedByCreation(r.db.orderItems, func(o models.OrderItem) time.Time { return o.CreatedAt }), nil
+}
+
+func (r *memoryOrderItemRepository) DeleteByOrder(ctx context.Context, orderId string) error {
+	defer r.db.lock(ctx)()
+
+	for id, orderItem := range r.db.orderItems {
+		if orderItem.OrderId == orderId {
+			delete(r.db.orderItems, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryOrderItemRepository) MoveToOrder(ctx context.Context, fromOrderId, toOrderId string, orderItemIds []string) ([]models.OrderItem, error) {
+	defer r.db.lock(ctx)()
+
+	var moved []models.OrderItem
+	if len(orderItemIds) > 0 {
+		moved = make([]models.OrderItem, 0, len(orderItemIds))
+		for _, orderItemId := range orderItemIds {
+			orderItem, ok := r.db.orderItems[orderItemId]
+			if !ok || orderItem.OrderId != fromOrderId {
+				return nil, ErrNotFound
+			}
+			moved = append(moved, orderItem)
+		}
+	} else {
+		moved = make([]models.OrderItem, 0)
+		for _, orderItem := range sortedByCreation(r.db.orderItems, func(o models.OrderItem) time.Time { return o.CreatedAt }) {
+			if orderItem.OrderId == fromOrderId {
+				moved = append(moved, orderItem)
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	for i := range moved {
+		moved[i].OrderId = toOrderId
+		moved[i].UpdatedAt = now
+		r.db.orderItems[moved[i].OrderItemId] = moved[i]
+	}
+	return moved, nil
+}
+
+func (r *memoryOrderItemRepository) Void(ctx context.Context, orderItemId string, void models.Reversal) error {
+	defer r.db.lock(ctx)()
+
+	orderItem, ok := r.db.orderItems[orderItemId]
+	if !ok {
+		return ErrNotFound
+	}
+	orderItem.Void = &void
+	orderItem.UpdatedAt = time.Now().UTC()
+
+	r.db.orderItems[orderItemId] = orderItem
+	return nil
+}
+
+func (r *memoryOrderItemRepository) ItemsByOrder(ctx context.Context, orderId string) ([]models.OrderSummary, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	summaries := make([]models.OrderSummary, 0)
+	var summary *models.OrderSummary
+
+	for _, orderItem := range sortedByCreation(r.db.orderItems, func(o models.OrderItem) time.Time { return o.CreatedAt }) {
+		if orderItem.OrderId != orderId || orderItem.Void != nil {
+			continue
+		}
+
+		detail := models.OrderItemDetail{
+			Quantity:    orderItem.Quantity,
+			UnitPrice:   orderItem.UnitPrice,
+			Amount:      orderItem.LineTotal,
+			Count:       itemCount(orderItem),
+			FoodId:      orderItem.FoodId,
+			OrderItemId: orderItem.OrderItemId,
+			Seat:        orderItem.Seat,
+			CreatedAt:   orderItem.CreatedAt,
+		}
+		if food, ok := r.db.foods[orderItem.FoodId]; ok {
+			if detail.UnitPrice == nil {
+				detail.UnitPrice = food.Price
+			}
+			detail.FoodName = food.Name
+			detail.FoodImage = food.FoodImage
+		}
+		if order, ok := r.db.orders[orderItem.OrderId]; ok {
+			detail.OrderId = order.OrderID
+			if table, ok := r.db.tables[order.TableId]; ok {
+				detail.TableId = table.TableId
+				detail.TableNumber = table.TableNumber
+			}
+		}
+
+		if detail.Amount == nil && detail.UnitPrice != nil {
+			lineTotal := detail.UnitPrice.Mul(detail.Count)
+			detail.Amount = &lineTotal
+		}
+
+		if summary == nil {
+			summary = &models.OrderSummary{TableNumber: detail.TableNumber}
+		}
+		if detail.Amount != nil {
+			summary.PaymentDue += *detail.Amount
+		}
+		summary.TotalCount += detail.Count
+		summary.OrderItems = append(summary.OrderItems, detail)
+	}
+
+	if summary != nil {
+		summaries = append(summaries, *summary)
+	}
+	return summaries, nil
+}
+
+func (r *memoryOrderItemRepository) SalesBetween(ctx context.Context, from, to time.Time) ([]models.SoldItem, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	sold := make([]models.SoldItem, 0)
+	for _, orderItem := range sortedByCreation(r.db.orderItems, func(o models.OrderItem) time.Time { return o.CreatedAt }) {
+		if orderItem.Void != nil || orderItem.CreatedAt.Before(from) || !orderItem.CreatedAt.Before(to) {
+			continue
+		}
+		if order, ok := r.db.orders[orderItem.OrderId]; !ok || order.Status != constants.ORDER_STATUS_PAID {
+			continue
+		}
+
+		item := models.SoldItem{
+			OrderItemId: orderItem.OrderItemId,
+			OrderId:     orderItem.OrderId,
+			FoodId:      orderItem.FoodId,
+			Count:       itemCount(orderItem),
+			CreatedAt:   orderItem.CreatedAt,
+		}
+		unitPrice := orderItem.UnitPrice
+		if food, ok := r.db.foods[orderItem.FoodId]; ok {
+			if unitPrice == nil {
+				unitPrice = food.Price
+			}
+			item.FoodName = food.Name
+			item.MenuId = food.MenuId
+			if food.MenuId != nil {
+				if menu, ok := r.db.menus[*food.MenuId]; ok {
+					item.Category = &menu.Category
+				}
+			}
+		}
+		switch {
+		case orderItem.LineTotal != nil:
+			item.Amount = *orderItem.LineTotal
+		case unitPrice != nil:
+			item.Amount = unitPrice.Mul(item.Count)
+		}
+		sold = append(sold, item)
+	}
+	return sold, nil
+}
+
+// itemCount is the number of portions on an order item; items stored before
+// counts existed are a single portion.
+func itemCount(orderItem models.OrderItem) int {
+	if orderItem.Count == nil {
+		return 1
+	}
+	return *orderItem.Count
+}
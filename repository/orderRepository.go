@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type OrderRepository interface {
+	Create(ctx context.Context, order models.Order) error
+	UpdateTable(ctx context.Context, orderId, tableId string) error
+	// Lock claims an order for the rest of a transaction, so that concurrent
+	// transactions billing or closing the same order conflict and one of
+	// them is retried against the other's writes.
+	Lock(ctx context.Context, orderId string) error
+	// UpdateStatus moves the order to change.Status only if it is still in
+	// status from, appending change to its history. It returns ErrNotFound
+	// when the order does not exist or has moved on in the meantime.
+	UpdateStatus(ctx context.Context, orderId, from string, change models.OrderStatusChange) (models.Order, error)
+	Delete(ctx context.Context, orderId string) error
+	Get(ctx context.Context, orderId string) (models.Order, error)
+	List(ctx context.Context) ([]models.Order, error)
+}
+
+type mongoOrderRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoOrderRepository) Create(ctx context.Context, order models.Order) error {
+	_, err := r.collection.InsertOne(ctx, order)
+	return err
+}
+
+func (r *mongoOrderRepository) UpdateTable(ctx context.Context, orderId, tableId string) error {
+	update := bson.M{"tableId": tableId, "updatedAt": time.Now().UTC()}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"orderId": orderId}, bson.M{"$set": update})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoOrderRepository) UpdateStatus(ctx context.Context, orderId, from string, change models.OrderStatusChange) (models.Order, error) {
+	filter := bson.M{"orderId": orderId, "status": from}
+	if from == constants.ORDER_STATUS_OPEN {
+		// Orders created before statuses existed have no status field.
+		filter["status"] = bson.M{"$in": bson.A{from, "", nil}}
+	}
+	update := bson.M{
+		"$set":  bson.M{"status": change.Status, "updatedAt": change.ChangedAt},
+		"$push": bson.M{"statusHistory": change},
+	}
+
+	var order models.Order
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&order)
+	return order, mongoErr(err)
+}
+
+func (r *mongoOrderRepository) Lock(ctx context.Context, orderId string) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"orderId": orderId}, bson.M{"$inc": bson.M{"lockVersion": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoOrderRepository) Delete(ctx context.Context, orderId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"orderId": orderId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoOrderRepository) Get(ctx context.Context, orderId string) (models.Order, error) {
+	var order models.Order
+	err := r.collection.FindOne(ctx, bson.M{"orderId": orderId}).Decode(&order)
+	return order, mongoErr(err)
+}
+
+func (r *mongoOrderRepository) List(ctx context.Context) ([]models.Order, error) {
+	result, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]models.Order, 0)
+	if err := result.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+type memoryOrderRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryOrderRepository) Create(ctx context.Context, order models.Order) error {
+	defer r.db.lock(ctx)()
+
+	r.db.orders[order.OrderID] = order
+	return nil
+}
+
+func (r *memoryOrderRepository) UpdateTable(ctx context.Context, orderId, tableId string) error {
+	defer r.db.lock(ctx)()
+
+	order, ok := r.db.orders[orderId]
+	if !ok {
+		return ErrNotFound
+	}
+	order.TableId = tableId
+	order.UpdatedAt = time.Now().UTC()
+
+	r.db.orders[orderId] = order
+	return nil
+}
+
+func (r *memoryOrderRepository) UpdateStatus(ctx context.Context, orderId, from string, change models.OrderStatusChange) (models.Order, error) {
+	defer r.db.lock(ctx)()
+
+	order, ok := r.db.orders[orderId]
+	if !ok || (order.Status != from && !(order.Status == "" && from == constants.ORDER_STATUS_OPEN)) {
+		return models.Order{}, ErrNotFound
+	}
+	order.Status = change.Status
+	order.StatusHistory = append(slices.Clone(order.StatusHistory), change)
+	order.UpdatedAt = change.ChangedAt
+
+	r.db.orders[orderId] = order
+	return order, nil
+}
+
+// Lock only checks that the order exists: memory transactions already run
+// one at a time.
+func (r *memoryOrderRepository) Lock(ctx context.Context, orderId string) error {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	if _, ok := r.db.orders[orderId]; !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *memoryOrderRepository) Delete(ctx context.Context, orderId string) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.orders[orderId]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.orders, orderId)
+	return nil
+}
+
+func (r *memoryOrderRepository) Get(ctx context.Context, orderId string) (models.Order, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	order, ok := r.db.orders[orderId]
+	if !ok {
+		return models.Order{}, ErrNotFound
+	}
+	return order, nil
+}
+
+func (r *memoryOrderRepository) List(ctx context.Context) ([]models.Order, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedByCreation(r.db.orders, func(o models.Order) time.Time { return o.CreatedAt }), nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// PaymentRepository is an append-only ledger: payments are never updated
+// or deleted once recorded.
+type PaymentRepository interface {
+	Create(ctx context.Context, payment models.Payment) error
+	// ListByInvoice returns the payments of an invoice, oldest first.
+	ListByInvoice(ctx context.Context, invoiceId string) ([]models.Payment, error)
+	// ListByOrder returns every ledger entry of an order, including voids of
+	// items that were never invoiced, oldest first.
+	ListByOrder(ctx context.Context, orderId string) ([]models.Payment, error)
+	// ListBetween returns the entries recorded from from up to but not
+	// including to, oldest first.
+	ListBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error)
+}
+
+type mongoPaymentRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoPaymentRepository) Create(ctx context.Context, payment models.Payment) error {
+	_, err := r.collection.InsertOne(ctx, payment)
+	return err
+}
+
+func (r *mongoPaymentRepository) ListByInvoice(ctx context.Context, invoiceId string) ([]models.Payment, error) {
+	return r.find(ctx, bson.M{"invoiceId": invoiceId})
+}
+
+func (r *mongoPaymentRepository) ListByOrder(ctx context.Context, orderId string) ([]models.Payment, error) {
+	return r.find(ctx, bson.M{"orderId": orderId})
+}
+
+func (r *mongoPaymentRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error) {
+	return r.find(ctx, bson.M{"createdAt": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (r *mongoPaymentRepository) find(ctx context.Context, filter bson.M) ([]models.Payment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	result, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]models.Payment, 0)
+	if err := result.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+type memoryPaymentRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryPaymentRepository) Create(ctx context.Context, payment models.Payment) error {
+	defer r.db.lock(ctx)()
+
+	r.db.payments[payment.PaymentId] = payment
+	return nil
+}
+
+func (r *memoryPaymentRepository) ListByInvoice(ctx context.Context, invoiceId string) ([]models.Payment, error) {
+	return r.filter(func(p models.Payment) bool { return p.InvoiceId == invoiceId }), nil
+}
+
+func (r *memoryPaymentRepository) ListByOrder(ctx context.Context, orderId string) ([]models.Payment, error) {
+	return r.filter(func(p models.Payment) bool { return p.OrderId == orderId }), nil
+}
+
+func (r *memoryPaymentRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.Payment, error) {
+	return r.filter(func(p models.Payment) bool { return !p.CreatedAt.Before(from) && p.CreatedAt.Before(to) }), nil
+}
+
+func (r *memoryPaymentRepository) filter(keep func(models.Payment) bool) []models.Payment {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	payments := make([]models.Payment, 0)
+	for _, payment := range sortedByCreation(r.db.payments, func(p models.Payment) time.Time { return p.CreatedAt }) {
+		if keep(payment) {
+			payments = append(payments, payment)
+		}
+	}
+	return payments
+}
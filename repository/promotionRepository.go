@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion models.Promotion) error
+	Update(ctx context.Context, promotionId string, update models.UpdatePromotionDto) error
+	Delete(ctx context.Context, promotionId string) error
+	Get(ctx context.Context, promotionId string) (models.Promotion, error)
+	List(ctx context.Context) ([]models.Promotion, error)
+	// FindByCoupon returns the promotion with the given coupon code, which
+	// is stored in upper case.
+	FindByCoupon(ctx context.Context, couponCode string) (models.Promotion, error)
+	// Redeem counts one use of a promotion. It returns false, and counts
+	// nothing, when the usage limit has been reached.
+	Redeem(ctx context.Context, promotionId string) (bool, error)
+	// Release gives back a use counted by Redeem.
+	Release(ctx context.Context, promotionId string) error
+}
+
+type mongoPromotionRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoPromotionRepository) Create(ctx context.Context, promotion models.Promotion) error {
+	_, err := r.collection.InsertOne(ctx, promotion)
+	return err
+}
+
+func (r *mongoPromotionRepository) Update(ctx context.Context, promotionId string, update models.UpdatePromotionDto) error {
+	updateFields := bson.M{
+		"name":       update.Name,
+		"active":     update.Active,
+		"stackable":  update.Stackable,
+		"usageLimit": update.UsageLimit,
+		"endsAt":     update.EndsAt,
+	}
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+	for k, v := range updateFields {
+		if !utils.IsNil(v) {
+			updateObj[k] = v
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"promotionId": promotionId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoPromotionRepository) Delete(ctx context.Context, promotionId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"promotionId": promotionId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoPromotionRepository) Get(ctx context.Context, promotionId string) (models.Promotion, error) {
+	var promotion models.Promotion
+	err := r.collection.FindOne(ctx, bson.M{"promotionId": promotionId}).Decode(&promotion)
+	return promotion, mongoErr(err)
+}
+
+func (r *mongoPromotionRepository) List(ctx context.Context) ([]models.Promotion, error) {
+	result, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	promotions := make([]models.Promotion, 0)
+	if err := result.All(ctx, &promotions); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+func (r *mongoPromotionRepository) FindByCoupon(ctx context.Context, couponCode string) (models.Promotion, error) {
+	var promotion models.Promotion
+	err := r.collection.FindOne(ctx, bson.M{"couponCode": couponCode}).Decode(&promotion)
+	return promotion, mongoErr(err)
+}
+
+func (r *mongoPromotionRepository) Redeem(ctx context.Context, promotionId string) (bool, error) {
+	filter := bson.M{
+		"promotionId": promotionId,
+		"$or": bson.A{
+			bson.M{"usageLimit": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$usageCount", "$usageLimit"}}},
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"usageCount": 1}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *mongoPromotionRepository) Release(ctx context.Context, promotionId string) error {
+	filter := bson.M{"promotionId": promotionId, "usageCount": bson.M{"$gt": 0}}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"usageCount": -1}})
+	return err
+}
+
+type memoryPromotionRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryPromotionRepository) Create(ctx context.Context, promotion models.Promotion) error {
+	defer r.db.lock(ctx)()
+
+	r.db.promotions[promotion.PromotionId] = promotion
+	return nil
+}
+
+func (r *memoryPromotionRepository) Update(ctx context.Context, promotionId string, update models.UpdatePromotionDto) error {
+	defer r.db.lock(ctx)()
+
+	promotion, ok := r.db.promotions[promotionId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.Name != nil {
+		promotion.Name = update.Name
+	}
+	if update.Active != nil {
+		promotion.Active = update.Active
+	}
+	if update.Stackable != nil {
+		promotion.Stackable = *update.Stackable
+	}
+	if update.UsageLimit != nil {
+		promotion.UsageLimit = *update.UsageLimit
+	}
+	if update.EndsAt != nil {
+		promotion.EndsAt = update.EndsAt
+	}
+	promotion.UpdatedAt = time.Now().UTC()
+
+	r.db.promotions[promotionId] = promotion
+	return nil
+}
+
+func (r *memoryPromotionRepository) Delete(ctx context.Context, promotionId string) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.promotions[promotionId]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.promotions, promotionId)
+	return nil
+}
+
+func (r *memoryPromotionRepository) Get(ctx context.Context, promotionId string) (models.Promotion, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	promotion, ok := r.db.promotions[promotionId]
+	if !ok {
+		return models.Promotion{}, ErrNotFound
+	}
+	return promotion, nil
+}
+
+func (r *memoryPromotionRepository) List(ctx context.Context) ([]models.Promotion, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedByCreation(r.db.promotions, func(p models.Promotion) time.Time { return p.CreatedAt }), nil
+}
+
+func (r *memoryPromotionRepository) FindByCoupon(ctx context.Context, couponCode string) (models.Promotion, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, promotion := range r.db.promotions {
+		if promotion.CouponCode != "" && promotion.CouponCode == couponCode {
+			return promotion, nil
+		}
+	}
+	return models.Promotion{}, ErrNotFound
+}
+
+func (r *memoryPromotionRepository) Redeem(ctx context.Context, promotionId string) (bool, error) {
+	defer r.db.lock(ctx)()
+
+	promotion, ok := r.db.promotions[promotionId]
+	if !ok {
+		return false, nil
+	}
+	if promotion.UsageLimit > 0 && promotion.UsageCount >= promotion.UsageLimit {
+		return false, nil
+	}
+	promotion.UsageCount++
+	r.db.promotions[promotionId] = promotion
+	return true, nil
+}
+
+func (r *memoryPromotionRepository) Release(ctx context.Context, promotionId string) error {
+	defer r.db.lock(ctx)()
+
+	promotion, ok := r.db.promotions[promotionId]
+	if ok && promotion.UsageCount > 0 {
+		promotion.UsageCount--
+		r.db.promotions[promotionId] = promotion
+	}
+	return nil
+}
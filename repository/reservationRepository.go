@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation models.Reservation) error
+	Update(ctx context.Context, reservationId string, update models.UpdateReservationDto) error
+	SetStatus(ctx context.Context, reservationId, status string) error
+	Get(ctx context.Context, reservationId string) (models.Reservation, error)
+	// ListBetween returns the reservations starting from from up to but not
+	// including to, whatever their status, earliest first.
+	ListBetween(ctx context.Context, from, to time.Time) ([]models.Reservation, error)
+	// ListOverlapping returns the booked and seated reservations that hold
+	// their table at some time between from and to.
+	ListOverlapping(ctx context.Context, from, to time.Time) ([]models.Reservation, error)
+}
+
+type mongoReservationRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoReservationRepository) Create(ctx context.Context, reservation models.Reservation) error {
+	_, err := r.collection.InsertOne(ctx, reservation)
+	return err
+}
+
+func (r *mongoReservationRepository) Update(ctx context.Context, reservationId string, update models.UpdateReservationDto) error {
+	updateFields := bson.M{
+		"tableId":   update.TableId,
+		"guestName": update.GuestName,
+		"phone":     update.Phone,
+		"email":     update.Email,
+		"partySize": update.PartySize,
+		"startsAt":  update.StartsAt,
+		"endsAt":    update.EndsAt,
+		"notes":     update.Notes,
+	}
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+	for k, v := range updateFields {
+		if !utils.IsNil(v) {
+			updateObj[k] = v
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"reservationId": reservationId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoReservationRepository) SetStatus(ctx context.Context, reservationId, status string) error {
+	update := bson.M{"$set": bson.M{"status": status, "updatedAt": time.Now().UTC()}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"reservationId": reservationId}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoReservationRepository) Get(ctx context.Context, reservationId string) (models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.collection.FindOne(ctx, bson.M{"reservationId": reservationId}).Decode(&reservation)
+	return reservation, mongoErr(err)
+}
+
+func (r *mongoReservationRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.Reservation, error) {
+	return r.find(ctx, bson.M{"startsAt": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (r *mongoReservationRepository) ListOverlapping(ctx context.Context, from, to time.Time) ([]models.Reservation, error) {
+	return r.find(ctx, bson.M{
+		"status":   bson.M{"$in": bson.A{constants.RESERVATION_STATUS_BOOKED, constants.RESERVATION_STATUS_SEATED}},
+		"startsAt": bson.M{"$lt": to},
+		"endsAt":   bson.M{"$gt": from},
+	})
+}
+
+func (r *mongoReservationRepository) find(ctx context.Context, filter bson.M) ([]models.Reservation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "startsAt", Value: 1}, {Key: "_id", Value: 1}})
+	result, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := make([]models.Reservation, 0)
+	if err := result.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+type memoryReservationRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryReservationRepository) Create(ctx context.Context, reservation models.Reservation) error {
+	defer r.db.lock(ctx)()
+
+	r.db.reservations[reservation.ReservationId] = reservation
+	return nil
+}
+
+func (r *memoryReservationRepository) Update(ctx context.Context, reservationId string, update models.UpdateReservationDto) error {
+	defer r.db.lock(ctx)()
+
+	reservation, ok := r.db.reservations[reservationId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.TableId != nil {
+		reservation.TableId = *update.TableId
+	}
+	if update.GuestName != nil {
+		reservation.GuestName = *update.GuestName
+	}
+	if update.Phone != nil {
+		reservation.Phone = *update.Phone
+	}
+	if update.Email != nil {
+		reservation.Email = *update.Email
+	}
+	if update.PartySize != nil {
+		reservation.PartySize = *update.PartySize
+	}
+	if update.StartsAt != nil {
+		reservation.StartsAt = *update.StartsAt
+	}
+	if update.EndsAt != nil {
+		reservation.EndsAt = *update.EndsAt
+	}
+	if update.Notes != nil {
+		reservation.Notes = *update.Notes
+	}
+	reservation.UpdatedAt = time.Now().UTC()
+
+	r.db.reservations[reservationId] = reservation
+	return nil
+}
+
+func (r *memoryReservationRepository) SetStatus(ctx context.Context, reservationId, status string) error {
+	defer r.db.lock(ctx)()
+
+	reservation, ok := r.db.reservations[reservationId]
+	if !ok {
+		return ErrNotFound
+	}
+	reservation.Status = status
+	reservation.UpdatedAt = time.Now().UTC()
+
+	r.db.reservations[reservationId] = reservation
+	return nil
+}
+
+func (r *memoryReservationRepository) Get(ctx context.Context, reservationId string) (models.Reservation, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	reservation, ok := r.db.reservations[reservationId]
+	if !ok {
+		return models.Reservation{}, ErrNotFound
+	}
+	return reservation, nil
+}
+
+func (r *memoryReservationRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.Reservation, error) {
+	return r.filter(func(res models.Reservation) bool {
+		return !res.StartsAt.Before(from) && res.StartsAt.Before(to)
+	}), nil
+}
+
+func (r *memoryReservationRepository) ListOverlapping(ctx context.Context, from, to time.Time) ([]models.Reservation, error) {
+	return r.filter(func(res models.Reservation) bool {
+		holds := res.Status == constants.RESERVATION_STATUS_BOOKED || res.Status == constants.RESERVATION_STATUS_SEATED
+		return holds && res.StartsAt.Before(to) && res.EndsAt.After(from)
+	}), nil
+}
+
+func (r *memoryReservationRepository) filter(keep func(models.Reservation) bool) []models.Reservation {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	reservations := make([]models.Reservation, 0)
+	for _, reservation := range sortedByCreation(r.db.reservations, func(res models.Reservation) time.Time { return res.StartsAt }) {
+		if keep(reservation) {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return reservations
+}
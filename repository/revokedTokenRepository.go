@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// RevokedTokenRepository is a deny-list of token or session ids. Entries only
+// need to outlive the tokens they revoke, so each one carries an expiry.
+type RevokedTokenRepository interface {
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+type mongoRevokedTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoRevokedTokenRepository) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	opts := options.UpdateOne().SetUpsert(true)
+	update := bson.M{"$set": bson.M{"revokedId": id, "expiresAt": expiresAt.UTC()}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"revokedId": id}, update, opts)
+	return err
+}
+
+func (r *mongoRevokedTokenRepository) IsRevoked(ctx context.Context, id string) (bool, error) {
+	filter := bson.M{"revokedId": id, "expiresAt": bson.M{"$gt": time.Now().UTC()}}
+	count, err := r.collection.CountDocuments(ctx, filter)
+	return count > 0, err
+}
+
+type memoryRevokedTokenRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryRevokedTokenRepository) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	defer r.db.lock(ctx)()
+
+	r.db.revokedTokens[id] = expiresAt.UTC()
+	return nil
+}
+
+func (r *memoryRevokedTokenRepository) IsRevoked(ctx context.Context, id string) (bool, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	expiresAt, ok := r.db.revokedTokens[id]
+	return ok && expiresAt.After(time.Now()), nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type SectionRepository interface {
+	Create(ctx context.Context, section models.Section) error
+	Update(ctx context.Context, sectionId string, update models.UpdateSectionDto) error
+	Delete(ctx context.Context, sectionId string) error
+	Get(ctx context.Context, sectionId string) (models.Section, error)
+	List(ctx context.Context) ([]models.Section, error)
+	ListByFloorPlan(ctx context.Context, floorPlanId string) ([]models.Section, error)
+}
+
+type mongoSectionRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoSectionRepository) Create(ctx context.Context, section models.Section) error {
+	_, err := r.collection.InsertOne(ctx, section)
+	return err
+}
+
+func (r *mongoSectionRepository) Update(ctx context.Context, sectionId string, update models.UpdateSectionDto) error {
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+	if update.Name != nil {
+		updateObj["name"] = update.Name
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"sectionId": sectionId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoSectionRepository) Delete(ctx context.Context, sectionId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"sectionId": sectionId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoSectionRepository) Get(ctx context.Context, sectionId string) (models.Section, error) {
+	var section models.Section
+	err := r.collection.FindOne(ctx, bson.M{"sectionId": sectionId}).Decode(&section)
+	return section, mongoErr(err)
+}
+
+func (r *mongoSectionRepository) List(ctx context.Context) ([]models.Section, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *mongoSectionRepository) ListByFloorPlan(ctx context.Context, floorPlanId string) ([]models.Section, error) {
+	return r.find(ctx, bson.M{"floorPlanId": floorPlanId})
+}
+
+func (r *mongoSectionRepository) find(ctx context.Context, filter bson.M) ([]models.Section, error) {
+	result, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make([]models.Section, 0)
+	if err := result.All(ctx, &sections); err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+type memorySectionRepository struct {
+	db *memoryDB
+}
+
+func (r *memorySectionRepository) Create(ctx context.Context, section models.Section) error {
+	defer r.db.lock(ctx)()
+
+	r.db.sections[section.SectionId] = section
+	return nil
+}
+
+func (r *memorySectionRepository) Update(ctx context.Context, sectionId string, update models.UpdateSectionDto) error {
+	defer r.db.lock(ctx)()
+
+	section, ok := r.db.sections[sectionId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.Name != nil {
+		section.Name = *update.Name
+	}
+	section.UpdatedAt = time.Now().UTC()
+
+	r.db.sections[sectionId] = section
+	return nil
+}
+
+func (r *memorySectionRepository) Delete(ctx context.Context, sectionId string) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.sections[sectionId]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.sections, sectionId)
+	return nil
+}
+
+func (r *memorySectionRepository) Get(ctx context.Context, sectionId string) (models.Section, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	section, ok := r.db.sections[sectionId]
+	if !ok {
+		return models.Section{}, ErrNotFound
+	}
+	return section, nil
+}
+
+func (r *memorySectionRepository) List(ctx context.Context) ([]models.Section, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedByCreation(r.db.sections, func(s models.Section) time.Time { return s.CreatedAt }), nil
+}
+
+func (r *memorySectionRepository) ListByFloorPlan(ctx context.Context, floorPlanId string) ([]models.Section, error) {
+	sections, _ := r.List(ctx)
+
+	matched := make([]models.Section, 0)
+	for _, section := range sections {
+		if section.FloorPlanId == floorPlanId {
+			matched = append(matched, section)
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// SequenceRepository hands out consecutive numbers per named series,
+// starting from 1. Called inside Store.Transaction, a number is only used
+// up when the transaction commits, so a series has no gaps.
+type SequenceRepository interface {
+	Next(ctx context.Context, series string) (int64, error)
+}
+
+type mongoSequenceRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoSequenceRepository) Next(ctx context.Context, series string) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var sequence struct {
+		Value int64 `bson:"value"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": series}, bson.M{"$inc": bson.M{"value": 1}}, opts).Decode(&sequence)
+	if err != nil {
+		return 0, err
+	}
+	return sequence.Value, nil
+}
+
+type memorySequenceRepository struct {
+	db *memoryDB
+}
+
+func (r *memorySequenceRepository) Next(ctx context.Context, series string) (int64, error) {
+	defer r.db.lock(ctx)()
+
+	r.db.sequences[series]++
+	return r.db.sequences[series], nil
+}
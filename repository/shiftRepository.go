@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ShiftRepository interface {
+	Create(ctx context.Context, shift models.Shift) error
+	Delete(ctx context.Context, shiftId string) error
+	Get(ctx context.Context, shiftId string) (models.Shift, error)
+	// ListOverlapping returns the shifts worked at some time between from
+	// and to, earliest first.
+	ListOverlapping(ctx context.Context, from, to time.Time) ([]models.Shift, error)
+}
+
+type mongoShiftRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoShiftRepository) Create(ctx context.Context, shift models.Shift) error {
+	_, err := r.collection.InsertOne(ctx, shift)
+	return err
+}
+
+func (r *mongoShiftRepository) Delete(ctx context.Context, shiftId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"shiftId": shiftId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoShiftRepository) Get(ctx context.Context, shiftId string) (models.Shift, error) {
+	var shift models.Shift
+	err := r.collection.FindOne(ctx, bson.M{"shiftId": shiftId}).Decode(&shift)
+	return shift, mongoErr(err)
+}
+
+func (r *mongoShiftRepository) ListOverlapping(ctx context.Context, from, to time.Time) ([]models.Shift, error) {
+	filter := bson.M{"startsAt": bson.M{"$lt": to}, "endsAt": bson.M{"$gt": from}}
+	opts := options.Find().SetSort(bson.D{{Key: "startsAt", Value: 1}, {Key: "_id", Value: 1}})
+	result, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	shifts := make([]models.Shift, 0)
+	if err := result.All(ctx, &shifts); err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
+
+type memoryShiftRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryShiftRepository) Create(ctx context.Context, shift models.Shift) error {
+	defer r.db.lock(ctx)()
+
+	r.db.shifts[shift.ShiftId] = shift
+	return nil
+}
+
+func (r *memoryShiftRepository) Delete(ctx context.Context, shiftId string) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.shifts[shiftId]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.shifts, shiftId)
+	return nil
+}
+
+func (r *memoryShiftRepository) Get(ctx context.Context, shiftId string) (models.Shift, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	shift, ok := r.db.shifts[shiftId]
+	if !ok {
+		return models.Shift{}, ErrNotFound
+	}
+	return shift, nil
+}
+
+func (r *memoryShiftRepository) ListOverlapping(ctx context.Context, from, to time.Time) ([]models.Shift, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	shifts := make([]models.Shift, 0)
+	for _, shift := range sortedByCreation(r.db.shifts, func(s models.Shift) time.Time { return s.StartsAt }) {
+		if shift.StartsAt.Before(to) && shift.EndsAt.After(from) {
+			shifts = append(shifts, shift)
+		}
+	}
+	return shifts, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/database"
+	"github.com/jrskg/go-restaurant/events"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/writeconcern"
+)
+
+// eventHistorySize is how many events a reconnecting screen can catch up on.
+const eventHistorySize = 1000
+
+// ErrNotFound is returned by every repository when the requested document
+// does not exist, regardless of the backend.
+var ErrNotFound = errors.New("document not found")
+
+// Store groups the repositories of every aggregate so handlers can be wired
+// against either the Mongo or the in-memory backend.
+type Store struct {
+	Foods         FoodRepository
+	Menus         MenuRepository
+	Tables        TableRepository
+	Orders        OrderRepository
+	OrderItems    OrderItemRepository
+	Invoices      InvoiceRepository
+	Users         UserRepository
+	RevokedTokens RevokedTokenRepository
+	TaxRates      TaxRateRepository
+	Payments      PaymentRepository
+	Promotions    PromotionRepository
+	Sequences     SequenceRepository
+	Drawers       DrawerRepository
+	BusinessDays  BusinessDayRepository
+	Reservations  ReservationRepository
+	FloorPlans    FloorPlanRepository
+	Sections      SectionRepository
+	Shifts        ShiftRepository
+	Waitlist      WaitlistRepository
+
+	// Events is the live change feed. It is in-process, so every instance
+	// of the service only sees the changes made through it.
+	Events *events.Broker
+
+	withTransaction func(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Transaction runs fn so that either all of its writes are applied or none
+// are. Repository calls inside fn must use the context passed to fn.
+func (s *Store) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.withTransaction(ctx, fn)
+}
+
+func NewMongoStore(client *mongo.Client) *Store {
+	foodCollection := database.OpenCollection(client, constants.FOOD_COLLECTION)
+	menuCollection := database.OpenCollection(client, constants.MENU_COLLECTION)
+	tableCollection := database.OpenCollection(client, constants.TABLE_COLLECTION)
+	orderCollection := database.OpenCollection(client, constants.ORDER_COLLECTION)
+	orderItemCollection := database.OpenCollection(client, constants.ORDER_ITEM_COLLECTION)
+	invoiceCollection := database.OpenCollection(client, constants.INVOICE_COLLECTION)
+	userCollection := database.OpenCollection(client, constants.USER_COLLECTION)
+	revokedTokenCollection := database.OpenCollection(client, constants.REVOKED_TOKEN_COLLECTION)
+	taxRateCollection := database.OpenCollection(client, constants.TAX_RATE_COLLECTION)
+	paymentCollection := database.OpenCollection(client, constants.PAYMENT_COLLECTION)
+	promotionCollection := database.OpenCollection(client, constants.PROMOTION_COLLECTION)
+	sequenceCollection := database.OpenCollection(client, constants.SEQUENCE_COLLECTION)
+	drawerCollection := database.OpenCollection(client, constants.DRAWER_COLLECTION)
+	businessDayCollection := database.OpenCollection(client, constants.BUSINESS_DAY_COLLECTION)
+	reservationCollection := database.OpenCollection(client, constants.RESERVATION_COLLECTION)
+	floorPlanCollection := database.OpenCollection(client, constants.FLOOR_PLAN_COLLECTION)
+	sectionCollection := database.OpenCollection(client, constants.SECTION_COLLECTION)
+	shiftCollection := database.OpenCollection(client, constants.SHIFT_COLLECTION)
+	waitlistCollection := database.OpenCollection(client, constants.WAITLIST_COLLECTION)
+
+	return &Store{
+		Foods:         &mongoFoodRepository{collection: foodCollection},
+		Menus:         &mongoMenuRepository{collection: menuCollection},
+		Tables:        &mongoTableRepository{collection: tableCollection},
+		Orders:        &mongoOrderRepository{collection: orderCollection},
+		OrderItems:    &mongoOrderItemRepository{collection: orderItemCollection},
+		Invoices:      &mongoInvoiceRepository{collection: invoiceCollection},
+		Users:         &mongoUserRepository{collection: userCollection},
+		RevokedTokens: &mongoRevokedTokenRepository{collection: revokedTokenCollection},
+		TaxRates:      &mongoTaxRateRepository{collection: taxRateCollection},
+		Payments:      &mongoPaymentRepository{collection: paymentCollection},
+		Promotions:    &mongoPromotionRepository{collection: promotionCollection},
+		Sequences:     &mongoSequenceRepository{collection: sequenceCollection},
+		Drawers:       &mongoDrawerRepository{collection: drawerCollection},
+		BusinessDays:  &mongoBusinessDayRepository{collection: businessDayCollection},
+		Reservations:  &mongoReservationRepository{collection: reservationCollection},
+		FloorPlans:    &mongoFloorPlanRepository{collection: floorPlanCollection},
+		Sections:      &mongoSectionRepository{collection: sectionCollection},
+		Shifts:        &mongoShiftRepository{collection: shiftCollection},
+		Waitlist:      &mongoWaitlistRepository{collection: waitlistCollection},
+		Events:        events.NewBroker(eventHistorySize),
+
+		withTransaction: func(ctx context.Context, fn func(ctx context.Context) error) error {
+			session, err := client.StartSession()
+			if err != nil {
+				return err
+			}
+			defer session.EndSession(context.Background())
+
+			txnOptions := options.Transaction().SetWriteConcern(writeconcern.Majority())
+			_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+				return nil, fn(ctx)
+			}, txnOptions)
+			return err
+		},
+	}
+}
+
+func mongoErr(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type TableRepository interface {
+	Create(ctx context.Context, table models.Table) error
+	// Update changes the fields of update that are set. Taking a table off
+	// its floor plan also drops its layout.
+	Update(ctx context.Context, tableId string, update models.UpdateTableDto) error
+	Get(ctx context.Context, tableId string) (models.Table, error)
+	List(ctx context.Context) ([]models.Table, error)
+	Exists(ctx context.Context, tableId string) (bool, error)
+	// Lock claims a table for the rest of a transaction, so that concurrent
+	// transactions booking the same table conflict and one of them is
+	// retried against the other's writes.
+	Lock(ctx context.Context, tableId string) error
+	// SetStatus records the status of a table, since when it has been in it
+	// and the party occupying it, nil once the table is free.
+	SetStatus(ctx context.Context, tableId, status string, since time.Time, occupancy *models.TableOccupancy) error
+	// SetCombination records the tables combined with a table, or the
+	// table it is combined into; nil and empty once they are split.
+	SetCombination(ctx context.Context, tableId string, combination *models.TableCombination, combinedInto string) error
+}
+
+type mongoTableRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoTableRepository) Create(ctx context.Context, table models.Table) error {
+	_, err := r.collection.InsertOne(ctx, table)
+	return err
+}
+
+func (r *mongoTableRepository) Update(ctx context.Context, tableId string, update models.UpdateTableDto) error {
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+	if update.NumberOfGuests != nil {
+		updateObj["numberOfGuests"] = update.NumberOfGuests
+	}
+	if update.TableNumber != nil {
+		updateObj["tableNumber"] = update.TableNumber
+	}
+	if update.FloorPlanId != nil {
+		updateObj["floorPlanId"] = update.FloorPlanId
+	}
+	if update.SectionId != nil {
+		updateObj["sectionId"] = update.SectionId
+	}
+	if update.Layout != nil {
+		updateObj["layout"] = update.Layout
+	}
+	if update.FloorPlanId != nil && *update.FloorPlanId == "" {
+		updateObj["layout"] = nil
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"tableId": tableId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoTableRepository) Get(ctx context.Context, tableId string) (models.Table, error) {
+	var table models.Table
+	err := r.collection.FindOne(ctx, bson.M{"tableId": tableId}).Decode(&table)
+	return table, mongoErr(err)
+}
+
+func (r *mongoTableRepository) List(ctx context.Context) ([]models.Table, error) {
+	result, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]models.Table, 0)
+	if err := result.All(ctx, &tables); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+func (r *mongoTableRepository) Exists(ctx context.Context, tableId string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"tableId": tableId})
+	return count > 0, err
+}
+
+func (r *mongoTableRepository) Lock(ctx context.Context, tableId string) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"tableId": tableId}, bson.M{"$inc": bson.M{"lockVersion": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoTableRepository) SetStatus(ctx context.Context, tableId, status string, since time.Time, occupancy *models.TableOccupancy) error {
+	updateObj := bson.M{
+		"status":      status,
+		"statusSince": since,
+		"occupancy":   occupancy,
+		"updatedAt":   time.Now().UTC(),
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"tableId": tableId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoTableRepository) SetCombination(ctx context.Context, tableId string, combination *models.TableCombination, combinedInto string) error {
+	updateObj := bson.M{
+		"combination":  combination,
+		"combinedInto": combinedInto,
+		"updatedAt":    time.Now().UTC(),
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"tableId": tableId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type memoryTableRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryTableRepository) Create(ctx context.Context, table models.Table) error {
+	defer r.db.lock(ctx)()
+
+	r.db.tables[table.TableId] = table
+	return nil
+}
+
+func (r *memoryTableRepository) Update(ctx context.Context, tableId string, update models.UpdateTableDto) error {
+	defer r.db.lock(ctx)()
+
+	table, ok := r.db.tables[tableId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.NumberOfGuests != nil {
+		table.NumberOfGuests = update.NumberOfGuests
+	}
+	if update.TableNumber != nil {
+		table.TableNumber = update.TableNumber
+	}
+	if update.FloorPlanId != nil {
+		table.FloorPlanId = *update.FloorPlanId
+	}
+	if update.SectionId != nil {
+		table.SectionId = *update.SectionId
+	}
+	if update.Layout != nil {
+		table.Layout = update.Layout
+	}
+	if update.FloorPlanId != nil && *update.FloorPlanId == "" {
+		table.Layout = nil
+	}
+	table.UpdatedAt = time.Now().UTC()
+
+	r.db.tables[tableId] = table
+	return nil
+}
+
+func (r *memoryTableRepository) Get(ctx context.Context, tableId string) (models.Table, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	table, ok := r.db.tables[tableId]
+	if !ok {
+		return models.Table{}, ErrNotFound
+	}
+	return table, nil
+}
+
+func (r *memoryTableRepository) List(ctx context.Context) ([]models.Table, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedByCreation(r.db.tables, func(t models.Table) time.Time { return t.CreatedAt }), nil
+}
+
+func (r *memoryTableRepository) Exists(ctx context.Context, tableId string) (bool, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	_, ok := r.db.tables[tableId]
+	return ok, nil
+}
+
+// Lock only checks that the table exists: memory transactions already run
+// one at a time.
+func (r *memoryTableRepository) Lock(ctx context.Context, tableId string) error {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	if _, ok := r.db.tables[tableId]; !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *memoryTableRepository) SetStatus(ctx context.Context, tableId, status string, since time.Time, occupancy *models.TableOccupancy) error {
+	defer r.db.lock(ctx)()
+
+	table, ok := r.db.tables[tableId]
+	if !ok {
+		return ErrNotFound
+	}
+	table.Status = status
+	table.StatusSince = since
+	table.Occupancy = occupancy
+	table.UpdatedAt = time.Now().UTC()
+
+	r.db.tables[tableId] = table
+	return nil
+}
+
+func (r *memoryTableRepository) SetCombination(ctx context.Context, tableId string, combination *models.TableCombination, combinedInto string) error {
+	defer r.db.lock(ctx)()
+
+	table, ok := r.db.tables[tableId]
+	if !ok {
+		return ErrNotFound
+	}
+	table.Combination = combination
+	table.CombinedInto = combinedInto
+	table.UpdatedAt = time.Now().UTC()
+
+	r.db.tables[tableId] = table
+	return nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type TaxRateRepository interface {
+	Create(ctx context.Context, taxRate models.TaxRate) error
+	Update(ctx context.Context, taxRateId string, update models.UpdateTaxRateDto) error
+	Delete(ctx context.Context, taxRateId string) error
+	Get(ctx context.Context, taxRateId string) (models.TaxRate, error)
+	List(ctx context.Context) ([]models.TaxRate, error)
+}
+
+type mongoTaxRateRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoTaxRateRepository) Create(ctx context.Context, taxRate models.TaxRate) error {
+	_, err := r.collection.InsertOne(ctx, taxRate)
+	return err
+}
+
+func (r *mongoTaxRateRepository) Update(ctx context.Context, taxRateId string, update models.UpdateTaxRateDto) error {
+	updateFields := bson.M{
+		"name":      update.Name,
+		"rate":      update.Rate,
+		"inclusive": update.Inclusive,
+		"default":   update.Default,
+	}
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+	for k, v := range updateFields {
+		if !utils.IsNil(v) {
+			updateObj[k] = v
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"taxRateId": taxRateId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoTaxRateRepository) Delete(ctx context.Context, taxRateId string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"taxRateId": taxRateId})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoTaxRateRepository) Get(ctx context.Context, taxRateId string) (models.TaxRate, error) {
+	var taxRate models.TaxRate
+	err := r.collection.FindOne(ctx, bson.M{"taxRateId": taxRateId}).Decode(&taxRate)
+	return taxRate, mongoErr(err)
+}
+
+func (r *mongoTaxRateRepository) List(ctx context.Context) ([]models.TaxRate, error) {
+	result, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	taxRates := make([]models.TaxRate, 0)
+	if err := result.All(ctx, &taxRates); err != nil {
+		return nil, err
+	}
+	return taxRates, nil
+}
+
+type memoryTaxRateRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryTaxRateRepository) Create(ctx context.Context, taxRate models.TaxRate) error {
+	defer r.db.lock(ctx)()
+
+	r.db.taxRates[taxRate.TaxRateId] = taxRate
+	return nil
+}
+
+func (r *memoryTaxRateRepository) Update(ctx context.Context, taxRateId string, update models.UpdateTaxRateDto) error {
+	defer r.db.lock(ctx)()
+
+	taxRate, ok := r.db.taxRates[taxRateId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.Name != nil {
+		taxRate.Name = update.Name
+	}
+	if update.Rate != nil {
+		taxRate.Rate = update.Rate
+	}
+	if update.Inclusive != nil {
+		taxRate.Inclusive = *update.Inclusive
+	}
+	if update.Default != nil {
+		taxRate.Default = *update.Default
+	}
+	taxRate.UpdatedAt = time.Now().UTC()
+
+	r.db.taxRates[taxRateId] = taxRate
+	return nil
+}
+
+func (r *memoryTaxRateRepository) Delete(ctx context.Context, taxRateId string) error {
+	defer r.db.lock(ctx)()
+
+	if _, ok := r.db.taxRates[taxRateId]; !ok {
+		return ErrNotFound
+	}
+	delete(r.db.taxRates, taxRateId)
+	return nil
+}
+
+func (r *memoryTaxRateRepository) Get(ctx context.Context, taxRateId string) (models.TaxRate, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	taxRate, ok := r.db.taxRates[taxRateId]
+	if !ok {
+		return models.TaxRate{}, ErrNotFound
+	}
+	return taxRate, nil
+}
+
+func (r *memoryTaxRateRepository) List(ctx context.Context) ([]models.TaxRate, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return sortedByCreation(r.db.taxRates, func(t models.TaxRate) time.Time { return t.CreatedAt }), nil
+}
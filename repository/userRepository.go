@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type UserRepository interface {
+	Create(ctx context.Context, user models.User) error
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	// UpdateTokens stores a freshly issued token pair for the user with the
+	// given email as a new session, drops the sessions that have expired and
+	// returns the updated user.
+	UpdateTokens(ctx context.Context, email, token string, session models.UserSession) (models.User, error)
+	// RotateTokens replaces the refresh token of session only while the one
+	// stored for it still equals oldRefreshToken, and returns ErrNotFound
+	// otherwise so callers can treat a lost race as refresh token reuse.
+	RotateTokens(ctx context.Context, userId, oldRefreshToken, token string, session models.UserSession) error
+	// ClearTokens ends the session sessionId of the user.
+	ClearTokens(ctx context.Context, userId, sessionId string) error
+	UpdateRole(ctx context.Context, userId, role string) (models.User, error)
+	Count(ctx context.Context) (int64, error)
+	Get(ctx context.Context, userId string) (models.User, error)
+	// List returns up to limit users created strictly after the given time,
+	// oldest first. A zero time lists from the beginning.
+	List(ctx context.Context, after time.Time, limit int64) ([]models.User, error)
+}
+
+type mongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoUserRepository) Create(ctx context.Context, user models.User) error {
+	_, err := r.collection.InsertOne(ctx, user)
+	return err
+}
+
+func (r *mongoUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	return user, mongoErr(err)
+}
+
+func (r *mongoUserRepository) UpdateTokens(ctx context.Context, email, token string, session models.UserSession) (models.User, error) {
+	now := time.Now().UTC()
+	live := bson.M{"$filter": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$sessions", bson.A{}}},
+		"cond":  bson.M{"$gt": bson.A{"$$this.expiresAt", now}},
+	}}
+	updates := bson.A{
+		bson.M{"$set": bson.M{
+			"token":        token,
+			"refreshToken": session.RefreshToken,
+			"sessions":     bson.M{"$concatArrays": bson.A{live, bson.A{session}}},
+			"updatedAt":    now,
+		}},
+	}
+
+	var user models.User
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"email": email}, updates, opts).Decode(&user)
+	return user, mongoErr(err)
+}
+
+func (r *mongoUserRepository) RotateTokens(ctx context.Context, userId, oldRefreshToken, token string, session models.UserSession) error {
+	updates := bson.M{
+		"$set": bson.M{
+			"token":        &token,
+			"refreshToken": &session.RefreshToken,
+			"sessions.$":   session,
+			"updatedAt":    time.Now().UTC(),
+		},
+	}
+
+	filter := bson.M{
+		"userId":   userId,
+		"sessions": bson.M{"$elemMatch": bson.M{"sessionId": session.SessionId, "refreshToken": oldRefreshToken}},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, updates)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) ClearTokens(ctx context.Context, userId, sessionId string) error {
+	updates := bson.M{
+		"$set": bson.M{
+			"token":        nil,
+			"refreshToken": nil,
+			"updatedAt":    time.Now().UTC(),
+		},
+		"$pull": bson.M{"sessions": bson.M{"sessionId": sessionId}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"userId": userId}, updates)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) UpdateRole(ctx context.Context, userId, role string) (models.User, error) {
+	updates := bson.M{
+		"$set": bson.M{
+			"role":      &role,
+			"updatedAt": time.Now().UTC(),
+		},
+	}
+
+	var user models.User
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"userId": userId}, updates, opts).Decode(&user)
+	return user, mongoErr(err)
+}
+
+func (r *mongoUserRepository) Count(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{})
+}
+
+func (r *mongoUserRepository) Get(ctx context.Context, userId string) (models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"userId": userId}).Decode(&user)
+	return user, mongoErr(err)
+}
+
+func (r *mongoUserRepository) List(ctx context.Context, after time.Time, limit int64) ([]models.User, error) {
+	filter := bson.M{}
+	if !after.IsZero() {
+		filter = bson.M{"createdAt": bson.M{"$gt": after}}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetLimit(limit)
+
+	result, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]models.User, 0)
+	if err := result.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+type memoryUserRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user models.User) error {
+	defer r.db.lock(ctx)()
+
+	r.db.users[user.UserId] = user
+	return nil
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, user := range r.db.users {
+		if user.Email != nil && *user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r *memoryUserRepository) UpdateTokens(ctx context.Context, email, token string, session models.UserSession) (models.User, error) {
+	defer r.db.lock(ctx)()
+
+	now := time.Now().UTC()
+	for id, user := range r.db.users {
+		if user.Email != nil && *user.Email == email {
+			user.Token = &token
+			user.RefreshToken = &session.RefreshToken
+			user.Sessions = slices.DeleteFunc(slices.Clone(user.Sessions), func(s models.UserSession) bool {
+				return !s.ExpiresAt.After(now)
+			})
+			user.Sessions = append(user.Sessions, session)
+			user.UpdatedAt = now
+			r.db.users[id] = user
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r *memoryUserRepository) RotateTokens(ctx context.Context, userId, oldRefreshToken, token string, session models.UserSession) error {
+	defer r.db.lock(ctx)()
+
+	user, ok := r.db.users[userId]
+	if !ok {
+		return ErrNotFound
+	}
+	i := slices.IndexFunc(user.Sessions, func(s models.UserSession) bool {
+		return s.SessionId == session.SessionId && s.RefreshToken == oldRefreshToken
+	})
+	if i < 0 {
+		return ErrNotFound
+	}
+	user.Sessions = slices.Clone(user.Sessions)
+	user.Sessions[i] = session
+	user.Token = &token
+	user.RefreshToken = &session.RefreshToken
+	user.UpdatedAt = time.Now().UTC()
+
+	r.db.users[userId] = user
+	return nil
+}
+
+func (r *memoryUserRepository) ClearTokens(ctx context.Context, userId, sessionId string) error {
+	defer r.db.lock(ctx)()
+
+	user, ok := r.db.users[userId]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Token = nil
+	user.RefreshToken = nil
+	user.Sessions = slices.DeleteFunc(slices.Clone(user.Sessions), func(s models.UserSession) bool { return s.SessionId == sessionId })
+	user.UpdatedAt = time.Now().UTC()
+
+	r.db.users[userId] = user
+	return nil
+}
+
+func (r *memoryUserRepository) UpdateRole(ctx context.Context, userId, role string) (models.User, error) {
+	defer r.db.lock(ctx)()
+
+	user, ok := r.db.users[userId]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	user.Role = &role
+	user.UpdatedAt = time.Now().UTC()
+
+	r.db.users[userId] = user
+	return user, nil
+}
+
+func (r *memoryUserRepository) Count(ctx context.Context) (int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return int64(len(r.db.users)), nil
+}
+
+func (r *memoryUserRepository) Get(ctx context.Context, userId string) (models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	user, ok := r.db.users[userId]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, after time.Time, limit int64) ([]models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	users := make([]models.User, 0)
+	for _, user := range sortedByCreation(r.db.users, func(u models.User) time.Time { return u.CreatedAt }) {
+		if int64(len(users)) == limit {
+			break
+		}
+		if user.CreatedAt.After(after) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type WaitlistRepository interface {
+	Create(ctx context.Context, entry models.WaitlistEntry) error
+	Update(ctx context.Context, entryId string, update models.WaitlistUpdate) error
+	Get(ctx context.Context, entryId string) (models.WaitlistEntry, error)
+	// ListActive returns the parties still waiting or called to a table,
+	// in line order.
+	ListActive(ctx context.Context) ([]models.WaitlistEntry, error)
+	// ListBetween returns the parties that joined the waitlist from from
+	// up to but not including to, whatever their status, in line order.
+	ListBetween(ctx context.Context, from, to time.Time) ([]models.WaitlistEntry, error)
+}
+
+type mongoWaitlistRepository struct {
+	collection *mongo.Collection
+}
+
+func (r *mongoWaitlistRepository) Create(ctx context.Context, entry models.WaitlistEntry) error {
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *mongoWaitlistRepository) Update(ctx context.Context, entryId string, update models.WaitlistUpdate) error {
+	updateFields := bson.M{
+		"status":     update.Status,
+		"tableId":    update.TableId,
+		"orderId":    update.OrderId,
+		"notifiedAt": update.NotifiedAt,
+		"seatedAt":   update.SeatedAt,
+	}
+	updateObj := bson.M{"updatedAt": time.Now().UTC()}
+	for k, v := range updateFields {
+		if !utils.IsNil(v) {
+			updateObj[k] = v
+		}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"entryId": entryId}, bson.M{"$set": updateObj})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoWaitlistRepository) Get(ctx context.Context, entryId string) (models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	err := r.collection.FindOne(ctx, bson.M{"entryId": entryId}).Decode(&entry)
+	return entry, mongoErr(err)
+}
+
+func (r *mongoWaitlistRepository) ListActive(ctx context.Context) ([]models.WaitlistEntry, error) {
+	return r.find(ctx, bson.M{"status": bson.M{"$in": bson.A{constants.WAITLIST_STATUS_WAITING, constants.WAITLIST_STATUS_NOTIFIED}}})
+}
+
+func (r *mongoWaitlistRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.WaitlistEntry, error) {
+	return r.find(ctx, bson.M{"createdAt": bson.M{"$gte": from, "$lt": to}})
+}
+
+func (r *mongoWaitlistRepository) find(ctx context.Context, filter bson.M) ([]models.WaitlistEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	result, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.WaitlistEntry, 0)
+	if err := result.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+type memoryWaitlistRepository struct {
+	db *memoryDB
+}
+
+func (r *memoryWaitlistRepository) Create(ctx context.Context, entry models.WaitlistEntry) error {
+	defer r.db.lock(ctx)()
+
+	r.db.waitlist[entry.EntryId] = entry
+	return nil
+}
+
+func (r *memoryWaitlistRepository) Update(ctx context.Context, entryId string, update models.WaitlistUpdate) error {
+	defer r.db.lock(ctx)()
+
+	entry, ok := r.db.waitlist[entryId]
+	if !ok {
+		return ErrNotFound
+	}
+	if update.Status != nil {
+		entry.Status = *update.Status
+	}
+	if update.TableId != nil {
+		entry.TableId = *update.TableId
+	}
+	if update.OrderId != nil {
+		entry.OrderId = *update.OrderId
+	}
+	if update.NotifiedAt != nil {
+		entry.NotifiedAt = update.NotifiedAt
+	}
+	if update.SeatedAt != nil {
+		entry.SeatedAt = update.SeatedAt
+	}
+	entry.UpdatedAt = time.Now().UTC()
+
+	r.db.waitlist[entryId] = entry
+	return nil
+}
+
+func (r *memoryWaitlistRepository) Get(ctx context.Context, entryId string) (models.WaitlistEntry, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	entry, ok := r.db.waitlist[entryId]
+	if !ok {
+		return models.WaitlistEntry{}, ErrNotFound
+	}
+	return entry, nil
+}
+
+func (r *memoryWaitlistRepository) ListActive(ctx context.Context) ([]models.WaitlistEntry, error) {
+	return r.filter(func(entry models.WaitlistEntry) bool {
+		return entry.Status == constants.WAITLIST_STATUS_WAITING || entry.Status == constants.WAITLIST_STATUS_NOTIFIED
+	}), nil
+}
+
+func (r *memoryWaitlistRepository) ListBetween(ctx context.Context, from, to time.Time) ([]models.WaitlistEntry, error) {
+	return r.filter(func(entry models.WaitlistEntry) bool {
+		return !entry.CreatedAt.Before(from) && entry.CreatedAt.Before(to)
+	}), nil
+}
+
+func (r *memoryWaitlistRepository) filter(keep func(models.WaitlistEntry) bool) []models.WaitlistEntry {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	entries := make([]models.WaitlistEntry, 0)
+	for _, entry := range sortedByCreation(r.db.waitlist, func(e models.WaitlistEntry) time.Time { return e.CreatedAt }) {
+		if keep(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
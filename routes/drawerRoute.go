@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func DrawerRoute(router *gin.Engine, store *repository.Store) {
+	drawerGroup := router.Group("/drawer")
+	drawerGroup.Use(middlewares.Authenticate(store), middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_CASHIER))
+	drawerGroup.POST("/open", middlewares.BusinessDayOpen(store), controllers.OpenDrawer(store))
+	drawerGroup.GET("/current", controllers.GetCurrentDrawer(store))
+	drawerGroup.GET("/:drawerId", controllers.GetDrawer(store))
+	drawerGroup.POST("/:drawerId/movement", middlewares.BusinessDayOpen(store), controllers.RecordDrawerMovement(store))
+	drawerGroup.POST("/:drawerId/close", controllers.CloseDrawer(store))
+}
@@ -0,0 +1,110 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+	"github.com/jrskg/go-restaurant/money"
+)
+
+func TestCashDrawerAndZReport(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	foodId := s.createFood(menuId, 10)
+	tableId := s.createTable(1, 2)
+	newInvoice := func() models.Invoice {
+		items := s.createOrderItems(tableId, map[string]any{"foodId": foodId, "quantity": "M", "count": 2})
+		var invoice models.Invoice
+		s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": items[0].OrderId}, http.StatusCreated, &invoice)
+		return invoice
+	}
+
+	var drawer models.Drawer
+	s.mustDo(http.MethodPost, "/drawer/open", map[string]any{"openingFloat": 100}, http.StatusCreated, &drawer)
+	s.expectStatus(http.MethodPost, "/drawer/open", map[string]any{"openingFloat": 50}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/drawer/open", map[string]any{"openingFloat": -1}, http.StatusBadRequest)
+	if code, _ := s.doWithToken(http.MethodGet, "/drawer/current", s.tokenFor(constants.ROLE_WAITER), nil); code != http.StatusForbidden {
+		t.Fatalf("waiter drawer status = %d", code)
+	}
+
+	// Cash with change and a tip, part of it refunded in cash; a card
+	// payment; and a bill voided before anyone paid it.
+	cashInvoice := newInvoice()
+	s.mustDo(http.MethodPost, "/invoice/"+cashInvoice.InvoiceId+"/payment", map[string]any{
+		"method": "CASH", "amount": 50, "tip": 2,
+	}, http.StatusCreated, nil)
+	s.expectStatus(http.MethodPost, "/invoice/"+cashInvoice.InvoiceId+"/refund", map[string]any{
+		"reasonCode": "QUALITY", "amount": 5, "method": "CASH",
+	}, http.StatusCreated)
+	cardInvoice := newInvoice()
+	s.pay(cardInvoice.InvoiceId, "CARD", cardInvoice.Totals.Total, "slip-1")
+	voided := newInvoice()
+	s.expectStatus(http.MethodPost, "/invoice/"+voided.InvoiceId+"/void", map[string]any{"reasonCode": "DUPLICATE"}, http.StatusOK)
+
+	movementPath := "/drawer/" + drawer.DrawerId + "/movement"
+	s.expectStatus(http.MethodPost, movementPath, map[string]any{"type": "PAY_IN", "amount": 20, "reason": "Change from the bank"}, http.StatusCreated)
+	s.expectStatus(http.MethodPost, movementPath, map[string]any{"type": "PAY_OUT", "amount": 15, "reason": "Ice delivery"}, http.StatusCreated)
+	s.expectStatus(http.MethodPost, movementPath, map[string]any{"type": "PAY_OUT", "amount": 15}, http.StatusBadRequest)
+
+	cashSales := cashInvoice.Totals.Total
+	expected := money.Amount(10000) + cashSales + 200 - 500 + 2000 - 1500
+	s.mustDo(http.MethodGet, "/drawer/current", nil, http.StatusOK, &drawer)
+	if tally := drawer.Tally; tally == nil || tally.CashSales != cashSales || tally.CashTips.String() != "2.00" ||
+		tally.CashRefunds.String() != "5.00" || tally.PayIns.String() != "20.00" || tally.PayOuts.String() != "15.00" || tally.Expected != expected {
+		t.Fatalf("open drawer tally = %+v, want expected %s", drawer.Tally, expected)
+	}
+
+	// The day cannot close while the drawer is open.
+	s.expectStatus(http.MethodPost, "/report/z", nil, http.StatusConflict)
+
+	s.mustDo(http.MethodPost, "/drawer/"+drawer.DrawerId+"/close", map[string]any{"counted": expected - 200, "note": "Short two"}, http.StatusOK, &drawer)
+	if drawer.Status != constants.DRAWER_STATUS_CLOSED || drawer.Closing == nil || drawer.Closing.OverShort.String() != "-2.00" {
+		t.Fatalf("closed drawer = %+v", drawer)
+	}
+	s.expectStatus(http.MethodPost, "/drawer/"+drawer.DrawerId+"/close", map[string]any{"counted": 0}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, movementPath, map[string]any{"type": "PAY_IN", "amount": 1, "reason": "Late"}, http.StatusConflict)
+	s.expectStatus(http.MethodGet, "/drawer/current", nil, http.StatusNotFound)
+
+	today := time.Now().Format("2006-01-02")
+	var report models.ZReport
+	s.mustDo(http.MethodGet, "/report/z/"+today, nil, http.StatusOK, &report)
+	if report.Closed || report.BusinessDay != today || report.Sales.Invoices != 2 || report.Sales.Refunded.String() != "5.00" {
+		t.Fatalf("Z-report preview = %+v", report)
+	}
+	if len(report.Payments) != 2 || report.Payments[0].Method != "CARD" || report.Payments[0].Amount != cardInvoice.Totals.Total ||
+		report.Payments[1].Method != "CASH" || report.Payments[1].Amount != cashSales || report.Payments[1].Tips.String() != "2.00" {
+		t.Fatalf("Z-report payments = %+v", report.Payments)
+	}
+	if report.Refunds.Count != 1 || report.Refunds.Amount.String() != "5.00" || report.Voids.Count != 1 || report.Voids.Amount != voided.Totals.Total {
+		t.Fatalf("Z-report refunds = %+v, voids = %+v", report.Refunds, report.Voids)
+	}
+	if len(report.Drawers) != 1 || report.CashExpected != expected || report.OverShort.String() != "-2.00" {
+		t.Fatalf("Z-report cash = %+v", report)
+	}
+
+	var closed models.ZReport
+	s.mustDo(http.MethodPost, "/report/z", nil, http.StatusCreated, &closed)
+	if !closed.Closed || closed.ClosedAt == nil || closed.BusinessDay != today || closed.Sales != report.Sales {
+		t.Fatalf("closed Z-report = %+v", closed)
+	}
+	s.expectStatus(http.MethodPost, "/report/z", map[string]any{"businessDay": today}, http.StatusConflict)
+	s.mustDo(http.MethodGet, "/report/z/"+today, nil, http.StatusOK, &report)
+	if !report.Closed {
+		t.Fatalf("stored Z-report = %+v", report)
+	}
+
+	// Nothing more is recorded in a closed day.
+	s.expectStatus(http.MethodPost, "/order/create", map[string]any{"tableId": tableId}, http.StatusConflict)
+	s.expectStatus(http.MethodPut, "/invoice/"+cardInvoice.InvoiceId, map[string]any{"paymentMethod": "CASH"}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/invoice/"+cashInvoice.InvoiceId+"/refund", map[string]any{"reasonCode": "QUALITY", "amount": 1}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/drawer/open", map[string]any{"openingFloat": 100}, http.StatusConflict)
+	s.expectStatus(http.MethodGet, "/invoice/"+cardInvoice.InvoiceId, nil, http.StatusOK)
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	s.expectStatus(http.MethodPost, "/report/z", map[string]any{"businessDay": tomorrow}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/report/z", map[string]any{"businessDay": "17/10/2026"}, http.StatusBadRequest)
+	s.expectStatus(http.MethodGet, "/report/z/yesterday", nil, http.StatusBadRequest)
+}
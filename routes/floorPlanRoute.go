@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func FloorPlanRoute(router *gin.Engine, store *repository.Store) {
+	floorPlanGroup := router.Group("/floor-plan")
+	floorPlanGroup.Use(middlewares.Authenticate(store))
+	floorPlanGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER), controllers.CreateFloorPlan(store))
+	floorPlanGroup.PUT("/:floorPlanId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.UpdateFloorPlan(store))
+	floorPlanGroup.DELETE("/:floorPlanId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.DeleteFloorPlan(store))
+	floorPlanGroup.GET("/:floorPlanId", controllers.GetFloorPlan(store))
+	floorPlanGroup.GET("/all", controllers.GetAllFloorPlans(store))
+}
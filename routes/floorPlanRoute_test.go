@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func (s *testServer) createFloorPlan(name string, width, height float64) string {
+	s.t.Helper()
+
+	var floorPlan models.FloorPlan
+	s.mustDo(http.MethodPost, "/floor-plan/create", map[string]any{"name": name, "width": width, "height": height}, http.StatusCreated, &floorPlan)
+	return floorPlan.FloorPlanId
+}
+
+func (s *testServer) createSection(floorPlanId, name string) string {
+	s.t.Helper()
+
+	var section models.Section
+	s.mustDo(http.MethodPost, "/section/create", map[string]any{"floorPlanId": floorPlanId, "name": name}, http.StatusCreated, &section)
+	return section.SectionId
+}
+
+func TestFloorPlansAndSections(t *testing.T) {
+	s := newTestServer(t)
+	diningId := s.createFloorPlan("Dining room", 20, 10)
+	terraceId := s.createFloorPlan("Terrace", 8, 8)
+	windowId := s.createSection(diningId, "Window")
+	patioId := s.createSection(terraceId, "Patio")
+
+	s.expectStatus(http.MethodPost, "/floor-plan/create", map[string]any{"name": "Bar", "width": 0, "height": 5}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/section/create", map[string]any{"floorPlanId": "missing", "name": "Nowhere"}, http.StatusBadRequest)
+
+	layout := map[string]any{"x": 4, "y": 3, "width": 1.2, "height": 1.2, "shape": "ROUND"}
+	var table models.Table
+	s.mustDo(http.MethodPost, "/table/create", map[string]any{
+		"tableNumber": 1, "numberOfGuests": 4, "sectionId": windowId, "layout": layout,
+	}, http.StatusCreated, &table)
+	if table.FloorPlanId != diningId || table.SectionId != windowId || table.Layout.Shape != "ROUND" {
+		t.Fatalf("placed table = %+v", table)
+	}
+	tableId := table.TableId
+
+	s.expectStatus(http.MethodPost, "/table/create", map[string]any{
+		"tableNumber": 2, "numberOfGuests": 2, "layout": layout,
+	}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/table/"+tableId, map[string]any{"layout": map[string]any{"x": 25, "y": 3, "width": 1, "height": 1, "shape": "SQUARE"}}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/table/"+tableId, map[string]any{"layout": map[string]any{"x": 1, "y": 1, "width": 1, "height": 1, "shape": "OVAL"}}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/table/"+tableId, map[string]any{"floorPlanId": diningId, "sectionId": patioId}, http.StatusBadRequest)
+
+	// Moving to the terrace's section takes the table to the terrace; the
+	// layout still fits there.
+	s.expectStatus(http.MethodPut, "/table/"+tableId, map[string]any{"sectionId": patioId}, http.StatusOK)
+	var view models.FloorPlanView
+	s.mustDo(http.MethodGet, "/floor-plan/"+terraceId, nil, http.StatusOK, &view)
+	if len(view.Sections) != 1 || len(view.Tables) != 1 || view.Tables[0].SectionId != patioId {
+		t.Fatalf("terrace = %+v", view)
+	}
+	s.expectStatus(http.MethodPut, "/floor-plan/"+terraceId, map[string]any{"width": 3}, http.StatusConflict)
+	s.expectStatus(http.MethodPut, "/floor-plan/"+terraceId, map[string]any{"name": "Roof terrace", "width": 12}, http.StatusOK)
+
+	s.expectStatus(http.MethodDelete, "/section/"+patioId, nil, http.StatusConflict)
+	s.expectStatus(http.MethodDelete, "/floor-plan/"+terraceId, nil, http.StatusConflict)
+
+	// Taking the table off its floor plan takes it out of its section and
+	// drops its layout.
+	s.expectStatus(http.MethodPut, "/table/"+tableId, map[string]any{"floorPlanId": ""}, http.StatusOK)
+	var unplaced models.Table
+	s.mustDo(http.MethodGet, "/table/"+tableId, nil, http.StatusOK, &unplaced)
+	if unplaced.FloorPlanId != "" || unplaced.SectionId != "" || unplaced.Layout != nil {
+		t.Fatalf("table off the plan = %+v", unplaced)
+	}
+
+	s.expectStatus(http.MethodDelete, "/section/"+patioId, nil, http.StatusOK)
+	s.expectStatus(http.MethodDelete, "/floor-plan/"+terraceId, nil, http.StatusOK)
+	var floorPlans []models.FloorPlan
+	s.mustDo(http.MethodGet, "/floor-plan/all", nil, http.StatusOK, &floorPlans)
+	if len(floorPlans) != 1 || floorPlans[0].FloorPlanId != diningId {
+		t.Fatalf("floor plans = %+v", floorPlans)
+	}
+	s.expectStatus(http.MethodGet, "/floor-plan/"+terraceId, nil, http.StatusNotFound)
+}
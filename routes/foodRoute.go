@@ -2,16 +2,18 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
 	"github.com/jrskg/go-restaurant/controllers"
 	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
 )
 
-func FoodRoute(router *gin.Engine) {
+func FoodRoute(router *gin.Engine, store *repository.Store) {
 	foodGroup := router.Group("/food")
-	foodGroup.Use(middlewares.Authenticate())
-	foodGroup.POST("/create", controllers.CreateFood())
-	foodGroup.PUT("/:foodId", controllers.UpdateFood())
-	foodGroup.DELETE("/:foodId", controllers.DeleteFood())
-	foodGroup.GET("/:foodId", controllers.GetFood())
-	foodGroup.GET("/all", controllers.GetAllFoods())
+	foodGroup.Use(middlewares.Authenticate(store))
+	foodGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER), controllers.CreateFood(store))
+	foodGroup.PUT("/:foodId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.UpdateFood(store))
+	foodGroup.DELETE("/:foodId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.DeleteFood(store))
+	foodGroup.GET("/:foodId", controllers.GetFood(store))
+	foodGroup.GET("/all", controllers.GetAllFoods(store))
 }
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func TestFoodCRUD(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	foodId := s.createFood(menuId, 9.999)
+
+	var food models.Food
+	s.mustDo(http.MethodGet, "/food/"+foodId, nil, http.StatusOK, &food)
+	if *food.Name != "Burger" || *food.MenuId != menuId {
+		t.Fatalf("GET food = %+v", food)
+	}
+	if food.Price.String() != "10.00" || food.Currency != "USD" {
+		t.Errorf("price = %s %s, want 10.00 USD", food.Price, food.Currency)
+	}
+
+	s.expectStatus(http.MethodPut, "/food/"+foodId, map[string]any{"name": "Cheeseburger", "price": 11.5}, http.StatusOK)
+	s.mustDo(http.MethodGet, "/food/"+foodId, nil, http.StatusOK, &food)
+	if *food.Name != "Cheeseburger" || food.Price.String() != "11.50" || *food.FoodImage != "burger.png" {
+		t.Fatalf("food after update = %+v", food)
+	}
+
+	s.expectStatus(http.MethodDelete, "/food/"+foodId, nil, http.StatusOK)
+	s.expectStatus(http.MethodGet, "/food/"+foodId, nil, http.StatusNotFound)
+	s.expectStatus(http.MethodDelete, "/food/"+foodId, nil, http.StatusNotFound)
+	s.expectStatus(http.MethodPut, "/food/"+foodId, map[string]any{"name": "Cheeseburger"}, http.StatusNotFound)
+}
+
+func TestFoodValidation(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+
+	s.expectStatus(http.MethodPost, "/food/create", nil, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/food/create", map[string]any{
+		"name": "Burger", "foodImage": "burger.png", "menuId": menuId,
+	}, http.StatusBadRequest)
+	resp := s.expectStatus(http.MethodPost, "/food/create", map[string]any{
+		"name": "Burger", "price": 5, "foodImage": "burger.png", "menuId": "missing",
+	}, http.StatusBadRequest)
+	if resp.Message != "menu not found" {
+		t.Errorf("unknown menu message = %q", resp.Message)
+	}
+
+	foodId := s.createFood(menuId, 5)
+	s.expectStatus(http.MethodPut, "/food/"+foodId, map[string]any{"menuId": "missing"}, http.StatusBadRequest)
+}
+
+func TestGetAllFoodsPagination(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	for i := range 55 {
+		s.createFood(menuId, float64(i))
+	}
+
+	type page struct {
+		HasMore    bool          `json:"hasMore"`
+		Foods      []models.Food `json:"foods"`
+		NextCursor string        `json:"nextCursor"`
+	}
+
+	var first page
+	s.mustDo(http.MethodGet, "/food/all", nil, http.StatusOK, &first)
+	if !first.HasMore || len(first.Foods) != 50 {
+		t.Fatalf("first page: hasMore=%v len=%d", first.HasMore, len(first.Foods))
+	}
+
+	var second page
+	s.mustDo(http.MethodGet, fmt.Sprintf("/food/all?cursor=%s", first.NextCursor), nil, http.StatusOK, &second)
+	if second.HasMore || len(second.Foods) != 5 {
+		t.Fatalf("second page: hasMore=%v len=%d", second.HasMore, len(second.Foods))
+	}
+
+	s.expectStatus(http.MethodGet, "/food/all?cursor=yesterday", nil, http.StatusBadRequest)
+}
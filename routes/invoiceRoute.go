@@ -2,15 +2,24 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
 	"github.com/jrskg/go-restaurant/controllers"
 	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
 )
 
-func InvoiceRoute(router *gin.Engine) {
+func InvoiceRoute(router *gin.Engine, store *repository.Store) {
 	invoiceGroup := router.Group("/invoice")
-	invoiceGroup.Use(middlewares.Authenticate())
-	invoiceGroup.POST("/create", controllers.CreateInvoice())
-	invoiceGroup.PUT("/:invoiceId", controllers.UpdateInvoice())
-	invoiceGroup.GET("/:invoiceId", controllers.GetInvoice())
-	invoiceGroup.GET("/all", controllers.GetAllInvoices())
+	invoiceGroup.Use(middlewares.Authenticate(store))
+	invoiceGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER, constants.ROLE_CASHIER), middlewares.BusinessDayOpen(store), controllers.CreateInvoice(store))
+	invoiceGroup.POST("/split", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER, constants.ROLE_CASHIER), middlewares.BusinessDayOpen(store), controllers.SplitInvoice(store))
+	invoiceGroup.PUT("/:invoiceId", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_CASHIER), middlewares.BusinessDayOpen(store), controllers.UpdateInvoice(store))
+	invoiceGroup.GET("/:invoiceId", controllers.GetInvoice(store))
+	invoiceGroup.POST("/:invoiceId/payment", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_CASHIER), middlewares.BusinessDayOpen(store), controllers.CreatePayment(store))
+	invoiceGroup.GET("/:invoiceId/payments", controllers.GetInvoicePayments(store))
+	invoiceGroup.POST("/:invoiceId/void", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_CASHIER), middlewares.BusinessDayOpen(store), controllers.VoidInvoice(store))
+	invoiceGroup.POST("/:invoiceId/refund", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_CASHIER), middlewares.BusinessDayOpen(store), controllers.RefundInvoice(store))
+	invoiceGroup.GET("/all", controllers.GetAllInvoices(store))
+	invoiceGroup.GET("/order/:orderId", controllers.GetOrderInvoices(store))
+	invoiceGroup.GET("/order/:orderId/ledger", controllers.GetOrderLedger(store))
 }
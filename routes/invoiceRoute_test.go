@@ -0,0 +1,630 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func (s *testServer) pay(invoiceId, method string, amount any, reference string) controllers.PaymentReceipt {
+	s.t.Helper()
+
+	var receipt controllers.PaymentReceipt
+	s.mustDo(http.MethodPost, "/invoice/"+invoiceId+"/payment", map[string]any{
+		"method": method, "amount": amount, "reference": reference,
+	}, http.StatusCreated, &receipt)
+	return receipt
+}
+
+func TestInvoiceLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	burgerId := s.createFood(menuId, 10)
+	friesId := s.createFood(menuId, 4)
+	tableId := s.createTable(5, 2)
+	items := s.createOrderItems(tableId,
+		map[string]any{"foodId": burgerId, "quantity": "M"},
+		map[string]any{"foodId": friesId, "quantity": "S"},
+	)
+	orderId := items[0].OrderId
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{
+		"orderId":       orderId,
+		"paymentMethod": "CASH",
+	}, http.StatusCreated, &invoice)
+	if *invoice.PaymentStatus != "PENDING" || invoice.InvoiceId == "" {
+		t.Fatalf("created invoice = %+v", invoice)
+	}
+
+	var view controllers.InvoiceViewFromat
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, http.StatusOK, &view)
+	if view.OrderId != orderId || view.PaymentDue != float64(13) || view.TableNumber != float64(5) || view.PaymentMethod != "CASH" {
+		t.Fatalf("invoice view = %+v", view)
+	}
+
+	s.expectStatus(http.MethodPut, "/invoice/"+invoice.InvoiceId, map[string]any{"paymentStatus": "PAID"}, http.StatusConflict)
+	s.pay(invoice.InvoiceId, "CARD", 13, "slip-1")
+	s.mustDo(http.MethodPut, "/invoice/"+invoice.InvoiceId, map[string]any{
+		"paymentMethod": "CARD",
+		"paymentStatus": "PAID",
+	}, http.StatusOK, &invoice)
+	if *invoice.PaymentStatus != "PAID" || *invoice.PaymentMethod != "CARD" {
+		t.Fatalf("updated invoice = %+v", invoice)
+	}
+
+	var invoices []models.Invoice
+	s.mustDo(http.MethodGet, "/invoice/all", nil, http.StatusOK, &invoices)
+	if len(invoices) != 1 {
+		t.Fatalf("len(invoices) = %d, want 1", len(invoices))
+	}
+}
+
+func TestInvoiceValidation(t *testing.T) {
+	s := newTestServer(t)
+	tableId := s.createTable(5, 2)
+	orderId := s.createOrder(tableId)
+
+	s.expectStatus(http.MethodPost, "/invoice/create", nil, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"paymentMethod": "CASH"}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{
+		"orderId": orderId, "paymentMethod": "CHEQUE",
+	}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"orderId": "missing"}, http.StatusNotFound)
+
+	s.expectStatus(http.MethodPut, "/invoice/missing", map[string]any{"paymentStatus": "PAID"}, http.StatusNotFound)
+	s.expectStatus(http.MethodGet, "/invoice/missing", nil, http.StatusNotFound)
+}
+
+func TestInvoiceLedgerFieldsAreNotTakenFromClient(t *testing.T) {
+	s := newTestServer(t)
+	items := s.createOrderItems(s.createTable(5, 2), map[string]any{"foodId": s.createFood(s.createMenu(), 10), "quantity": "M"})
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{
+		"orderId":        items[0].OrderId,
+		"amountPaid":     10,
+		"amountRefunded": 500,
+		"tips":           999,
+		"void":           map[string]any{"reasonCode": "DUPLICATE"},
+	}, http.StatusCreated, &invoice)
+	stored, err := s.store.Invoices.Get(context.Background(), invoice.InvoiceId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.AmountPaid != 0 || stored.AmountRefunded != 0 || stored.Tips != 0 || stored.Void != nil {
+		t.Fatalf("stored invoice = %+v", stored)
+	}
+}
+
+func TestInvoiceForOrderWithoutItems(t *testing.T) {
+	s := newTestServer(t)
+	orderId := s.createOrder(s.createTable(5, 2))
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+
+	var view controllers.InvoiceViewFromat
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, http.StatusOK, &view)
+	if view.OrderId != orderId || view.OrderDetails != nil {
+		t.Fatalf("invoice view = %+v", view)
+	}
+}
+
+func TestInvoiceTotals(t *testing.T) {
+	s := newTestServer(t)
+	salesTaxId := s.createTaxRate("Sales tax", "8.875", false, true)
+	vatId := s.createTaxRate("VAT", "10", true, false)
+	menuId := s.createMenu()
+	burgerId := s.createFood(menuId, 10)
+	wineId := s.createFood(menuId, 11)
+	s.expectStatus(http.MethodPut, "/food/"+wineId, map[string]any{"taxRateIds": []string{vatId}}, http.StatusOK)
+	s.expectStatus(http.MethodPut, "/food/"+wineId, map[string]any{"taxRateIds": []string{"missing"}}, http.StatusBadRequest)
+
+	items := s.createOrderItems(s.createTable(5, 2),
+		map[string]any{"foodId": burgerId, "quantity": "M"},
+		map[string]any{"foodId": wineId, "quantity": "M"},
+	)
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{
+		"orderId":           items[0].OrderId,
+		"serviceChargeRate": "12.5",
+	}, http.StatusCreated, &invoice)
+
+	// 10.00 + 8.875% sales tax on top, 11.00 with 10% VAT inside, and 12.5%
+	// service on the 21.00 subtotal.
+	totals := invoice.Totals
+	if totals.Currency != "USD" || totals.Subtotal.String() != "21.00" || totals.ServiceCharge.String() != "2.63" || totals.Total.String() != "24.52" {
+		t.Fatalf("totals = %+v", totals)
+	}
+	if len(totals.Taxes) != 2 {
+		t.Fatalf("tax lines = %+v", totals.Taxes)
+	}
+	salesTax, vat := totals.Taxes[0], totals.Taxes[1]
+	if salesTax.TaxRateId != salesTaxId || salesTax.Taxable.String() != "10.00" || salesTax.Amount.String() != "0.89" {
+		t.Errorf("sales tax line = %+v", salesTax)
+	}
+	if vat.TaxRateId != vatId || !vat.Inclusive || vat.Taxable.String() != "10.00" || vat.Amount.String() != "1.00" {
+		t.Errorf("VAT line = %+v", vat)
+	}
+
+	var view controllers.InvoiceViewFromat
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, http.StatusOK, &view)
+	if view.PaymentDue != 24.52 || view.Totals.Total != totals.Total {
+		t.Fatalf("invoice view = %+v", view)
+	}
+
+	// Totals follow the order until the invoice is paid, then stay put.
+	s.expectStatus(http.MethodPut, "/order-item/"+items[0].OrderItemId, map[string]any{"count": 2}, http.StatusOK)
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, http.StatusOK, &view)
+	invoice = s.pay(invoice.InvoiceId, "CARD", view.Totals.Total, "slip-1").Invoice
+	if invoice.Totals.Subtotal.String() != "31.00" {
+		t.Fatalf("totals when paid = %+v", invoice.Totals)
+	}
+	s.expectStatus(http.MethodPut, "/tax-rate/"+salesTaxId, map[string]any{"rate": 20}, http.StatusOK)
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, http.StatusOK, &view)
+	if view.Totals.Total != invoice.Totals.Total {
+		t.Fatalf("paid invoice total moved from %s to %s", invoice.Totals.Total, view.Totals.Total)
+	}
+
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{
+		"orderId": items[0].OrderId, "serviceChargeRate": 150,
+	}, http.StatusBadRequest)
+}
+
+func TestSplitInvoiceByItem(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	burgerId := s.createFood(menuId, 10)
+	friesId := s.createFood(menuId, 4)
+	items := s.createOrderItems(s.createTable(5, 2),
+		map[string]any{"foodId": burgerId, "quantity": "M"},
+		map[string]any{"foodId": friesId, "quantity": "M"},
+		map[string]any{"foodId": friesId, "quantity": "M", "count": 2},
+	)
+	orderId := items[0].OrderId
+
+	split := func(bills ...[]string) map[string]any {
+		return map[string]any{"orderId": orderId, "mode": "ITEM", "bills": bills}
+	}
+	s.expectStatus(http.MethodPost, "/invoice/split", map[string]any{"orderId": orderId, "mode": "ITEM"}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/split", split([]string{items[0].OrderItemId}), http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/split", split(
+		[]string{items[0].OrderItemId, items[1].OrderItemId},
+		[]string{items[1].OrderItemId, items[2].OrderItemId},
+	), http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/split", split([]string{"missing"}), http.StatusBadRequest)
+
+	var invoices []models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/split", split(
+		[]string{items[0].OrderItemId, items[1].OrderItemId},
+		[]string{items[2].OrderItemId},
+	), http.StatusCreated, &invoices)
+	if len(invoices) != 2 {
+		t.Fatalf("len(invoices) = %d, want 2", len(invoices))
+	}
+	if invoices[0].Totals.Total.String() != "14.00" || invoices[1].Totals.Total.String() != "8.00" {
+		t.Errorf("split totals = %s, %s", invoices[0].Totals.Total, invoices[1].Totals.Total)
+	}
+
+	var view controllers.InvoiceViewFromat
+	s.mustDo(http.MethodGet, "/invoice/"+invoices[1].InvoiceId, nil, http.StatusOK, &view)
+	if view.PaymentDue != float64(8) {
+		t.Fatalf("split invoice view = %+v", view)
+	}
+
+	var listed []models.Invoice
+	s.mustDo(http.MethodGet, "/invoice/order/"+orderId, nil, http.StatusOK, &listed)
+	if len(listed) != 2 || listed[0].InvoiceId != invoices[0].InvoiceId {
+		t.Fatalf("order invoices = %+v", listed)
+	}
+
+	s.expectStatus(http.MethodPost, "/invoice/split", split([]string{items[0].OrderItemId}), http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusConflict)
+}
+
+func TestSplitInvoiceBySeat(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	burgerId := s.createFood(menuId, 10)
+	friesId := s.createFood(menuId, 4)
+	items := s.createOrderItems(s.createTable(5, 3),
+		map[string]any{"foodId": burgerId, "quantity": "M", "seat": 2},
+		map[string]any{"foodId": friesId, "quantity": "M", "seat": 1},
+		map[string]any{"foodId": burgerId, "quantity": "M", "seat": 2},
+		map[string]any{"foodId": friesId, "quantity": "M"},
+	)
+
+	var invoices []models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/split", map[string]any{
+		"orderId": items[0].OrderId, "mode": "SEAT",
+	}, http.StatusCreated, &invoices)
+	if len(invoices) != 3 {
+		t.Fatalf("len(invoices) = %d, want 3", len(invoices))
+	}
+	if *invoices[0].Seat != 1 || invoices[0].Totals.Total.String() != "4.00" {
+		t.Errorf("seat 1 invoice = %+v", invoices[0])
+	}
+	if *invoices[1].Seat != 2 || invoices[1].Totals.Total.String() != "20.00" || len(invoices[1].OrderItemIds) != 2 {
+		t.Errorf("seat 2 invoice = %+v", invoices[1])
+	}
+	if invoices[2].Seat != nil || invoices[2].Totals.Total.String() != "4.00" {
+		t.Errorf("shared invoice = %+v", invoices[2])
+	}
+}
+
+func TestSplitInvoiceEvenlySettlesOrder(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	items := s.createOrderItems(s.createTable(5, 3),
+		map[string]any{"foodId": s.createFood(menuId, 10), "quantity": "M"},
+	)
+	orderId := items[0].OrderId
+
+	s.expectStatus(http.MethodPost, "/invoice/split", map[string]any{"orderId": orderId, "mode": "EVEN", "guests": 1}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/split", map[string]any{"orderId": "missing", "mode": "EVEN", "guests": 3}, http.StatusNotFound)
+
+	var invoices []models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/split", map[string]any{
+		"orderId": orderId, "mode": "EVEN", "guests": 3,
+	}, http.StatusCreated, &invoices)
+	var shares []string
+	for _, invoice := range invoices {
+		shares = append(shares, invoice.Totals.Total.String())
+	}
+	if len(shares) != 3 || shares[0] != "3.34" || shares[1] != "3.33" || shares[2] != "3.33" {
+		t.Fatalf("even shares = %v", shares)
+	}
+
+	for _, status := range []string{"SENT_TO_KITCHEN", "PREPARING", "READY", "SERVED"} {
+		s.setOrderStatus(orderId, status, http.StatusOK)
+	}
+	s.setOrderStatus(orderId, "PAID", http.StatusConflict)
+
+	var order models.Order
+	for i, invoice := range invoices {
+		s.pay(invoice.InvoiceId, "CASH", invoice.Totals.Total, "")
+		s.mustDo(http.MethodGet, "/order/"+orderId, nil, http.StatusOK, &order)
+		if settled := order.Status == "PAID"; settled != (i == len(invoices)-1) {
+			t.Fatalf("after paying %d of %d invoices, order status = %s", i+1, len(invoices), order.Status)
+		}
+	}
+}
+
+func TestInvoicePaymentsLedger(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	items := s.createOrderItems(s.createTable(5, 2),
+		map[string]any{"foodId": s.createFood(menuId, 10), "quantity": "M", "count": 3},
+	)
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": items[0].OrderId}, http.StatusCreated, &invoice)
+	paymentPath := "/invoice/" + invoice.InvoiceId + "/payment"
+
+	s.expectStatus(http.MethodPost, paymentPath, map[string]any{"method": "CHEQUE", "amount": 5}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, paymentPath, map[string]any{"method": "CASH", "amount": 0}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, paymentPath, map[string]any{"method": "CARD", "amount": 5}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, paymentPath, map[string]any{"method": "CARD", "amount": 5, "reference": "slip", "change": 1}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, paymentPath, map[string]any{"method": "CASH", "amount": 5, "change": 6}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/missing/payment", map[string]any{"method": "CASH", "amount": 5}, http.StatusNotFound)
+
+	receipt := s.pay(invoice.InvoiceId, "VOUCHER", "10.50", "GIFT-42")
+	if *receipt.Invoice.PaymentStatus != "PARTIALLY_PAID" || receipt.Invoice.AmountPaid.String() != "10.50" {
+		t.Fatalf("after voucher: %+v", receipt.Invoice)
+	}
+	s.expectStatus(http.MethodPut, "/invoice/"+invoice.InvoiceId, map[string]any{"paymentStatus": "PAID"}, http.StatusConflict)
+	s.expectStatus(http.MethodPut, "/invoice/"+invoice.InvoiceId, map[string]any{"paymentStatus": "PENDING"}, http.StatusConflict)
+
+	receipt = s.pay(invoice.InvoiceId, "WALLET", 9, "wallet-7")
+	if *receipt.Invoice.PaymentStatus != "PARTIALLY_PAID" {
+		t.Fatalf("after wallet: %+v", receipt.Invoice)
+	}
+
+	// 10.50 of 30.00 is still due, so a 20.00 note gets 9.50 back.
+	receipt = s.pay(invoice.InvoiceId, "CASH", 20, "")
+	if receipt.Payment.Change.String() != "9.50" || *receipt.Invoice.PaymentStatus != "PAID" || receipt.Invoice.AmountPaid.String() != "30.00" {
+		t.Fatalf("after cash: payment %+v, invoice %+v", receipt.Payment, receipt.Invoice)
+	}
+	s.expectStatus(http.MethodPost, paymentPath, map[string]any{"method": "CASH", "amount": 1}, http.StatusConflict)
+
+	var view controllers.InvoiceViewFromat
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, http.StatusOK, &view)
+	if view.AmountPaid.String() != "30.00" || view.BalanceDue != 0 {
+		t.Fatalf("invoice view = %+v", view)
+	}
+
+	var payments []models.Payment
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId+"/payments", nil, http.StatusOK, &payments)
+	if len(payments) != 3 || *payments[0].Method != "VOUCHER" || payments[0].Reference != "GIFT-42" || payments[2].ReceivedBy != "test-ADMIN" {
+		t.Fatalf("payments = %+v", payments)
+	}
+}
+
+func TestInvoiceOverpaid(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	items := s.createOrderItems(s.createTable(5, 2),
+		map[string]any{"foodId": s.createFood(menuId, 10), "quantity": "M"},
+	)
+	var invoice models.Invoice
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"orderId": items[0].OrderId, "paymentStatus": "PAID"}, http.StatusConflict)
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": items[0].OrderId}, http.StatusCreated, &invoice)
+
+	// Cards give no change, so paying 12.00 on a 10.00 bill overpays it.
+	receipt := s.pay(invoice.InvoiceId, "CARD", 12, "slip-9")
+	if *receipt.Invoice.PaymentStatus != "OVERPAID" || receipt.Payment.Change.String() != "0.00" {
+		t.Fatalf("after card: payment %+v, invoice %+v", receipt.Payment, receipt.Invoice)
+	}
+	s.mustDo(http.MethodPut, "/invoice/"+invoice.InvoiceId, map[string]any{"paymentStatus": "PAID"}, http.StatusOK, &invoice)
+	if *invoice.PaymentStatus != "OVERPAID" {
+		t.Fatalf("status = %s, want OVERPAID", *invoice.PaymentStatus)
+	}
+}
+
+func TestInvoiceVoidAndRefunds(t *testing.T) {
+	s := newTestServer(t)
+	s.signup("Ada Admin", "ada@example.com", "secret1")
+	manager := s.signup("Max Manager", "max@example.com", "secret1")
+	s.expectStatus(http.MethodPut, "/user/"+manager.UserId+"/role", map[string]any{"role": "MANAGER"}, http.StatusOK)
+	waiter := s.signup("Walter Waiter", "walter@example.com", "secret1")
+	cashier := s.tokenFor(constants.ROLE_CASHIER)
+
+	menuId := s.createMenu()
+	items := s.createOrderItems(s.createTable(5, 2),
+		map[string]any{"foodId": s.createFood(menuId, 10), "quantity": "M"},
+		map[string]any{"foodId": s.createFood(menuId, 4), "quantity": "M"},
+	)
+	orderId := items[0].OrderId
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	voidPath := "/invoice/" + invoice.InvoiceId + "/void"
+	for _, tc := range []struct {
+		body map[string]any
+		want int
+	}{
+		{map[string]any{"reasonCode": "DUPLICATE"}, http.StatusBadRequest},
+		{map[string]any{"reasonCode": "DUPLICATE", "approvedBy": "missing", "approverPassword": "secret1"}, http.StatusBadRequest},
+		{map[string]any{"reasonCode": "DUPLICATE", "approvedBy": waiter.UserId, "approverPassword": "secret1"}, http.StatusForbidden},
+		{map[string]any{"approvedBy": manager.UserId, "approverPassword": "secret1"}, http.StatusBadRequest},
+		{map[string]any{"reasonCode": "DUPLICATE", "approvedBy": manager.UserId}, http.StatusBadRequest},
+		{map[string]any{"reasonCode": "DUPLICATE", "approvedBy": manager.UserId, "approverPassword": "wrong"}, http.StatusForbidden},
+		{map[string]any{"reasonCode": "DUPLICATE", "approvedBy": manager.UserId, "approverPassword": "secret1"}, http.StatusOK},
+		{map[string]any{"reasonCode": "DUPLICATE", "approvedBy": manager.UserId, "approverPassword": "secret1"}, http.StatusConflict},
+	} {
+		if code, resp := s.doWithToken(http.MethodPost, voidPath, cashier, tc.body); code != tc.want {
+			t.Fatalf("void %v: status = %d, want %d (%s)", tc.body, code, tc.want, resp.Message)
+		}
+	}
+	s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payment", map[string]any{"method": "CASH", "amount": 14}, http.StatusConflict)
+	s.expectStatus(http.MethodPut, "/invoice/"+invoice.InvoiceId, map[string]any{"paymentStatus": "PAID"}, http.StatusConflict)
+
+	// A voided bill can be issued again.
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	refundPath := "/invoice/" + invoice.InvoiceId + "/refund"
+	s.expectStatus(http.MethodPost, refundPath, map[string]any{"reasonCode": "QUALITY"}, http.StatusConflict)
+	s.pay(invoice.InvoiceId, "CARD", 14, "slip-1")
+	s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]any{"reasonCode": "DUPLICATE"}, http.StatusConflict)
+	s.expectStatus(http.MethodDelete, "/order/"+orderId, nil, http.StatusConflict)
+
+	var receipt controllers.PaymentReceipt
+	s.mustDo(http.MethodPost, refundPath, map[string]any{
+		"reasonCode": "QUALITY", "orderItemId": items[1].OrderItemId,
+	}, http.StatusCreated, &receipt)
+	if receipt.Payment.Type != "REFUND" || receipt.Payment.Amount.String() != "-4.00" || *receipt.Payment.Method != "CARD" {
+		t.Fatalf("item refund = %+v", receipt.Payment)
+	}
+	if *receipt.Invoice.PaymentStatus != "PARTIALLY_REFUNDED" || receipt.Invoice.AmountRefunded.String() != "4.00" {
+		t.Fatalf("invoice after item refund = %+v", receipt.Invoice)
+	}
+	s.expectStatus(http.MethodPost, refundPath, map[string]any{"reasonCode": "QUALITY", "orderItemId": items[1].OrderItemId}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, refundPath, map[string]any{"reasonCode": "QUALITY", "orderItemId": "missing"}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, refundPath, map[string]any{"reasonCode": "QUALITY", "amount": 20}, http.StatusBadRequest)
+
+	s.mustDo(http.MethodPost, refundPath, map[string]any{
+		"reasonCode": "CUSTOMER_COMPLAINT", "method": "CASH",
+	}, http.StatusCreated, &receipt)
+	if receipt.Payment.Amount.String() != "-10.00" || *receipt.Invoice.PaymentStatus != "REFUNDED" {
+		t.Fatalf("full refund: payment %+v, invoice %+v", receipt.Payment, receipt.Invoice)
+	}
+	s.expectStatus(http.MethodPost, refundPath, map[string]any{"reasonCode": "QUALITY"}, http.StatusConflict)
+
+	var ledger []models.Payment
+	s.mustDo(http.MethodGet, "/invoice/order/"+orderId+"/ledger", nil, http.StatusOK, &ledger)
+	var amounts []string
+	for _, entry := range ledger {
+		amounts = append(amounts, entry.Type+" "+entry.Amount.String())
+	}
+	if want := "[VOID -14.00 PAYMENT 14.00 REFUND -4.00 REFUND -10.00]"; fmt.Sprint(amounts) != want {
+		t.Fatalf("ledger = %v, want %s", amounts, want)
+	}
+	if r := ledger[0].Reversal; r.ApprovedBy != manager.UserId || r.RecordedBy != "test-CASHIER" || r.ReasonCode != "DUPLICATE" {
+		t.Errorf("void reversal = %+v", r)
+	}
+	if r := ledger[3].Reversal; r.ApprovedBy != "test-ADMIN" {
+		t.Errorf("refund reversal = %+v", r)
+	}
+}
+
+func TestRefundNeedsSettledInvoice(t *testing.T) {
+	s := newTestServer(t)
+	items := s.createOrderItems(s.createTable(5, 2), map[string]any{"foodId": s.createFood(s.createMenu(), 10), "quantity": "M"})
+	orderId := items[0].OrderId
+	for _, status := range []string{"SENT_TO_KITCHEN", "PREPARING", "READY", "SERVED"} {
+		s.setOrderStatus(orderId, status, http.StatusOK)
+	}
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	refundPath := "/invoice/" + invoice.InvoiceId + "/refund"
+	s.pay(invoice.InvoiceId, "CARD", 4, "slip-1")
+	s.expectStatus(http.MethodPost, refundPath, map[string]any{"reasonCode": "QUALITY", "amount": 1}, http.StatusConflict)
+
+	receipt := s.pay(invoice.InvoiceId, "CARD", 6, "slip-2")
+	if *receipt.Invoice.PaymentStatus != "PAID" {
+		t.Fatalf("invoice after paying the balance = %+v", receipt.Invoice)
+	}
+	var order models.Order
+	s.mustDo(http.MethodGet, "/order/"+orderId, nil, http.StatusOK, &order)
+	if order.Status != "PAID" {
+		t.Fatalf("order status = %s, want PAID", order.Status)
+	}
+	s.mustDo(http.MethodPost, refundPath, map[string]any{"reasonCode": "QUALITY", "amount": 1}, http.StatusCreated, &receipt)
+	if *receipt.Invoice.PaymentStatus != "PARTIALLY_REFUNDED" {
+		t.Fatalf("invoice after refund = %+v", receipt.Invoice)
+	}
+}
+
+// fetch sends an authenticated GET with an Accept header and returns the raw
+// response.
+func (s *testServer) fetch(path, accept string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestInvoiceReceiptFormats(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	items := s.createOrderItems(s.createTable(4, 2), map[string]any{"foodId": foodId, "quantity": "M", "count": 2})
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": items[0].OrderId}, http.StatusCreated, &invoice)
+	s.pay(invoice.InvoiceId, "CASH", 25, "")
+	path := "/invoice/" + invoice.InvoiceId
+
+	for _, tc := range []struct {
+		path, accept, contentType string
+		want                      []string
+	}{
+		{path + "?format=text", "", "text/plain", []string{"Burger (M)", "TOTAL USD", "20.00", "Change", "-5.00", "PAID"}},
+		{path, "text/plain", "text/plain", []string{"Table", "Balance due"}},
+		{path, "application/pdf", "application/pdf", []string{"%PDF-1.4", "/BaseFont /Courier", "%%EOF"}},
+		{path + "?format=escpos", "application/pdf", "application/vnd.escpos", []string{"\x1b@", "TOTAL USD"}},
+		{path, "", "application/json", []string{`"success":true`, `"totals"`}},
+		{path, "text/html, */*;q=0.1", "application/json", []string{`"success":true`}},
+	} {
+		rec := s.fetch(tc.path, tc.accept)
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), tc.contentType) {
+			t.Fatalf("GET %s (Accept %q): %d %s", tc.path, tc.accept, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		body := rec.Body.Bytes()
+		for _, want := range tc.want {
+			if !bytes.Contains(body, []byte(want)) {
+				t.Errorf("GET %s (Accept %q) has no %q:\n%s", tc.path, tc.accept, want, body)
+			}
+		}
+	}
+
+	if rec := s.fetch(path+"?format=xml", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown format status = %d", rec.Code)
+	}
+	if rec := s.fetch(path, "image/png"); rec.Code != http.StatusNotAcceptable {
+		t.Errorf("unacceptable Accept status = %d", rec.Code)
+	}
+}
+
+func TestInvoiceNumbering(t *testing.T) {
+	helpers.SetInvoiceNumbering(helpers.InvoiceNumbering{
+		Prefix: "TST", Series: constants.INVOICE_SERIES_BRANCH_YEAR, Branch: "DT", FiscalYearStart: time.January,
+	})
+	t.Cleanup(func() {
+		helpers.SetInvoiceNumbering(helpers.InvoiceNumbering{
+			Prefix: "INV", Series: constants.INVOICE_SERIES_YEAR, FiscalYearStart: time.January,
+		})
+	})
+
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	tableId := s.createTable(1, 4)
+	newOrder := func() string {
+		return s.createOrderItems(tableId, map[string]any{"foodId": foodId, "quantity": "M"})[0].OrderId
+	}
+	series := fmt.Sprintf("TST-DT-%d-", time.Now().Year())
+
+	var first models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": newOrder(), "invoiceNumber": "TST-DT-1999-000007"}, http.StatusCreated, &first)
+	if first.InvoiceNumber != series+"000001" || first.FiscalYear != time.Now().Year() {
+		t.Fatalf("first invoice number = %q (%d)", first.InvoiceNumber, first.FiscalYear)
+	}
+
+	var shares []models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/split", map[string]any{"orderId": newOrder(), "mode": "EVEN", "guests": 2}, http.StatusCreated, &shares)
+	if shares[0].InvoiceNumber != series+"000002" || shares[1].InvoiceNumber != series+"000003" {
+		t.Fatalf("split invoice numbers = %q, %q", shares[0].InvoiceNumber, shares[1].InvoiceNumber)
+	}
+
+	// A failed create uses up no number, and a voided invoice keeps its own.
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"orderId": first.OrderId}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/invoice/"+first.InvoiceId+"/void", map[string]any{"reasonCode": "DUPLICATE"}, http.StatusOK)
+	var reissued models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": first.OrderId}, http.StatusCreated, &reissued)
+	if reissued.InvoiceNumber != series+"000004" {
+		t.Fatalf("reissued invoice number = %q", reissued.InvoiceNumber)
+	}
+	s.expectStatus(http.MethodPut, "/invoice/"+reissued.InvoiceId, map[string]any{"invoiceNumber": "X", "paymentMethod": "CARD"}, http.StatusOK)
+	var voided models.Invoice
+	s.mustDo(http.MethodGet, "/invoice/"+first.InvoiceId, nil, http.StatusOK, &voided)
+	if voided.InvoiceNumber != first.InvoiceNumber {
+		t.Fatalf("voided invoice number = %q, want %q", voided.InvoiceNumber, first.InvoiceNumber)
+	}
+	var view controllers.InvoiceViewFromat
+	s.mustDo(http.MethodGet, "/invoice/"+reissued.InvoiceId, nil, http.StatusOK, &view)
+	if view.InvoiceNumber != reissued.InvoiceNumber {
+		t.Fatalf("invoice number after update = %q", view.InvoiceNumber)
+	}
+
+	// Concurrent creates get consecutive numbers with no repeats.
+	orderIds := make([]string, 10)
+	for i := range orderIds {
+		orderIds[i] = newOrder()
+	}
+	numbers := make(chan string, len(orderIds))
+	var wg sync.WaitGroup
+	for _, orderId := range orderIds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, resp := s.do(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId})
+			var invoice models.Invoice
+			if code == http.StatusCreated && json.Unmarshal(resp.Data, &invoice) == nil {
+				numbers <- invoice.InvoiceNumber
+			} else {
+				numbers <- fmt.Sprintf("status %d: %s", code, resp.Message)
+			}
+		}()
+	}
+	wg.Wait()
+	close(numbers)
+	var got []string
+	for number := range numbers {
+		got = append(got, number)
+	}
+	slices.Sort(got)
+	for i, number := range got {
+		if want := fmt.Sprintf("%s%06d", series, i+5); number != want {
+			t.Fatalf("concurrent invoice numbers = %v", got)
+		}
+	}
+}
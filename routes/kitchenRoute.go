@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func KitchenRoute(router *gin.Engine, store *repository.Store) {
+	kitchenGroup := router.Group("/kitchen")
+	kitchenGroup.Use(middlewares.Authenticate(store))
+	kitchenGroup.GET("/feed", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_KITCHEN, constants.ROLE_WAITER), controllers.KitchenFeed(store))
+}
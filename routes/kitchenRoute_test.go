@@ -0,0 +1,191 @@
+package routes
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/events"
+)
+
+type sseEvent struct {
+	id    string
+	name  string
+	event events.Event
+}
+
+type feedClient struct {
+	t      *testing.T
+	resp   *http.Response
+	events chan sseEvent
+}
+
+func (s *testServer) openFeed(server *httptest.Server, query, lastEventId string) *feedClient {
+	s.t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/kitchen/feed"+query, nil)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.tokenFor(constants.ROLE_KITCHEN))
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		s.t.Fatalf("feed status = %d", resp.StatusCode)
+	}
+
+	feed := &feedClient{t: s.t, resp: resp, events: make(chan sseEvent, 100)}
+	go func() {
+		defer close(feed.events)
+		scanner := bufio.NewScanner(resp.Body)
+		var current sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if current.name != "" {
+					feed.events <- current
+				}
+				current = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				current.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				current.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.event)
+			}
+		}
+	}()
+	s.t.Cleanup(func() { resp.Body.Close() })
+	return feed
+}
+
+func (f *feedClient) next() sseEvent {
+	f.t.Helper()
+
+	select {
+	case e, ok := <-f.events:
+		if !ok {
+			f.t.Fatal("feed closed")
+		}
+		return e
+	case <-time.After(2 * time.Second):
+		f.t.Fatal("timed out waiting for event")
+	}
+	return sseEvent{}
+}
+
+func (f *feedClient) expectNone() {
+	f.t.Helper()
+
+	select {
+	case e := <-f.events:
+		f.t.Fatalf("unexpected event %s %+v", e.name, e.event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestKitchenFeedStreamsOrderChanges(t *testing.T) {
+	s := newTestServer(t)
+	server := httptest.NewServer(s.router)
+	t.Cleanup(server.Close)
+
+	menuId := s.createMenu()
+	var grill, bar idResponse
+	s.mustDo(http.MethodPost, "/food/create", map[string]any{
+		"name": "Steak", "price": 25, "foodImage": "steak.png", "menuId": menuId, "station": "grill",
+	}, http.StatusCreated, &grill)
+	s.mustDo(http.MethodPost, "/food/create", map[string]any{
+		"name": "Mojito", "price": 8, "foodImage": "mojito.png", "menuId": menuId, "station": "bar",
+	}, http.StatusCreated, &bar)
+	tableId := s.createTable(1, 2)
+
+	grillFeed := s.openFeed(server, "?station=grill", "")
+
+	items := s.createOrderItems(tableId,
+		map[string]any{"foodId": grill.FoodId, "quantity": "M"},
+		map[string]any{"foodId": bar.FoodId, "quantity": "M"},
+	)
+
+	if e := grillFeed.next(); e.name != constants.EVENT_ORDER_CREATED || e.event.OrderId != items[0].OrderId {
+		t.Fatalf("first event = %s %+v", e.name, e.event)
+	}
+	e := grillFeed.next()
+	if e.name != constants.EVENT_ORDER_ITEM_CREATED || e.event.Stations[0] != "grill" {
+		t.Fatalf("item event = %s %+v", e.name, e.event)
+	}
+	data := e.event.Data.(map[string]any)
+	if data["foodName"] != "Steak" {
+		t.Errorf("item event data = %v", data)
+	}
+
+	s.expectStatus(http.MethodPut, "/order-item/"+items[0].OrderItemId, map[string]any{"quantity": "L"}, http.StatusOK)
+	if e := grillFeed.next(); e.name != constants.EVENT_ORDER_ITEM_UPDATED {
+		t.Fatalf("update event = %s", e.name)
+	}
+
+	s.setOrderStatus(items[0].OrderId, "SENT_TO_KITCHEN", http.StatusOK)
+	e = grillFeed.next()
+	if e.name != constants.EVENT_ORDER_STATUS_CHANGED || e.event.Data.(map[string]any)["status"] != "SENT_TO_KITCHEN" {
+		t.Fatalf("status event = %s %+v", e.name, e.event)
+	}
+	grillFeed.expectNone()
+}
+
+func TestKitchenFeedDropsMergedOrders(t *testing.T) {
+	s := newTestServer(t)
+	server := httptest.NewServer(s.router)
+	t.Cleanup(server.Close)
+
+	tableId := s.createTable(1, 2)
+	check := s.createOrder(tableId)
+	second := s.createOrder(tableId)
+
+	feed := s.openFeed(server, "", "")
+	s.expectStatus(http.MethodPost, "/order/"+check+"/merge", map[string]any{"orderIds": []string{second}}, http.StatusOK)
+	if e := feed.next(); e.name != constants.EVENT_ORDER_DELETED || e.event.OrderId != second {
+		t.Fatalf("merge event = %s %+v", e.name, e.event)
+	}
+	feed.expectNone()
+}
+
+func TestKitchenFeedResume(t *testing.T) {
+	s := newTestServer(t)
+	server := httptest.NewServer(s.router)
+	t.Cleanup(server.Close)
+
+	tableId := s.createTable(1, 2)
+	s.createOrder(tableId)
+	second := s.createOrder(tableId)
+	third := s.createOrder(tableId)
+
+	feed := s.openFeed(server, "", "1")
+	for _, want := range []string{second, third} {
+		if e := feed.next(); e.event.OrderId != want {
+			t.Fatalf("resumed event for order %s, want %s", e.event.OrderId, want)
+		}
+	}
+	feed.expectNone()
+
+	stale := s.openFeed(server, "?lastEventId=999", "")
+	if e := stale.next(); e.name != "resync" {
+		t.Fatalf("stale resume event = %s", e.name)
+	}
+
+	s.expectStatus(http.MethodGet, "/kitchen/feed?lastEventId=abc", nil, http.StatusBadRequest)
+	code, _ := s.doWithToken(http.MethodGet, "/kitchen/feed", s.tokenFor(constants.ROLE_CASHIER), nil)
+	if code != http.StatusForbidden {
+		t.Fatalf("cashier feed status = %d", code)
+	}
+}
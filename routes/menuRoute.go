@@ -2,16 +2,18 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
 	"github.com/jrskg/go-restaurant/controllers"
 	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
 )
 
-func MenuRoute(router *gin.Engine) {
+func MenuRoute(router *gin.Engine, store *repository.Store) {
 	menuGroup := router.Group("/menu")
-	menuGroup.Use(middlewares.Authenticate())
-	menuGroup.POST("/create", controllers.CreateMenu())
-	menuGroup.PUT("/:menuId", controllers.UpdateMenu())
-	menuGroup.DELETE("/:menuId", controllers.DeleteMenu())
-	menuGroup.GET("/:menuId", controllers.GetMenu())
-	menuGroup.GET("/all", controllers.GetAllMenus())
+	menuGroup.Use(middlewares.Authenticate(store))
+	menuGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER), controllers.CreateMenu(store))
+	menuGroup.PUT("/:menuId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.UpdateMenu(store))
+	menuGroup.DELETE("/:menuId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.DeleteMenu(store))
+	menuGroup.GET("/:menuId", controllers.GetMenu(store))
+	menuGroup.GET("/all", controllers.GetAllMenus(store))
 }
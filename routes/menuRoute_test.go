@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func TestMenuCRUD(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+
+	var menu models.Menu
+	s.mustDo(http.MethodGet, "/menu/"+menuId, nil, http.StatusOK, &menu)
+	if menu.Name != "Dinner" || menu.Category != "Mains" {
+		t.Fatalf("GET menu = %+v", menu)
+	}
+
+	start := time.Now().Add(time.Hour)
+	end := start.Add(24 * time.Hour)
+	s.expectStatus(http.MethodPut, "/menu/"+menuId, map[string]any{
+		"name":      "Late dinner",
+		"startDate": start,
+		"endDate":   end,
+	}, http.StatusOK)
+
+	s.mustDo(http.MethodGet, "/menu/"+menuId, nil, http.StatusOK, &menu)
+	if menu.Name != "Late dinner" || menu.StartDate == nil || !menu.StartDate.Equal(start) {
+		t.Fatalf("menu after update = %+v", menu)
+	}
+
+	var menus []models.Menu
+	s.mustDo(http.MethodGet, "/menu/all", nil, http.StatusOK, &menus)
+	if len(menus) != 1 {
+		t.Fatalf("len(menus) = %d, want 1", len(menus))
+	}
+
+	s.expectStatus(http.MethodDelete, "/menu/"+menuId, nil, http.StatusOK)
+	s.expectStatus(http.MethodGet, "/menu/"+menuId, nil, http.StatusNotFound)
+	s.expectStatus(http.MethodDelete, "/menu/"+menuId, nil, http.StatusNotFound)
+}
+
+func TestMenuValidation(t *testing.T) {
+	s := newTestServer(t)
+
+	resp := s.expectStatus(http.MethodPost, "/menu/create", nil, http.StatusBadRequest)
+	if resp.Message != "request body is empty" {
+		t.Errorf("empty body message = %q", resp.Message)
+	}
+	s.expectStatus(http.MethodPost, "/menu/create", map[string]any{"name": "Dinner"}, http.StatusBadRequest)
+
+	menuId := s.createMenu()
+	s.expectStatus(http.MethodPut, "/menu/"+menuId, map[string]any{"name": "x"}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/menu/"+menuId, map[string]any{
+		"startDate": time.Now().Add(-time.Hour),
+		"endDate":   time.Now().Add(time.Hour),
+	}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/menu/missing", map[string]any{"name": "Lunch"}, http.StatusNotFound)
+}
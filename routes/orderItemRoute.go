@@ -2,16 +2,19 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
 	"github.com/jrskg/go-restaurant/controllers"
 	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
 )
 
-func OrderItemRoute(router *gin.Engine) {
+func OrderItemRoute(router *gin.Engine, store *repository.Store) {
 	orderItemGroup := router.Group("/order-item")
-	orderItemGroup.Use(middlewares.Authenticate())
-	orderItemGroup.POST("/create", controllers.CreateOrderItem())
-	orderItemGroup.PUT("/:orderItemId", controllers.UpdateOrderItem())
-	orderItemGroup.GET("/order/:orderId", controllers.GetOrderItemsByOrder())
-	orderItemGroup.GET("/:orderItemId", controllers.GetOrderItem())
+	orderItemGroup.Use(middlewares.Authenticate(store))
+	orderItemGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), middlewares.BusinessDayOpen(store), controllers.CreateOrderItem(store))
+	orderItemGroup.PUT("/:orderItemId", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), middlewares.BusinessDayOpen(store), controllers.UpdateOrderItem(store))
+	orderItemGroup.POST("/:orderItemId/void", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER, constants.ROLE_CASHIER), middlewares.BusinessDayOpen(store), controllers.VoidOrderItem(store))
+	orderItemGroup.GET("/order/:orderId", controllers.GetOrderItemsByOrder(store))
+	orderItemGroup.GET("/:orderItemId", controllers.GetOrderItem(store))
 	//todo: add more routes
 }
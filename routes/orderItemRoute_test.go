@@ -0,0 +1,230 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func (s *testServer) createOrderItems(tableId string, items ...map[string]any) []models.OrderItem {
+	s.t.Helper()
+
+	var created []models.OrderItem
+	s.mustDo(http.MethodPost, "/order-item/create", map[string]any{
+		"tableId":    tableId,
+		"orderItems": items,
+	}, http.StatusCreated, &created)
+	return created
+}
+
+func TestOrderItemCreateAndGet(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	burgerId := s.createFood(menuId, 10)
+	friesId := s.createFood(menuId, 4)
+	tableId := s.createTable(3, 2)
+
+	items := s.createOrderItems(tableId,
+		map[string]any{"foodId": burgerId, "quantity": "L"},
+		map[string]any{"foodId": friesId, "quantity": "S"},
+	)
+	if len(items) != 2 || items[0].OrderId == "" || items[0].OrderId != items[1].OrderId {
+		t.Fatalf("created items = %+v", items)
+	}
+	if items[0].UnitPrice.String() != "15.00" || items[1].UnitPrice.String() != "3.00" || items[0].Currency != "USD" {
+		t.Errorf("unit prices = %s, %s, want 15.00, 3.00", items[0].UnitPrice, items[1].UnitPrice)
+	}
+
+	var item models.OrderItem
+	s.mustDo(http.MethodGet, "/order-item/"+items[0].OrderItemId, nil, http.StatusOK, &item)
+	if item.FoodId != burgerId || *item.Quantity != "L" {
+		t.Fatalf("GET order item = %+v", item)
+	}
+
+	var order models.Order
+	s.mustDo(http.MethodGet, "/order/"+items[0].OrderId, nil, http.StatusOK, &order)
+	if order.TableId != tableId {
+		t.Fatalf("order created for items = %+v", order)
+	}
+}
+
+func TestOrderItemUpdate(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	tableId := s.createTable(3, 2)
+	items := s.createOrderItems(tableId, map[string]any{"foodId": foodId, "quantity": "M"})
+
+	s.expectStatus(http.MethodPut, "/order-item/"+items[0].OrderItemId, map[string]any{"quantity": "L"}, http.StatusOK)
+
+	var item models.OrderItem
+	s.mustDo(http.MethodGet, "/order-item/"+items[0].OrderItemId, nil, http.StatusOK, &item)
+	if *item.Quantity != "L" || item.UnitPrice.String() != "15.00" || *item.Count != 1 || item.LineTotal.String() != "15.00" {
+		t.Fatalf("order item after update = %+v", item)
+	}
+
+	s.expectStatus(http.MethodPut, "/order-item/"+items[0].OrderItemId, map[string]any{"count": 3}, http.StatusOK)
+	s.mustDo(http.MethodGet, "/order-item/"+items[0].OrderItemId, nil, http.StatusOK, &item)
+	if *item.Count != 3 || item.LineTotal.String() != "45.00" {
+		t.Fatalf("order item after count update = %+v", item)
+	}
+	s.expectStatus(http.MethodPut, "/order-item/"+items[0].OrderItemId, map[string]any{"count": 0}, http.StatusBadRequest)
+
+	s.expectStatus(http.MethodPut, "/order-item/"+items[0].OrderItemId, map[string]any{"quantity": "XL"}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/order-item/missing", map[string]any{"quantity": "L"}, http.StatusNotFound)
+	s.expectStatus(http.MethodGet, "/order-item/missing", nil, http.StatusNotFound)
+}
+
+func TestOrderItemValidation(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	tableId := s.createTable(3, 2)
+
+	s.expectStatus(http.MethodPost, "/order-item/create", nil, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/order-item/create", map[string]any{"tableId": tableId}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/order-item/create", map[string]any{
+		"tableId":    tableId,
+		"orderItems": []map[string]any{{"quantity": "M"}},
+	}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/order-item/create", map[string]any{
+		"tableId":    tableId,
+		"orderItems": []map[string]any{{"foodId": "missing", "quantity": "M"}},
+	}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/order-item/create", map[string]any{
+		"tableId":    tableId,
+		"orderItems": []map[string]any{{"foodId": foodId, "quantity": "M", "count": 100}},
+	}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/order-item/create", map[string]any{
+		"tableId":    tableId,
+		"orderItems": []map[string]any{{"foodId": foodId, "quantity": "XL"}},
+	}, http.StatusBadRequest)
+}
+
+func TestOrderItemPricing(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	foodId := s.createFood(menuId, 10)
+	var pizza idResponse
+	s.mustDo(http.MethodPost, "/food/create", map[string]any{
+		"name": "Pizza", "price": 12, "foodImage": "pizza.png", "menuId": menuId,
+		"sizePrices": map[string]float64{"L": 17.5},
+	}, http.StatusCreated, &pizza)
+	tableId := s.createTable(3, 2)
+
+	items := s.createOrderItems(tableId,
+		map[string]any{"foodId": foodId, "quantity": "S", "unitPrice": 0.01},
+		map[string]any{"foodId": pizza.FoodId, "quantity": "L"},
+		map[string]any{"foodId": pizza.FoodId, "quantity": "M"},
+	)
+	for i, want := range []string{"7.50", "17.50", "12.00"} {
+		if items[i].UnitPrice.String() != want {
+			t.Errorf("item %d unitPrice = %s, want %s", i, items[i].UnitPrice, want)
+		}
+	}
+
+	// Later catalog changes leave priced items alone.
+	s.expectStatus(http.MethodPut, "/food/"+foodId, map[string]any{"price": 20}, http.StatusOK)
+	var item models.OrderItem
+	s.mustDo(http.MethodGet, "/order-item/"+items[0].OrderItemId, nil, http.StatusOK, &item)
+	if item.UnitPrice.String() != "7.50" {
+		t.Fatalf("snapshot price = %s, want 7.50", item.UnitPrice)
+	}
+
+	s.expectStatus(http.MethodPut, "/order-item/"+items[0].OrderItemId, map[string]any{"foodId": pizza.FoodId}, http.StatusOK)
+	s.mustDo(http.MethodGet, "/order-item/"+items[0].OrderItemId, nil, http.StatusOK, &item)
+	if item.UnitPrice.String() != "9.00" {
+		t.Fatalf("price after changing food = %s, want 9.00", item.UnitPrice)
+	}
+	s.expectStatus(http.MethodPut, "/order-item/"+items[0].OrderItemId, map[string]any{"foodId": "missing"}, http.StatusBadRequest)
+
+	s.expectStatus(http.MethodPost, "/food/create", map[string]any{
+		"name": "Soup", "price": 5, "foodImage": "soup.png", "menuId": menuId,
+		"sizePrices": map[string]float64{"XL": 9},
+	}, http.StatusBadRequest)
+}
+
+func TestItemsByOrder(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	burgerId := s.createFood(menuId, 10)
+	friesId := s.createFood(menuId, 4)
+	tableId := s.createTable(9, 2)
+	items := s.createOrderItems(tableId,
+		map[string]any{"foodId": burgerId, "quantity": "M"},
+		map[string]any{"foodId": friesId, "quantity": "S", "count": 2},
+	)
+
+	var summaries []models.OrderSummary
+	s.mustDo(http.MethodGet, "/order-item/order/"+items[0].OrderId, nil, http.StatusOK, &summaries)
+	if len(summaries) != 1 {
+		t.Fatalf("len(summaries) = %d, want 1", len(summaries))
+	}
+	summary := summaries[0]
+	if summary.PaymentDue.String() != "16.00" || summary.TotalCount != 3 || *summary.TableNumber != 9 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	if len(summary.OrderItems) != 2 || *summary.OrderItems[0].FoodName != "Burger" {
+		t.Fatalf("summary items = %+v", summary.OrderItems)
+	}
+	if fries := summary.OrderItems[1]; fries.Count != 2 || fries.UnitPrice.String() != "3.00" || fries.Amount.String() != "6.00" {
+		t.Fatalf("summary items = %+v", summary.OrderItems)
+	}
+
+	s.mustDo(http.MethodGet, "/order-item/order/missing", nil, http.StatusOK, &summaries)
+	if len(summaries) != 0 {
+		t.Fatalf("summaries for unknown order = %+v", summaries)
+	}
+}
+
+func TestVoidOrderItem(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	burgerId := s.createFood(menuId, 10)
+	friesId := s.createFood(menuId, 4)
+	items := s.createOrderItems(s.createTable(9, 2),
+		map[string]any{"foodId": burgerId, "quantity": "M"},
+		map[string]any{"foodId": friesId, "quantity": "M", "count": 2},
+		map[string]any{"foodId": friesId, "quantity": "M"},
+	)
+	orderId := items[0].OrderId
+	voidPath := "/order-item/" + items[1].OrderItemId + "/void"
+
+	s.expectStatus(http.MethodPost, voidPath, map[string]any{"reasonCode": "BORED"}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, voidPath, map[string]any{"reasonCode": "OTHER"}, http.StatusBadRequest)
+	code, resp := s.doWithToken(http.MethodPost, voidPath, s.tokenFor(constants.ROLE_WAITER), map[string]any{"reasonCode": "WRONG_ITEM"})
+	if code != http.StatusBadRequest {
+		t.Fatalf("waiter void without approval: status = %d (%s)", code, resp.Message)
+	}
+	s.expectStatus(http.MethodPost, "/order-item/missing/void", map[string]any{"reasonCode": "WRONG_ITEM"}, http.StatusNotFound)
+
+	var voided models.OrderItem
+	code, resp = s.doWithToken(http.MethodPost, voidPath, s.tokenFor(constants.ROLE_MANAGER), map[string]any{"reasonCode": "WRONG_ITEM"})
+	if code != http.StatusOK {
+		t.Fatalf("manager void: status = %d (%s)", code, resp.Message)
+	}
+	if err := json.Unmarshal(resp.Data, &voided); err != nil || voided.Void == nil || voided.Void.ApprovedBy != "test-MANAGER" {
+		t.Fatalf("voided item = %+v (%v)", voided, err)
+	}
+	s.expectStatus(http.MethodPost, voidPath, map[string]any{"reasonCode": "WRONG_ITEM"}, http.StatusConflict)
+	s.expectStatus(http.MethodPut, "/order-item/"+items[1].OrderItemId, map[string]any{"count": 3}, http.StatusConflict)
+
+	var summaries []models.OrderSummary
+	s.mustDo(http.MethodGet, "/order-item/order/"+orderId, nil, http.StatusOK, &summaries)
+	if len(summaries[0].OrderItems) != 2 || summaries[0].PaymentDue.String() != "14.00" {
+		t.Fatalf("summary after void = %+v", summaries[0])
+	}
+
+	var ledger []models.Payment
+	s.mustDo(http.MethodGet, "/invoice/order/"+orderId+"/ledger", nil, http.StatusOK, &ledger)
+	if len(ledger) != 1 || ledger[0].Type != "VOID" || ledger[0].Amount.String() != "-8.00" || ledger[0].OrderItemId != items[1].OrderItemId {
+		t.Fatalf("ledger = %+v", ledger)
+	}
+
+	// Once the bill is paid, taking an item off needs a refund.
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	s.pay(invoice.InvoiceId, "CASH", 5, "")
+	s.expectStatus(http.MethodPost, "/order-item/"+items[2].OrderItemId+"/void", map[string]any{"reasonCode": "QUALITY"}, http.StatusConflict)
+}
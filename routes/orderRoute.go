@@ -2,16 +2,22 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
 	"github.com/jrskg/go-restaurant/controllers"
 	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
 )
 
-func OrderRoute(router *gin.Engine) {
+func OrderRoute(router *gin.Engine, store *repository.Store) {
 	orderGroup := router.Group("/order")
-	orderGroup.Use(middlewares.Authenticate())
-	orderGroup.POST("/create", controllers.CreateOrder())
-	orderGroup.PUT("/:orderId", controllers.UpdateOrder())
-	orderGroup.DELETE("/:orderId", controllers.DeleteOrder())
-	orderGroup.GET("/:orderId", controllers.GetOrder())
-	orderGroup.GET("/all", controllers.GetAllOrders())
+	orderGroup.Use(middlewares.Authenticate(store))
+	orderGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), middlewares.BusinessDayOpen(store), controllers.CreateOrder(store))
+	orderGroup.PUT("/:orderId", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), middlewares.BusinessDayOpen(store), controllers.UpdateOrder(store))
+	orderGroup.PUT("/:orderId/status", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER, constants.ROLE_KITCHEN, constants.ROLE_CASHIER), middlewares.BusinessDayOpen(store), controllers.UpdateOrderStatus(store))
+	orderGroup.PUT("/:orderId/cancel", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), middlewares.BusinessDayOpen(store), controllers.CancelOrder(store))
+	orderGroup.POST("/:orderId/transfer", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), middlewares.BusinessDayOpen(store), controllers.TransferOrderItems(store))
+	orderGroup.POST("/:orderId/merge", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), middlewares.BusinessDayOpen(store), controllers.MergeOrders(store))
+	orderGroup.DELETE("/:orderId", middlewares.Authorize(constants.ROLE_MANAGER), middlewares.BusinessDayOpen(store), controllers.DeleteOrder(store))
+	orderGroup.GET("/:orderId", controllers.GetOrder(store))
+	orderGroup.GET("/all", controllers.GetAllOrders(store))
 }
@@ -0,0 +1,304 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (s *testServer) createOrder(tableId string) string {
+	s.t.Helper()
+
+	var order idResponse
+	s.mustDo(http.MethodPost, "/order/create", map[string]any{
+		"tableId":   tableId,
+		"orderDate": time.Now().Add(time.Hour),
+	}, http.StatusCreated, &order)
+	return order.OrderId
+}
+
+func TestOrderCRUD(t *testing.T) {
+	s := newTestServer(t)
+	tableId := s.createTable(1, 2)
+	otherTableId := s.createTable(2, 4)
+	orderId := s.createOrder(tableId)
+
+	var order models.Order
+	s.mustDo(http.MethodGet, "/order/"+orderId, nil, http.StatusOK, &order)
+	if order.TableId != tableId {
+		t.Fatalf("GET order = %+v", order)
+	}
+
+	s.expectStatus(http.MethodPut, "/order/"+orderId, map[string]any{"tableId": otherTableId}, http.StatusOK)
+	s.mustDo(http.MethodGet, "/order/"+orderId, nil, http.StatusOK, &order)
+	if order.TableId != otherTableId {
+		t.Fatalf("tableId after update = %q, want %q", order.TableId, otherTableId)
+	}
+
+	var orders []models.Order
+	s.mustDo(http.MethodGet, "/order/all", nil, http.StatusOK, &orders)
+	if len(orders) != 1 {
+		t.Fatalf("len(orders) = %d, want 1", len(orders))
+	}
+
+	s.expectStatus(http.MethodDelete, "/order/"+orderId, nil, http.StatusOK)
+	s.expectStatus(http.MethodGet, "/order/"+orderId, nil, http.StatusNotFound)
+	s.expectStatus(http.MethodDelete, "/order/"+orderId, nil, http.StatusNotFound)
+}
+
+func TestOrderValidation(t *testing.T) {
+	s := newTestServer(t)
+	tableId := s.createTable(1, 2)
+
+	s.expectStatus(http.MethodPost, "/order/create", nil, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/order/create", map[string]any{
+		"tableId":   tableId,
+		"orderDate": time.Now().Add(-time.Hour),
+	}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/order/create", map[string]any{
+		"tableId":   "missing",
+		"orderDate": time.Now().Add(time.Hour),
+	}, http.StatusNotFound)
+
+	orderId := s.createOrder(tableId)
+	s.expectStatus(http.MethodPut, "/order/"+orderId, map[string]any{}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/order/"+orderId, map[string]any{"tableId": "missing"}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/order/missing", map[string]any{"tableId": tableId}, http.StatusNotFound)
+}
+
+func TestDeleteOrderRemovesItems(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	tableId := s.createTable(1, 2)
+
+	items := s.createOrderItems(tableId, map[string]any{"foodId": foodId, "quantity": "M"})
+	orderId := items[0].OrderId
+
+	s.expectStatus(http.MethodDelete, "/order/"+orderId, nil, http.StatusOK)
+	s.expectStatus(http.MethodGet, "/order-item/"+items[0].OrderItemId, nil, http.StatusNotFound)
+}
+
+func TestDeleteBilledOrder(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	orderId := s.createOrderItems(s.createTable(1, 2), map[string]any{"foodId": foodId, "quantity": "M"})[0].OrderId
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	s.expectStatus(http.MethodDelete, "/order/"+orderId, nil, http.StatusConflict)
+
+	// A voided bill keeps its number and its ledger entry, and so the order.
+	s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]any{"reasonCode": "DUPLICATE"}, http.StatusOK)
+	s.expectStatus(http.MethodDelete, "/order/"+orderId, nil, http.StatusConflict)
+	s.expectStatus(http.MethodGet, "/order/"+orderId, nil, http.StatusOK)
+	s.expectStatus(http.MethodDelete, "/order/missing", nil, http.StatusNotFound)
+}
+
+func (s *testServer) setOrderStatus(orderId, status string, want int) models.Order {
+	s.t.Helper()
+
+	var order models.Order
+	if want != http.StatusOK {
+		s.expectStatus(http.MethodPut, "/order/"+orderId+"/status", map[string]any{"status": status}, want)
+		return order
+	}
+	s.mustDo(http.MethodPut, "/order/"+orderId+"/status", map[string]any{"status": status}, want, &order)
+	return order
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	orderId := s.createOrderItems(s.createTable(1, 2), map[string]any{"foodId": foodId, "quantity": "M"})[0].OrderId
+
+	var order models.Order
+	s.mustDo(http.MethodGet, "/order/"+orderId, nil, http.StatusOK, &order)
+	if order.Status != "OPEN" || len(order.StatusHistory) != 1 {
+		t.Fatalf("new order = %+v", order)
+	}
+
+	s.setOrderStatus(orderId, "PREPARING", http.StatusConflict)
+	for _, status := range []string{"SENT_TO_KITCHEN", "PREPARING", "READY", "SERVED"} {
+		order = s.setOrderStatus(orderId, status, http.StatusOK)
+		if order.Status != status {
+			t.Fatalf("status = %s, want %s", order.Status, status)
+		}
+	}
+	// An order is only closed once it has been billed and paid.
+	s.setOrderStatus(orderId, "PAID", http.StatusConflict)
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	s.setOrderStatus(orderId, "PAID", http.StatusConflict)
+	s.pay(invoice.InvoiceId, "CASH", invoice.Totals.Total, "")
+
+	s.mustDo(http.MethodGet, "/order/"+orderId, nil, http.StatusOK, &order)
+	if order.Status != "PAID" {
+		t.Fatalf("status = %s, want PAID", order.Status)
+	}
+	if len(order.StatusHistory) != 6 || order.StatusHistory[5].ChangedAt.IsZero() {
+		t.Fatalf("status history = %+v", order.StatusHistory)
+	}
+	if order.StatusHistory[5].ChangedBy != "test-ADMIN" {
+		t.Errorf("changedBy = %q", order.StatusHistory[5].ChangedBy)
+	}
+
+	s.setOrderStatus(orderId, "OPEN", http.StatusConflict)
+	s.expectStatus(http.MethodPut, "/order/"+orderId+"/cancel", nil, http.StatusConflict)
+	s.setOrderStatus(orderId, "DONE", http.StatusBadRequest)
+	s.setOrderStatus("missing", "PAID", http.StatusNotFound)
+}
+
+func TestCancelOrder(t *testing.T) {
+	s := newTestServer(t)
+	tableId := s.createTable(1, 2)
+	orderId := s.createOrder(tableId)
+	s.setOrderStatus(orderId, "SENT_TO_KITCHEN", http.StatusOK)
+
+	var order models.Order
+	s.mustDo(http.MethodPut, "/order/"+orderId+"/cancel", nil, http.StatusOK, &order)
+	if order.Status != "CANCELLED" {
+		t.Fatalf("status = %s, want CANCELLED", order.Status)
+	}
+	s.expectStatus(http.MethodPut, "/order/"+orderId, map[string]any{"tableId": tableId}, http.StatusConflict)
+
+	servedId := s.createOrder(tableId)
+	for _, status := range []string{"SENT_TO_KITCHEN", "PREPARING", "READY", "SERVED"} {
+		s.setOrderStatus(servedId, status, http.StatusOK)
+	}
+	s.expectStatus(http.MethodPut, "/order/"+servedId+"/cancel", nil, http.StatusConflict)
+}
+
+func TestCancelBilledOrder(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	orderId := s.createOrderItems(s.createTable(1, 2), map[string]any{"foodId": foodId, "quantity": "M"})[0].OrderId
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	s.expectStatus(http.MethodPut, "/order/"+orderId+"/cancel", nil, http.StatusConflict)
+	s.setOrderStatus(orderId, "CANCELLED", http.StatusConflict)
+
+	s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]any{"reasonCode": "DUPLICATE"}, http.StatusOK)
+	s.expectStatus(http.MethodPut, "/order/"+orderId+"/cancel", nil, http.StatusOK)
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/invoice/split", map[string]any{"orderId": orderId, "mode": "EVEN", "guests": 2}, http.StatusConflict)
+}
+
+func TestPaidOrderIsFrozen(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	items := s.createOrderItems(s.createTable(1, 2), map[string]any{"foodId": foodId, "quantity": "M"})
+	orderId := items[0].OrderId
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	s.pay(invoice.InvoiceId, "CASH", invoice.Totals.Total, "")
+	// Paid up front, the order is settled as soon as it is served.
+	var order models.Order
+	for _, status := range []string{"SENT_TO_KITCHEN", "PREPARING", "READY", "SERVED"} {
+		order = s.setOrderStatus(orderId, status, http.StatusOK)
+	}
+	if order.Status != "PAID" {
+		t.Fatalf("status = %s, want PAID", order.Status)
+	}
+
+	s.expectStatus(http.MethodPut, "/order-item/"+items[0].OrderItemId, map[string]any{"quantity": "L"}, http.StatusConflict)
+	s.expectStatus(http.MethodDelete, "/order/"+orderId, nil, http.StatusConflict)
+}
+
+func TestOrderOfClosedDayIsFrozen(t *testing.T) {
+	s := newTestServer(t)
+	tableId := s.createTable(1, 2)
+	placed := time.Now().UTC().AddDate(0, 0, -1)
+	order := models.Order{
+		ID:            bson.NewObjectID(),
+		TableId:       tableId,
+		Status:        constants.ORDER_STATUS_OPEN,
+		CreatedAt:     placed,
+		StatusHistory: []models.OrderStatusChange{{Status: constants.ORDER_STATUS_OPEN, ChangedAt: placed}},
+	}
+	order.OrderID = order.ID.Hex()
+	if err := s.store.Orders.Create(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+	s.expectStatus(http.MethodPost, "/report/z", map[string]any{"businessDay": helpers.BusinessDay(placed)}, http.StatusCreated)
+
+	orderId := order.OrderID
+	otherId := s.createOrder(tableId)
+	s.expectStatus(http.MethodPut, "/order/"+orderId, map[string]any{"tableId": s.createTable(2, 2)}, http.StatusConflict)
+	s.setOrderStatus(orderId, "SENT_TO_KITCHEN", http.StatusConflict)
+	s.expectStatus(http.MethodPut, "/order/"+orderId+"/cancel", nil, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/order/"+otherId+"/merge", map[string]any{"orderIds": []string{orderId}}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/order/"+orderId+"/merge", map[string]any{"orderIds": []string{otherId}}, http.StatusConflict)
+	s.expectStatus(http.MethodDelete, "/order/"+orderId, nil, http.StatusConflict)
+	s.setOrderStatus(otherId, "SENT_TO_KITCHEN", http.StatusOK)
+}
+
+func TestTransferAndMergeOrders(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	burgerId := s.createFood(menuId, 10)
+	friesId := s.createFood(menuId, 4)
+	tableId := s.createTable(1, 4)
+	otherId := s.createTable(2, 4)
+
+	items := s.createOrderItems(tableId,
+		map[string]any{"foodId": burgerId, "quantity": "M"},
+		map[string]any{"foodId": friesId, "quantity": "S"},
+	)
+	orderId, friesItemId := items[0].OrderId, items[1].OrderItemId
+	otherOrderId := s.createOrderItems(otherId, map[string]any{"foodId": burgerId, "quantity": "M"})[0].OrderId
+
+	var moved []models.OrderItem
+	s.mustDo(http.MethodPost, "/order/"+orderId+"/transfer", map[string]any{
+		"toOrderId": otherOrderId, "orderItemIds": []string{friesItemId},
+	}, http.StatusOK, &moved)
+	if len(moved) != 1 || moved[0].OrderItemId != friesItemId || moved[0].OrderId != otherOrderId {
+		t.Fatalf("moved = %+v", moved)
+	}
+	var summaries []models.OrderSummary
+	s.mustDo(http.MethodGet, "/order-item/order/"+otherOrderId, nil, http.StatusOK, &summaries)
+	if len(summaries) != 1 || summaries[0].PaymentDue.String() != "13.00" {
+		t.Fatalf("summaries after transfer = %+v", summaries)
+	}
+
+	transfer := map[string]any{"toOrderId": otherOrderId, "orderItemIds": []string{friesItemId}}
+	s.expectStatus(http.MethodPost, "/order/"+orderId+"/transfer", transfer, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/order/"+orderId+"/transfer", map[string]any{"toOrderId": orderId, "orderItemIds": []string{items[0].OrderItemId}}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/order/"+orderId+"/transfer", map[string]any{"toOrderId": "missing", "orderItemIds": []string{items[0].OrderItemId}}, http.StatusNotFound)
+	s.expectStatus(http.MethodPost, "/order/"+orderId+"/transfer", map[string]any{"toOrderId": otherOrderId, "orderItemIds": []string{}}, http.StatusBadRequest)
+
+	// A second round at the table is merged into the first one and deleted.
+	second := s.createOrderItems(tableId, map[string]any{"foodId": friesId, "quantity": "M"})[0].OrderId
+	s.expectStatus(http.MethodPost, "/order/"+orderId+"/merge", map[string]any{"orderIds": []string{orderId}}, http.StatusBadRequest)
+	var check models.Order
+	s.mustDo(http.MethodPost, "/order/"+orderId+"/merge", map[string]any{"orderIds": []string{second}}, http.StatusOK, &check)
+	if check.OrderID != orderId {
+		t.Fatalf("check = %+v, want order %s", check, orderId)
+	}
+	s.expectStatus(http.MethodGet, "/order/"+second, nil, http.StatusNotFound)
+	s.mustDo(http.MethodGet, "/order-item/order/"+orderId, nil, http.StatusOK, &summaries)
+	if len(summaries) != 1 || summaries[0].PaymentDue.String() != "14.00" || len(summaries[0].OrderItems) != 2 {
+		t.Fatalf("summaries after merge = %+v", summaries)
+	}
+	if table := s.expectTableStatus(tableId, "ORDERED"); len(table.Occupancy.OrderIds) != 1 || table.Occupancy.OrderIds[0] != orderId {
+		t.Fatalf("occupancy after merge = %+v", table.Occupancy)
+	}
+
+	// Billed orders keep their items until the bill is voided.
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": otherOrderId}, http.StatusCreated, &invoice)
+	s.expectStatus(http.MethodPost, "/order/"+orderId+"/transfer", map[string]any{"toOrderId": otherOrderId, "orderItemIds": []string{items[0].OrderItemId}}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/order/"+otherOrderId+"/merge", map[string]any{"orderIds": []string{orderId}}, http.StatusConflict)
+
+	// A voided bill still keeps its order from being merged away.
+	s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]any{"reasonCode": "DUPLICATE"}, http.StatusOK)
+	s.expectStatus(http.MethodPost, "/order/"+orderId+"/merge", map[string]any{"orderIds": []string{otherOrderId}}, http.StatusConflict)
+	s.expectStatus(http.MethodGet, "/order/"+otherOrderId, nil, http.StatusOK)
+}
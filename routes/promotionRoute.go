@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func PromotionRoute(router *gin.Engine, store *repository.Store) {
+	promotionGroup := router.Group("/promotion")
+	promotionGroup.Use(middlewares.Authenticate(store))
+	promotionGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER), controllers.CreatePromotion(store))
+	promotionGroup.PUT("/:promotionId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.UpdatePromotion(store))
+	promotionGroup.DELETE("/:promotionId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.DeletePromotion(store))
+	promotionGroup.GET("/:promotionId", controllers.GetPromotion(store))
+	promotionGroup.GET("/all", controllers.GetAllPromotions(store))
+}
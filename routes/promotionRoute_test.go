@@ -0,0 +1,204 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+type promotionResponse struct {
+	PromotionId string `json:"promotionId"`
+}
+
+func (s *testServer) createPromotion(promotion map[string]any) string {
+	s.t.Helper()
+
+	var created promotionResponse
+	s.mustDo(http.MethodPost, "/promotion/create", promotion, http.StatusCreated, &created)
+	return created.PromotionId
+}
+
+// promotionView is the invoice view with its order items decoded.
+type promotionView struct {
+	Totals       models.InvoiceTotals     `json:"totals"`
+	OrderDetails []models.OrderItemDetail `json:"orderDetails"`
+}
+
+func TestPromotionCRUD(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	foodId := s.createFood(menuId, 10)
+
+	promotionId := s.createPromotion(map[string]any{
+		"name": "Burger week", "kind": "PERCENT", "level": "ITEM", "rate": 20,
+		"foodIds": []string{foodId}, "couponCode": "burger20",
+	})
+
+	var promotion models.Promotion
+	s.mustDo(http.MethodGet, "/promotion/"+promotionId, nil, http.StatusOK, &promotion)
+	if *promotion.Name != "Burger week" || promotion.CouponCode != "BURGER20" || !*promotion.Active || promotion.Rate.String() != "20" {
+		t.Fatalf("GET promotion = %+v", promotion)
+	}
+
+	s.expectStatus(http.MethodPut, "/promotion/"+promotionId, map[string]any{"active": false, "usageLimit": 3}, http.StatusOK)
+	s.mustDo(http.MethodGet, "/promotion/"+promotionId, nil, http.StatusOK, &promotion)
+	if *promotion.Active || promotion.UsageLimit != 3 {
+		t.Fatalf("promotion after update = %+v", promotion)
+	}
+
+	var promotions []models.Promotion
+	s.mustDo(http.MethodGet, "/promotion/all", nil, http.StatusOK, &promotions)
+	if len(promotions) != 1 {
+		t.Fatalf("len(promotions) = %d, want 1", len(promotions))
+	}
+
+	for _, tc := range []struct {
+		body map[string]any
+		want int
+	}{
+		{map[string]any{"name": "No rate", "kind": "PERCENT", "level": "ITEM"}, http.StatusBadRequest},
+		{map[string]any{"name": "No level", "kind": "FIXED", "amount": 2}, http.StatusBadRequest},
+		{map[string]any{"name": "No counts", "kind": "BUY_X_GET_Y"}, http.StatusBadRequest},
+		{map[string]any{"name": "Bad hour", "kind": "PERCENT", "level": "BILL", "rate": 5, "happyHour": map[string]any{"start": "25:00", "end": "19:00"}}, http.StatusBadRequest},
+		{map[string]any{"name": "Bad food", "kind": "PERCENT", "level": "ITEM", "rate": 5, "foodIds": []string{"missing"}}, http.StatusBadRequest},
+		{map[string]any{"name": "Bad menu", "kind": "PERCENT", "level": "ITEM", "rate": 5, "menuIds": []string{"missing"}}, http.StatusBadRequest},
+		{map[string]any{"name": "Same code", "kind": "FIXED", "level": "BILL", "amount": 2, "couponCode": "BURGER20"}, http.StatusConflict},
+	} {
+		if code, resp := s.do(http.MethodPost, "/promotion/create", tc.body); code != tc.want {
+			t.Fatalf("create %v: status = %d, want %d (%s)", tc.body, code, tc.want, resp.Message)
+		}
+	}
+	code, _ := s.doWithToken(http.MethodPost, "/promotion/create", s.tokenFor(constants.ROLE_WAITER), map[string]any{
+		"name": "Staff deal", "kind": "PERCENT", "level": "BILL", "rate": 50,
+	})
+	if code != http.StatusForbidden {
+		t.Fatalf("waiter create promotion status = %d", code)
+	}
+
+	s.expectStatus(http.MethodDelete, "/promotion/"+promotionId, nil, http.StatusOK)
+	s.expectStatus(http.MethodGet, "/promotion/"+promotionId, nil, http.StatusNotFound)
+}
+
+func TestPromotionDiscounts(t *testing.T) {
+	s := newTestServer(t)
+	burgerMenuId := s.createMenu()
+	sidesMenuId := s.createMenu()
+	burgerId := s.createFood(burgerMenuId, 10)
+	friesId := s.createFood(sidesMenuId, 4)
+	items := s.createOrderItems(s.createTable(1, 2),
+		map[string]any{"foodId": burgerId, "quantity": "M", "count": 2},
+		map[string]any{"foodId": friesId, "quantity": "M", "count": 3},
+	)
+
+	burgerDealId := s.createPromotion(map[string]any{
+		"name": "Burgers 25% off", "kind": "PERCENT", "level": "ITEM", "rate": 25,
+		"foodIds": []string{burgerId}, "stackable": true,
+	})
+	friesDealId := s.createPromotion(map[string]any{
+		"name": "Sides 3 for 2", "kind": "BUY_X_GET_Y", "buyCount": 2, "getCount": 1,
+		"menuIds": []string{sidesMenuId}, "stackable": true,
+	})
+	billDealId := s.createPromotion(map[string]any{
+		"name": "3 off the bill", "kind": "FIXED", "level": "BILL", "amount": 3, "stackable": true,
+	})
+	// Outside its happy hour a promotion does nothing, however generous.
+	later := time.Now().Add(2 * time.Hour)
+	s.createPromotion(map[string]any{
+		"name": "Free food hour", "kind": "PERCENT", "level": "ITEM", "rate": 100,
+		"happyHour": map[string]any{"start": later.Format("15:04"), "end": later.Add(time.Hour).Format("15:04")},
+	})
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": items[0].OrderId}, http.StatusCreated, &invoice)
+
+	// 20.00 of burgers less 5.00, three fries at 4.00 with one free, and 3.00
+	// off the 23.00 left, shared 15:8 between the lines.
+	totals := invoice.Totals
+	if totals.Subtotal.String() != "32.00" || totals.Discount.String() != "12.00" || totals.Total.String() != "20.00" {
+		t.Fatalf("totals = %+v", totals)
+	}
+	if len(totals.Discounts) != 3 {
+		t.Fatalf("discount lines = %+v", totals.Discounts)
+	}
+	for i, want := range []struct{ promotionId, amount string }{
+		{burgerDealId, "5.00"}, {friesDealId, "4.00"}, {billDealId, "3.00"},
+	} {
+		if line := totals.Discounts[i]; line.PromotionId != want.promotionId || line.Amount.String() != want.amount {
+			t.Errorf("discount line %d = %+v, want %s of %s", i, line, want.amount, want.promotionId)
+		}
+	}
+
+	var view promotionView
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, http.StatusOK, &view)
+	if view.Totals.Total != totals.Total || len(view.OrderDetails) != 2 {
+		t.Fatalf("invoice view = %+v", view)
+	}
+	for _, item := range view.OrderDetails {
+		want := map[string]string{burgerId: "6.96", friesId: "5.04"}[item.FoodId]
+		if item.Discount == nil || item.Discount.String() != want {
+			t.Errorf("discount of %s = %v, want %s", item.FoodId, item.Discount, want)
+		}
+	}
+
+	// A promotion that does not stack wins only when it saves more alone.
+	s.createPromotion(map[string]any{"name": "10% off", "kind": "PERCENT", "level": "BILL", "rate": 10})
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, http.StatusOK, &view)
+	if view.Totals.Discount.String() != "12.00" {
+		t.Fatalf("discount with a smaller exclusive promotion = %+v", view.Totals)
+	}
+	halfId := s.createPromotion(map[string]any{"name": "Half price", "kind": "PERCENT", "level": "BILL", "rate": 50})
+	s.mustDo(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, http.StatusOK, &view)
+	if view.Totals.Discount.String() != "16.00" || len(view.Totals.Discounts) != 1 || view.Totals.Discounts[0].PromotionId != halfId {
+		t.Fatalf("discount with a larger exclusive promotion = %+v", view.Totals)
+	}
+}
+
+func TestCouponRedemption(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	foodId := s.createFood(menuId, 20)
+	tableId := s.createTable(1, 2)
+	newOrder := func() string {
+		return s.createOrderItems(tableId, map[string]any{"foodId": foodId, "quantity": "M"})[0].OrderId
+	}
+
+	s.createPromotion(map[string]any{
+		"name": "Welcome", "kind": "PERCENT", "level": "BILL", "rate": 10,
+		"couponCode": "welcome10", "usageLimit": 1,
+	})
+	yesterday := time.Now().Add(-24 * time.Hour)
+	s.createPromotion(map[string]any{
+		"name": "Old deal", "kind": "FIXED", "level": "BILL", "amount": 5,
+		"couponCode": "OLD5", "startsAt": yesterday.Add(-time.Hour), "endsAt": yesterday,
+	})
+
+	// Without the code the coupon does nothing.
+	var plain models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": newOrder()}, http.StatusCreated, &plain)
+	if plain.Totals.Discount != 0 {
+		t.Fatalf("discount without coupon = %+v", plain.Totals)
+	}
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{
+		"orderId": newOrder(), "couponCodes": []string{"Welcome10"},
+	}, http.StatusCreated, &invoice)
+	if invoice.Totals.Total.String() != "18.00" || len(invoice.Totals.Discounts) != 1 || invoice.Totals.Discounts[0].CouponCode != "WELCOME10" {
+		t.Fatalf("totals with coupon = %+v", invoice.Totals)
+	}
+
+	orderId := newOrder()
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId, "couponCodes": []string{"WELCOME10"}}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId, "couponCodes": []string{"OLD5"}}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId, "couponCodes": []string{"NOPE"}}, http.StatusBadRequest)
+
+	// Voiding the bill gives the coupon back.
+	s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]any{"reasonCode": "DUPLICATE"}, http.StatusOK)
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId, "couponCodes": []string{"WELCOME10"}}, http.StatusCreated, &invoice)
+	if invoice.Totals.Discount.String() != "2.00" {
+		t.Fatalf("totals with returned coupon = %+v", invoice.Totals)
+	}
+}
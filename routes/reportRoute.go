@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func ReportRoute(router *gin.Engine, store *repository.Store) {
+	reportGroup := router.Group("/report")
+	reportGroup.Use(middlewares.Authenticate(store), middlewares.Authorize(constants.ROLE_MANAGER))
+	reportGroup.GET("/revenue", controllers.GetRevenueReport(store))
+	reportGroup.GET("/foods", controllers.GetFoodSalesReport(store))
+	reportGroup.GET("/categories", controllers.GetCategorySalesReport(store))
+	reportGroup.GET("/heatmap", controllers.GetSalesHeatmap(store))
+	reportGroup.GET("/servers", controllers.GetServerSalesReport(store))
+	reportGroup.GET("/sections", controllers.GetSectionSalesReport(store))
+	reportGroup.POST("/z", controllers.CloseBusinessDay(store))
+	reportGroup.GET("/z/:businessDay", controllers.GetZReport(store))
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func TestSalesReports(t *testing.T) {
+	s := newTestServer(t)
+	mainsId := s.createMenu()
+	var drinks idResponse
+	s.mustDo(http.MethodPost, "/menu/create", map[string]any{"name": "Bar", "category": "Drinks"}, http.StatusCreated, &drinks)
+	burgerId := s.createFood(mainsId, 10)
+	var cola, salad idResponse
+	s.mustDo(http.MethodPost, "/food/create", map[string]any{
+		"name": "Cola", "price": 2, "foodImage": "cola.png", "menuId": drinks.MenuId,
+	}, http.StatusCreated, &cola)
+	s.mustDo(http.MethodPost, "/food/create", map[string]any{
+		"name": "Salad", "price": 8, "foodImage": "salad.png", "menuId": mainsId,
+	}, http.StatusCreated, &salad)
+
+	// A paid order counts; an order still waiting on its bill does not.
+	paidItems := s.createOrderItems(s.createTable(1, 2),
+		map[string]any{"foodId": burgerId, "quantity": "M", "count": 2},
+		map[string]any{"foodId": cola.FoodId, "quantity": "M"},
+	)
+	for _, status := range []string{"SENT_TO_KITCHEN", "PREPARING", "READY", "SERVED"} {
+		s.setOrderStatus(paidItems[0].OrderId, status, http.StatusOK)
+	}
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": paidItems[0].OrderId}, http.StatusCreated, &invoice)
+	s.pay(invoice.InvoiceId, "CASH", invoice.Totals.Total, "")
+
+	openItems := s.createOrderItems(s.createTable(2, 2), map[string]any{"foodId": burgerId, "quantity": "M", "count": 5})
+	s.expectStatus(http.MethodPost, "/invoice/create", map[string]any{"orderId": openItems[0].OrderId}, http.StatusCreated)
+
+	today := time.Now().Format("2006-01-02")
+	dates := "?from=" + today + "&to=" + today
+
+	var revenue models.RevenueReport
+	s.mustDo(http.MethodGet, "/report/revenue"+dates+"&period=month", nil, http.StatusOK, &revenue)
+	summary := revenue.Summary
+	if summary.Tickets != 1 || summary.Invoices != 1 || summary.Sales.String() != "22.00" ||
+		summary.Total != invoice.Totals.Total || summary.AverageTicket != invoice.Totals.Total {
+		t.Fatalf("revenue summary = %+v", summary)
+	}
+	if revenue.Period != constants.REPORT_PERIOD_MONTH || len(revenue.Periods) != 1 ||
+		revenue.Periods[0].Period != time.Now().Format("2006-01") || revenue.Periods[0].Total != summary.Total {
+		t.Fatalf("revenue periods = %+v", revenue.Periods)
+	}
+
+	var top []models.FoodSales
+	s.mustDo(http.MethodGet, "/report/foods"+dates, nil, http.StatusOK, &top)
+	if len(top) != 3 || top[0].FoodId != burgerId || top[0].Quantity != 2 || top[0].Revenue.String() != "20.00" ||
+		top[0].Category != "Mains" || top[1].FoodId != cola.FoodId {
+		t.Fatalf("top sellers = %+v", top)
+	}
+	var bottom []models.FoodSales
+	s.mustDo(http.MethodGet, "/report/foods"+dates+"&rank=bottom&by=revenue&limit=1", nil, http.StatusOK, &bottom)
+	if len(bottom) != 1 || bottom[0].FoodId != salad.FoodId || bottom[0].Quantity != 0 {
+		t.Fatalf("bottom sellers = %+v", bottom)
+	}
+
+	var categories []models.CategorySales
+	s.mustDo(http.MethodGet, "/report/categories"+dates, nil, http.StatusOK, &categories)
+	if len(categories) != 2 || categories[0].Category != "Mains" || categories[0].Revenue.String() != "20.00" ||
+		categories[1].Category != "Drinks" || categories[1].Share.String() != "9.0909" {
+		t.Fatalf("category sales = %+v", categories)
+	}
+
+	var heatmap []models.HeatmapRow
+	s.mustDo(http.MethodGet, "/report/heatmap"+dates, nil, http.StatusOK, &heatmap)
+	issued := invoice.CreatedAt.In(time.Local)
+	if len(heatmap) != 7 || heatmap[issued.Weekday()].Hours[issued.Hour()].Tickets != 1 ||
+		heatmap[issued.Weekday()].Hours[issued.Hour()].Revenue != invoice.Totals.Total {
+		t.Fatalf("heatmap row = %+v", heatmap[issued.Weekday()])
+	}
+
+	// Nothing sold yesterday.
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	s.mustDo(http.MethodGet, "/report/revenue?from="+yesterday+"&to="+yesterday, nil, http.StatusOK, &revenue)
+	if revenue.Summary.Invoices != 0 || len(revenue.Periods) != 1 {
+		t.Fatalf("revenue of yesterday = %+v", revenue)
+	}
+
+	for _, query := range []string{
+		"?to=" + today,
+		"?from=" + today + "&to=" + yesterday,
+		"?from=yesterday&to=" + today,
+		dates + "&period=year",
+		dates + "&limit=0&rank=middle",
+		"?from=2020-01-01&to=" + today,
+	} {
+		s.expectStatus(http.MethodGet, "/report/revenue"+query, nil, http.StatusBadRequest)
+	}
+	if code, _ := s.doWithToken(http.MethodGet, "/report/revenue"+dates, s.tokenFor(constants.ROLE_WAITER), nil); code != http.StatusForbidden {
+		t.Fatalf("waiter revenue report status = %d", code)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func ReservationRoute(router *gin.Engine, store *repository.Store) {
+	reservationGroup := router.Group("/reservation")
+	reservationGroup.Use(middlewares.Authenticate(store))
+	reservationGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.CreateReservation(store))
+	reservationGroup.PUT("/:reservationId", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.UpdateReservation(store))
+	reservationGroup.POST("/:reservationId/cancel", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.CancelReservation(store))
+	reservationGroup.POST("/:reservationId/no-show", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.MarkNoShow(store))
+	reservationGroup.GET("/:reservationId", controllers.GetReservation(store))
+	reservationGroup.GET("/all", controllers.GetReservations(store))
+	reservationGroup.GET("/availability", controllers.GetAvailability(store))
+}
@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func (s *testServer) reserve(reservation map[string]any, want int) models.Reservation {
+	s.t.Helper()
+
+	var created models.Reservation
+	if want != http.StatusCreated {
+		s.expectStatus(http.MethodPost, "/reservation/create", reservation, want)
+		return created
+	}
+	s.mustDo(http.MethodPost, "/reservation/create", reservation, want, &created)
+	return created
+}
+
+func tableIds(tables []models.Table) []string {
+	ids := make([]string, 0, len(tables))
+	for _, table := range tables {
+		ids = append(ids, table.TableId)
+	}
+	return ids
+}
+
+func TestReservations(t *testing.T) {
+	s := newTestServer(t)
+	twoTop := s.createTable(1, 2)
+	fourTop := s.createTable(2, 4)
+	sixTop := s.createTable(3, 6)
+
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	at := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 19, 0, 0, 0, time.Local)
+	booking := func(partySize int, startsAt time.Time, tableId string) map[string]any {
+		return map[string]any{
+			"guestName": "Ada", "phone": "555-0100", "partySize": partySize,
+			"startsAt": startsAt.Format(time.RFC3339Nano), "tableId": tableId,
+		}
+	}
+
+	// A party of three gets the smallest table that seats it, for the turn
+	// time of its size.
+	first := s.reserve(booking(3, at, ""), http.StatusCreated)
+	if first.TableId != fourTop || first.Status != constants.RESERVATION_STATUS_BOOKED || !first.EndsAt.Equal(at.Add(90*time.Minute)) {
+		t.Fatalf("reservation = %+v", first)
+	}
+
+	availability := func(partySize int, at time.Time) []string {
+		var tables []models.Table
+		s.mustDo(http.MethodGet, "/reservation/availability?partySize="+strconv.Itoa(partySize)+"&at="+at.UTC().Format(time.RFC3339), nil, http.StatusOK, &tables)
+		return tableIds(tables)
+	}
+	if free := availability(3, at); len(free) != 1 || free[0] != sixTop {
+		t.Fatalf("free tables for 3 = %v", free)
+	}
+	if free := availability(2, at.Add(30*time.Minute)); len(free) != 2 || free[0] != twoTop || free[1] != sixTop {
+		t.Fatalf("free tables for 2 = %v", free)
+	}
+
+	// The table stays booked until the party leaves and it has been reset.
+	s.reserve(booking(4, at.Add(60*time.Minute), fourTop), http.StatusConflict)
+	s.reserve(booking(4, at.Add(100*time.Minute), fourTop), http.StatusConflict)
+	s.reserve(booking(4, at.Add(105*time.Minute), fourTop), http.StatusCreated)
+	s.reserve(booking(3, at, twoTop), http.StatusBadRequest)
+	s.reserve(booking(3, at, "missing"), http.StatusBadRequest)
+
+	second := s.reserve(booking(4, at, ""), http.StatusCreated)
+	if second.TableId != sixTop {
+		t.Fatalf("second reservation table = %s, want %s", second.TableId, sixTop)
+	}
+	s.reserve(booking(5, at, ""), http.StatusConflict)
+
+	// Growing the party needs a bigger table, and none is free; moving it
+	// later keeps its table and its length.
+	path := "/reservation/" + first.ReservationId
+	s.expectStatus(http.MethodPut, path, map[string]any{"partySize": 5}, http.StatusConflict)
+	var moved models.Reservation
+	s.mustDo(http.MethodPut, path, map[string]any{"startsAt": at.Add(-2 * time.Hour).Format(time.RFC3339), "notes": "Window seat"}, http.StatusOK, &moved)
+	if moved.TableId != fourTop || !moved.EndsAt.Equal(at.Add(-30*time.Minute)) || moved.Notes != "Window seat" || moved.PartySize != 3 {
+		t.Fatalf("moved reservation = %+v", moved)
+	}
+
+	s.expectStatus(http.MethodPost, "/reservation/"+second.ReservationId+"/no-show", nil, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/reservation/"+second.ReservationId+"/cancel", nil, http.StatusOK)
+	s.expectStatus(http.MethodPost, "/reservation/"+second.ReservationId+"/cancel", nil, http.StatusConflict)
+	s.expectStatus(http.MethodPut, "/reservation/"+second.ReservationId, map[string]any{"partySize": 2}, http.StatusConflict)
+	if free := availability(5, at); len(free) != 1 || free[0] != sixTop {
+		t.Fatalf("free tables after cancelling = %v", free)
+	}
+
+	soon := s.reserve(booking(2, time.Now().Add(50*time.Millisecond), twoTop), http.StatusCreated)
+	time.Sleep(100 * time.Millisecond)
+	var noShow models.Reservation
+	s.mustDo(http.MethodPost, "/reservation/"+soon.ReservationId+"/no-show", nil, http.StatusOK, &noShow)
+	if noShow.Status != constants.RESERVATION_STATUS_NO_SHOW {
+		t.Fatalf("no-show = %+v", noShow)
+	}
+
+	var listed []models.Reservation
+	s.mustDo(http.MethodGet, "/reservation/all?date="+tomorrow.Format("2006-01-02"), nil, http.StatusOK, &listed)
+	if len(listed) != 3 || listed[0].ReservationId != first.ReservationId {
+		t.Fatalf("reservations of tomorrow = %+v", listed)
+	}
+
+	s.reserve(map[string]any{"phone": "555-0100", "partySize": 2, "startsAt": at}, http.StatusBadRequest)
+	s.reserve(booking(2, time.Now().Add(-time.Hour), ""), http.StatusBadRequest)
+	s.expectStatus(http.MethodGet, "/reservation/availability?partySize=0&at="+at.UTC().Format(time.RFC3339), nil, http.StatusBadRequest)
+	s.expectStatus(http.MethodGet, "/reservation/all", nil, http.StatusBadRequest)
+	s.expectStatus(http.MethodGet, "/reservation/missing", nil, http.StatusNotFound)
+	if code, _ := s.doWithToken(http.MethodPost, "/reservation/create", s.tokenFor(constants.ROLE_KITCHEN), booking(2, at, "")); code != http.StatusForbidden {
+		t.Fatalf("kitchen create reservation status = %d", code)
+	}
+}
@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func TestRoleAccess(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	foodId := s.createFood(menuId, 10)
+	tableId := s.createTable(1, 4)
+	items := s.createOrderItems(tableId, map[string]any{"foodId": foodId, "quantity": "M"})
+	orderId := items[0].OrderId
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+
+	cases := []struct {
+		role   string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{constants.ROLE_WAITER, http.MethodDelete, "/menu/" + menuId, nil, http.StatusForbidden},
+		{constants.ROLE_CASHIER, http.MethodDelete, "/menu/" + menuId, nil, http.StatusForbidden},
+		{constants.ROLE_WAITER, http.MethodPut, "/invoice/" + invoice.InvoiceId, map[string]any{"paymentStatus": "PAID"}, http.StatusForbidden},
+		{constants.ROLE_KITCHEN, http.MethodPut, "/invoice/" + invoice.InvoiceId, map[string]any{"paymentStatus": "PAID"}, http.StatusForbidden},
+		{constants.ROLE_WAITER, http.MethodPost, "/invoice/" + invoice.InvoiceId + "/payment", map[string]any{"method": "CASH", "amount": 10}, http.StatusForbidden},
+		{constants.ROLE_CASHIER, http.MethodPost, "/invoice/" + invoice.InvoiceId + "/payment", map[string]any{"method": "CASH", "amount": 10}, http.StatusCreated},
+		{constants.ROLE_CASHIER, http.MethodPut, "/invoice/" + invoice.InvoiceId, map[string]any{"paymentStatus": "PAID"}, http.StatusOK},
+		{constants.ROLE_WAITER, http.MethodDelete, "/order/" + orderId, nil, http.StatusForbidden},
+		{constants.ROLE_KITCHEN, http.MethodPut, "/order/" + orderId + "/status", map[string]any{"status": "CANCELLED"}, http.StatusForbidden},
+		{constants.ROLE_KITCHEN, http.MethodPut, "/order/" + orderId + "/status", map[string]any{"status": "PAID"}, http.StatusForbidden},
+		{constants.ROLE_CASHIER, http.MethodPut, "/order/" + orderId + "/status", map[string]any{"status": "CANCELLED"}, http.StatusForbidden},
+		{constants.ROLE_WAITER, http.MethodPut, "/order/" + orderId + "/status", map[string]any{"status": "PAID"}, http.StatusForbidden},
+		{constants.ROLE_KITCHEN, http.MethodPost, "/table/create", map[string]any{"tableNumber": 2, "numberOfGuests": 2}, http.StatusForbidden},
+		{constants.ROLE_WAITER, http.MethodGet, "/menu/all", nil, http.StatusOK},
+		{constants.ROLE_KITCHEN, http.MethodGet, "/order/" + orderId, nil, http.StatusOK},
+		{constants.ROLE_WAITER, http.MethodGet, "/user/all", nil, http.StatusForbidden},
+		{constants.ROLE_MANAGER, http.MethodGet, "/user/all", nil, http.StatusOK},
+		{constants.ROLE_MANAGER, http.MethodDelete, "/menu/" + menuId, nil, http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		code, resp := s.doWithToken(tc.method, tc.path, s.tokenFor(tc.role), tc.body)
+		if code != tc.want {
+			t.Errorf("%s %s %s: status = %d, want %d (%s)", tc.role, tc.method, tc.path, code, tc.want, resp.Message)
+		}
+	}
+}
+
+func TestSignupRolesAndRoleUpdate(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.signup("Admin", "admin@example.com", "secret1")
+	waiter := s.signup("Walter", "walter@example.com", "secret1")
+	if *admin.Role != constants.ROLE_ADMIN || *waiter.Role != constants.ROLE_WAITER {
+		t.Fatalf("roles after signup: admin=%s waiter=%s", *admin.Role, *waiter.Role)
+	}
+
+	body := map[string]any{"role": constants.ROLE_CASHIER}
+	code, _ := s.doWithToken(http.MethodPut, "/user/"+waiter.UserId+"/role", *waiter.Token, body)
+	if code != http.StatusForbidden {
+		t.Fatalf("waiter changing own role: status = %d", code)
+	}
+	code, _ = s.doWithToken(http.MethodPut, "/user/"+waiter.UserId+"/role", s.tokenFor(constants.ROLE_MANAGER), body)
+	if code != http.StatusForbidden {
+		t.Fatalf("manager changing role: status = %d", code)
+	}
+
+	var updated models.UserView
+	s.mustDo(http.MethodPut, "/user/"+waiter.UserId+"/role", body, http.StatusOK, &updated)
+	if *updated.Role != constants.ROLE_CASHIER {
+		t.Fatalf("role after update = %s", *updated.Role)
+	}
+	s.expectStatus(http.MethodPut, "/user/"+waiter.UserId+"/role", map[string]any{"role": "CHEF"}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/user/missing/role", body, http.StatusNotFound)
+
+	// Tokens issued with the old role stop working.
+	code, _ = s.doWithToken(http.MethodGet, "/table/all", *waiter.Token, nil)
+	if code != http.StatusUnauthorized {
+		t.Fatalf("token issued as waiter after role change: status = %d", code)
+	}
+	s.expectStatus(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": *waiter.RefreshToken}, http.StatusUnauthorized)
+
+	// The new role is picked up by the next login.
+	var user models.User
+	s.mustDo(http.MethodPost, "/user/login", map[string]any{
+		"email": "walter@example.com", "password": "secret1",
+	}, http.StatusOK, &user)
+	code, _ = s.doWithToken(http.MethodPut, "/invoice/missing", *user.Token, map[string]any{"paymentStatus": "PAID"})
+	if code != http.StatusNotFound {
+		t.Fatalf("cashier updating invoice: status = %d", code)
+	}
+}
+
+func TestConcurrentFirstSignups(t *testing.T) {
+	s := newTestServer(t)
+
+	const signups = 8
+	var wg sync.WaitGroup
+	for i := range signups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, resp := s.doWithToken(http.MethodPost, "/user/signup", "", map[string]any{
+				"name": "User", "email": fmt.Sprintf("user%d@example.com", i), "password": "secret1",
+			})
+			if code != http.StatusCreated {
+				t.Errorf("signup %d: status = %d (%s)", i, code, resp.Message)
+			}
+		}()
+	}
+	wg.Wait()
+
+	users, err := s.store.Users.List(context.Background(), time.Time{}, signups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admins := 0
+	for _, user := range users {
+		if *user.Role == constants.ROLE_ADMIN {
+			admins++
+		}
+	}
+	if len(users) != signups || admins != 1 {
+		t.Fatalf("%d users with %d admins, want %d users with one admin", len(users), admins, signups)
+	}
+}
@@ -0,0 +1,200 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Exit(m.Run())
+}
+
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+	store  *repository.Store
+	token  string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	store := repository.NewMemoryStore()
+	router := gin.New()
+	UserRoute(router, store)
+	FoodRoute(router, store)
+	InvoiceRoute(router, store)
+	MenuRoute(router, store)
+	OrderItemRoute(router, store)
+	OrderRoute(router, store)
+	TableRoute(router, store)
+	KitchenRoute(router, store)
+	TaxRateRoute(router, store)
+	TipRoute(router, store)
+	PromotionRoute(router, store)
+	ReportRoute(router, store)
+	DrawerRoute(router, store)
+	ReservationRoute(router, store)
+	FloorPlanRoute(router, store)
+	SectionRoute(router, store)
+	ShiftRoute(router, store)
+	WaitlistRoute(router, store)
+
+	s := &testServer{t: t, router: router, store: store}
+	s.token = s.tokenFor(constants.ROLE_ADMIN)
+	return s
+}
+
+// tokenFor mints an access token for a user holding role.
+func (s *testServer) tokenFor(role string) string {
+	s.t.Helper()
+
+	_, token, _, err := helpers.GetTokens("test-"+role, "Test User", role+"@example.com", "", role)
+	if err != nil {
+		s.t.Fatalf("GetTokens: %v", err)
+	}
+	return token
+}
+
+// do sends an authenticated JSON request and decodes the standard envelope.
+// A nil body sends no body at all.
+func (s *testServer) do(method, path string, body any) (int, apiResponse) {
+	s.t.Helper()
+	return s.doWithToken(method, path, s.token, body)
+}
+
+func (s *testServer) doWithToken(method, path, token string, body any) (int, apiResponse) {
+	s.t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal body: %v", err)
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	var resp apiResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		s.t.Fatalf("%s %s: decode response %q: %v", method, path, rec.Body.String(), err)
+	}
+	return rec.Code, resp
+}
+
+// mustDo is do for requests that are expected to succeed with want; the
+// response data is decoded into out when out is not nil.
+func (s *testServer) mustDo(method, path string, body any, want int, out any) {
+	s.t.Helper()
+
+	code, resp := s.do(method, path, body)
+	if code != want {
+		s.t.Fatalf("%s %s: status = %d, want %d (%s)", method, path, code, want, resp.Message)
+	}
+	if out != nil {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			s.t.Fatalf("%s %s: decode data %s: %v", method, path, resp.Data, err)
+		}
+	}
+}
+
+func (s *testServer) expectStatus(method, path string, body any, want int) apiResponse {
+	s.t.Helper()
+
+	code, resp := s.do(method, path, body)
+	if code != want {
+		s.t.Fatalf("%s %s: status = %d, want %d (%s)", method, path, code, want, resp.Message)
+	}
+	if resp.Success != (want < http.StatusBadRequest) {
+		s.t.Fatalf("%s %s: success = %v for status %d", method, path, resp.Success, code)
+	}
+	return resp
+}
+
+type idResponse struct {
+	MenuId      string `json:"menuId"`
+	FoodId      string `json:"foodId"`
+	TableId     string `json:"tableId"`
+	OrderId     string `json:"orderId"`
+	OrderItemId string `json:"orderItemId"`
+	InvoiceId   string `json:"invoiceId"`
+}
+
+func (s *testServer) createMenu() string {
+	s.t.Helper()
+
+	var menu idResponse
+	s.mustDo(http.MethodPost, "/menu/create", map[string]any{
+		"name":     "Dinner",
+		"category": "Mains",
+	}, http.StatusCreated, &menu)
+	return menu.MenuId
+}
+
+func (s *testServer) createFood(menuId string, price float64) string {
+	s.t.Helper()
+
+	var food idResponse
+	s.mustDo(http.MethodPost, "/food/create", map[string]any{
+		"name":      "Burger",
+		"price":     price,
+		"foodImage": "burger.png",
+		"menuId":    menuId,
+	}, http.StatusCreated, &food)
+	return food.FoodId
+}
+
+func (s *testServer) createTable(number, guests int) string {
+	s.t.Helper()
+
+	var table idResponse
+	s.mustDo(http.MethodPost, "/table/create", map[string]any{
+		"numberOfGuests": guests,
+		"tableNumber":    number,
+	}, http.StatusCreated, &table)
+	return table.TableId
+}
+
+func TestAuthenticationRequired(t *testing.T) {
+	s := newTestServer(t)
+
+	paths := []string{"/food/all", "/menu/all", "/table/all", "/order/all", "/invoice/all"}
+	for _, path := range paths {
+		code, _ := s.doWithToken(http.MethodGet, path, "", nil)
+		if code != http.StatusUnauthorized {
+			t.Errorf("GET %s without token: status = %d, want 401", path, code)
+		}
+		code, _ = s.doWithToken(http.MethodGet, path, "not-a-jwt", nil)
+		if code != http.StatusUnauthorized {
+			t.Errorf("GET %s with bad token: status = %d, want 401", path, code)
+		}
+	}
+}
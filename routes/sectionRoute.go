@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func SectionRoute(router *gin.Engine, store *repository.Store) {
+	sectionGroup := router.Group("/section")
+	sectionGroup.Use(middlewares.Authenticate(store))
+	sectionGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER), controllers.CreateSection(store))
+	sectionGroup.PUT("/:sectionId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.UpdateSection(store))
+	sectionGroup.DELETE("/:sectionId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.DeleteSection(store))
+	sectionGroup.GET("/:sectionId", controllers.GetSection(store))
+	sectionGroup.GET("/all", controllers.GetAllSections(store))
+}
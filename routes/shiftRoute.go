@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func ShiftRoute(router *gin.Engine, store *repository.Store) {
+	shiftGroup := router.Group("/shift")
+	shiftGroup.Use(middlewares.Authenticate(store))
+	shiftGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER), controllers.CreateShift(store))
+	shiftGroup.DELETE("/:shiftId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.DeleteShift(store))
+	shiftGroup.GET("/all", controllers.GetShifts(store))
+}
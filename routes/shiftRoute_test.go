@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func TestShiftsAndServiceReports(t *testing.T) {
+	s := newTestServer(t)
+	s.signup("Ada Admin", "ada@example.com", "secret1")
+	ann := s.signup("Ann Waiter", "ann@example.com", "secret1")
+	bob := s.signup("Bob Waiter", "bob@example.com", "secret1")
+	kim := s.signup("Kim Cook", "kim@example.com", "secret1")
+	s.expectStatus(http.MethodPut, "/user/"+kim.UserId+"/role", map[string]any{"role": "KITCHEN"}, http.StatusOK)
+
+	diningId := s.createFloorPlan("Dining room", 20, 10)
+	windowId := s.createSection(diningId, "Window")
+	barId := s.createSection(diningId, "Bar")
+	windowTable := s.createTable(1, 4)
+	barTable := s.createTable(2, 2)
+	s.expectStatus(http.MethodPut, "/table/"+windowTable, map[string]any{"sectionId": windowId}, http.StatusOK)
+	s.expectStatus(http.MethodPut, "/table/"+barTable, map[string]any{"sectionId": barId}, http.StatusOK)
+
+	start := time.Now().Add(-time.Hour)
+	shift := func(sectionId, userId string, hours int, want int) {
+		t.Helper()
+		body := map[string]any{"sectionId": sectionId, "userId": userId, "startsAt": start, "endsAt": start.Add(time.Duration(hours) * time.Hour)}
+		s.expectStatus(http.MethodPost, "/shift/create", body, want)
+	}
+	shift(windowId, ann.UserId, 8, http.StatusCreated)
+	shift(barId, bob.UserId, 8, http.StatusCreated)
+	shift(windowId, ann.UserId, 4, http.StatusConflict)
+	shift(windowId, kim.UserId, 8, http.StatusBadRequest)
+	shift(windowId, bob.UserId, 20, http.StatusBadRequest)
+	shift("missing", bob.UserId, 8, http.StatusBadRequest)
+
+	var shifts []models.Shift
+	s.mustDo(http.MethodGet, "/shift/all?date="+time.Now().Format("2006-01-02")+"&sectionId="+windowId, nil, http.StatusOK, &shifts)
+	if len(shifts) != 1 || shifts[0].UserId != ann.UserId {
+		t.Fatalf("window shifts = %+v", shifts)
+	}
+
+	// Orders are credited to the server working the table's section,
+	// whoever places them; tables outside any section to whoever placed
+	// the order.
+	foodId := s.createFood(s.createMenu(), 10)
+	pay := func(tableId string, count int, tip float64) models.Order {
+		t.Helper()
+		items := s.createOrderItems(tableId, map[string]any{"foodId": foodId, "quantity": "M", "count": count})
+		var order models.Order
+		s.mustDo(http.MethodGet, "/order/"+items[0].OrderId, nil, http.StatusOK, &order)
+		var invoice models.Invoice
+		s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": order.OrderID}, http.StatusCreated, &invoice)
+		s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payment", map[string]any{
+			"method": "CASH", "amount": invoice.Totals.Total, "tip": tip,
+		}, http.StatusCreated)
+		return order
+	}
+	if order := pay(windowTable, 3, 3); order.ServerId != ann.UserId || order.SectionId != windowId {
+		t.Fatalf("window order credited to %s in %s", order.ServerId, order.SectionId)
+	}
+	pay(windowTable, 1, 1)
+	if order := pay(barTable, 2, 2); order.ServerId != bob.UserId || order.SectionId != barId {
+		t.Fatalf("bar order credited to %s in %s", order.ServerId, order.SectionId)
+	}
+	if order := pay(s.createTable(3, 2), 1, 0); order.ServerId != "test-ADMIN" || order.SectionId != "" {
+		t.Fatalf("unplaced order credited to %s in %s", order.ServerId, order.SectionId)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	var servers []models.ServiceSales
+	s.mustDo(http.MethodGet, "/report/servers?from="+today+"&to="+today, nil, http.StatusOK, &servers)
+	if len(servers) != 3 || servers[0].Id != ann.UserId || servers[0].Name != "Ann Waiter" ||
+		servers[0].Tickets != 2 || servers[0].Net.String() != "40.00" || servers[0].Tips.String() != "4.00" {
+		t.Fatalf("server report = %+v", servers)
+	}
+	if servers[1].Id != bob.UserId || servers[1].Net.String() != "20.00" || servers[2].Id != "test-ADMIN" {
+		t.Fatalf("server report = %+v", servers)
+	}
+
+	var sections []models.ServiceSales
+	s.mustDo(http.MethodGet, "/report/sections?from="+today+"&to="+today, nil, http.StatusOK, &sections)
+	if len(sections) != 3 || sections[0].Name != "Window" || sections[1].Name != "Bar" || sections[2].Name != "Unassigned" || sections[2].Net.String() != "10.00" {
+		t.Fatalf("section report = %+v", sections)
+	}
+
+	var pool models.TipPoolReport
+	s.mustDo(http.MethodPost, "/tip/pool", map[string]any{
+		"from": start, "to": time.Now().Add(time.Hour), "rule": "HOURS",
+		"staff": []map[string]any{{"userId": ann.UserId, "hours": 8}, {"userId": bob.UserId, "hours": 8}},
+	}, http.StatusOK, &pool)
+	if len(pool.ByServer) != 2 || pool.ByServer[0].Name != "Ann Waiter" || pool.ByServer[0].Tips.String() != "4.00" ||
+		len(pool.BySection) != 2 || pool.BySection[1].Name != "Bar" || pool.BySection[1].Tips.String() != "2.00" {
+		t.Fatalf("tip breakdown = %+v, %+v", pool.ByServer, pool.BySection)
+	}
+
+	s.expectStatus(http.MethodDelete, "/shift/"+shifts[0].ShiftId, nil, http.StatusOK)
+	s.expectStatus(http.MethodDelete, "/shift/"+shifts[0].ShiftId, nil, http.StatusNotFound)
+}
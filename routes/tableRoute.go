@@ -2,15 +2,23 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
 	"github.com/jrskg/go-restaurant/controllers"
 	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
 )
 
-func TableRoute(router *gin.Engine) {
+func TableRoute(router *gin.Engine, store *repository.Store) {
 	tableGroup := router.Group("/table")
-	tableGroup.Use(middlewares.Authenticate())
-	tableGroup.POST("/create", controllers.CreateTable())
-	tableGroup.PUT("/:tableId", controllers.UpdateTable())
-	tableGroup.GET("/:tableId", controllers.GetTable())
-	tableGroup.GET("/all", controllers.GetAllTables())
+	tableGroup.Use(middlewares.Authenticate(store))
+	tableGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER), controllers.CreateTable(store))
+	tableGroup.PUT("/:tableId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.UpdateTable(store))
+	tableGroup.GET("/:tableId", controllers.GetTable(store))
+	tableGroup.GET("/all", controllers.GetAllTables(store))
+	tableGroup.GET("/floor", controllers.GetFloor(store))
+	tableGroup.POST("/:tableId/seat", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.SeatTable(store))
+	tableGroup.POST("/:tableId/clear", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.ClearTable(store))
+	tableGroup.POST("/:tableId/merge", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), middlewares.BusinessDayOpen(store), controllers.MergeTables(store))
+	tableGroup.POST("/:tableId/combine", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.CombineTables(store))
+	tableGroup.POST("/:tableId/split", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.SplitTable(store))
 }
@@ -0,0 +1,269 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func (s *testServer) floorTable(tableId string) models.FloorTable {
+	s.t.Helper()
+
+	var floor []models.FloorTable
+	s.mustDo(http.MethodGet, "/table/floor", nil, http.StatusOK, &floor)
+	for _, table := range floor {
+		if table.TableId == tableId {
+			return table
+		}
+	}
+	s.t.Fatalf("table %s is not on the floor", tableId)
+	return models.FloorTable{}
+}
+
+func (s *testServer) expectTableStatus(tableId, want string) models.FloorTable {
+	s.t.Helper()
+
+	table := s.floorTable(tableId)
+	if table.Status != want {
+		s.t.Fatalf("table status = %s, want %s", table.Status, want)
+	}
+	return table
+}
+
+func TestTableCRUD(t *testing.T) {
+	s := newTestServer(t)
+	tableId := s.createTable(7, 4)
+
+	var table models.Table
+	s.mustDo(http.MethodGet, "/table/"+tableId, nil, http.StatusOK, &table)
+	if *table.TableNumber != 7 || *table.NumberOfGuests != 4 {
+		t.Fatalf("GET table = %+v", table)
+	}
+
+	s.expectStatus(http.MethodPut, "/table/"+tableId, map[string]any{"numberOfGuests": 6}, http.StatusOK)
+	s.mustDo(http.MethodGet, "/table/"+tableId, nil, http.StatusOK, &table)
+	if *table.TableNumber != 7 || *table.NumberOfGuests != 6 {
+		t.Fatalf("table after update = %+v", table)
+	}
+
+	s.createTable(8, 2)
+	var tables []models.Table
+	s.mustDo(http.MethodGet, "/table/all", nil, http.StatusOK, &tables)
+	if len(tables) != 2 {
+		t.Fatalf("len(tables) = %d, want 2", len(tables))
+	}
+}
+
+func TestTableValidation(t *testing.T) {
+	s := newTestServer(t)
+
+	s.expectStatus(http.MethodPost, "/table/create", nil, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/table/create", map[string]any{"tableNumber": 1}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPut, "/table/missing", map[string]any{"tableNumber": 1}, http.StatusNotFound)
+	s.expectStatus(http.MethodGet, "/table/missing", nil, http.StatusNotFound)
+}
+
+func TestTableStatus(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	tableId := s.createTable(3, 4)
+	otherId := s.createTable(1, 2)
+
+	var floor []models.FloorTable
+	s.mustDo(http.MethodGet, "/table/floor", nil, http.StatusOK, &floor)
+	if len(floor) != 2 || floor[0].TableId != otherId || floor[0].Status != "FREE" {
+		t.Fatalf("floor = %+v, want two free tables by number", floor)
+	}
+
+	s.expectStatus(http.MethodPost, "/table/"+tableId+"/clear", nil, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/table/"+tableId+"/seat", map[string]any{}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/table/"+tableId+"/seat", map[string]any{"partySize": 6}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/table/missing/seat", map[string]any{"partySize": 2}, http.StatusNotFound)
+
+	var seated models.Table
+	s.mustDo(http.MethodPost, "/table/"+tableId+"/seat", map[string]any{"partySize": 3}, http.StatusOK, &seated)
+	if seated.Status != "SEATED" || seated.Occupancy == nil || seated.Occupancy.PartySize != 3 {
+		t.Fatalf("seated table = %+v", seated)
+	}
+	s.expectStatus(http.MethodPost, "/table/"+tableId+"/seat", map[string]any{"partySize": 2}, http.StatusConflict)
+
+	time.Sleep(1100 * time.Millisecond)
+	if table := s.expectTableStatus(tableId, "SEATED"); table.SecondsInState < 1 {
+		t.Fatalf("secondsInState = %d, want at least 1", table.SecondsInState)
+	}
+
+	items := s.createOrderItems(tableId, map[string]any{"foodId": foodId, "quantity": "M"})
+	orderId := items[0].OrderId
+	table := s.expectTableStatus(tableId, "ORDERED")
+	if table.SecondsInState != 0 || table.Occupancy.PartySize != 3 || len(table.Occupancy.OrderIds) != 1 {
+		t.Fatalf("ordered table = %+v", table)
+	}
+	s.expectStatus(http.MethodPost, "/table/"+tableId+"/clear", nil, http.StatusConflict)
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	s.expectTableStatus(tableId, "BILLED")
+
+	// A second round puts the table back to waiting on an unbilled order.
+	second := s.createOrderItems(tableId, map[string]any{"foodId": foodId, "quantity": "M"})
+	s.expectTableStatus(tableId, "ORDERED")
+	s.setOrderStatus(second[0].OrderId, "CANCELLED", http.StatusOK)
+	s.expectTableStatus(tableId, "BILLED")
+
+	for _, status := range []string{"SENT_TO_KITCHEN", "PREPARING", "READY", "SERVED"} {
+		s.setOrderStatus(orderId, status, http.StatusOK)
+	}
+	s.pay(invoice.InvoiceId, "CASH", invoice.Totals.Total, "")
+	table = s.expectTableStatus(tableId, "CLEANING")
+	if len(table.Occupancy.OrderIds) != 2 {
+		t.Fatalf("orderIds = %v, want both rounds", table.Occupancy.OrderIds)
+	}
+
+	s.mustDo(http.MethodPost, "/table/"+tableId+"/clear", nil, http.StatusOK, &table.Table)
+	if table = s.expectTableStatus(tableId, "FREE"); table.Occupancy != nil {
+		t.Fatalf("cleared table occupancy = %+v", table.Occupancy)
+	}
+
+	// Ordering at a free table seats a walk-in.
+	walkIn := s.createOrderItems(otherId, map[string]any{"foodId": foodId, "quantity": "M"})[0].OrderId
+	if table = s.expectTableStatus(otherId, "ORDERED"); table.Occupancy == nil || table.Occupancy.SeatedAt.IsZero() {
+		t.Fatalf("walk-in occupancy = %+v", table.Occupancy)
+	}
+
+	// Moving an order to another table moves it off the party at its old
+	// one.
+	s.mustDo(http.MethodPut, "/order/"+walkIn, map[string]any{"tableId": tableId}, http.StatusOK, nil)
+	if table = s.expectTableStatus(otherId, "SEATED"); len(table.Occupancy.OrderIds) != 0 {
+		t.Fatalf("old table occupancy = %+v", table.Occupancy)
+	}
+	if table = s.expectTableStatus(tableId, "ORDERED"); len(table.Occupancy.OrderIds) != 1 || table.Occupancy.OrderIds[0] != walkIn {
+		t.Fatalf("new table occupancy = %+v", table.Occupancy)
+	}
+}
+
+func TestSeatReservation(t *testing.T) {
+	s := newTestServer(t)
+	bookedId := s.createTable(1, 4)
+	tableId := s.createTable(2, 4)
+
+	reservation := s.reserve(map[string]any{
+		"tableId":   bookedId,
+		"guestName": "Ada",
+		"phone":     "555-0100",
+		"partySize": 4,
+		"startsAt":  time.Now().Add(time.Hour).Format(time.RFC3339Nano),
+	}, http.StatusCreated)
+	if next := s.floorTable(bookedId).NextReservation; next == nil || next.ReservationId != reservation.ReservationId {
+		t.Fatalf("next reservation = %+v", next)
+	}
+
+	var seated models.Table
+	s.mustDo(http.MethodPost, "/table/"+tableId+"/seat", map[string]any{"reservationId": reservation.ReservationId}, http.StatusOK, &seated)
+	if seated.Occupancy.PartySize != 4 || seated.Occupancy.ReservationId != reservation.ReservationId {
+		t.Fatalf("occupancy = %+v", seated.Occupancy)
+	}
+	s.mustDo(http.MethodGet, "/reservation/"+reservation.ReservationId, nil, http.StatusOK, &reservation)
+	if reservation.Status != "SEATED" || reservation.TableId != tableId {
+		t.Fatalf("reservation = %+v, want seated at %s", reservation, tableId)
+	}
+	if next := s.floorTable(bookedId).NextReservation; next != nil {
+		t.Fatalf("next reservation at the old table = %+v", next)
+	}
+
+	s.expectStatus(http.MethodPost, "/table/"+bookedId+"/seat", map[string]any{"reservationId": reservation.ReservationId}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/reservation/"+reservation.ReservationId+"/cancel", nil, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/table/"+tableId+"/clear", nil, http.StatusOK)
+}
+
+func TestCombineAndMergeTables(t *testing.T) {
+	s := newTestServer(t)
+	foodId := s.createFood(s.createMenu(), 10)
+	hostId := s.createTable(1, 2)
+	joinedId := s.createTable(2, 2)
+	otherId := s.createTable(3, 4)
+
+	s.expectStatus(http.MethodPost, "/table/"+hostId+"/combine", map[string]any{"tableIds": []string{hostId}}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/table/"+hostId+"/split", nil, http.StatusConflict)
+	var host models.Table
+	s.mustDo(http.MethodPost, "/table/"+hostId+"/combine", map[string]any{"tableIds": []string{joinedId}}, http.StatusOK, &host)
+	if host.Combination == nil || host.Combination.Seats != 4 || len(host.Combination.TableIds) != 1 {
+		t.Fatalf("combined table = %+v", host)
+	}
+	if joined := s.expectTableStatus(joinedId, "COMBINED"); joined.CombinedInto != hostId {
+		t.Fatalf("joined table = %+v", joined)
+	}
+	s.expectStatus(http.MethodPost, "/table/"+otherId+"/combine", map[string]any{"tableIds": []string{joinedId}}, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/table/"+joinedId+"/seat", map[string]any{"partySize": 2}, http.StatusConflict)
+	// While the combination stands only the table combined into is offered,
+	// with the seats of both; next week each table seats its own again.
+	available := func(partySize string, at time.Time) []string {
+		var free []models.Table
+		s.mustDo(http.MethodGet, "/reservation/availability?partySize="+partySize+"&at="+at.UTC().Format(time.RFC3339), nil, http.StatusOK, &free)
+		return tableIds(free)
+	}
+	if ids := available("4", time.Now().Add(time.Minute)); len(ids) != 2 || ids[0] != hostId || ids[1] != otherId {
+		t.Fatalf("available tables now = %v, want %s and %s", ids, hostId, otherId)
+	}
+	week := time.Now().AddDate(0, 0, 7)
+	nextWeek := time.Date(week.Year(), week.Month(), week.Day(), 19, 0, 0, 0, time.Local)
+	if ids := available("4", nextWeek); len(ids) != 1 || ids[0] != otherId {
+		t.Fatalf("available tables next week = %v, want %s", ids, otherId)
+	}
+	if ids := available("2", nextWeek); len(ids) != 3 || ids[0] != hostId || ids[1] != joinedId {
+		t.Fatalf("available two-tops next week = %v", ids)
+	}
+	booking := map[string]any{"guestName": "Ada", "phone": "555-0100", "partySize": 4, "startsAt": nextWeek.Format(time.RFC3339), "tableId": hostId}
+	s.reserve(booking, http.StatusBadRequest)
+	booking["partySize"] = 2
+	s.reserve(booking, http.StatusCreated)
+
+	// Together the two-tops seat four, and orders at either of them go to
+	// the party at the table they were combined into.
+	s.mustDo(http.MethodPost, "/table/"+hostId+"/seat", map[string]any{"partySize": 4}, http.StatusOK, nil)
+	s.expectStatus(http.MethodPost, "/table/"+hostId+"/split", nil, http.StatusConflict)
+	orderId := s.createOrderItems(joinedId, map[string]any{"foodId": foodId, "quantity": "M"})[0].OrderId
+	if table := s.expectTableStatus(hostId, "ORDERED"); len(table.Occupancy.OrderIds) != 1 || table.Occupancy.OrderIds[0] != orderId {
+		t.Fatalf("host occupancy = %+v", table.Occupancy)
+	}
+	s.expectTableStatus(joinedId, "COMBINED")
+	s.expectStatus(http.MethodPost, "/table/"+joinedId+"/clear", nil, http.StatusConflict)
+
+	// Merging the party at the four-top puts its orders on the host's check.
+	s.expectStatus(http.MethodPost, "/table/"+hostId+"/merge", map[string]any{"fromTableId": otherId}, http.StatusConflict)
+	otherOrderId := s.createOrderItems(otherId, map[string]any{"foodId": foodId, "quantity": "M"})[0].OrderId
+	var check models.Order
+	s.mustDo(http.MethodPost, "/table/"+hostId+"/merge", map[string]any{"fromTableId": otherId}, http.StatusOK, &check)
+	if check.OrderID != orderId {
+		t.Fatalf("check = %s, want %s", check.OrderID, orderId)
+	}
+	s.expectStatus(http.MethodGet, "/order/"+otherOrderId, nil, http.StatusNotFound)
+	if table := s.expectTableStatus(otherId, "ORDERED"); len(table.Occupancy.OrderIds) != 1 || table.Occupancy.OrderIds[0] != orderId {
+		t.Fatalf("merged table occupancy = %+v", table.Occupancy)
+	}
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": orderId}, http.StatusCreated, &invoice)
+	if invoice.Totals.Subtotal.String() != "20.00" {
+		t.Fatalf("merged check subtotal = %s, want 20.00", invoice.Totals.Subtotal)
+	}
+	s.expectTableStatus(otherId, "BILLED")
+	for _, status := range []string{"SENT_TO_KITCHEN", "PREPARING", "READY", "SERVED"} {
+		s.setOrderStatus(orderId, status, http.StatusOK)
+	}
+	s.pay(invoice.InvoiceId, "CASH", invoice.Totals.Total, "")
+	s.expectTableStatus(hostId, "CLEANING")
+	s.expectTableStatus(otherId, "CLEANING")
+
+	// Clearing the party's table splits the tables again.
+	var cleared models.Table
+	s.mustDo(http.MethodPost, "/table/"+hostId+"/clear", nil, http.StatusOK, &cleared)
+	if cleared.Combination != nil {
+		t.Fatalf("cleared table combination = %+v", cleared.Combination)
+	}
+	if joined := s.expectTableStatus(joinedId, "FREE"); joined.CombinedInto != "" {
+		t.Fatalf("split table = %+v", joined)
+	}
+	s.expectStatus(http.MethodPost, "/table/"+hostId+"/seat", map[string]any{"partySize": 4}, http.StatusBadRequest)
+}
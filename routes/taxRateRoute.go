@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func TaxRateRoute(router *gin.Engine, store *repository.Store) {
+	taxRateGroup := router.Group("/tax-rate")
+	taxRateGroup.Use(middlewares.Authenticate(store))
+	taxRateGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER), controllers.CreateTaxRate(store))
+	taxRateGroup.PUT("/:taxRateId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.UpdateTaxRate(store))
+	taxRateGroup.DELETE("/:taxRateId", middlewares.Authorize(constants.ROLE_MANAGER), controllers.DeleteTaxRate(store))
+	taxRateGroup.GET("/:taxRateId", controllers.GetTaxRate(store))
+	taxRateGroup.GET("/all", controllers.GetAllTaxRates(store))
+}
@@ -0,0 +1,75 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+type taxRateResponse struct {
+	TaxRateId string `json:"taxRateId"`
+}
+
+func (s *testServer) createTaxRate(name, rate string, inclusive, isDefault bool) string {
+	s.t.Helper()
+
+	var taxRate taxRateResponse
+	s.mustDo(http.MethodPost, "/tax-rate/create", map[string]any{
+		"name":      name,
+		"rate":      rate,
+		"inclusive": inclusive,
+		"default":   isDefault,
+	}, http.StatusCreated, &taxRate)
+	return taxRate.TaxRateId
+}
+
+func TestTaxRateCRUD(t *testing.T) {
+	s := newTestServer(t)
+	taxRateId := s.createTaxRate("Sales tax", "8.875", false, true)
+
+	var taxRate models.TaxRate
+	s.mustDo(http.MethodGet, "/tax-rate/"+taxRateId, nil, http.StatusOK, &taxRate)
+	if *taxRate.Name != "Sales tax" || taxRate.Rate.String() != "8.875" || !taxRate.Default {
+		t.Fatalf("GET tax rate = %+v", taxRate)
+	}
+
+	s.expectStatus(http.MethodPut, "/tax-rate/"+taxRateId, map[string]any{"rate": 9, "inclusive": true}, http.StatusOK)
+	s.mustDo(http.MethodGet, "/tax-rate/"+taxRateId, nil, http.StatusOK, &taxRate)
+	if taxRate.Rate.String() != "9" || !taxRate.Inclusive {
+		t.Fatalf("tax rate after update = %+v", taxRate)
+	}
+
+	var taxRates []models.TaxRate
+	s.mustDo(http.MethodGet, "/tax-rate/all", nil, http.StatusOK, &taxRates)
+	if len(taxRates) != 1 {
+		t.Fatalf("len(taxRates) = %d, want 1", len(taxRates))
+	}
+
+	s.expectStatus(http.MethodPost, "/tax-rate/create", map[string]any{"name": "Bad", "rate": 101}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/tax-rate/create", map[string]any{"name": "Bad", "rate": "ten"}, http.StatusBadRequest)
+	code, _ := s.doWithToken(http.MethodPost, "/tax-rate/create", s.tokenFor(constants.ROLE_CASHIER), map[string]any{"name": "VAT", "rate": 5})
+	if code != http.StatusForbidden {
+		t.Fatalf("cashier create tax rate status = %d", code)
+	}
+
+	s.expectStatus(http.MethodDelete, "/tax-rate/"+taxRateId, nil, http.StatusOK)
+	s.expectStatus(http.MethodGet, "/tax-rate/"+taxRateId, nil, http.StatusNotFound)
+	s.expectStatus(http.MethodDelete, "/tax-rate/"+taxRateId, nil, http.StatusNotFound)
+}
+
+func TestTaxRateInUseCannotBeDeleted(t *testing.T) {
+	s := newTestServer(t)
+	taxRateId := s.createTaxRate("VAT", "10", false, false)
+	var food idResponse
+	s.mustDo(http.MethodPost, "/food/create", map[string]any{
+		"name": "Soup", "price": 6, "foodImage": "soup.png", "menuId": s.createMenu(), "taxRateIds": []string{taxRateId},
+	}, http.StatusCreated, &food)
+
+	s.expectStatus(http.MethodDelete, "/tax-rate/"+taxRateId, nil, http.StatusConflict)
+	s.expectStatus(http.MethodGet, "/tax-rate/"+taxRateId, nil, http.StatusOK)
+
+	s.expectStatus(http.MethodPut, "/food/"+food.FoodId, map[string]any{"taxRateIds": []string{}}, http.StatusOK)
+	s.expectStatus(http.MethodDelete, "/tax-rate/"+taxRateId, nil, http.StatusOK)
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func TipRoute(router *gin.Engine, store *repository.Store) {
+	tipGroup := router.Group("/tip")
+	tipGroup.Use(middlewares.Authenticate(store))
+	tipGroup.POST("/pool", middlewares.Authorize(constants.ROLE_MANAGER), controllers.TipPool(store))
+}
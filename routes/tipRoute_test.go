@@ -0,0 +1,164 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func TestPaymentTips(t *testing.T) {
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	items := s.createOrderItems(s.createTable(5, 2),
+		map[string]any{"foodId": s.createFood(menuId, 10), "quantity": "M", "count": 2},
+	)
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": items[0].OrderId}, http.StatusCreated, &invoice)
+
+	s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payment", map[string]any{
+		"method": "CARD", "amount": 10, "reference": "slip-1", "tip": -1,
+	}, http.StatusBadRequest)
+
+	var receipt struct {
+		Invoice models.Invoice `json:"invoice"`
+	}
+	s.mustDo(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payment", map[string]any{
+		"method": "CARD", "amount": 10, "reference": "slip-1", "tip": "1.50",
+	}, http.StatusCreated, &receipt)
+	if receipt.Invoice.Tips.String() != "1.50" || *receipt.Invoice.PaymentStatus != "PARTIALLY_PAID" {
+		t.Fatalf("after card with tip: %+v", receipt.Invoice)
+	}
+	s.mustDo(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payment", map[string]any{
+		"method": "CASH", "amount": 10, "tip": 2,
+	}, http.StatusCreated, &receipt)
+	if receipt.Invoice.Tips.String() != "3.50" || receipt.Invoice.AmountPaid.String() != "20.00" || *receipt.Invoice.PaymentStatus != "PAID" {
+		t.Fatalf("after cash with tip: %+v", receipt.Invoice)
+	}
+}
+
+func TestAutoGratuity(t *testing.T) {
+	helpers.SetAutoGratuity(helpers.AutoGratuity{MinGuests: 6, Rate: 180000})
+	t.Cleanup(func() { helpers.SetAutoGratuity(helpers.AutoGratuity{}) })
+
+	s := newTestServer(t)
+	menuId := s.createMenu()
+	burgerId := s.createFood(menuId, 10)
+	order := func(guests int) string {
+		items := s.createOrderItems(s.createTable(guests, guests),
+			map[string]any{"foodId": burgerId, "quantity": "M", "count": 2},
+		)
+		return items[0].OrderId
+	}
+
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": order(8)}, http.StatusCreated, &invoice)
+	if invoice.GratuityRate.String() != "18" || invoice.Totals.Gratuity.String() != "3.60" || invoice.Totals.Total.String() != "23.60" {
+		t.Fatalf("large party invoice: rate %v, totals %+v", invoice.GratuityRate, invoice.Totals)
+	}
+
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": order(2)}, http.StatusCreated, &invoice)
+	if invoice.GratuityRate != nil || invoice.Totals.Gratuity != 0 {
+		t.Fatalf("small party invoice: rate %v, totals %+v", invoice.GratuityRate, invoice.Totals)
+	}
+
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": order(7), "gratuityRate": 0}, http.StatusCreated, &invoice)
+	if invoice.Totals.Gratuity != 0 {
+		t.Fatalf("waived gratuity: totals %+v", invoice.Totals)
+	}
+
+	var invoices []models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/split", map[string]any{"orderId": order(6), "mode": "EVEN", "guests": 2}, http.StatusCreated, &invoices)
+	if invoices[0].Totals.Gratuity.String() != "1.80" || invoices[1].Totals.Total.String() != "11.80" {
+		t.Fatalf("split gratuity: %+v, %+v", invoices[0].Totals, invoices[1].Totals)
+	}
+}
+
+func TestTipPool(t *testing.T) {
+	s := newTestServer(t)
+	start := time.Now().UTC().Add(-time.Minute)
+	admin := s.signup("Ada Admin", "ada@example.com", "secret1")
+	ann := s.signup("Ann Waiter", "ann@example.com", "secret1")
+	bob := s.signup("Bob Waiter", "bob@example.com", "secret1")
+	kim := s.signup("Kim Cook", "kim@example.com", "secret1")
+	s.expectStatus(http.MethodPut, "/user/"+kim.UserId+"/role", map[string]any{"role": "KITCHEN"}, http.StatusOK)
+
+	menuId := s.createMenu()
+	items := s.createOrderItems(s.createTable(5, 2),
+		map[string]any{"foodId": s.createFood(menuId, 10), "quantity": "M", "count": 3},
+	)
+	var invoice models.Invoice
+	s.mustDo(http.MethodPost, "/invoice/create", map[string]any{"orderId": items[0].OrderId}, http.StatusCreated, &invoice)
+	s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payment", map[string]any{
+		"method": "CARD", "amount": 20, "reference": "slip-1", "tip": 6,
+	}, http.StatusCreated)
+	s.expectStatus(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payment", map[string]any{
+		"method": "CASH", "amount": 10, "tip": 4,
+	}, http.StatusCreated)
+
+	staff := []map[string]any{
+		{"userId": ann.UserId, "hours": 6},
+		{"userId": bob.UserId, "hours": 4},
+		{"userId": kim.UserId, "hours": 5},
+		{"userId": admin.UserId, "hours": 8},
+	}
+	pool := func(rule string, rolePoints map[string]int) []string {
+		t.Helper()
+		var report models.TipPoolReport
+		s.mustDo(http.MethodPost, "/tip/pool", map[string]any{
+			"from": start, "to": time.Now().Add(time.Hour), "rule": rule, "staff": staff, "rolePoints": rolePoints,
+		}, http.StatusOK, &report)
+		if report.TotalTips.String() != "10.00" || len(report.Shares) != 4 {
+			t.Fatalf("%s report = %+v", rule, report)
+		}
+		var amounts []string
+		var sum int64
+		for _, share := range report.Shares {
+			amounts = append(amounts, share.Amount.String())
+			sum += int64(share.Amount)
+		}
+		if sum != int64(report.TotalTips) {
+			t.Fatalf("%s shares %v do not add up to %s", rule, amounts, report.TotalTips)
+		}
+		return amounts
+	}
+
+	// 6, 4, 5 and 8 hours of a 23 hour shift; the last cent goes to the
+	// largest remainder.
+	if got := pool("HOURS", nil); got[0] != "2.61" || got[1] != "1.74" || got[2] != "2.17" || got[3] != "3.48" {
+		t.Errorf("HOURS shares = %v", got)
+	}
+	// Waiters 10 points, the kitchen 5 and the admin none.
+	if got := pool("POINTS", nil); got[0] != "4.00" || got[1] != "4.00" || got[2] != "2.00" || got[3] != "0.00" {
+		t.Errorf("POINTS shares = %v", got)
+	}
+	if got := pool("HOURS_AND_POINTS", map[string]int{"KITCHEN": 10}); got[0] != "4.00" || got[1] != "2.67" || got[2] != "3.33" || got[3] != "0.00" {
+		t.Errorf("HOURS_AND_POINTS shares = %v", got)
+	}
+
+	var report models.TipPoolReport
+	s.mustDo(http.MethodPost, "/tip/pool", map[string]any{
+		"from": start.Add(-time.Hour), "to": start, "rule": "HOURS", "staff": staff,
+	}, http.StatusOK, &report)
+	if report.TotalTips != 0 || report.Shares[0].Amount != 0 {
+		t.Fatalf("report before the shift = %+v", report)
+	}
+
+	for _, body := range []map[string]any{
+		{"from": start, "to": start.Add(-time.Hour), "rule": "HOURS", "staff": staff},
+		{"from": start, "to": time.Now(), "rule": "SENIORITY", "staff": staff},
+		{"from": start, "to": time.Now(), "rule": "HOURS", "staff": []map[string]any{{"userId": "missing", "hours": 1}}},
+		{"from": start, "to": time.Now(), "rule": "HOURS", "staff": []map[string]any{{"userId": ann.UserId, "hours": 25}}},
+		{"from": start, "to": time.Now(), "rule": "POINTS", "staff": staff, "rolePoints": map[string]int{"CHEF": 3}},
+		{"from": start, "to": time.Now(), "rule": "HOURS", "staff": []map[string]any{staff[0], staff[0]}},
+	} {
+		s.expectStatus(http.MethodPost, "/tip/pool", body, http.StatusBadRequest)
+	}
+	code, _ := s.doWithToken(http.MethodPost, "/tip/pool", s.tokenFor(constants.ROLE_WAITER), map[string]any{})
+	if code != http.StatusForbidden {
+		t.Fatalf("waiter tip pool: status = %d", code)
+	}
+}
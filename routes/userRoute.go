@@ -2,16 +2,20 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
 	"github.com/jrskg/go-restaurant/controllers"
 	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
 )
 
-func UserRoute(router *gin.Engine) {
+func UserRoute(router *gin.Engine, store *repository.Store) {
 	userGroup := router.Group("/user")
-	userGroup.POST("/signup", controllers.Signup())
-	userGroup.POST("/login", controllers.Login())
-	userGroup.GET("/logout", controllers.Logout())
+	userGroup.POST("/signup", controllers.Signup(store))
+	userGroup.POST("/login", controllers.Login(store))
+	userGroup.POST("/refresh", controllers.RefreshToken(store))
+	userGroup.GET("/logout", middlewares.Authenticate(store), controllers.Logout(store))
 
-	userGroup.GET("/:userId", middlewares.Authenticate(), controllers.GetUser())
-	userGroup.GET("/all", middlewares.Authenticate(), controllers.GetAllUsers())
+	userGroup.GET("/:userId", middlewares.Authenticate(store), controllers.GetUser(store))
+	userGroup.GET("/all", middlewares.Authenticate(store), middlewares.Authorize(constants.ROLE_MANAGER), controllers.GetAllUsers(store))
+	userGroup.PUT("/:userId/role", middlewares.Authenticate(store), middlewares.Authorize(), controllers.UpdateUserRole(store))
 }
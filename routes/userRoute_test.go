@@ -0,0 +1,222 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jrskg/go-restaurant/models"
+)
+
+func (s *testServer) signup(name, email, password string) models.User {
+	s.t.Helper()
+
+	var user models.User
+	s.mustDo(http.MethodPost, "/user/signup", map[string]any{
+		"name":     name,
+		"email":    email,
+		"password": password,
+	}, http.StatusCreated, &user)
+	return user
+}
+
+func TestSignupAndLogin(t *testing.T) {
+	s := newTestServer(t)
+	created := s.signup("Alice", "alice@example.com", "secret1")
+	if created.UserId == "" || created.Token == nil {
+		t.Fatalf("signed up user = %+v", created)
+	}
+
+	s.expectStatus(http.MethodPost, "/user/signup", map[string]any{
+		"name": "Alice", "email": "alice@example.com", "password": "secret1",
+	}, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/user/login", map[string]any{
+		"email": "alice@example.com", "password": "wrong",
+	}, http.StatusUnauthorized)
+
+	var user models.User
+	s.mustDo(http.MethodPost, "/user/login", map[string]any{
+		"email": "alice@example.com", "password": "secret1",
+	}, http.StatusOK, &user)
+
+	code, _ := s.doWithToken(http.MethodGet, "/table/all", *user.Token, nil)
+	if code != http.StatusOK {
+		t.Fatalf("login token rejected: status = %d", code)
+	}
+	code, _ = s.doWithToken(http.MethodGet, "/table/all", *user.RefreshToken, nil)
+	if code != http.StatusUnauthorized {
+		t.Fatalf("refresh token accepted as access token: status = %d", code)
+	}
+}
+
+func TestGetUserHidesSecrets(t *testing.T) {
+	s := newTestServer(t)
+	created := s.signup("Alice", "alice@example.com", "secret1")
+
+	code, resp := s.do(http.MethodGet, "/user/"+created.UserId, nil)
+	if code != http.StatusOK {
+		t.Fatalf("GET user: status = %d (%s)", code, resp.Message)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(resp.Data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"password", "token", "refreshToken"} {
+		if _, ok := fields[secret]; ok {
+			t.Errorf("GET user exposes %q", secret)
+		}
+	}
+	if fields["email"] != "alice@example.com" || fields["userId"] != created.UserId {
+		t.Errorf("GET user = %v", fields)
+	}
+
+	s.expectStatus(http.MethodGet, "/user/missing", nil, http.StatusNotFound)
+}
+
+func TestGetAllUsersPagination(t *testing.T) {
+	s := newTestServer(t)
+	for i := range 51 {
+		s.signup("User", fmt.Sprintf("user%d@example.com", i), "secret1")
+	}
+
+	type page struct {
+		HasMore    bool              `json:"hasMore"`
+		Users      []json.RawMessage `json:"users"`
+		NextCursor string            `json:"nextCursor"`
+	}
+
+	var first page
+	s.mustDo(http.MethodGet, "/user/all", nil, http.StatusOK, &first)
+	if !first.HasMore || len(first.Users) != 50 {
+		t.Fatalf("first page: hasMore=%v len=%d", first.HasMore, len(first.Users))
+	}
+
+	var second page
+	s.mustDo(http.MethodGet, "/user/all?cursor="+first.NextCursor, nil, http.StatusOK, &second)
+	if second.HasMore || len(second.Users) != 1 {
+		t.Fatalf("second page: hasMore=%v len=%d", second.HasMore, len(second.Users))
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(second.Users[0], &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["password"]; ok {
+		t.Errorf("user listing exposes password")
+	}
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	s := newTestServer(t)
+	user := s.signup("Alice", "alice@example.com", "secret1")
+	token := *user.Token
+
+	code, _ := s.doWithToken(http.MethodGet, "/user/logout", token, nil)
+	if code != http.StatusOK {
+		t.Fatalf("logout: status = %d", code)
+	}
+
+	code, resp := s.doWithToken(http.MethodGet, "/table/all", token, nil)
+	if code != http.StatusUnauthorized {
+		t.Fatalf("token still accepted after logout: status = %d (%s)", code, resp.Message)
+	}
+	code, _ = s.doWithToken(http.MethodGet, "/user/logout", "", nil)
+	if code != http.StatusUnauthorized {
+		t.Fatalf("logout without token: status = %d", code)
+	}
+
+	// Logging in again starts a fresh session that is not affected.
+	var again models.User
+	s.mustDo(http.MethodPost, "/user/login", map[string]any{
+		"email": "alice@example.com", "password": "secret1",
+	}, http.StatusOK, &again)
+	code, _ = s.doWithToken(http.MethodGet, "/table/all", *again.Token, nil)
+	if code != http.StatusOK {
+		t.Fatalf("new session rejected: status = %d", code)
+	}
+}
+
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s := newTestServer(t)
+	user := s.signup("Alice", "alice@example.com", "secret1")
+
+	var rotated tokenPair
+	s.mustDo(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": *user.RefreshToken}, http.StatusOK, &rotated)
+	if rotated.Token == "" || rotated.RefreshToken == *user.RefreshToken {
+		t.Fatalf("rotated pair = %+v", rotated)
+	}
+	code, _ := s.doWithToken(http.MethodGet, "/table/all", rotated.Token, nil)
+	if code != http.StatusOK {
+		t.Fatalf("rotated access token rejected: status = %d", code)
+	}
+
+	var again tokenPair
+	s.mustDo(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": rotated.RefreshToken}, http.StatusOK, &again)
+
+	s.expectStatus(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": *user.Token}, http.StatusUnauthorized)
+	s.expectStatus(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": "garbage"}, http.StatusUnauthorized)
+	s.expectStatus(http.MethodPost, "/user/refresh", map[string]any{}, http.StatusBadRequest)
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	s := newTestServer(t)
+	user := s.signup("Alice", "alice@example.com", "secret1")
+
+	var rotated tokenPair
+	s.mustDo(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": *user.RefreshToken}, http.StatusOK, &rotated)
+
+	resp := s.expectStatus(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": *user.RefreshToken}, http.StatusUnauthorized)
+	if resp.Message != "refresh token reuse detected" {
+		t.Errorf("reuse message = %q", resp.Message)
+	}
+
+	s.expectStatus(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": rotated.RefreshToken}, http.StatusUnauthorized)
+	for _, token := range []string{*user.Token, rotated.Token} {
+		code, _ := s.doWithToken(http.MethodGet, "/table/all", token, nil)
+		if code != http.StatusUnauthorized {
+			t.Fatalf("token of revoked family still accepted: status = %d", code)
+		}
+	}
+}
+
+func TestRefreshAfterLogout(t *testing.T) {
+	s := newTestServer(t)
+	user := s.signup("Alice", "alice@example.com", "secret1")
+
+	code, _ := s.doWithToken(http.MethodGet, "/user/logout", *user.Token, nil)
+	if code != http.StatusOK {
+		t.Fatalf("logout: status = %d", code)
+	}
+	s.expectStatus(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": *user.RefreshToken}, http.StatusUnauthorized)
+}
+
+func TestRefreshTokenPerSession(t *testing.T) {
+	s := newTestServer(t)
+	phone := s.signup("Alice", "alice@example.com", "secret1")
+
+	// Logging in on a second device leaves the first one's session alone.
+	var tablet models.User
+	s.mustDo(http.MethodPost, "/user/login", map[string]any{
+		"email": "alice@example.com", "password": "secret1",
+	}, http.StatusOK, &tablet)
+
+	var fromPhone, fromTablet tokenPair
+	s.mustDo(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": *phone.RefreshToken}, http.StatusOK, &fromPhone)
+	s.mustDo(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": *tablet.RefreshToken}, http.StatusOK, &fromTablet)
+	s.mustDo(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": fromPhone.RefreshToken}, http.StatusOK, &fromPhone)
+
+	// Logging out on one device ends only its own session.
+	code, _ := s.doWithToken(http.MethodGet, "/user/logout", fromTablet.Token, nil)
+	if code != http.StatusOK {
+		t.Fatalf("logout: status = %d", code)
+	}
+	s.expectStatus(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": fromTablet.RefreshToken}, http.StatusUnauthorized)
+	s.mustDo(http.MethodPost, "/user/refresh", map[string]any{"refreshToken": fromPhone.RefreshToken}, http.StatusOK, &fromPhone)
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/controllers"
+	"github.com/jrskg/go-restaurant/middlewares"
+	"github.com/jrskg/go-restaurant/repository"
+)
+
+func WaitlistRoute(router *gin.Engine, store *repository.Store) {
+	waitlistGroup := router.Group("/waitlist")
+	waitlistGroup.Use(middlewares.Authenticate(store))
+	waitlistGroup.POST("/create", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.CreateWaitlistEntry(store))
+	waitlistGroup.GET("/quote", controllers.GetWaitQuote(store))
+	waitlistGroup.GET("/all", controllers.GetWaitlist(store))
+	waitlistGroup.GET("/:entryId", controllers.GetWaitlistEntry(store))
+	waitlistGroup.POST("/:entryId/notify", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.NotifyWaitlistEntry(store))
+	waitlistGroup.POST("/:entryId/seat", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), middlewares.BusinessDayOpen(store), controllers.SeatWaitlistEntry(store))
+	waitlistGroup.POST("/:entryId/cancel", middlewares.Authorize(constants.ROLE_MANAGER, constants.ROLE_WAITER), controllers.CancelWaitlistEntry(store))
+}
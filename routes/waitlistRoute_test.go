@@ -0,0 +1,152 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrskg/go-restaurant/constants"
+	"github.com/jrskg/go-restaurant/helpers"
+	"github.com/jrskg/go-restaurant/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (s *testServer) joinWaitlist(guestName string, partySize int) models.WaitlistEntry {
+	s.t.Helper()
+
+	var entry models.WaitlistEntry
+	s.mustDo(http.MethodPost, "/waitlist/create", map[string]any{
+		"guestName": guestName, "phone": "555-0100", "partySize": partySize,
+	}, http.StatusCreated, &entry)
+	return entry
+}
+
+// settleTurn stores an order paid turn after it was placed at tableId, an
+// hour ago.
+func (s *testServer) settleTurn(tableId string, turn time.Duration) {
+	s.t.Helper()
+
+	ctx := context.Background()
+	placed := time.Now().UTC().Add(-time.Hour)
+	order := models.Order{
+		ID:        bson.NewObjectID(),
+		TableId:   tableId,
+		Status:    constants.ORDER_STATUS_PAID,
+		CreatedAt: placed,
+		StatusHistory: []models.OrderStatusChange{
+			{Status: constants.ORDER_STATUS_OPEN, ChangedAt: placed},
+			{Status: constants.ORDER_STATUS_PAID, ChangedAt: placed.Add(turn)},
+		},
+	}
+	order.OrderID = order.ID.Hex()
+	paid := constants.INVOICE_STATUS_PAID
+	invoice := models.Invoice{ID: bson.NewObjectID(), OrderId: order.OrderID, PaymentStatus: &paid, CreatedAt: placed, UpdatedAt: placed.Add(turn)}
+	invoice.InvoiceId = invoice.ID.Hex()
+	if err := s.store.Orders.Create(ctx, order); err != nil {
+		s.t.Fatal(err)
+	}
+	if err := s.store.Invoices.Create(ctx, invoice); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+func TestWaitlist(t *testing.T) {
+	s := newTestServer(t)
+	twoTop := s.createTable(1, 2)
+	fourTop := s.createTable(2, 4)
+
+	var mu sync.Mutex
+	var notified []string
+	helpers.SetWaitlistNotifier(func(ctx context.Context, entry models.WaitlistEntry, table models.Table) error {
+		mu.Lock()
+		defer mu.Unlock()
+		notified = append(notified, entry.EntryId+"@"+table.TableId)
+		return nil
+	})
+	t.Cleanup(func() {
+		helpers.SetWaitlistNotifier(func(context.Context, models.WaitlistEntry, models.Table) error { return nil })
+	})
+
+	var quote models.WaitQuote
+	s.mustDo(http.MethodGet, "/waitlist/quote?partySize=2", nil, http.StatusOK, &quote)
+	if quote.WaitMinutes != 0 || quote.PartiesAhead != 0 {
+		t.Fatalf("quote with free tables = %+v, want no wait", quote)
+	}
+	s.expectStatus(http.MethodGet, "/waitlist/quote?partySize=8", nil, http.StatusBadRequest)
+	s.expectStatus(http.MethodPost, "/waitlist/create", map[string]any{"guestName": "Big", "phone": "1", "partySize": 8}, http.StatusBadRequest)
+
+	// Four-tops have lately turned in half an hour; two-tops have too
+	// little history and keep the configured 75 minutes.
+	for range constants.WAITLIST_MIN_TURN_SAMPLES {
+		s.settleTurn(fourTop, 30*time.Minute)
+	}
+	s.settleTurn(twoTop, 20*time.Minute)
+	s.mustDo(http.MethodPost, "/table/"+twoTop+"/seat", map[string]any{"partySize": 2}, http.StatusOK, nil)
+	s.mustDo(http.MethodPost, "/table/"+fourTop+"/seat", map[string]any{"partySize": 4}, http.StatusOK, nil)
+
+	// The four-top frees up after its 30 minute turn and the 15 minute
+	// reset, before the two-top does.
+	first := s.joinWaitlist("Ada", 2)
+	if first.Status != "WAITING" || first.QuotedWaitMinutes != 45 {
+		t.Fatalf("first party = %+v, want a 45 minute wait", first)
+	}
+	// The next party waits for whichever table the first one leaves.
+	second := s.joinWaitlist("Grace", 2)
+	if second.QuotedWaitMinutes != 90 {
+		t.Fatalf("second party quoted %d minutes, want 90", second.QuotedWaitMinutes)
+	}
+	s.mustDo(http.MethodGet, "/waitlist/quote?partySize=4", nil, http.StatusOK, &quote)
+	if quote.PartiesAhead != 2 || quote.WaitMinutes != 90 {
+		t.Fatalf("quote for four = %+v, want 90 minutes behind 2 parties", quote)
+	}
+
+	var waiting []models.WaitlistEntry
+	s.mustDo(http.MethodGet, "/waitlist/all", nil, http.StatusOK, &waiting)
+	if len(waiting) != 2 || waiting[0].EntryId != first.EntryId || waiting[1].EntryId != second.EntryId {
+		t.Fatalf("waitlist = %+v, want both parties in line order", waiting)
+	}
+
+	// Clearing the two-top calls the first party in line.
+	s.expectStatus(http.MethodPost, "/waitlist/"+second.EntryId+"/notify", map[string]any{"tableId": fourTop}, http.StatusConflict)
+	s.mustDo(http.MethodPost, "/table/"+twoTop+"/clear", nil, http.StatusOK, nil)
+	var entry models.WaitlistEntry
+	s.mustDo(http.MethodGet, "/waitlist/"+first.EntryId, nil, http.StatusOK, &entry)
+	if entry.Status != "NOTIFIED" || entry.TableId != twoTop || entry.NotifiedAt == nil {
+		t.Fatalf("first party after clear = %+v, want called to the two-top", entry)
+	}
+	if len(notified) != 1 || notified[0] != first.EntryId+"@"+twoTop {
+		t.Fatalf("notified = %v", notified)
+	}
+
+	var seated models.SeatedParty
+	s.mustDo(http.MethodPost, "/waitlist/"+first.EntryId+"/seat", nil, http.StatusOK, &seated)
+	if seated.Entry.Status != "SEATED" || seated.Entry.OrderId != seated.Order.OrderID || seated.Order.Status != "OPEN" {
+		t.Fatalf("seated party = %+v", seated)
+	}
+	if seated.Table.Status != "ORDERED" || seated.Table.Occupancy.PartySize != 2 || len(seated.Table.Occupancy.OrderIds) != 1 {
+		t.Fatalf("seated table = %+v", seated.Table)
+	}
+	s.expectStatus(http.MethodPost, "/waitlist/"+first.EntryId+"/seat", nil, http.StatusConflict)
+	s.expectStatus(http.MethodPost, "/waitlist/"+second.EntryId+"/seat", nil, http.StatusBadRequest)
+
+	if code, _ := s.doWithToken(http.MethodPost, "/waitlist/"+second.EntryId+"/cancel", s.tokenFor(constants.ROLE_KITCHEN), nil); code != http.StatusForbidden {
+		t.Fatalf("kitchen cancelling: status = %d, want 403", code)
+	}
+	s.mustDo(http.MethodPost, "/waitlist/"+second.EntryId+"/cancel", nil, http.StatusOK, &entry)
+	if entry.Status != "CANCELLED" {
+		t.Fatalf("cancelled entry = %+v", entry)
+	}
+	s.expectStatus(http.MethodPost, "/waitlist/"+second.EntryId+"/notify", map[string]any{"tableId": twoTop}, http.StatusConflict)
+
+	s.mustDo(http.MethodGet, "/waitlist/all", nil, http.StatusOK, &waiting)
+	if len(waiting) != 0 {
+		t.Fatalf("waitlist = %+v, want nobody waiting", waiting)
+	}
+	var today []models.WaitlistEntry
+	s.mustDo(http.MethodGet, "/waitlist/all?date="+time.Now().UTC().Format("2006-01-02"), nil, http.StatusOK, &today)
+	if len(today) != 2 {
+		t.Fatalf("waitlist of the day = %+v, want both parties", today)
+	}
+}
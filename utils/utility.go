@@ -2,7 +2,6 @@ package utils
 
 import (
 	"fmt"
-	"math"
 	"reflect"
 	"time"
 
@@ -34,11 +33,6 @@ func ApiSuccess(c *gin.Context, code int, data any, message string) {
 
 var Validate = validator.New()
 
-func ToFixed(num float64, precision int) float64 {
-	factor := math.Pow(10, float64(precision))
-	return math.Floor(num*factor) / factor
-}
-
 func ValidateAndParseTime(timeStr string) (time.Time, error) {
 	if timeStr == "" {
 		return time.Time{}, fmt.Errorf("invalid time format")